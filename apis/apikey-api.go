@@ -0,0 +1,92 @@
+package apis
+
+import (
+	"elect/controllers"
+	"elect/dto"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type APIKeyAPI struct {
+	apiKeyController controllers.APIKeyController
+}
+
+func NewAPIKeyAPI(apiKeyController controllers.APIKeyController) *APIKeyAPI {
+	return &APIKeyAPI{
+		apiKeyController: apiKeyController,
+	}
+}
+
+// CreateAPIKey godoc
+// @Summary Create an API key for scripts
+// @ID createAPIKey
+// @Tags apikeys
+// @Description The key is only shown in this response, send it as "Authorization: Bearer <key>". Each scope is a path and method like the ones in policy.csv(e.g, /api/participants/* and POST), expires_in defaults to API_KEY_MAX_EXPIRY. Keys can't be created with another key.
+// @Accept json
+// @Produce json
+// @Param apikey body dto.CreateAPIKeyDTO true "API key"
+// @Success 200 {object} dto.NewAPIKeyDTO
+// @Failure 401 {object} dto.Response
+// @Failure 400 {object} dto.Response
+// @Router /api/apikeys [post]
+func (apiKey *APIKeyAPI) CreateAPIKeyHandler(cxt *gin.Context) {
+	newAPIKeyDTO, err := apiKey.apiKeyController.CreateAPIKey(cxt)
+	if err != nil {
+		cxt.JSON(http.StatusBadRequest, dto.Response{
+			Message: err.Error(),
+		})
+		return
+	}
+
+	cxt.JSON(http.StatusOK, newAPIKeyDTO)
+	return
+}
+
+// GetAPIKeys godoc
+// @Summary Get your API keys
+// @ID getAPIKeys
+// @Tags apikeys
+// @Description Revoked keys are left out, expired ones are listed until they are revoked.
+// @Produce json
+// @Success 200 {array} dto.APIKeyDTO
+// @Failure 401 {object} dto.Response
+// @Failure 400 {object} dto.Response
+// @Router /api/apikeys [get]
+func (apiKey *APIKeyAPI) GetAPIKeysHandler(cxt *gin.Context) {
+	apiKeyDTOs, err := apiKey.apiKeyController.GetAPIKeys(cxt)
+	if err != nil {
+		cxt.JSON(http.StatusBadRequest, dto.Response{
+			Message: err.Error(),
+		})
+		return
+	}
+
+	cxt.JSON(http.StatusOK, apiKeyDTOs)
+	return
+}
+
+// RevokeAPIKey godoc
+// @Summary Revoke one of your API keys
+// @ID revokeAPIKey
+// @Tags apikeys
+// @Produce json
+// @Param id path string true "API Key ID"
+// @Success 200 {object} dto.Response
+// @Failure 401 {object} dto.Response
+// @Failure 400 {object} dto.Response
+// @Router /api/apikey/{id} [delete]
+func (apiKey *APIKeyAPI) RevokeAPIKeyHandler(cxt *gin.Context) {
+	err := apiKey.apiKeyController.RevokeAPIKey(cxt)
+	if err != nil {
+		cxt.JSON(http.StatusBadRequest, dto.Response{
+			Message: err.Error(),
+		})
+		return
+	}
+
+	cxt.JSON(http.StatusOK, dto.Response{
+		Message: "API Key revoked.",
+	})
+	return
+}
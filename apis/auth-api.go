@@ -3,6 +3,7 @@ package apis
 import (
 	"elect/controllers"
 	"elect/dto"
+	"elect/otpmethods"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
@@ -28,20 +29,28 @@ func NewAuthAPI(userController controllers.UserController) *AuthAPI {
 // @Success 200 {object} dto.LoginResponse
 // @Failure 401 {object} dto.Response
 // @Failure 400 {object} dto.Response
+// @Failure 423 {object} dto.Response
+// @Failure 429 {object} dto.Response
 // @Router /login [post]
 func (auth *AuthAPI) LoginHandler(cxt *gin.Context) {
-	email, err := auth.userController.Login(cxt)
+	email, method, err := auth.userController.Login(cxt)
 
 	if err != nil {
-		cxt.JSON(http.StatusBadRequest, dto.Response{
+		cxt.JSON(lockoutStatus(err), dto.Response{
 			Message: err.Error(),
 		})
 		return
 	}
 
+	message := "OTP Sent"
+	if method == otpmethods.Authenticator {
+		message = "Enter the OTP from your authenticator app"
+	}
+
 	cxt.JSON(http.StatusOK, dto.LoginResponse{
-		Email:   email,
-		Message: "OTP Sent",
+		Email:     email,
+		OTPMethod: method,
+		Message:   message,
 	})
 	return
 }
@@ -90,7 +99,7 @@ func (auth *AuthAPI) LogoutGETHandler(cxt *gin.Context) {
 // @Summary Refresh Token
 // @ID refresh
 // @Tags auth
-// @Description A user needs a valid refresh token to access this endpoint.
+// @Description A user needs a valid refresh token to access this endpoint. Every refresh token works once, presenting one that was already used logs the session out.
 // @Produce json
 // @Success 200 {object} dto.Response
 // @Failure 401 {object} dto.Response
@@ -100,8 +109,8 @@ func (auth *AuthAPI) RefreshHandler(cxt *gin.Context) {
 	err := auth.userController.Refresh(cxt)
 
 	if err != nil {
-		if err.Error() == "Logged in other device!" {
-			cxt.JSON(http.StatusNetworkAuthenticationRequired, dto.Response{
+		if err.Error() == "Session revoked!" {
+			cxt.JSON(http.StatusUnauthorized, dto.Response{
 				Message: err.Error(),
 			})
 			return
@@ -129,13 +138,14 @@ func (auth *AuthAPI) RefreshHandler(cxt *gin.Context) {
 // @Param password body dto.Verify true "Verify"
 // @Success 200 {object} dto.Response
 // @Failure 401 {object} dto.Response
+// @Failure 403 {object} dto.Response
 // @Failure 400 {object} dto.Response
 // @Router /setpassword [post]
 func (auth *AuthAPI) VerifyHandler(cxt *gin.Context) {
 	err := auth.userController.Verify(cxt)
 
 	if err != nil {
-		cxt.JSON(http.StatusBadRequest, dto.Response{
+		cxt.JSON(verifyTokenErrorStatus(err), dto.Response{
 			Message: err.Error(),
 		})
 		return
@@ -177,17 +187,20 @@ func (auth *AuthAPI) OTPGETHandler(cxt *gin.Context) {
 // @Summary Submit OTP
 // @ID submitOTP
 // @Tags auth
+// @Description The OTP is checked for the user the otp cookie was issued to at login, the email is optional and has to match. The OTP can also be one of your recovery codes, each of them works once. Method is 0 for email, 1 for the authenticator app and 2 for a recovery code, only your own OTP method or 2 is accepted and it defaults to your OTP method.
 // @Produce json
 // @Param otp body dto.OTP true "Verify OTP"
 // @Success 200 {object} dto.OTPResponse
 // @Failure 401 {object} dto.Response
 // @Failure 400 {object} dto.Response
+// @Failure 423 {object} dto.Response
+// @Failure 429 {object} dto.Response
 // @Router /otp [post]
 func (auth *AuthAPI) OTPHandler(cxt *gin.Context) {
 	userId, email, role, err := auth.userController.OTPVerication(cxt)
 
 	if err != nil {
-		cxt.JSON(http.StatusBadRequest, dto.Response{
+		cxt.JSON(lockoutStatus(err), dto.Response{
 			Message: err.Error(),
 		})
 		return
@@ -245,13 +258,14 @@ func (auth *AuthAPI) ChangePasswordHandler(cxt *gin.Context) {
 // @Param token path string true "Verify Token"
 // @Success 200 {object} dto.Response
 // @Failure 401 {object} dto.Response
+// @Failure 403 {object} dto.Response
 // @Failure 400 {object} dto.Response
 // @Router /verifytoken/{token} [post]
 func (auth *AuthAPI) CheckVerifyTokenValidityHandler(cxt *gin.Context) {
 	err := auth.userController.CheckVerifyTokenValidity(cxt)
 
 	if err != nil {
-		cxt.JSON(http.StatusBadRequest, dto.Response{
+		cxt.JSON(verifyTokenErrorStatus(err), dto.Response{
 			Message: err.Error(),
 		})
 		return
@@ -315,6 +329,32 @@ func (auth *AuthAPI) CreateResetTokenHandler(cxt *gin.Context) {
 	}
 }
 
+// ResendVerificationByEmail godoc
+// @Summary Send a new verification link to your email if your account is not verified yet, the answer is the same for any email
+// @ID resendVerificationByEmail
+// @Tags auth
+// @Produce json
+// @Param resendVerification body dto.ResendVerificationDTO true "Email"
+// @Success 200 {object} dto.Response
+// @Failure 401 {object} dto.Response
+// @Failure 400 {object} dto.Response
+// @Router /resendverification [post]
+func (auth *AuthAPI) ResendVerificationByEmailHandler(cxt *gin.Context) {
+	err := auth.userController.ResendVerificationByEmail(cxt)
+
+	if err != nil {
+		cxt.JSON(http.StatusBadRequest, dto.Response{
+			Message: err.Error(),
+		})
+		return
+	} else {
+		cxt.JSON(http.StatusOK, dto.Response{
+			Message: "If the email belongs to an account that isn't verified yet, a verification email has been sent",
+		})
+		return
+	}
+}
+
 // ResetPassword godoc
 // @Summary Reset password if you have a valid token
 // @ID resetPassword
@@ -340,3 +380,24 @@ func (auth *AuthAPI) ResetPasswordHandler(cxt *gin.Context) {
 		return
 	}
 }
+
+// verifyTokenErrorStatus answers expired verification tokens with 403 so that the frontend can offer a new link, other errors stay 400.
+func verifyTokenErrorStatus(err error) int {
+	if err.Error() == "Verify Token Expired!" {
+		return http.StatusForbidden
+	}
+
+	return http.StatusBadRequest
+}
+
+// lockoutStatus tells a locked account or a throttled client apart from a wrong password or OTP.
+func lockoutStatus(err error) int {
+	switch err.Error() {
+	case "Account locked!":
+		return http.StatusLocked
+	case "Too many attempts!":
+		return http.StatusTooManyRequests
+	}
+
+	return http.StatusBadRequest
+}
@@ -0,0 +1,144 @@
+package apis
+
+import (
+	"elect/controllers"
+	"elect/database"
+	"elect/database/databasetest"
+	"elect/directory"
+	"elect/jwtkeys"
+	"elect/models"
+	"elect/roles"
+	"elect/services"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/securecookie"
+	"github.com/jinzhu/gorm"
+	uuid "github.com/satori/go.uuid"
+)
+
+// refreshTestServer serves RefreshHandler against a database of its own, the test is skipped without TEST_DATABASE_URL.
+func refreshTestServer(t *testing.T) (*gin.Engine, database.Database, *gorm.DB, services.JWTService, services.SessionService) {
+	db, conn := databasetest.New(t)
+
+	os.Setenv("COOKIE_HASH_SECRET", "cookie-hash-secret")
+	os.Setenv("ACCESS_TOKEN_SECRET", "access-token-secret")
+	os.Setenv("REFRESH_TOKEN_SECRET", "refresh-token-secret")
+
+	keySet, err := jwtkeys.FromEnv()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	jwtService := services.NewJWTService("elect.test", db, keySet)
+	sessionService := services.NewSessionService(db)
+	otpService, err := services.NewOTPService(db, nil, "refresh-test-otp-encryption-key-0123")
+	if err != nil {
+		t.Fatal(err)
+	}
+	userController := controllers.NewUserController(services.NewUserService(db, nil), otpService, services.NewLockoutService(db, nil), sessionService, services.NewDirectoryService(db, directory.Config{}), jwtService)
+
+	gin.SetMode(gin.TestMode)
+	server := gin.New()
+	server.POST("/refresh", NewAuthAPI(userController).RefreshHandler)
+
+	return server, db, conn, jwtService, sessionService
+}
+
+func refreshRequest(t *testing.T, server *gin.Engine, cookie *http.Cookie) *httptest.ResponseRecorder {
+	request := httptest.NewRequest(http.MethodPost, "/refresh", nil)
+	request.AddCookie(cookie)
+
+	recorder := httptest.NewRecorder()
+	server.ServeHTTP(recorder, request)
+
+	return recorder
+}
+
+func tokenCookie(t *testing.T, accessToken string, refreshToken string) *http.Cookie {
+	encoded, err := securecookie.New([]byte(os.Getenv("COOKIE_HASH_SECRET")), nil).Encode("tokens", map[string]string{
+		"access_token":  accessToken,
+		"refresh_token": refreshToken,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return &http.Cookie{Name: "token", Value: encoded}
+}
+
+func TestRefreshRotatesAndDetectsReuse(t *testing.T) {
+	server, db, conn, jwtService, sessionService := refreshTestServer(t)
+
+	password, err := database.HashPassword("Password@123")
+	if err != nil {
+		t.Fatal(err)
+	}
+	id := uuid.NewV4()
+	user := models.User{UserID: id, FirstName: "Test", LastName: "User", RegNumber: id.String()[:12], Email: id.String() + "@elect.test", Password: password, Role: roles.Student, Verified: true}
+	if err := conn.Create(&user).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	authUser, err := db.FindUserForAuth(user.Email)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sessionId := uuid.NewV4().String()
+	accessToken := jwtService.GenerateToken(authUser, roles.Student, sessionId)
+	refreshToken := jwtService.GenerateRefreshToken(authUser, sessionId)
+	if err := sessionService.CreateSession(sessionId, user.UserID.String(), refreshToken, "127.0.0.1", "Go-http-client"); err != nil {
+		t.Fatal(err)
+	}
+
+	oldCookie := tokenCookie(t, accessToken, refreshToken)
+
+	recorder := refreshRequest(t, server, oldCookie)
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("refresh returned %d, want %d: %s", recorder.Code, http.StatusOK, recorder.Body.String())
+	}
+
+	var newCookie *http.Cookie
+	for _, cookie := range recorder.Result().Cookies() {
+		if cookie.Name == "token" {
+			newCookie = cookie
+		}
+	}
+	if newCookie == nil {
+		t.Fatal("refresh didn't set a new token cookie")
+	}
+
+	value := make(map[string]string)
+	if err := securecookie.New([]byte(os.Getenv("COOKIE_HASH_SECRET")), nil).Decode("tokens", newCookie.Value, &value); err != nil {
+		t.Fatal(err)
+	}
+	if value["access_token"] == "" || value["refresh_token"] == "" || value["refresh_token"] == refreshToken {
+		t.Fatal("refresh didn't issue a new token pair")
+	}
+
+	// The rotated token showing up again revokes the session, so the token that replaced it stops working too
+	recorder = refreshRequest(t, server, oldCookie)
+	if recorder.Code != http.StatusUnauthorized {
+		t.Errorf("replayed refresh returned %d, want %d", recorder.Code, http.StatusUnauthorized)
+	}
+
+	recorder = refreshRequest(t, server, &http.Cookie{Name: "token", Value: newCookie.Value})
+	if recorder.Code != http.StatusUnauthorized {
+		t.Errorf("refresh after the replay returned %d, want %d", recorder.Code, http.StatusUnauthorized)
+	}
+
+	var session models.Session
+	conn.Where("session_id = ?", sessionId).First(&session)
+	if session.RevokedAt == nil {
+		t.Error("session was not revoked")
+	}
+
+	var events []models.AuditEvent
+	conn.Where("action = ? AND target = ?", "session.token_reuse", "user:"+user.UserID.String()).Find(&events)
+	if len(events) != 1 {
+		t.Errorf("%d session.token_reuse events were recorded, want 1", len(events))
+	}
+}
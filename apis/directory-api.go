@@ -0,0 +1,42 @@
+package apis
+
+import (
+	"elect/controllers"
+	"elect/dto"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type DirectoryAPI struct {
+	directoryController controllers.DirectoryController
+}
+
+func NewDirectoryAPI(directoryController controllers.DirectoryController) *DirectoryAPI {
+	return &DirectoryAPI{
+		directoryController: directoryController,
+	}
+}
+
+// SyncStudents godoc
+// @Summary Sync students from the LDAP directory
+// @ID syncStudents
+// @Tags user
+// @Description Creates or updates a verified student for every entry under LDAP_BASE_DN matching LDAP_FILTER, in place of uploading them. New students are registered by you and log in with their directory password. Entries without a valid register number, first name or email, or clashing with another user, are skipped.
+// @Produce json
+// @Success 200 {object} dto.DirectorySyncDTO
+// @Failure 401 {object} dto.Response
+// @Failure 400 {object} dto.Response
+// @Router /api/directory/sync [post]
+func (directory *DirectoryAPI) SyncStudentsHandler(cxt *gin.Context) {
+	directorySyncDTO, err := directory.directoryController.SyncStudents(cxt)
+	if err != nil {
+		cxt.JSON(http.StatusBadRequest, dto.Response{
+			Message: err.Error(),
+		})
+		return
+	}
+
+	cxt.JSON(http.StatusOK, directorySyncDTO)
+	return
+}
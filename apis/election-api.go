@@ -121,6 +121,70 @@ func (election *ElectionAPI) DeleteElectionHandler(cxt *gin.Context) {
 	return
 }
 
+// AddPosition godoc
+// @Summary Add a position to the election you created
+// @ID position
+// @Tags position
+// @Produce json
+// @Param position body dto.CreatePositionDTO true "Position Details"
+// @Success 200 {object} dto.Response
+// @Failure 401 {object} dto.Response
+// @Failure 400 {object} dto.Response
+// @Router /api/position [post]
+func (election *ElectionAPI) AddPositionHandler(cxt *gin.Context) {
+	err := election.electionController.AddPosition(cxt)
+
+	if err != nil {
+		cxt.JSON(http.StatusBadRequest, dto.Response{
+			Message: err.Error(),
+		})
+		return
+	}
+
+	select {
+	case update <- []byte("update"):
+
+	default:
+	}
+
+	cxt.JSON(http.StatusOK, dto.Response{
+		Message: "Position added.",
+	})
+	return
+}
+
+// DeletePosition godoc
+// @Summary Delete a position of the election you created
+// @ID positionP
+// @Tags position
+// @Produce json
+// @Param id path string true "Position ID"
+// @Success 200 {object} dto.Response
+// @Failure 401 {object} dto.Response
+// @Failure 400 {object} dto.Response
+// @Router /api/position/{id} [delete]
+func (election *ElectionAPI) DeletePositionHandler(cxt *gin.Context) {
+	err := election.electionController.DeletePosition(cxt)
+
+	if err != nil {
+		cxt.JSON(http.StatusBadRequest, dto.Response{
+			Message: err.Error(),
+		})
+		return
+	}
+
+	select {
+	case update <- []byte("update"):
+
+	default:
+	}
+
+	cxt.JSON(http.StatusOK, dto.Response{
+		Message: "Position deleted.",
+	})
+	return
+}
+
 // AddParticipants godoc
 // @Summary Add participants to the election you created
 // @ID addParticipants
@@ -309,12 +373,12 @@ func (election *ElectionAPI) GetElectionHandler(cxt *gin.Context) {
 // @Tags participant
 // @Produce json
 // @Param vote body dto.CastVoteDTO true "Cast Vote"
-// @Success 200 {object} dto.Response
+// @Success 200 {object} dto.CastVoteResponse
 // @Failure 401 {object} dto.Response
 // @Failure 400 {object} dto.Response
 // @Router /api/vote [post]
 func (election *ElectionAPI) CastVoteHandler(cxt *gin.Context) {
-	err := election.electionController.CastVote(cxt)
+	receipt, err := election.electionController.CastVote(cxt)
 	if err != nil {
 		cxt.JSON(http.StatusBadRequest, dto.Response{
 			Message: err.Error(),
@@ -322,7 +386,8 @@ func (election *ElectionAPI) CastVoteHandler(cxt *gin.Context) {
 		return
 	}
 
-	cxt.JSON(http.StatusOK, dto.Response{
+	cxt.JSON(http.StatusOK, dto.CastVoteResponse{
+		Receipt: receipt,
 		Message: "Vote casted.",
 	})
 	return
@@ -351,12 +416,89 @@ func (election *ElectionAPI) GetElectionResultsHandler(cxt *gin.Context) {
 	return
 }
 
+// RecountVotes godoc
+// @Summary Recount the stored ballots of the election you created
+// @ID recountVotes
+// @Tags election
+// @Produce json
+// @Param id path string true "Election ID"
+// @Success 200 {object} dto.GeneralElectionResultsDTO
+// @Failure 401 {object} dto.Response
+// @Failure 400 {object} dto.Response
+// @Router /api/results/{id}/recount [post]
+func (election *ElectionAPI) RecountVotesHandler(cxt *gin.Context) {
+	generalElectionResultsDTO, err := election.electionController.RecountVotes(cxt)
+	if err != nil {
+		cxt.JSON(http.StatusBadRequest, dto.Response{
+			Message: err.Error(),
+		})
+		return
+	}
+
+	cxt.JSON(http.StatusOK, generalElectionResultsDTO)
+	return
+}
+
+// GetElectionReceipts godoc
+// @Summary Get the ballot receipts of a completed election to verify your vote was counted
+// @ID getElectionReceipts
+// @Tags election
+// @Produce json
+// @Param id path string true "Election ID"
+// @Success 200 {object} dto.ElectionReceiptsDTO
+// @Failure 400 {object} dto.Response
+// @Router /api/results/{id}/receipts [get]
+func (election *ElectionAPI) GetElectionReceiptsHandler(cxt *gin.Context) {
+	electionReceiptsDTO, err := election.electionController.GetElectionReceipts(cxt)
+	if err != nil {
+		cxt.JSON(http.StatusBadRequest, dto.Response{
+			Message: err.Error(),
+		})
+		return
+	}
+
+	cxt.JSON(http.StatusOK, electionReceiptsDTO)
+	return
+}
+
+// VerifyAuditChain godoc
+// @Summary Verify the hash chain of the audit log of the election you created
+// @ID verifyAuditChain
+// @Tags election
+// @Produce json
+// @Param id path string true "Election ID"
+// @Success 200 {object} dto.AuditChainDTO
+// @Failure 401 {object} dto.Response
+// @Failure 400 {object} dto.Response
+// @Router /api/audit/{id} [get]
+func (election *ElectionAPI) VerifyAuditChainHandler(cxt *gin.Context) {
+	auditChainDTO, err := election.electionController.VerifyAuditChain(cxt)
+	if err != nil {
+		cxt.JSON(http.StatusBadRequest, dto.Response{
+			Message: err.Error(),
+		})
+		return
+	}
+
+	cxt.JSON(http.StatusOK, auditChainDTO)
+	return
+}
+
 func (election *ElectionAPI) ElectionUpdatesHandler(cxt *gin.Context) {
 	electionWS(cxt.Writer, cxt.Request)
 }
 
 var connections = make(map[*websocket.Conn]bool)
 
+// PushElectionEvent sends a message to the connected websocket clients without blocking the caller.
+func PushElectionEvent(message []byte) {
+	select {
+	case update <- message:
+
+	default:
+	}
+}
+
 func electionWS(w http.ResponseWriter, r *http.Request) {
 	var wsUpgrader = websocket.Upgrader{}
 	conn, err := wsUpgrader.Upgrade(w, r, nil)
@@ -0,0 +1,32 @@
+package apis
+
+import (
+	"elect/controllers"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type JWKSAPI struct {
+	jwksController controllers.JWKSController
+}
+
+func NewJWKSAPI(jwksController controllers.JWKSController) *JWKSAPI {
+	return &JWKSAPI{
+		jwksController: jwksController,
+	}
+}
+
+// GetJWKS godoc
+// @Summary Get the public keys access tokens are signed with
+// @ID getJWKS
+// @Tags auth
+// @Description Other services can verify ELECT access tokens with these keys, the kid header of a token names its key. The set is empty while tokens are signed with HS256.
+// @Produce json
+// @Success 200 {object} jwtkeys.JSONWebKeySet
+// @Router /.well-known/jwks.json [get]
+func (jwks *JWKSAPI) GetJWKSHandler(cxt *gin.Context) {
+	// Caches shouldn't hold on to a key set for longer than a rotation takes
+	cxt.Header("Cache-Control", "public, max-age=300")
+	cxt.JSON(http.StatusOK, jwks.jwksController.GetJWKS())
+}
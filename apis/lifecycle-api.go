@@ -0,0 +1,206 @@
+package apis
+
+import (
+	"elect/controllers"
+	"elect/dto"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type LifecycleAPI struct {
+	lifecycleController controllers.LifecycleController
+}
+
+func NewLifecycleAPI(lifecycleController controllers.LifecycleController) *LifecycleAPI {
+	return &LifecycleAPI{
+		lifecycleController: lifecycleController,
+	}
+}
+
+// OpenElection godoc
+// @Summary Open the nominations of a draft election you created
+// @ID openElection
+// @Tags lifecycle
+// @Produce json
+// @Param id path string true "Election ID"
+// @Success 200 {object} dto.Response
+// @Failure 401 {object} dto.Response
+// @Failure 400 {object} dto.Response
+// @Router /api/election/{id}/open [post]
+func (lifecycle *LifecycleAPI) OpenElectionHandler(cxt *gin.Context) {
+	err := lifecycle.lifecycleController.OpenElection(cxt)
+	if err != nil {
+		cxt.JSON(http.StatusBadRequest, dto.Response{
+			Message: err.Error(),
+		})
+		return
+	}
+
+	select {
+	case update <- []byte("update"):
+
+	default:
+	}
+
+	cxt.JSON(http.StatusOK, dto.Response{
+		Message: "Election opened.",
+	})
+	return
+}
+
+// PauseElection godoc
+// @Summary Pause the election you created
+// @ID pauseElection
+// @Tags lifecycle
+// @Produce json
+// @Param id path string true "Election ID"
+// @Success 200 {object} dto.Response
+// @Failure 401 {object} dto.Response
+// @Failure 400 {object} dto.Response
+// @Router /api/election/{id}/pause [post]
+func (lifecycle *LifecycleAPI) PauseElectionHandler(cxt *gin.Context) {
+	err := lifecycle.lifecycleController.PauseElection(cxt)
+	if err != nil {
+		cxt.JSON(http.StatusBadRequest, dto.Response{
+			Message: err.Error(),
+		})
+		return
+	}
+
+	select {
+	case update <- []byte("update"):
+
+	default:
+	}
+
+	cxt.JSON(http.StatusOK, dto.Response{
+		Message: "Election paused.",
+	})
+	return
+}
+
+// ResumeElection godoc
+// @Summary Resume the paused election you created
+// @ID resumeElection
+// @Tags lifecycle
+// @Produce json
+// @Param id path string true "Election ID"
+// @Success 200 {object} dto.Response
+// @Failure 401 {object} dto.Response
+// @Failure 400 {object} dto.Response
+// @Router /api/election/{id}/resume [post]
+func (lifecycle *LifecycleAPI) ResumeElectionHandler(cxt *gin.Context) {
+	err := lifecycle.lifecycleController.ResumeElection(cxt)
+	if err != nil {
+		cxt.JSON(http.StatusBadRequest, dto.Response{
+			Message: err.Error(),
+		})
+		return
+	}
+
+	select {
+	case update <- []byte("update"):
+
+	default:
+	}
+
+	cxt.JSON(http.StatusOK, dto.Response{
+		Message: "Election resumed.",
+	})
+	return
+}
+
+// ExtendElection godoc
+// @Summary Extend the voting of the election you created
+// @ID extendElection
+// @Tags lifecycle
+// @Produce json
+// @Param id path string true "Election ID"
+// @Param ending_at body dto.ExtendElectionDTO true "New Ending Time"
+// @Success 200 {object} dto.Response
+// @Failure 401 {object} dto.Response
+// @Failure 400 {object} dto.Response
+// @Router /api/election/{id}/extend [post]
+func (lifecycle *LifecycleAPI) ExtendElectionHandler(cxt *gin.Context) {
+	err := lifecycle.lifecycleController.ExtendElection(cxt)
+	if err != nil {
+		cxt.JSON(http.StatusBadRequest, dto.Response{
+			Message: err.Error(),
+		})
+		return
+	}
+
+	select {
+	case update <- []byte("update"):
+
+	default:
+	}
+
+	cxt.JSON(http.StatusOK, dto.Response{
+		Message: "Election extended.",
+	})
+	return
+}
+
+// PublishResults godoc
+// @Summary Publish the results of the completed election you created ahead of time
+// @ID publishResults
+// @Tags lifecycle
+// @Produce json
+// @Param id path string true "Election ID"
+// @Success 200 {object} dto.Response
+// @Failure 401 {object} dto.Response
+// @Failure 400 {object} dto.Response
+// @Router /api/election/{id}/publish [post]
+func (lifecycle *LifecycleAPI) PublishResultsHandler(cxt *gin.Context) {
+	err := lifecycle.lifecycleController.PublishResults(cxt)
+	if err != nil {
+		cxt.JSON(http.StatusBadRequest, dto.Response{
+			Message: err.Error(),
+		})
+		return
+	}
+
+	select {
+	case update <- []byte("update"):
+
+	default:
+	}
+
+	cxt.JSON(http.StatusOK, dto.Response{
+		Message: "Results published.",
+	})
+	return
+}
+
+// ArchiveElection godoc
+// @Summary Archive the election you created after its results are published
+// @ID archiveElection
+// @Tags lifecycle
+// @Produce json
+// @Param id path string true "Election ID"
+// @Success 200 {object} dto.Response
+// @Failure 401 {object} dto.Response
+// @Failure 400 {object} dto.Response
+// @Router /api/election/{id}/archive [post]
+func (lifecycle *LifecycleAPI) ArchiveElectionHandler(cxt *gin.Context) {
+	err := lifecycle.lifecycleController.ArchiveElection(cxt)
+	if err != nil {
+		cxt.JSON(http.StatusBadRequest, dto.Response{
+			Message: err.Error(),
+		})
+		return
+	}
+
+	select {
+	case update <- []byte("update"):
+
+	default:
+	}
+
+	cxt.JSON(http.StatusOK, dto.Response{
+		Message: "Election archived.",
+	})
+	return
+}
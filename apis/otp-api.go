@@ -0,0 +1,136 @@
+package apis
+
+import (
+	"elect/controllers"
+	"elect/dto"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type OTPAPI struct {
+	otpController controllers.OTPController
+}
+
+func NewOTPAPI(otpController controllers.OTPController) *OTPAPI {
+	return &OTPAPI{
+		otpController: otpController,
+	}
+}
+
+// EnrollAuthenticator godoc
+// @Summary Start adding an authenticator app, scan the QR code(base64 PNG) or open the otpauth URL and confirm with a code from the app
+// @ID enrollAuthenticator
+// @Tags otp
+// @Produce json
+// @Success 200 {object} dto.TOTPEnrollmentDTO
+// @Failure 401 {object} dto.Response
+// @Failure 400 {object} dto.Response
+// @Router /api/otp/enroll [post]
+func (otp *OTPAPI) EnrollAuthenticatorHandler(cxt *gin.Context) {
+	enrollment, err := otp.otpController.EnrollAuthenticator(cxt)
+	if err != nil {
+		cxt.JSON(http.StatusBadRequest, dto.Response{
+			Message: err.Error(),
+		})
+		return
+	}
+
+	cxt.JSON(http.StatusOK, enrollment)
+	return
+}
+
+// ConfirmAuthenticator godoc
+// @Summary Confirm the authenticator app with a code from it, it becomes your OTP method for logging in
+// @ID confirmAuthenticator
+// @Tags otp
+// @Produce json
+// @Param otp body dto.ConfirmTOTPDTO true "Authenticator OTP"
+// @Success 200 {object} dto.Response
+// @Failure 401 {object} dto.Response
+// @Failure 400 {object} dto.Response
+// @Router /api/otp/confirm [post]
+func (otp *OTPAPI) ConfirmAuthenticatorHandler(cxt *gin.Context) {
+	err := otp.otpController.ConfirmAuthenticator(cxt)
+	if err != nil {
+		cxt.JSON(http.StatusBadRequest, dto.Response{
+			Message: err.Error(),
+		})
+		return
+	}
+
+	cxt.JSON(http.StatusOK, dto.Response{
+		Message: "Authenticator app added.",
+	})
+	return
+}
+
+// SetOTPMethod godoc
+// @Summary Choose how you get the OTP when logging in, 0 for email and 1 for the authenticator app
+// @ID setOTPMethod
+// @Tags otp
+// @Produce json
+// @Param method body dto.OTPMethodDTO true "OTP Method"
+// @Success 200 {object} dto.Response
+// @Failure 401 {object} dto.Response
+// @Failure 400 {object} dto.Response
+// @Router /api/otp/method [put]
+func (otp *OTPAPI) SetOTPMethodHandler(cxt *gin.Context) {
+	err := otp.otpController.SetOTPMethod(cxt)
+	if err != nil {
+		cxt.JSON(http.StatusBadRequest, dto.Response{
+			Message: err.Error(),
+		})
+		return
+	}
+
+	cxt.JSON(http.StatusOK, dto.Response{
+		Message: "OTP method changed.",
+	})
+	return
+}
+
+// GenerateRecoveryCodes godoc
+// @Summary Get 10 new single use recovery codes for logging in without an OTP, the old ones stop working and the new ones are only shown this once
+// @ID generateRecoveryCodes
+// @Tags otp
+// @Produce json
+// @Success 200 {object} dto.RecoveryCodesDTO
+// @Failure 401 {object} dto.Response
+// @Failure 400 {object} dto.Response
+// @Router /api/otp/recovery-codes [post]
+func (otp *OTPAPI) GenerateRecoveryCodesHandler(cxt *gin.Context) {
+	recoveryCodes, err := otp.otpController.GenerateRecoveryCodes(cxt)
+	if err != nil {
+		cxt.JSON(http.StatusBadRequest, dto.Response{
+			Message: err.Error(),
+		})
+		return
+	}
+
+	cxt.JSON(http.StatusOK, recoveryCodes)
+	return
+}
+
+// RegenerateStudentRecoveryCodes godoc
+// @Summary Generate new recovery codes for a student you registered who can't get an OTP, the student is emailed that it happened
+// @ID regenerateStudentRecoveryCodes
+// @Tags otp
+// @Produce json
+// @Param id path string true "Student User ID"
+// @Success 200 {object} dto.RecoveryCodesDTO
+// @Failure 401 {object} dto.Response
+// @Failure 400 {object} dto.Response
+// @Router /api/registeredstudent/{id}/recovery-codes [post]
+func (otp *OTPAPI) RegenerateStudentRecoveryCodesHandler(cxt *gin.Context) {
+	recoveryCodes, err := otp.otpController.RegenerateStudentRecoveryCodes(cxt)
+	if err != nil {
+		cxt.JSON(http.StatusBadRequest, dto.Response{
+			Message: err.Error(),
+		})
+		return
+	}
+
+	cxt.JSON(http.StatusOK, recoveryCodes)
+	return
+}
@@ -0,0 +1,67 @@
+package apis
+
+import (
+	"elect/controllers"
+	"elect/dto"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type OutboxAPI struct {
+	outboxController controllers.OutboxController
+}
+
+func NewOutboxAPI(outboxController controllers.OutboxController) *OutboxAPI {
+	return &OutboxAPI{
+		outboxController: outboxController,
+	}
+}
+
+// GetFailedEmails godoc
+// @Summary Get the emails to the students you registered that failed to send
+// @ID getFailedEmails
+// @Tags emails
+// @Produce json
+// @Success 200 {array} dto.OutboxEmailDTO
+// @Failure 401 {object} dto.Response
+// @Failure 400 {object} dto.Response
+// @Router /api/emails/failed [get]
+func (outbox *OutboxAPI) GetFailedEmailsHandler(cxt *gin.Context) {
+	outboxEmailDTOs, err := outbox.outboxController.GetFailedEmails(cxt)
+	if err != nil {
+		cxt.JSON(http.StatusBadRequest, dto.Response{
+			Message: err.Error(),
+		})
+		return
+	}
+
+	cxt.JSON(http.StatusOK, outboxEmailDTOs)
+	return
+}
+
+// ResendEmails godoc
+// @Summary Resend a failed email, or all of them when no ID is given
+// @ID resendEmails
+// @Tags emails
+// @Produce json
+// @Param email body dto.ResendEmailsDTO true "Email ID"
+// @Success 200 {object} dto.Response
+// @Failure 401 {object} dto.Response
+// @Failure 400 {object} dto.Response
+// @Router /api/emails/resend [post]
+func (outbox *OutboxAPI) ResendEmailsHandler(cxt *gin.Context) {
+	count, err := outbox.outboxController.ResendEmails(cxt)
+	if err != nil {
+		cxt.JSON(http.StatusBadRequest, dto.Response{
+			Message: err.Error(),
+		})
+		return
+	}
+
+	cxt.JSON(http.StatusOK, dto.Response{
+		Message: strconv.Itoa(count) + " emails queued.",
+	})
+	return
+}
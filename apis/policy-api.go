@@ -0,0 +1,168 @@
+package apis
+
+import (
+	"elect/controllers"
+	"elect/dto"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type PolicyAPI struct {
+	policyController controllers.PolicyController
+}
+
+func NewPolicyAPI(policyController controllers.PolicyController) *PolicyAPI {
+	return &PolicyAPI{
+		policyController: policyController,
+	}
+}
+
+// GetPolicies godoc
+// @Summary Get the authorization policies
+// @ID getPolicies
+// @Tags policies
+// @Description Each policy allows or denies a role(-2 waiting for OTP, -1 anonymous, 0 student, 1 admin, 2 super admin or * for anyone) a path and method, the paths and methods may use * as a wildcard. Deny wins over allow.
+// @Produce json
+// @Success 200 {array} dto.PolicyDTO
+// @Failure 401 {object} dto.Response
+// @Failure 400 {object} dto.Response
+// @Router /api/policies [get]
+func (policy *PolicyAPI) GetPoliciesHandler(cxt *gin.Context) {
+	policyDTOs, err := policy.policyController.GetPolicies(cxt)
+	if err != nil {
+		cxt.JSON(http.StatusBadRequest, dto.Response{
+			Message: err.Error(),
+		})
+		return
+	}
+
+	cxt.JSON(http.StatusOK, policyDTOs)
+	return
+}
+
+// AddPolicy godoc
+// @Summary Add an authorization policy
+// @ID addPolicy
+// @Tags policies
+// @Description Takes effect right away on this instance and within POLICY_RELOAD_INTERVAL on the others. Policies that would stop super admins from managing policies are refused.
+// @Accept json
+// @Produce json
+// @Param policy body dto.PolicyDTO true "Policy"
+// @Success 200 {object} dto.PolicyDTO
+// @Failure 401 {object} dto.Response
+// @Failure 400 {object} dto.Response
+// @Router /api/policies [post]
+func (policy *PolicyAPI) AddPolicyHandler(cxt *gin.Context) {
+	policyDTO, err := policy.policyController.AddPolicy(cxt)
+	if err != nil {
+		cxt.JSON(http.StatusBadRequest, dto.Response{
+			Message: err.Error(),
+		})
+		return
+	}
+
+	cxt.JSON(http.StatusOK, policyDTO)
+	return
+}
+
+// EditPolicy godoc
+// @Summary Edit an authorization policy
+// @ID editPolicy
+// @Tags policies
+// @Description Takes effect right away on this instance and within POLICY_RELOAD_INTERVAL on the others. Changes that would stop super admins from managing policies are refused.
+// @Accept json
+// @Produce json
+// @Param id path int true "Policy ID"
+// @Param policy body dto.PolicyDTO true "Policy"
+// @Success 200 {object} dto.PolicyDTO
+// @Failure 401 {object} dto.Response
+// @Failure 400 {object} dto.Response
+// @Router /api/policy/{id} [put]
+func (policy *PolicyAPI) EditPolicyHandler(cxt *gin.Context) {
+	policyDTO, err := policy.policyController.EditPolicy(cxt)
+	if err != nil {
+		cxt.JSON(http.StatusBadRequest, dto.Response{
+			Message: err.Error(),
+		})
+		return
+	}
+
+	cxt.JSON(http.StatusOK, policyDTO)
+	return
+}
+
+// DeletePolicy godoc
+// @Summary Delete an authorization policy
+// @ID deletePolicy
+// @Tags policies
+// @Produce json
+// @Param id path int true "Policy ID"
+// @Success 200 {object} dto.Response
+// @Failure 401 {object} dto.Response
+// @Failure 400 {object} dto.Response
+// @Router /api/policy/{id} [delete]
+func (policy *PolicyAPI) DeletePolicyHandler(cxt *gin.Context) {
+	err := policy.policyController.DeletePolicy(cxt)
+	if err != nil {
+		cxt.JSON(http.StatusBadRequest, dto.Response{
+			Message: err.Error(),
+		})
+		return
+	}
+
+	cxt.JSON(http.StatusOK, dto.Response{
+		Message: "Policy deleted.",
+	})
+	return
+}
+
+// ReloadPolicies godoc
+// @Summary Reload the authorization policies from the database
+// @ID reloadPolicies
+// @Tags policies
+// @Description Only this instance is reloaded, the others reload every POLICY_RELOAD_INTERVAL.
+// @Produce json
+// @Success 200 {object} dto.Response
+// @Failure 401 {object} dto.Response
+// @Failure 400 {object} dto.Response
+// @Router /api/policies/reload [post]
+func (policy *PolicyAPI) ReloadPoliciesHandler(cxt *gin.Context) {
+	err := policy.policyController.ReloadPolicies(cxt)
+	if err != nil {
+		cxt.JSON(http.StatusBadRequest, dto.Response{
+			Message: err.Error(),
+		})
+		return
+	}
+
+	cxt.JSON(http.StatusOK, dto.Response{
+		Message: "Policies reloaded.",
+	})
+	return
+}
+
+// CheckPolicy godoc
+// @Summary Check if a role may make a request
+// @ID checkPolicy
+// @Tags policies
+// @Description A dry run of the authorization check against the policies in effect, matched is the policy that decided it and is left out when none matched.
+// @Accept json
+// @Produce json
+// @Param check body dto.PolicyCheckDTO true "Role, path and method"
+// @Success 200 {object} dto.PolicyCheckResultDTO
+// @Failure 401 {object} dto.Response
+// @Failure 400 {object} dto.Response
+// @Router /api/policies/check [post]
+func (policy *PolicyAPI) CheckPolicyHandler(cxt *gin.Context) {
+	policyCheckResultDTO, err := policy.policyController.CheckPolicy(cxt)
+	if err != nil {
+		cxt.JSON(http.StatusBadRequest, dto.Response{
+			Message: err.Error(),
+		})
+		return
+	}
+
+	cxt.JSON(http.StatusOK, policyCheckResultDTO)
+	return
+}
@@ -0,0 +1,68 @@
+package apis
+
+import (
+	"elect/controllers"
+	"elect/dto"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ReminderAPI struct {
+	reminderController controllers.ReminderController
+}
+
+func NewReminderAPI(reminderController controllers.ReminderController) *ReminderAPI {
+	return &ReminderAPI{
+		reminderController: reminderController,
+	}
+}
+
+// ScheduleReminder godoc
+// @Summary Email a reminder to the participants who haven't voted in the election you created, now or at a later time
+// @ID scheduleReminder
+// @Tags reminders
+// @Produce json
+// @Param id path string true "Election ID"
+// @Param reminder body dto.ScheduleReminderDTO true "Sending Time"
+// @Success 200 {object} dto.Response
+// @Failure 401 {object} dto.Response
+// @Failure 400 {object} dto.Response
+// @Router /api/election/{id}/reminders [post]
+func (reminder *ReminderAPI) ScheduleReminderHandler(cxt *gin.Context) {
+	err := reminder.reminderController.ScheduleReminder(cxt)
+	if err != nil {
+		cxt.JSON(http.StatusBadRequest, dto.Response{
+			Message: err.Error(),
+		})
+		return
+	}
+
+	cxt.JSON(http.StatusOK, dto.Response{
+		Message: "Reminder scheduled.",
+	})
+	return
+}
+
+// GetReminders godoc
+// @Summary Get the reminders of the election you created with the delivery status of every participant
+// @ID getReminders
+// @Tags reminders
+// @Produce json
+// @Param id path string true "Election ID"
+// @Success 200 {array} dto.ReminderDTO
+// @Failure 401 {object} dto.Response
+// @Failure 400 {object} dto.Response
+// @Router /api/election/{id}/reminders [get]
+func (reminder *ReminderAPI) GetRemindersHandler(cxt *gin.Context) {
+	reminderDTOs, err := reminder.reminderController.GetReminders(cxt)
+	if err != nil {
+		cxt.JSON(http.StatusBadRequest, dto.Response{
+			Message: err.Error(),
+		})
+		return
+	}
+
+	cxt.JSON(http.StatusOK, reminderDTOs)
+	return
+}
@@ -0,0 +1,93 @@
+package apis
+
+import (
+	"elect/controllers"
+	"elect/dto"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type SessionAPI struct {
+	sessionController controllers.SessionController
+}
+
+func NewSessionAPI(sessionController controllers.SessionController) *SessionAPI {
+	return &SessionAPI{
+		sessionController: sessionController,
+	}
+}
+
+// GetSessions godoc
+// @Summary Get the devices you are logged in on
+// @ID getSessions
+// @Tags sessions
+// @Description The session you are using has current set to true.
+// @Produce json
+// @Success 200 {array} dto.SessionDTO
+// @Failure 401 {object} dto.Response
+// @Failure 400 {object} dto.Response
+// @Router /api/sessions [get]
+func (session *SessionAPI) GetSessionsHandler(cxt *gin.Context) {
+	sessionDTOs, err := session.sessionController.GetSessions(cxt)
+	if err != nil {
+		cxt.JSON(http.StatusBadRequest, dto.Response{
+			Message: err.Error(),
+		})
+		return
+	}
+
+	cxt.JSON(http.StatusOK, sessionDTOs)
+	return
+}
+
+// RevokeSession godoc
+// @Summary Log out one of your sessions
+// @ID revokeSession
+// @Tags sessions
+// @Description The device is logged out once its access token expires.
+// @Produce json
+// @Param id path string true "Session ID"
+// @Success 200 {object} dto.Response
+// @Failure 401 {object} dto.Response
+// @Failure 400 {object} dto.Response
+// @Router /api/session/{id} [delete]
+func (session *SessionAPI) RevokeSessionHandler(cxt *gin.Context) {
+	err := session.sessionController.RevokeSession(cxt)
+	if err != nil {
+		cxt.JSON(http.StatusBadRequest, dto.Response{
+			Message: err.Error(),
+		})
+		return
+	}
+
+	cxt.JSON(http.StatusOK, dto.Response{
+		Message: "Session revoked.",
+	})
+	return
+}
+
+// RevokeStudentSessions godoc
+// @Summary Log a student you have registered out of every device
+// @ID revokeStudentSessions
+// @Tags sessions
+// @Produce json
+// @Param id path string true "Student ID"
+// @Success 200 {object} dto.Response
+// @Failure 401 {object} dto.Response
+// @Failure 400 {object} dto.Response
+// @Router /api/registeredstudent/{id}/sessions [delete]
+func (session *SessionAPI) RevokeStudentSessionsHandler(cxt *gin.Context) {
+	err := session.sessionController.RevokeStudentSessions(cxt)
+	if err != nil {
+		cxt.JSON(http.StatusBadRequest, dto.Response{
+			Message: err.Error(),
+		})
+		return
+	}
+
+	cxt.JSON(http.StatusOK, dto.Response{
+		Message: "Logged out everywhere.",
+	})
+	return
+}
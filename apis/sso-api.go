@@ -0,0 +1,63 @@
+package apis
+
+import (
+	"elect/controllers"
+	"elect/dto"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type SSOAPI struct {
+	ssoController controllers.SSOController
+}
+
+func NewSSOAPI(ssoController controllers.SSOController) *SSOAPI {
+	return &SSOAPI{
+		ssoController: ssoController,
+	}
+}
+
+// BeginSSOLogin godoc
+// @Summary Log in with the institution's account
+// @ID beginSSOLogin
+// @Tags sso
+// @Description Redirects to the OpenID Connect provider set by OIDC_ISSUER, which sends the user back to /sso/callback.
+// @Success 302
+// @Failure 400 {object} dto.Response
+// @Router /sso/login [get]
+func (sso *SSOAPI) BeginLoginHandler(cxt *gin.Context) {
+	url, err := sso.ssoController.BeginLogin(cxt)
+	if err != nil {
+		cxt.JSON(http.StatusBadRequest, dto.Response{
+			Message: err.Error(),
+		})
+		return
+	}
+
+	cxt.Redirect(http.StatusFound, url)
+	return
+}
+
+// FinishSSOLogin godoc
+// @Summary Where the OpenID Connect provider sends the user back to, no OTP is needed
+// @ID finishSSOLogin
+// @Tags sso
+// @Description The first login links the provider's account to the user with the same register number or email, then sets the token cookie and redirects to the web app.
+// @Param code query string true "Authorization Code"
+// @Param state query string true "State"
+// @Success 302
+// @Failure 400 {object} dto.Response
+// @Router /sso/callback [get]
+func (sso *SSOAPI) FinishLoginHandler(cxt *gin.Context) {
+	_, _, _, err := sso.ssoController.FinishLogin(cxt)
+	if err != nil {
+		cxt.JSON(http.StatusBadRequest, dto.Response{
+			Message: err.Error(),
+		})
+		return
+	}
+
+	cxt.Redirect(http.StatusFound, "/")
+	return
+}
@@ -94,3 +94,51 @@ func (user *UserAPI) DeleteRegisteredStudentHandler(cxt *gin.Context) {
 		Message: "Deleted Successfully",
 	})
 }
+
+// ResendVerification godoc
+// @Summary Send a new verification link to the student you have registered
+// @ID resendVerification
+// @Tags user
+// @Produce json
+// @Param id path string true "Student ID"
+// @Success 200 {object} dto.Response
+// @Failure 401 {object} dto.Response
+// @Failure 400 {object} dto.Response
+// @Router /api/registeredstudent/{id}/resend-verification [post]
+func (user *UserAPI) ResendVerificationHandler(cxt *gin.Context) {
+	err := user.userController.ResendVerification(cxt)
+	if err != nil {
+		cxt.JSON(http.StatusBadRequest, dto.Response{
+			Message: err.Error(),
+		})
+		return
+	}
+
+	cxt.JSON(http.StatusOK, dto.Response{
+		Message: "Verification email sent",
+	})
+}
+
+// UnlockAccount godoc
+// @Summary Unlock the account of a student you have registered after too many failed logins
+// @ID unlockAccount
+// @Tags user
+// @Produce json
+// @Param id path string true "Student ID"
+// @Success 200 {object} dto.Response
+// @Failure 401 {object} dto.Response
+// @Failure 400 {object} dto.Response
+// @Router /api/registeredstudent/{id}/unlock [post]
+func (user *UserAPI) UnlockAccountHandler(cxt *gin.Context) {
+	err := user.userController.UnlockAccount(cxt)
+	if err != nil {
+		cxt.JSON(http.StatusBadRequest, dto.Response{
+			Message: err.Error(),
+		})
+		return
+	}
+
+	cxt.JSON(http.StatusOK, dto.Response{
+		Message: "Account unlocked",
+	})
+}
@@ -0,0 +1,165 @@
+package apis
+
+import (
+	"elect/controllers"
+	"elect/dto"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type WebAuthnAPI struct {
+	webAuthnController controllers.WebAuthnController
+}
+
+func NewWebAuthnAPI(webAuthnController controllers.WebAuthnController) *WebAuthnAPI {
+	return &WebAuthnAPI{
+		webAuthnController: webAuthnController,
+	}
+}
+
+// BeginPasskeyRegistration godoc
+// @Summary Start adding a passkey, pass the options to navigator.credentials.create()
+// @ID beginPasskeyRegistration
+// @Tags passkeys
+// @Produce json
+// @Success 200 {object} object
+// @Failure 401 {object} dto.Response
+// @Failure 400 {object} dto.Response
+// @Router /api/passkey/register/begin [post]
+func (passkey *WebAuthnAPI) BeginRegistrationHandler(cxt *gin.Context) {
+	creation, err := passkey.webAuthnController.BeginRegistration(cxt)
+	if err != nil {
+		cxt.JSON(http.StatusBadRequest, dto.Response{
+			Message: err.Error(),
+		})
+		return
+	}
+
+	cxt.JSON(http.StatusOK, creation)
+	return
+}
+
+// FinishPasskeyRegistration godoc
+// @Summary Add the passkey with the credential returned by navigator.credentials.create()
+// @ID finishPasskeyRegistration
+// @Tags passkeys
+// @Produce json
+// @Param name query string false "Passkey Name"
+// @Param credential body object true "Public Key Credential"
+// @Success 200 {object} dto.Response
+// @Failure 401 {object} dto.Response
+// @Failure 400 {object} dto.Response
+// @Router /api/passkey/register/finish [post]
+func (passkey *WebAuthnAPI) FinishRegistrationHandler(cxt *gin.Context) {
+	err := passkey.webAuthnController.FinishRegistration(cxt)
+	if err != nil {
+		cxt.JSON(http.StatusBadRequest, dto.Response{
+			Message: err.Error(),
+		})
+		return
+	}
+
+	cxt.JSON(http.StatusOK, dto.Response{
+		Message: "Passkey added.",
+	})
+	return
+}
+
+// BeginPasskeyLogin godoc
+// @Summary Start logging in with a passkey, pass the options to navigator.credentials.get()
+// @ID beginPasskeyLogin
+// @Tags passkeys
+// @Produce json
+// @Param login body dto.PasskeyLoginDTO true "Email"
+// @Success 200 {object} object
+// @Failure 401 {object} dto.Response
+// @Failure 400 {object} dto.Response
+// @Router /passkey/login/begin [post]
+func (passkey *WebAuthnAPI) BeginLoginHandler(cxt *gin.Context) {
+	assertion, err := passkey.webAuthnController.BeginLogin(cxt)
+	if err != nil {
+		cxt.JSON(http.StatusBadRequest, dto.Response{
+			Message: err.Error(),
+		})
+		return
+	}
+
+	cxt.JSON(http.StatusOK, assertion)
+	return
+}
+
+// FinishPasskeyLogin godoc
+// @Summary Log in with the assertion returned by navigator.credentials.get(), no OTP is needed
+// @ID finishPasskeyLogin
+// @Tags passkeys
+// @Produce json
+// @Param credential body object true "Public Key Credential"
+// @Success 200 {object} dto.OTPResponse
+// @Failure 401 {object} dto.Response
+// @Failure 400 {object} dto.Response
+// @Router /passkey/login/finish [post]
+func (passkey *WebAuthnAPI) FinishLoginHandler(cxt *gin.Context) {
+	userId, email, role, err := passkey.webAuthnController.FinishLogin(cxt)
+	if err != nil {
+		cxt.JSON(http.StatusBadRequest, dto.Response{
+			Message: err.Error(),
+		})
+		return
+	}
+
+	cxt.JSON(http.StatusOK, dto.OTPResponse{
+		UserId:  userId,
+		Email:   email,
+		Role:    role,
+		Message: "Login Sucessful!",
+	})
+	return
+}
+
+// GetPasskeys godoc
+// @Summary Get your passkeys
+// @ID getPasskeys
+// @Tags passkeys
+// @Produce json
+// @Success 200 {array} dto.PasskeyDTO
+// @Failure 401 {object} dto.Response
+// @Failure 400 {object} dto.Response
+// @Router /api/passkeys [get]
+func (passkey *WebAuthnAPI) GetPasskeysHandler(cxt *gin.Context) {
+	passkeyDTOs, err := passkey.webAuthnController.GetPasskeys(cxt)
+	if err != nil {
+		cxt.JSON(http.StatusBadRequest, dto.Response{
+			Message: err.Error(),
+		})
+		return
+	}
+
+	cxt.JSON(http.StatusOK, passkeyDTOs)
+	return
+}
+
+// DeletePasskey godoc
+// @Summary Remove one of your passkeys
+// @ID deletePasskey
+// @Tags passkeys
+// @Produce json
+// @Param id path string true "Passkey ID"
+// @Success 200 {object} dto.Response
+// @Failure 401 {object} dto.Response
+// @Failure 400 {object} dto.Response
+// @Router /api/passkey/{id} [delete]
+func (passkey *WebAuthnAPI) DeletePasskeyHandler(cxt *gin.Context) {
+	err := passkey.webAuthnController.DeletePasskey(cxt)
+	if err != nil {
+		cxt.JSON(http.StatusBadRequest, dto.Response{
+			Message: err.Error(),
+		})
+		return
+	}
+
+	cxt.JSON(http.StatusOK, dto.Response{
+		Message: "Passkey removed.",
+	})
+	return
+}
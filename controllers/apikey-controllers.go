@@ -0,0 +1,70 @@
+package controllers
+
+import (
+	"elect/dto"
+	"elect/middlewares"
+	"elect/services"
+	"errors"
+
+	"github.com/gin-gonic/gin"
+)
+
+type APIKeyController interface {
+	CreateAPIKey(cxt *gin.Context) (dto.NewAPIKeyDTO, error)
+	GetAPIKeys(cxt *gin.Context) ([]dto.APIKeyDTO, error)
+	RevokeAPIKey(cxt *gin.Context) error
+}
+
+type apiKeyController struct {
+	apiKeyService services.APIKeyService
+}
+
+func NewAPIKeyController(apiKeyService services.APIKeyService) APIKeyController {
+	return &apiKeyController{
+		apiKeyService: apiKeyService,
+	}
+}
+
+func (controller *apiKeyController) CreateAPIKey(cxt *gin.Context) (dto.NewAPIKeyDTO, error) {
+	var createAPIKeyDTO dto.CreateAPIKeyDTO
+	err := cxt.ShouldBindJSON(&createAPIKeyDTO)
+	if err != nil {
+		return dto.NewAPIKeyDTO{}, err
+	}
+
+	userId, _, err := middlewares.GetIdentity(cxt)
+	if err != nil {
+		return dto.NewAPIKeyDTO{}, err
+	}
+
+	// Only a login can create keys, otherwise a key could hand out wider scopes than its own
+	_, err = middlewares.GetSessionID(cxt)
+	if err != nil {
+		return dto.NewAPIKeyDTO{}, errors.New("Login required!")
+	}
+
+	return controller.apiKeyService.CreateAPIKey(userId, createAPIKeyDTO)
+}
+
+func (controller *apiKeyController) GetAPIKeys(cxt *gin.Context) ([]dto.APIKeyDTO, error) {
+	userId, _, err := middlewares.GetIdentity(cxt)
+	if err != nil {
+		return nil, err
+	}
+
+	return controller.apiKeyService.GetAPIKeys(userId)
+}
+
+func (controller *apiKeyController) RevokeAPIKey(cxt *gin.Context) error {
+	userId, _, err := middlewares.GetIdentity(cxt)
+	if err != nil {
+		return err
+	}
+
+	apiKeyId := cxt.Param("id")
+	if apiKeyId == "" {
+		return errors.New("Invalid API Key!")
+	}
+
+	return controller.apiKeyService.RevokeAPIKey(userId, apiKeyId)
+}
@@ -0,0 +1,32 @@
+package controllers
+
+import (
+	"elect/dto"
+	"elect/middlewares"
+	"elect/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type DirectoryController interface {
+	SyncStudents(cxt *gin.Context) (dto.DirectorySyncDTO, error)
+}
+
+type directoryController struct {
+	directoryService services.DirectoryService
+}
+
+func NewDirectoryController(directoryService services.DirectoryService) DirectoryController {
+	return &directoryController{
+		directoryService: directoryService,
+	}
+}
+
+func (controller *directoryController) SyncStudents(cxt *gin.Context) (dto.DirectorySyncDTO, error) {
+	userId, _, err := middlewares.GetIdentity(cxt)
+	if err != nil {
+		return dto.DirectorySyncDTO{}, err
+	}
+
+	return controller.directoryService.SyncStudents(userId)
+}
@@ -3,17 +3,16 @@ package controllers
 import (
 	"bytes"
 	"elect/dto"
+	"elect/middlewares"
 	"elect/services"
 	"errors"
 	"io"
 	"log"
 	"mime/multipart"
-	"os"
 	"strconv"
 	"strings"
 
 	"github.com/gin-gonic/gin"
-	"github.com/gorilla/securecookie"
 	"github.com/xuri/excelize/v2"
 )
 
@@ -21,6 +20,8 @@ type ElectionController interface {
 	CreateElection(cxt *gin.Context) error
 	EditElection(cxt *gin.Context) error
 	DeleteElection(cxt *gin.Context) error
+	AddPosition(cxt *gin.Context) error
+	DeletePosition(cxt *gin.Context) error
 	AddParticipants(cxt *gin.Context) (int, error)
 	DeleteParticipant(cxt *gin.Context) error
 	GetElections(cxt *gin.Context) ([]dto.GeneralElectionDTO, error)
@@ -28,19 +29,20 @@ type ElectionController interface {
 	ApproveCandidate(cxt *gin.Context) error
 	UnapproveCandidate(cxt *gin.Context) error
 	GetElection(cxt *gin.Context) (dto.GeneralElectionDTO, error)
-	CastVote(cxt *gin.Context) error
+	CastVote(cxt *gin.Context) (string, error)
 	GetElectionResults(cxt *gin.Context) (dto.GeneralElectionResultsDTO, error)
+	RecountVotes(cxt *gin.Context) (dto.GeneralElectionResultsDTO, error)
+	GetElectionReceipts(cxt *gin.Context) (dto.ElectionReceiptsDTO, error)
+	VerifyAuditChain(cxt *gin.Context) (dto.AuditChainDTO, error)
 }
 
 type electionController struct {
 	electionService services.ElectionService
-	jwtService      services.JWTService
 }
 
-func NewElectionController(electionService services.ElectionService, jwtService services.JWTService) ElectionController {
+func NewElectionController(electionService services.ElectionService) ElectionController {
 	return &electionController{
 		electionService: electionService,
-		jwtService:      jwtService,
 	}
 }
 
@@ -52,21 +54,7 @@ func (controller *electionController) CreateElection(cxt *gin.Context) error {
 		return err
 	}
 
-	cookie, err := cxt.Cookie("token")
-	if err != nil {
-		log.Println(err.Error())
-		return err
-	}
-
-	var s = securecookie.New([]byte(os.Getenv("COOKIE_HASH_SECRET")), nil)
-	value := make(map[string]string)
-	err = s.Decode("tokens", cookie, &value)
-	if err != nil {
-		log.Println(err.Error())
-		return err
-	}
-
-	userId, _, err := controller.jwtService.GetUserIDAndRole(value["access_token"])
+	userId, _, err := middlewares.GetIdentity(cxt)
 	if err != nil {
 		log.Println(err.Error())
 		return err
@@ -82,19 +70,7 @@ func (controller *electionController) EditElection(cxt *gin.Context) error {
 		return err
 	}
 
-	cookie, err := cxt.Cookie("token")
-	if err != nil {
-		return err
-	}
-
-	var s = securecookie.New([]byte(os.Getenv("COOKIE_HASH_SECRET")), nil)
-	value := make(map[string]string)
-	err = s.Decode("tokens", cookie, &value)
-	if err != nil {
-		return err
-	}
-
-	userId, _, err := controller.jwtService.GetUserIDAndRole(value["access_token"])
+	userId, _, err := middlewares.GetIdentity(cxt)
 	if err != nil {
 		return err
 	}
@@ -109,24 +85,42 @@ func (controller *electionController) DeleteElection(cxt *gin.Context) error {
 		return errors.New("Invalid Election ID!")
 	}
 
-	cookie, err := cxt.Cookie("token")
+	userId, _, err := middlewares.GetIdentity(cxt)
 	if err != nil {
 		return err
 	}
 
-	var s = securecookie.New([]byte(os.Getenv("COOKIE_HASH_SECRET")), nil)
-	value := make(map[string]string)
-	err = s.Decode("tokens", cookie, &value)
+	return controller.electionService.DeleteElection(userId, electionId)
+}
+
+func (controller *electionController) AddPosition(cxt *gin.Context) error {
+	var createPositionDTO dto.CreatePositionDTO
+	err := cxt.ShouldBindJSON(&createPositionDTO)
 	if err != nil {
 		return err
 	}
 
-	userId, _, err := controller.jwtService.GetUserIDAndRole(value["access_token"])
+	userId, _, err := middlewares.GetIdentity(cxt)
 	if err != nil {
 		return err
 	}
 
-	return controller.electionService.DeleteElection(userId, electionId)
+	return controller.electionService.AddPosition(userId, createPositionDTO)
+}
+
+func (controller *electionController) DeletePosition(cxt *gin.Context) error {
+	positionId := cxt.Param("id")
+	if positionId == "" {
+		log.Println("Invalid Position ID!")
+		return errors.New("Invalid Position ID!")
+	}
+
+	userId, _, err := middlewares.GetIdentity(cxt)
+	if err != nil {
+		return err
+	}
+
+	return controller.electionService.DeletePosition(userId, positionId)
 }
 
 func (controller *electionController) AddParticipants(cxt *gin.Context) (int, error) {
@@ -161,19 +155,7 @@ func (controller *electionController) AddParticipants(cxt *gin.Context) (int, er
 		return 0, err
 	}
 
-	cookie, err := cxt.Cookie("token")
-	if err != nil {
-		return 0, err
-	}
-
-	var s = securecookie.New([]byte(os.Getenv("COOKIE_HASH_SECRET")), nil)
-	value := make(map[string]string)
-	err = s.Decode("tokens", cookie, &value)
-	if err != nil {
-		return 0, err
-	}
-
-	userId, _, err := controller.jwtService.GetUserIDAndRole(value["access_token"])
+	userId, _, err := middlewares.GetIdentity(cxt)
 	if err != nil {
 		return 0, err
 	}
@@ -189,19 +171,7 @@ func (controller *electionController) AddParticipants(cxt *gin.Context) (int, er
 }
 
 func (controller *electionController) GetElections(cxt *gin.Context) ([]dto.GeneralElectionDTO, error) {
-	cookie, err := cxt.Cookie("token")
-	if err != nil {
-		return nil, err
-	}
-
-	var s = securecookie.New([]byte(os.Getenv("COOKIE_HASH_SECRET")), nil)
-	value := make(map[string]string)
-	err = s.Decode("tokens", cookie, &value)
-	if err != nil {
-		return nil, err
-	}
-
-	userId, role, err := controller.jwtService.GetUserIDAndRole(value["access_token"])
+	userId, role, err := middlewares.GetIdentity(cxt)
 	if err != nil {
 		return nil, err
 	}
@@ -235,19 +205,7 @@ func (controller *electionController) DeleteParticipant(cxt *gin.Context) error
 		return err
 	}
 
-	cookie, err := cxt.Cookie("token")
-	if err != nil {
-		return err
-	}
-
-	var s = securecookie.New([]byte(os.Getenv("COOKIE_HASH_SECRET")), nil)
-	value := make(map[string]string)
-	err = s.Decode("tokens", cookie, &value)
-	if err != nil {
-		return err
-	}
-
-	userId, _, err := controller.jwtService.GetUserIDAndRole(value["access_token"])
+	userId, _, err := middlewares.GetIdentity(cxt)
 	if err != nil {
 		return err
 	}
@@ -257,30 +215,19 @@ func (controller *electionController) DeleteParticipant(cxt *gin.Context) error
 
 func (controller *electionController) EnrollCandidate(cxt *gin.Context) error {
 	electionId := cxt.PostForm("election_id")
+	positionId := cxt.PostForm("position_id")
 	sex, err := strconv.Atoi(cxt.PostForm("sex"))
 	if err != nil {
 		return err
 	}
 
-	cookie, err := cxt.Cookie("token")
-	if err != nil {
-		return err
-	}
-
-	var s = securecookie.New([]byte(os.Getenv("COOKIE_HASH_SECRET")), nil)
-	value := make(map[string]string)
-	err = s.Decode("tokens", cookie, &value)
-	if err != nil {
-		return err
-	}
-
-	userId, _, err := controller.jwtService.GetUserIDAndRole(value["access_token"])
+	userId, _, err := middlewares.GetIdentity(cxt)
 	if err != nil {
 		log.Println(err.Error())
 		return err
 	}
 
-	err = controller.electionService.CheckCandidateEligibility(userId, electionId)
+	err = controller.electionService.CheckCandidateEligibility(userId, electionId, positionId)
 	if err != nil {
 		return err
 	}
@@ -320,6 +267,7 @@ func (controller *electionController) EnrollCandidate(cxt *gin.Context) error {
 
 	createCandidateDTO := dto.CreateCandidateDTO{
 		ElectionId:     electionId,
+		PositionId:     positionId,
 		Sex:            sex,
 		DisplayPicture: dpURL,
 		Poster:         posterURL,
@@ -336,19 +284,7 @@ func (controller *electionController) ApproveCandidate(cxt *gin.Context) error {
 		return errors.New("Invalid ID!")
 	}
 
-	cookie, err := cxt.Cookie("token")
-	if err != nil {
-		return err
-	}
-
-	var s = securecookie.New([]byte(os.Getenv("COOKIE_HASH_SECRET")), nil)
-	value := make(map[string]string)
-	err = s.Decode("tokens", cookie, &value)
-	if err != nil {
-		return err
-	}
-
-	userId, _, err := controller.jwtService.GetUserIDAndRole(value["access_token"])
+	userId, _, err := middlewares.GetIdentity(cxt)
 	if err != nil {
 		log.Println(err.Error())
 		return err
@@ -364,19 +300,7 @@ func (controller *electionController) UnapproveCandidate(cxt *gin.Context) error
 		return errors.New("Invalid ID!")
 	}
 
-	cookie, err := cxt.Cookie("token")
-	if err != nil {
-		return err
-	}
-
-	var s = securecookie.New([]byte(os.Getenv("COOKIE_HASH_SECRET")), nil)
-	value := make(map[string]string)
-	err = s.Decode("tokens", cookie, &value)
-	if err != nil {
-		return err
-	}
-
-	userId, _, err := controller.jwtService.GetUserIDAndRole(value["access_token"])
+	userId, _, err := middlewares.GetIdentity(cxt)
 	if err != nil {
 		log.Println(err.Error())
 		return err
@@ -392,19 +316,7 @@ func (controller *electionController) GetElection(cxt *gin.Context) (dto.General
 		return dto.GeneralElectionDTO{}, errors.New("Invalid ID!")
 	}
 
-	cookie, err := cxt.Cookie("token")
-	if err != nil {
-		return dto.GeneralElectionDTO{}, err
-	}
-
-	var s = securecookie.New([]byte(os.Getenv("COOKIE_HASH_SECRET")), nil)
-	value := make(map[string]string)
-	err = s.Decode("tokens", cookie, &value)
-	if err != nil {
-		return dto.GeneralElectionDTO{}, err
-	}
-
-	userId, role, err := controller.jwtService.GetUserIDAndRole(value["access_token"])
+	userId, role, err := middlewares.GetIdentity(cxt)
 	if err != nil {
 		return dto.GeneralElectionDTO{}, err
 	}
@@ -418,55 +330,71 @@ func (controller *electionController) GetElection(cxt *gin.Context) (dto.General
 	return dto.GeneralElectionDTO{}, errors.New("Invalid Role!")
 }
 
-func (controller *electionController) CastVote(cxt *gin.Context) error {
+func (controller *electionController) CastVote(cxt *gin.Context) (string, error) {
 	var castVoteDTO dto.CastVoteDTO
 	cxt.ShouldBindJSON(&castVoteDTO)
 
-	cookie, err := cxt.Cookie("token")
+	userId, _, err := middlewares.GetIdentity(cxt)
 	if err != nil {
-		return err
+		return "", err
 	}
 
-	var s = securecookie.New([]byte(os.Getenv("COOKIE_HASH_SECRET")), nil)
-	value := make(map[string]string)
-	err = s.Decode("tokens", cookie, &value)
-	if err != nil {
-		return err
+	return controller.electionService.CastVote(userId, castVoteDTO)
+}
+
+func (controller *electionController) GetElectionResults(cxt *gin.Context) (dto.GeneralElectionResultsDTO, error) {
+	electionId := cxt.Param("id")
+	if electionId == "" {
+		log.Println("Invalid ID!")
+		return dto.GeneralElectionResultsDTO{}, errors.New("Invalid ID!")
 	}
 
-	userId, _, err := controller.jwtService.GetUserIDAndRole(value["access_token"])
+	userId, role, err := middlewares.GetIdentity(cxt)
 	if err != nil {
-		return err
+		return dto.GeneralElectionResultsDTO{}, err
 	}
 
-	return controller.electionService.CastVote(userId, castVoteDTO)
+	return controller.electionService.GetElectionResults(userId, role, electionId)
 }
 
-func (controller *electionController) GetElectionResults(cxt *gin.Context) (dto.GeneralElectionResultsDTO, error) {
+func (controller *electionController) RecountVotes(cxt *gin.Context) (dto.GeneralElectionResultsDTO, error) {
 	electionId := cxt.Param("id")
 	if electionId == "" {
 		log.Println("Invalid ID!")
 		return dto.GeneralElectionResultsDTO{}, errors.New("Invalid ID!")
 	}
 
-	cookie, err := cxt.Cookie("token")
+	userId, role, err := middlewares.GetIdentity(cxt)
 	if err != nil {
 		return dto.GeneralElectionResultsDTO{}, err
 	}
 
-	var s = securecookie.New([]byte(os.Getenv("COOKIE_HASH_SECRET")), nil)
-	value := make(map[string]string)
-	err = s.Decode("tokens", cookie, &value)
-	if err != nil {
-		return dto.GeneralElectionResultsDTO{}, err
+	return controller.electionService.RecountVotes(userId, role, electionId)
+}
+
+func (controller *electionController) GetElectionReceipts(cxt *gin.Context) (dto.ElectionReceiptsDTO, error) {
+	electionId := cxt.Param("id")
+	if electionId == "" {
+		log.Println("Invalid ID!")
+		return dto.ElectionReceiptsDTO{}, errors.New("Invalid ID!")
+	}
+
+	return controller.electionService.GetElectionReceipts(electionId)
+}
+
+func (controller *electionController) VerifyAuditChain(cxt *gin.Context) (dto.AuditChainDTO, error) {
+	electionId := cxt.Param("id")
+	if electionId == "" {
+		log.Println("Invalid ID!")
+		return dto.AuditChainDTO{}, errors.New("Invalid ID!")
 	}
 
-	userId, role, err := controller.jwtService.GetUserIDAndRole(value["access_token"])
+	userId, role, err := middlewares.GetIdentity(cxt)
 	if err != nil {
-		return dto.GeneralElectionResultsDTO{}, err
+		return dto.AuditChainDTO{}, err
 	}
 
-	return controller.electionService.GetElectionResults(userId, role, electionId)
+	return controller.electionService.VerifyAuditChain(userId, role, electionId)
 }
 
 //Private functions
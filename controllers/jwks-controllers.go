@@ -0,0 +1,24 @@
+package controllers
+
+import (
+	"elect/jwtkeys"
+	"elect/services"
+)
+
+type JWKSController interface {
+	GetJWKS() jwtkeys.JSONWebKeySet
+}
+
+type jwksController struct {
+	jwtService services.JWTService
+}
+
+func NewJWKSController(jwtService services.JWTService) JWKSController {
+	return &jwksController{
+		jwtService: jwtService,
+	}
+}
+
+func (controller *jwksController) GetJWKS() jwtkeys.JSONWebKeySet {
+	return controller.jwtService.JWKS()
+}
@@ -0,0 +1,105 @@
+package controllers
+
+import (
+	"elect/dto"
+	"elect/middlewares"
+	"elect/services"
+	"errors"
+	"log"
+
+	"github.com/gin-gonic/gin"
+)
+
+type LifecycleController interface {
+	OpenElection(cxt *gin.Context) error
+	PauseElection(cxt *gin.Context) error
+	ResumeElection(cxt *gin.Context) error
+	ExtendElection(cxt *gin.Context) error
+	PublishResults(cxt *gin.Context) error
+	ArchiveElection(cxt *gin.Context) error
+}
+
+type lifecycleController struct {
+	lifecycleService services.LifecycleService
+}
+
+func NewLifecycleController(lifecycleService services.LifecycleService) LifecycleController {
+	return &lifecycleController{
+		lifecycleService: lifecycleService,
+	}
+}
+
+func (controller *lifecycleController) OpenElection(cxt *gin.Context) error {
+	userId, electionId, err := controller.getUserAndElection(cxt)
+	if err != nil {
+		return err
+	}
+
+	return controller.lifecycleService.OpenElection(userId, electionId)
+}
+
+func (controller *lifecycleController) PauseElection(cxt *gin.Context) error {
+	userId, electionId, err := controller.getUserAndElection(cxt)
+	if err != nil {
+		return err
+	}
+
+	return controller.lifecycleService.PauseElection(userId, electionId)
+}
+
+func (controller *lifecycleController) ResumeElection(cxt *gin.Context) error {
+	userId, electionId, err := controller.getUserAndElection(cxt)
+	if err != nil {
+		return err
+	}
+
+	return controller.lifecycleService.ResumeElection(userId, electionId)
+}
+
+func (controller *lifecycleController) ExtendElection(cxt *gin.Context) error {
+	var extendElectionDTO dto.ExtendElectionDTO
+	err := cxt.ShouldBindJSON(&extendElectionDTO)
+	if err != nil {
+		return err
+	}
+
+	userId, electionId, err := controller.getUserAndElection(cxt)
+	if err != nil {
+		return err
+	}
+
+	return controller.lifecycleService.ExtendElection(userId, electionId, extendElectionDTO)
+}
+
+func (controller *lifecycleController) PublishResults(cxt *gin.Context) error {
+	userId, electionId, err := controller.getUserAndElection(cxt)
+	if err != nil {
+		return err
+	}
+
+	return controller.lifecycleService.PublishResults(userId, electionId)
+}
+
+func (controller *lifecycleController) ArchiveElection(cxt *gin.Context) error {
+	userId, electionId, err := controller.getUserAndElection(cxt)
+	if err != nil {
+		return err
+	}
+
+	return controller.lifecycleService.ArchiveElection(userId, electionId)
+}
+
+func (controller *lifecycleController) getUserAndElection(cxt *gin.Context) (string, string, error) {
+	electionId := cxt.Param("id")
+	if electionId == "" {
+		log.Println("Invalid ID!")
+		return "", "", errors.New("Invalid ID!")
+	}
+
+	userId, _, err := middlewares.GetIdentity(cxt)
+	if err != nil {
+		return "", "", err
+	}
+
+	return userId, electionId, nil
+}
@@ -0,0 +1,100 @@
+package controllers
+
+import (
+	"elect/dto"
+	"elect/middlewares"
+	"elect/services"
+	"errors"
+
+	"github.com/gin-gonic/gin"
+)
+
+type OTPController interface {
+	EnrollAuthenticator(cxt *gin.Context) (dto.TOTPEnrollmentDTO, error)
+	ConfirmAuthenticator(cxt *gin.Context) error
+	SetOTPMethod(cxt *gin.Context) error
+	GenerateRecoveryCodes(cxt *gin.Context) (dto.RecoveryCodesDTO, error)
+	RegenerateStudentRecoveryCodes(cxt *gin.Context) (dto.RecoveryCodesDTO, error)
+}
+
+type otpController struct {
+	otpService services.OTPService
+}
+
+func NewOTPController(otpService services.OTPService) OTPController {
+	return &otpController{
+		otpService: otpService,
+	}
+}
+
+func (controller *otpController) EnrollAuthenticator(cxt *gin.Context) (dto.TOTPEnrollmentDTO, error) {
+	userId, _, err := middlewares.GetIdentity(cxt)
+	if err != nil {
+		return dto.TOTPEnrollmentDTO{}, err
+	}
+
+	return controller.otpService.EnrollAuthenticator(userId)
+}
+
+func (controller *otpController) ConfirmAuthenticator(cxt *gin.Context) error {
+	var confirmTOTPDTO dto.ConfirmTOTPDTO
+	err := cxt.ShouldBindJSON(&confirmTOTPDTO)
+	if err != nil {
+		return err
+	}
+
+	userId, _, err := middlewares.GetIdentity(cxt)
+	if err != nil {
+		return err
+	}
+
+	return controller.otpService.ConfirmAuthenticator(userId, confirmTOTPDTO.OTP)
+}
+
+func (controller *otpController) SetOTPMethod(cxt *gin.Context) error {
+	var otpMethodDTO dto.OTPMethodDTO
+	err := cxt.ShouldBindJSON(&otpMethodDTO)
+	if err != nil {
+		return err
+	}
+
+	userId, _, err := middlewares.GetIdentity(cxt)
+	if err != nil {
+		return err
+	}
+
+	return controller.otpService.SetOTPMethod(userId, *otpMethodDTO.Method)
+}
+
+func (controller *otpController) GenerateRecoveryCodes(cxt *gin.Context) (dto.RecoveryCodesDTO, error) {
+	userId, _, err := middlewares.GetIdentity(cxt)
+	if err != nil {
+		return dto.RecoveryCodesDTO{}, err
+	}
+
+	codes, err := controller.otpService.GenerateRecoveryCodes(userId)
+	if err != nil {
+		return dto.RecoveryCodesDTO{}, err
+	}
+
+	return dto.RecoveryCodesDTO{RecoveryCodes: codes}, nil
+}
+
+func (controller *otpController) RegenerateStudentRecoveryCodes(cxt *gin.Context) (dto.RecoveryCodesDTO, error) {
+	userId, role, err := middlewares.GetIdentity(cxt)
+	if err != nil {
+		return dto.RecoveryCodesDTO{}, err
+	}
+
+	studentUserId := cxt.Param("id")
+	if studentUserId == "" {
+		return dto.RecoveryCodesDTO{}, errors.New("Invalid Student ID!")
+	}
+
+	codes, err := controller.otpService.RegenerateStudentRecoveryCodes(userId, role, studentUserId)
+	if err != nil {
+		return dto.RecoveryCodesDTO{}, err
+	}
+
+	return dto.RecoveryCodesDTO{RecoveryCodes: codes}, nil
+}
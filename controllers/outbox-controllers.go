@@ -0,0 +1,48 @@
+package controllers
+
+import (
+	"elect/dto"
+	"elect/middlewares"
+	"elect/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type OutboxController interface {
+	GetFailedEmails(cxt *gin.Context) ([]dto.OutboxEmailDTO, error)
+	ResendEmails(cxt *gin.Context) (int, error)
+}
+
+type outboxController struct {
+	outboxService services.OutboxService
+}
+
+func NewOutboxController(outboxService services.OutboxService) OutboxController {
+	return &outboxController{
+		outboxService: outboxService,
+	}
+}
+
+func (controller *outboxController) GetFailedEmails(cxt *gin.Context) ([]dto.OutboxEmailDTO, error) {
+	userId, role, err := middlewares.GetIdentity(cxt)
+	if err != nil {
+		return nil, err
+	}
+
+	return controller.outboxService.GetFailedEmails(userId, role)
+}
+
+func (controller *outboxController) ResendEmails(cxt *gin.Context) (int, error) {
+	var resendEmailsDTO dto.ResendEmailsDTO
+	err := cxt.ShouldBindJSON(&resendEmailsDTO)
+	if err != nil {
+		return 0, err
+	}
+
+	userId, role, err := middlewares.GetIdentity(cxt)
+	if err != nil {
+		return 0, err
+	}
+
+	return controller.outboxService.ResendEmails(userId, role, resendEmailsDTO.EmailOutboxID)
+}
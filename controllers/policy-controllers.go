@@ -0,0 +1,106 @@
+package controllers
+
+import (
+	"elect/dto"
+	"elect/middlewares"
+	"elect/services"
+	"errors"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type PolicyController interface {
+	GetPolicies(cxt *gin.Context) ([]dto.PolicyDTO, error)
+	AddPolicy(cxt *gin.Context) (dto.PolicyDTO, error)
+	EditPolicy(cxt *gin.Context) (dto.PolicyDTO, error)
+	DeletePolicy(cxt *gin.Context) error
+	ReloadPolicies(cxt *gin.Context) error
+	CheckPolicy(cxt *gin.Context) (dto.PolicyCheckResultDTO, error)
+}
+
+type policyController struct {
+	policyService services.PolicyService
+}
+
+func NewPolicyController(policyService services.PolicyService) PolicyController {
+	return &policyController{
+		policyService: policyService,
+	}
+}
+
+func (controller *policyController) GetPolicies(cxt *gin.Context) ([]dto.PolicyDTO, error) {
+	return controller.policyService.GetPolicies()
+}
+
+func (controller *policyController) AddPolicy(cxt *gin.Context) (dto.PolicyDTO, error) {
+	var policyDTO dto.PolicyDTO
+	err := cxt.ShouldBindJSON(&policyDTO)
+	if err != nil {
+		return dto.PolicyDTO{}, err
+	}
+
+	userId, _, err := middlewares.GetIdentity(cxt)
+	if err != nil {
+		return dto.PolicyDTO{}, err
+	}
+
+	return controller.policyService.AddPolicy(userId, policyDTO)
+}
+
+func (controller *policyController) EditPolicy(cxt *gin.Context) (dto.PolicyDTO, error) {
+	var policyDTO dto.PolicyDTO
+	err := cxt.ShouldBindJSON(&policyDTO)
+	if err != nil {
+		return dto.PolicyDTO{}, err
+	}
+
+	policyDTO.ID, err = policyID(cxt)
+	if err != nil {
+		return dto.PolicyDTO{}, err
+	}
+
+	userId, _, err := middlewares.GetIdentity(cxt)
+	if err != nil {
+		return dto.PolicyDTO{}, err
+	}
+
+	return controller.policyService.EditPolicy(userId, policyDTO)
+}
+
+func (controller *policyController) DeletePolicy(cxt *gin.Context) error {
+	policyId, err := policyID(cxt)
+	if err != nil {
+		return err
+	}
+
+	userId, _, err := middlewares.GetIdentity(cxt)
+	if err != nil {
+		return err
+	}
+
+	return controller.policyService.DeletePolicy(userId, policyId)
+}
+
+func (controller *policyController) ReloadPolicies(cxt *gin.Context) error {
+	return controller.policyService.Reload()
+}
+
+func (controller *policyController) CheckPolicy(cxt *gin.Context) (dto.PolicyCheckResultDTO, error) {
+	var policyCheckDTO dto.PolicyCheckDTO
+	err := cxt.ShouldBindJSON(&policyCheckDTO)
+	if err != nil {
+		return dto.PolicyCheckResultDTO{}, err
+	}
+
+	return controller.policyService.Check(policyCheckDTO)
+}
+
+func policyID(cxt *gin.Context) (uint, error) {
+	policyId, err := strconv.ParseUint(cxt.Param("id"), 10, 32)
+	if err != nil || policyId == 0 {
+		return 0, errors.New("Invalid Policy ID!")
+	}
+
+	return uint(policyId), nil
+}
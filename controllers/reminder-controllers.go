@@ -0,0 +1,65 @@
+package controllers
+
+import (
+	"elect/dto"
+	"elect/middlewares"
+	"elect/services"
+	"errors"
+	"log"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ReminderController interface {
+	ScheduleReminder(cxt *gin.Context) error
+	GetReminders(cxt *gin.Context) ([]dto.ReminderDTO, error)
+}
+
+type reminderController struct {
+	reminderService services.ReminderService
+}
+
+func NewReminderController(reminderService services.ReminderService) ReminderController {
+	return &reminderController{
+		reminderService: reminderService,
+	}
+}
+
+func (controller *reminderController) ScheduleReminder(cxt *gin.Context) error {
+	var scheduleReminderDTO dto.ScheduleReminderDTO
+	err := cxt.ShouldBindJSON(&scheduleReminderDTO)
+	if err != nil {
+		return err
+	}
+
+	userId, electionId, err := controller.getUserAndElection(cxt)
+	if err != nil {
+		return err
+	}
+
+	return controller.reminderService.ScheduleReminder(userId, electionId, scheduleReminderDTO)
+}
+
+func (controller *reminderController) GetReminders(cxt *gin.Context) ([]dto.ReminderDTO, error) {
+	userId, electionId, err := controller.getUserAndElection(cxt)
+	if err != nil {
+		return nil, err
+	}
+
+	return controller.reminderService.GetReminders(userId, electionId)
+}
+
+func (controller *reminderController) getUserAndElection(cxt *gin.Context) (string, string, error) {
+	electionId := cxt.Param("id")
+	if electionId == "" {
+		log.Println("Invalid ID!")
+		return "", "", errors.New("Invalid ID!")
+	}
+
+	userId, _, err := middlewares.GetIdentity(cxt)
+	if err != nil {
+		return "", "", err
+	}
+
+	return userId, electionId, nil
+}
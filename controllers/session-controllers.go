@@ -0,0 +1,66 @@
+package controllers
+
+import (
+	"elect/dto"
+	"elect/middlewares"
+	"elect/services"
+	"errors"
+
+	"github.com/gin-gonic/gin"
+)
+
+type SessionController interface {
+	GetSessions(cxt *gin.Context) ([]dto.SessionDTO, error)
+	RevokeSession(cxt *gin.Context) error
+	RevokeStudentSessions(cxt *gin.Context) error
+}
+
+type sessionController struct {
+	sessionService services.SessionService
+}
+
+func NewSessionController(sessionService services.SessionService) SessionController {
+	return &sessionController{
+		sessionService: sessionService,
+	}
+}
+
+func (controller *sessionController) GetSessions(cxt *gin.Context) ([]dto.SessionDTO, error) {
+	userId, _, err := middlewares.GetIdentity(cxt)
+	if err != nil {
+		return nil, err
+	}
+
+	// Callers without a session see none of them marked as current
+	sessionId, _ := middlewares.GetSessionID(cxt)
+
+	return controller.sessionService.GetSessions(userId, sessionId)
+}
+
+func (controller *sessionController) RevokeSession(cxt *gin.Context) error {
+	userId, _, err := middlewares.GetIdentity(cxt)
+	if err != nil {
+		return err
+	}
+
+	sessionId := cxt.Param("id")
+	if sessionId == "" {
+		return errors.New("Invalid Session!")
+	}
+
+	return controller.sessionService.RevokeSession(userId, sessionId)
+}
+
+func (controller *sessionController) RevokeStudentSessions(cxt *gin.Context) error {
+	userId, role, err := middlewares.GetIdentity(cxt)
+	if err != nil {
+		return err
+	}
+
+	studentUserId := cxt.Param("id")
+	if studentUserId == "" {
+		return errors.New("Invalid Student ID!")
+	}
+
+	return controller.sessionService.RevokeUserSessions(userId, role, studentUserId)
+}
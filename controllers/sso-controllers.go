@@ -0,0 +1,118 @@
+package controllers
+
+import (
+	"elect/oidc"
+	"elect/services"
+	"errors"
+	"log"
+	"net/http"
+	"os"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/securecookie"
+)
+
+type SSOController interface {
+	BeginLogin(cxt *gin.Context) (string, error)
+	FinishLogin(cxt *gin.Context) (string, string, string, error)
+}
+
+type ssoController struct {
+	ssoService     services.SSOService
+	userService    services.UserService
+	sessionService services.SessionService
+	jwtService     services.JWTService
+}
+
+func NewSSOController(ssoService services.SSOService, userService services.UserService, sessionService services.SessionService, jwtService services.JWTService) SSOController {
+	return &ssoController{
+		ssoService:     ssoService,
+		userService:    userService,
+		sessionService: sessionService,
+		jwtService:     jwtService,
+	}
+}
+
+func (controller *ssoController) BeginLogin(cxt *gin.Context) (string, error) {
+	url, session, err := controller.ssoService.BeginLogin()
+	if err != nil {
+		return "", err
+	}
+
+	err = setSSOSession(cxt, session)
+	if err != nil {
+		return "", err
+	}
+
+	return url, nil
+}
+
+func (controller *ssoController) FinishLogin(cxt *gin.Context) (string, string, string, error) {
+	session, err := getSSOSession(cxt)
+	if err != nil {
+		return "", "", "", err
+	}
+
+	if cxt.Query("error") != "" {
+		log.Println("SSO login failed: " + cxt.Query("error") + " " + cxt.Query("error_description"))
+		return "", "", "", errors.New("SSO login failed!")
+	}
+
+	email, err := controller.ssoService.FinishLogin(session, cxt.Query("state"), cxt.Query("code"))
+	if err != nil {
+		return "", "", "", err
+	}
+
+	return issueTokens(cxt, controller.userService, controller.sessionService, controller.jwtService, email)
+}
+
+// The state, nonce and PKCE verifier are kept in the sso cookie while the user is at the provider, like the passkey cookie.
+func setSSOSession(cxt *gin.Context, session oidc.SessionData) error {
+	var s = securecookie.New([]byte(os.Getenv("COOKIE_HASH_SECRET")), nil)
+	s.MaxAge(600)
+
+	encoded, err := s.Encode("sso", session)
+	if err != nil {
+		return err
+	}
+
+	http.SetCookie(
+		cxt.Writer,
+		&http.Cookie{
+			Name:     "sso",
+			Value:    encoded,
+			MaxAge:   600,
+			HttpOnly: true,
+		},
+	)
+
+	return nil
+}
+
+func getSSOSession(cxt *gin.Context) (oidc.SessionData, error) {
+	cookie, err := cxt.Cookie("sso")
+	if err != nil {
+		return oidc.SessionData{}, errors.New("SSO session expired!")
+	}
+
+	http.SetCookie(
+		cxt.Writer,
+		&http.Cookie{
+			Name:     "sso",
+			Value:    "",
+			MaxAge:   -1,
+			HttpOnly: true,
+		},
+	)
+
+	var s = securecookie.New([]byte(os.Getenv("COOKIE_HASH_SECRET")), nil)
+	s.MaxAge(600)
+
+	var session oidc.SessionData
+	err = s.Decode("sso", cookie, &session)
+	if err != nil {
+		return oidc.SessionData{}, errors.New("SSO session expired!")
+	}
+
+	return session, nil
+}
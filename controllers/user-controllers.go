@@ -2,7 +2,7 @@ package controllers
 
 import (
 	"elect/dto"
-	"elect/email"
+	"elect/middlewares"
 	"elect/services"
 	"errors"
 	"log"
@@ -13,13 +13,13 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/gorilla/securecookie"
-	"github.com/hgfischer/go-otp"
+	uuid "github.com/satori/go.uuid"
 	"github.com/xuri/excelize/v2"
 	"golang.org/x/crypto/bcrypt"
 )
 
 type UserController interface {
-	Login(*gin.Context) (string, error)
+	Login(*gin.Context) (string, int, error)
 	Refresh(*gin.Context) error
 	Verify(*gin.Context) error
 	CheckToken(*gin.Context) error
@@ -29,48 +29,68 @@ type UserController interface {
 	RegisterStudents(*gin.Context) (int, error)
 	RegisteredStudents(cxt *gin.Context) ([]dto.GeneralStudentDTO, error)
 	DeleteRegisteredStudent(cxt *gin.Context) error
+	ResendVerification(cxt *gin.Context) error
+	ResendVerificationByEmail(cxt *gin.Context) error
 	ChangePassword(cxt *gin.Context) error
 	CheckVerifyTokenValidity(cxt *gin.Context) error
 	CheckResetTokenValidity(cxt *gin.Context) error
 	GenerateResetToken(cxt *gin.Context) error
 	ResetPassword(cxt *gin.Context) error
+	UnlockAccount(cxt *gin.Context) error
 }
 
 type userController struct {
-	userService services.UserService
-	jwtService  services.JWTService
+	userService      services.UserService
+	otpService       services.OTPService
+	lockoutService   services.LockoutService
+	sessionService   services.SessionService
+	directoryService services.DirectoryService
+	jwtService       services.JWTService
 }
 
-func NewUserController(userService services.UserService, jwtService services.JWTService) UserController {
+func NewUserController(userService services.UserService, otpService services.OTPService, lockoutService services.LockoutService, sessionService services.SessionService, directoryService services.DirectoryService, jwtService services.JWTService) UserController {
 	return &userController{
-		userService: userService,
-		jwtService:  jwtService,
+		userService:      userService,
+		otpService:       otpService,
+		lockoutService:   lockoutService,
+		sessionService:   sessionService,
+		directoryService: directoryService,
+		jwtService:       jwtService,
 	}
 }
 
-func (controller *userController) Login(cxt *gin.Context) (string, error) {
+func (controller *userController) Login(cxt *gin.Context) (string, int, error) {
 	var authUser dto.AuthUserDTO
 
 	err := cxt.ShouldBindJSON(&authUser)
 	if err != nil {
-		return "", err
+		return "", 0, err
 	}
 
-	dbUser, err := controller.userService.GetUserForAuth(authUser.Email)
+	err = controller.lockoutService.Check(authUser.Email, cxt.ClientIP())
 	if err != nil {
-		return "", err
+		return "", 0, err
 	}
 
-	auth := CheckPasswordHash(authUser.Password, dbUser.Password)
-	if !auth {
-		return "", errors.New("Invalid Email or Password!")
+	dbUser, err := controller.userService.GetUserForAuth(authUser.Email)
+	if err != nil {
+		if err.Error() == "Invalid user!" {
+			controller.recordLoginFailure(authUser.Email, cxt.ClientIP())
+		}
+		return "", 0, err
 	}
 
-	totp := &otp.TOTP{Secret: os.Getenv("OTP_SECRET") + dbUser.Email, Period: 240}
+	err = controller.checkPassword(dbUser, authUser.Password)
+	if err != nil {
+		if err.Error() == "Invalid Email or Password!" {
+			controller.recordLoginFailure(authUser.Email, cxt.ClientIP())
+		}
+		return "", 0, err
+	}
 
-	err = email.SendOTPEmail(dbUser.Email, totp.Get(), "otptemplate.html")
+	method, err := controller.otpService.SendLoginOTP(dbUser.Email)
 	if err != nil {
-		return "", err
+		return "", 0, err
 	}
 
 	var s = securecookie.New([]byte(os.Getenv("COOKIE_HASH_SECRET")), nil)
@@ -91,7 +111,7 @@ func (controller *userController) Login(cxt *gin.Context) (string, error) {
 		)
 	}
 
-	return dbUser.Email, nil
+	return dbUser.Email, method, nil
 }
 
 func (controller *userController) Refresh(cxt *gin.Context) error {
@@ -115,11 +135,6 @@ func (controller *userController) Refresh(cxt *gin.Context) error {
 		return err
 	}
 
-	email, err := controller.jwtService.GetEmail(value["access_token"])
-	if err != nil {
-		return err
-	}
-
 	if !refreshToken.Valid {
 		http.SetCookie(
 			cxt.Writer,
@@ -137,7 +152,19 @@ func (controller *userController) Refresh(cxt *gin.Context) error {
 		return err
 	}
 
-	err = controller.userService.CheckIfActiveRefreshToken(value["refresh_token"], email)
+	newAccessToken, newRefreshToken, err := controller.jwtService.GenerateNewTokens(value["refresh_token"])
+	if err != nil {
+		cxt.AbortWithStatusJSON(http.StatusUnauthorized, dto.Response{
+			Message: "Session Invalid",
+		})
+		return err
+	}
+
+	// Tokens issued before sessions existed have no session ID, they can't be refreshed
+	sessionId, err := controller.jwtService.GetSessionID(value["access_token"])
+	if err == nil {
+		err = controller.sessionService.RotateSession(sessionId, value["refresh_token"], newRefreshToken, cxt.ClientIP())
+	}
 	if err != nil {
 		http.SetCookie(
 			cxt.Writer,
@@ -148,18 +175,7 @@ func (controller *userController) Refresh(cxt *gin.Context) error {
 				HttpOnly: true,
 			},
 		)
-		cxt.AbortWithStatusJSON(http.StatusNetworkAuthenticationRequired, dto.Response{
-			Message: "Not logged in!",
-		})
-		return err
-	}
-
-	newAccessToken, newRefreshToken, err := controller.jwtService.GenerateNewTokens(value["refresh_token"])
-	if err != nil {
-		cxt.AbortWithStatusJSON(http.StatusUnauthorized, dto.Response{
-			Message: "Session Invalid",
-		})
-		return err
+		return errors.New("Session revoked!")
 	}
 
 	newValue := map[string]string{
@@ -179,15 +195,6 @@ func (controller *userController) Refresh(cxt *gin.Context) error {
 		)
 	}
 
-	if err != nil {
-		return err
-	}
-
-	err = controller.userService.SetActiveRefreshToken(newRefreshToken, email)
-	if err != nil {
-		return err
-	}
-
 	return nil
 }
 
@@ -214,6 +221,7 @@ func (controller *userController) CheckToken(cxt *gin.Context) error {
 	return nil
 }
 
+// OTPVerication verifies the OTP for the user the otp cookie was issued to, an email in the body has to be theirs.
 func (controller *userController) OTPVerication(cxt *gin.Context) (string, string, string, error) {
 	var otpDTO dto.OTPDTO
 	err := cxt.ShouldBindJSON(&otpDTO)
@@ -221,70 +229,43 @@ func (controller *userController) OTPVerication(cxt *gin.Context) (string, strin
 		return "", "", "", err
 	}
 
-	totp := &otp.TOTP{Secret: os.Getenv("OTP_SECRET") + otpDTO.Email, Period: 240}
-	valid := totp.Verify(otpDTO.OTP)
-	if !valid {
-		return "", "", "", errors.New("Invalid OTP!")
+	email, err := controller.GetOTP(cxt)
+	if err != nil {
+		log.Println(err.Error())
+		return "", "", "", errors.New("Unauthorized!")
+	}
+	if otpDTO.Email != "" && !strings.EqualFold(otpDTO.Email, email) {
+		return "", "", "", errors.New("Unauthorized!")
 	}
+	otpDTO.Email = email
 
-	dbUser, err := controller.userService.GetUserForAuth(otpDTO.Email)
+	err = controller.lockoutService.Check(otpDTO.Email, cxt.ClientIP())
 	if err != nil {
 		return "", "", "", err
 	}
 
-	role, err := controller.userService.GetUserRole(otpDTO.Email)
+	err = controller.otpService.VerifyLoginOTP(otpDTO.Email, otpDTO.OTP, otpDTO.Method)
 	if err != nil {
+		if err.Error() == "Invalid OTP!" || err.Error() == "Invalid user!" {
+			controller.recordLoginFailure(otpDTO.Email, cxt.ClientIP())
+		}
 		return "", "", "", err
 	}
 
-	var s = securecookie.New([]byte(os.Getenv("COOKIE_HASH_SECRET")), nil)
-
-	var value map[string]string
-
-	if role == 2 {
-		value = map[string]string{
-			"access_token":  controller.jwtService.GenerateTokenForSuperAdmin(dbUser, role),
-			"refresh_token": controller.jwtService.GenerateRefreshTokenForSuperAdmin(dbUser),
-		}
-	} else {
-		value = map[string]string{
-			"access_token":  controller.jwtService.GenerateToken(dbUser, role),
-			"refresh_token": controller.jwtService.GenerateRefreshToken(dbUser),
-		}
+	err = controller.lockoutService.RecordSuccess(otpDTO.Email)
+	if err != nil {
+		log.Println(err.Error())
 	}
 
-	if role == 2 {
-		if encoded, err := s.Encode("tokens", value); err == nil {
-			http.SetCookie(
-				cxt.Writer,
-				&http.Cookie{
-					Name:     "token",
-					Value:    encoded,
-					MaxAge:   3600 * 24,
-					HttpOnly: true,
-				},
-			)
-		}
-	} else {
-		if encoded, err := s.Encode("tokens", value); err == nil {
-			http.SetCookie(
-				cxt.Writer,
-				&http.Cookie{
-					Name:     "token",
-					Value:    encoded,
-					MaxAge:   3600 * 24 * 7,
-					HttpOnly: true,
-				},
-			)
-		}
-	}
+	return issueTokens(cxt, controller.userService, controller.sessionService, controller.jwtService, otpDTO.Email)
+}
 
-	err = controller.userService.SetActiveRefreshToken(value["refresh_token"], dbUser.Email)
+// recordLoginFailure only logs when counting fails, the user still gets the error of the login itself.
+func (controller *userController) recordLoginFailure(email string, ip string) {
+	err := controller.lockoutService.RecordFailure(email, ip)
 	if err != nil {
-		return "", "", "", err
+		log.Println(err.Error())
 	}
-
-	return dbUser.UserID, dbUser.Email, strconv.Itoa(role), nil
 }
 
 func (controller *userController) GetOTP(cxt *gin.Context) (string, error) {
@@ -310,25 +291,23 @@ func (controller *userController) GetOTP(cxt *gin.Context) (string, error) {
 }
 
 func (controller *userController) Logout(cxt *gin.Context) error {
-	var s = securecookie.New([]byte(os.Getenv("COOKIE_HASH_SECRET")), nil)
-
-	cookie, err := cxt.Cookie("token")
+	userId, _, err := middlewares.GetIdentity(cxt)
 	if err != nil {
 		return err
 	}
 
-	value := make(map[string]string)
-	err = s.Decode("tokens", cookie, &value)
+	sessionId, err := middlewares.GetSessionID(cxt)
 	if err != nil {
 		return err
 	}
 
-	email, err := controller.jwtService.GetEmail(value["access_token"])
-	if err != nil {
+	// A session that was already revoked elsewhere still gets its cookie cleared
+	err = controller.sessionService.RevokeSession(userId, sessionId)
+	if err != nil && err.Error() != "Invalid Session!" {
 		return err
 	}
 
-	return controller.userService.ClearActiveRefreshToken(email)
+	return nil
 }
 
 func (controller *userController) RegisterStudents(cxt *gin.Context) (int, error) {
@@ -359,19 +338,7 @@ func (controller *userController) RegisterStudents(cxt *gin.Context) (int, error
 		return 0, err
 	}
 
-	cookie, err := cxt.Cookie("token")
-	if err != nil {
-		return 0, err
-	}
-
-	var s = securecookie.New([]byte(os.Getenv("COOKIE_HASH_SECRET")), nil)
-	value := make(map[string]string)
-	err = s.Decode("tokens", cookie, &value)
-	if err != nil {
-		return 0, err
-	}
-
-	registeredBy, _, err := controller.jwtService.GetUserIDAndRole(value["access_token"])
+	registeredBy, _, err := middlewares.GetIdentity(cxt)
 	if err != nil {
 		return 0, err
 	}
@@ -400,19 +367,7 @@ func (controller *userController) RegisterStudents(cxt *gin.Context) (int, error
 }
 
 func (controller *userController) RegisteredStudents(cxt *gin.Context) ([]dto.GeneralStudentDTO, error) {
-	cookie, err := cxt.Cookie("token")
-	if err != nil {
-		return nil, err
-	}
-
-	var s = securecookie.New([]byte(os.Getenv("COOKIE_HASH_SECRET")), nil)
-	value := make(map[string]string)
-	err = s.Decode("tokens", cookie, &value)
-	if err != nil {
-		return nil, err
-	}
-
-	userId, _, err := controller.jwtService.GetUserIDAndRole(value["access_token"])
+	userId, _, err := middlewares.GetIdentity(cxt)
 	if err != nil {
 		return nil, err
 	}
@@ -438,19 +393,21 @@ func (controller *userController) RegisteredStudents(cxt *gin.Context) ([]dto.Ge
 }
 
 func (controller *userController) DeleteRegisteredStudent(cxt *gin.Context) error {
-	cookie, err := cxt.Cookie("token")
+	userId, _, err := middlewares.GetIdentity(cxt)
 	if err != nil {
 		return err
 	}
 
-	var s = securecookie.New([]byte(os.Getenv("COOKIE_HASH_SECRET")), nil)
-	value := make(map[string]string)
-	err = s.Decode("tokens", cookie, &value)
-	if err != nil {
-		return err
+	studentUserId := cxt.Param("id")
+	if studentUserId == "" {
+		return errors.New("Invalid Student ID!")
 	}
 
-	userId, _, err := controller.jwtService.GetUserIDAndRole(value["access_token"])
+	return controller.userService.DeleteRegisteredStudent(userId, studentUserId)
+}
+
+func (controller *userController) ResendVerification(cxt *gin.Context) error {
+	userId, _, err := middlewares.GetIdentity(cxt)
 	if err != nil {
 		return err
 	}
@@ -460,31 +417,54 @@ func (controller *userController) DeleteRegisteredStudent(cxt *gin.Context) erro
 		return errors.New("Invalid Student ID!")
 	}
 
-	return controller.userService.DeleteRegisteredStudent(userId, studentUserId)
+	return controller.userService.ResendVerification(userId, studentUserId)
+}
+
+func (controller *userController) ResendVerificationByEmail(cxt *gin.Context) error {
+	var resendVerificationDTO dto.ResendVerificationDTO
+	err := cxt.ShouldBindJSON(&resendVerificationDTO)
+	if err != nil {
+		return err
+	}
+
+	controller.userService.ResendVerificationByEmail(resendVerificationDTO, cxt.ClientIP())
+	return nil
 }
 
 func (controller *userController) ChangePassword(cxt *gin.Context) error {
 	var changePasswordDTO dto.ChangePasswordDTO
 	cxt.ShouldBindJSON(&changePasswordDTO)
 
-	cookie, err := cxt.Cookie("token")
+	userId, _, err := middlewares.GetIdentity(cxt)
 	if err != nil {
 		return err
 	}
 
-	var s = securecookie.New([]byte(os.Getenv("COOKIE_HASH_SECRET")), nil)
-	value := make(map[string]string)
-	err = s.Decode("tokens", cookie, &value)
+	sessionId, err := middlewares.GetSessionID(cxt)
 	if err != nil {
 		return err
 	}
 
-	userId, _, err := controller.jwtService.GetUserIDAndRole(value["access_token"])
+	err = controller.userService.ChangePassword(userId, changePasswordDTO)
 	if err != nil {
 		return err
 	}
 
-	err = controller.userService.ChangePassword(userId, changePasswordDTO)
+	// The old password may be how someone else got in, so every other device is logged out
+	err = controller.sessionService.RevokeOtherSessions(userId, sessionId)
+	if err != nil {
+		return err
+	}
+
+	// Bearer callers keep their access token, only the cookie holds a refresh token that has to be reissued
+	cookie, err := cxt.Cookie("token")
+	if err != nil {
+		return nil
+	}
+
+	var s = securecookie.New([]byte(os.Getenv("COOKIE_HASH_SECRET")), nil)
+	value := make(map[string]string)
+	err = s.Decode("tokens", cookie, &value)
 	if err != nil {
 		return err
 	}
@@ -495,10 +475,18 @@ func (controller *userController) ChangePassword(cxt *gin.Context) error {
 	}
 
 	dbUser, err := controller.userService.GetUserForAuth(user.Email)
+	if err != nil {
+		return err
+	}
 
 	newValue := map[string]string{
-		"access_token":  controller.jwtService.GenerateToken(dbUser, user.Role),
-		"refresh_token": controller.jwtService.GenerateRefreshToken(dbUser),
+		"access_token":  controller.jwtService.GenerateToken(dbUser, user.Role, sessionId),
+		"refresh_token": controller.jwtService.GenerateRefreshToken(dbUser, sessionId),
+	}
+
+	err = controller.sessionService.RotateSession(sessionId, value["refresh_token"], newValue["refresh_token"], cxt.ClientIP())
+	if err != nil {
+		return err
 	}
 
 	if encoded, err := s.Encode("tokens", newValue); err == nil {
@@ -513,11 +501,6 @@ func (controller *userController) ChangePassword(cxt *gin.Context) error {
 		)
 	}
 
-	err = controller.userService.SetActiveRefreshToken(value["refresh_token"], dbUser.Email)
-	if err != nil {
-		return err
-	}
-
 	return nil
 }
 
@@ -559,6 +542,98 @@ func (controller *userController) ResetPassword(cxt *gin.Context) error {
 	return controller.userService.ResetPassword(resetPasswordDTO)
 }
 
+func (controller *userController) UnlockAccount(cxt *gin.Context) error {
+	userId, role, err := middlewares.GetIdentity(cxt)
+	if err != nil {
+		return err
+	}
+
+	studentUserId := cxt.Param("id")
+	if studentUserId == "" {
+		return errors.New("Invalid Student ID!")
+	}
+
+	return controller.lockoutService.UnlockAccount(userId, role, studentUserId)
+}
+
+// issueTokens starts a new session and sets its token cookie once the user has passed the second factor, with an OTP or a passkey.
+func issueTokens(cxt *gin.Context, userService services.UserService, sessionService services.SessionService, jwtService services.JWTService, email string) (string, string, string, error) {
+	dbUser, err := userService.GetUserForAuth(email)
+	if err != nil {
+		return "", "", "", err
+	}
+
+	role, err := userService.GetUserRole(email)
+	if err != nil {
+		return "", "", "", err
+	}
+
+	var s = securecookie.New([]byte(os.Getenv("COOKIE_HASH_SECRET")), nil)
+
+	var value map[string]string
+
+	sessionId := uuid.NewV4().String()
+
+	if role == 2 {
+		value = map[string]string{
+			"access_token":  jwtService.GenerateTokenForSuperAdmin(dbUser, role, sessionId),
+			"refresh_token": jwtService.GenerateRefreshTokenForSuperAdmin(dbUser, sessionId),
+		}
+	} else {
+		value = map[string]string{
+			"access_token":  jwtService.GenerateToken(dbUser, role, sessionId),
+			"refresh_token": jwtService.GenerateRefreshToken(dbUser, sessionId),
+		}
+	}
+
+	err = sessionService.CreateSession(sessionId, dbUser.UserID, value["refresh_token"], cxt.ClientIP(), cxt.Request.UserAgent())
+	if err != nil {
+		return "", "", "", err
+	}
+
+	if role == 2 {
+		if encoded, err := s.Encode("tokens", value); err == nil {
+			http.SetCookie(
+				cxt.Writer,
+				&http.Cookie{
+					Name:     "token",
+					Value:    encoded,
+					MaxAge:   3600 * 24,
+					HttpOnly: true,
+				},
+			)
+		}
+	} else {
+		if encoded, err := s.Encode("tokens", value); err == nil {
+			http.SetCookie(
+				cxt.Writer,
+				&http.Cookie{
+					Name:     "token",
+					Value:    encoded,
+					MaxAge:   3600 * 24 * 7,
+					HttpOnly: true,
+				},
+			)
+		}
+	}
+
+	return dbUser.UserID, dbUser.Email, strconv.Itoa(role), nil
+}
+
+// checkPassword asks the directory about the password of a student synced from it, other passwords are checked
+// against their hash.
+func (controller *userController) checkPassword(dbUser dto.AuthUserDTO, password string) error {
+	if dbUser.Directory {
+		return controller.directoryService.Authenticate(dbUser.Email, password)
+	}
+
+	if !CheckPasswordHash(password, dbUser.Password) {
+		return errors.New("Invalid Email or Password!")
+	}
+
+	return nil
+}
+
 //Bcrypt Functions
 func HashPassword(password string) (string, error) {
 	bytes, err := bcrypt.GenerateFromPassword([]byte(password), 14)
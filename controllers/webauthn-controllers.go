@@ -0,0 +1,182 @@
+package controllers
+
+import (
+	"elect/dto"
+	"elect/middlewares"
+	"elect/services"
+	"elect/webauthn"
+	"errors"
+	"net/http"
+	"os"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/securecookie"
+)
+
+type WebAuthnController interface {
+	BeginRegistration(cxt *gin.Context) (*webauthn.CredentialCreation, error)
+	FinishRegistration(cxt *gin.Context) error
+	BeginLogin(cxt *gin.Context) (*webauthn.CredentialAssertion, error)
+	FinishLogin(cxt *gin.Context) (string, string, string, error)
+	GetPasskeys(cxt *gin.Context) ([]dto.PasskeyDTO, error)
+	DeletePasskey(cxt *gin.Context) error
+}
+
+type webAuthnController struct {
+	webAuthnService services.WebAuthnService
+	userService     services.UserService
+	sessionService  services.SessionService
+	jwtService      services.JWTService
+}
+
+func NewWebAuthnController(webAuthnService services.WebAuthnService, userService services.UserService, sessionService services.SessionService, jwtService services.JWTService) WebAuthnController {
+	return &webAuthnController{
+		webAuthnService: webAuthnService,
+		userService:     userService,
+		sessionService:  sessionService,
+		jwtService:      jwtService,
+	}
+}
+
+func (controller *webAuthnController) BeginRegistration(cxt *gin.Context) (*webauthn.CredentialCreation, error) {
+	userId, _, err := middlewares.GetIdentity(cxt)
+	if err != nil {
+		return nil, err
+	}
+
+	creation, challengeId, err := controller.webAuthnService.BeginRegistration(userId)
+	if err != nil {
+		return nil, err
+	}
+
+	err = setPasskeySession(cxt, challengeId)
+	if err != nil {
+		return nil, err
+	}
+
+	return creation, nil
+}
+
+func (controller *webAuthnController) FinishRegistration(cxt *gin.Context) error {
+	userId, _, err := middlewares.GetIdentity(cxt)
+	if err != nil {
+		return err
+	}
+
+	challengeId, err := getPasskeySession(cxt)
+	if err != nil {
+		return err
+	}
+
+	return controller.webAuthnService.FinishRegistration(userId, challengeId, cxt.Query("name"), cxt.Request.Body)
+}
+
+func (controller *webAuthnController) BeginLogin(cxt *gin.Context) (*webauthn.CredentialAssertion, error) {
+	var passkeyLoginDTO dto.PasskeyLoginDTO
+	err := cxt.ShouldBindJSON(&passkeyLoginDTO)
+	if err != nil {
+		return nil, err
+	}
+
+	assertion, challengeId, err := controller.webAuthnService.BeginLogin(passkeyLoginDTO.Email)
+	if err != nil {
+		return nil, err
+	}
+
+	err = setPasskeySession(cxt, challengeId)
+	if err != nil {
+		return nil, err
+	}
+
+	return assertion, nil
+}
+
+func (controller *webAuthnController) FinishLogin(cxt *gin.Context) (string, string, string, error) {
+	challengeId, err := getPasskeySession(cxt)
+	if err != nil {
+		return "", "", "", err
+	}
+
+	email, err := controller.webAuthnService.FinishLogin(challengeId, cxt.Request.Body)
+	if err != nil {
+		return "", "", "", err
+	}
+
+	return issueTokens(cxt, controller.userService, controller.sessionService, controller.jwtService, email)
+}
+
+func (controller *webAuthnController) GetPasskeys(cxt *gin.Context) ([]dto.PasskeyDTO, error) {
+	userId, _, err := middlewares.GetIdentity(cxt)
+	if err != nil {
+		return nil, err
+	}
+
+	return controller.webAuthnService.GetCredentials(userId)
+}
+
+func (controller *webAuthnController) DeletePasskey(cxt *gin.Context) error {
+	userId, _, err := middlewares.GetIdentity(cxt)
+	if err != nil {
+		return err
+	}
+
+	passkeyId := cxt.Param("id")
+	if passkeyId == "" {
+		return errors.New("Invalid Passkey!")
+	}
+
+	return controller.webAuthnService.DeleteCredential(userId, passkeyId)
+}
+
+// The challenge of a registration or login is stored by the server, the passkey cookie only names it until the
+// authenticator answers, like the otp cookie.
+func setPasskeySession(cxt *gin.Context, challengeId string) error {
+	var s = securecookie.New([]byte(os.Getenv("COOKIE_HASH_SECRET")), nil)
+	s.MaxAge(300)
+
+	encoded, err := s.Encode("passkey", challengeId)
+	if err != nil {
+		return err
+	}
+
+	http.SetCookie(
+		cxt.Writer,
+		&http.Cookie{
+			Name:     "passkey",
+			Value:    encoded,
+			MaxAge:   300,
+			HttpOnly: true,
+		},
+	)
+
+	return nil
+}
+
+// getPasskeySession reads the challenge ID back and clears the cookie, the challenge expires after 5 minutes either way.
+func getPasskeySession(cxt *gin.Context) (string, error) {
+	cookie, err := cxt.Cookie("passkey")
+	if err != nil {
+		return "", errors.New("Passkey session expired!")
+	}
+
+	http.SetCookie(
+		cxt.Writer,
+		&http.Cookie{
+			Name:     "passkey",
+			Value:    "",
+			MaxAge:   -1,
+			HttpOnly: true,
+		},
+	)
+
+	var s = securecookie.New([]byte(os.Getenv("COOKIE_HASH_SECRET")), nil)
+	s.MaxAge(300)
+
+	var challengeId string
+	err = s.Decode("passkey", cookie, &challengeId)
+	if err != nil {
+		return "", errors.New("Passkey session expired!")
+	}
+
+	return challengeId, nil
+}
@@ -1,15 +1,11 @@
 package database
 
 import (
-	"crypto/sha256"
 	"elect/dto"
 	"elect/email"
 	"elect/models"
-	"encoding/hex"
 	"log"
-	"math/rand"
 	"net/http"
-	"strconv"
 	"time"
 
 	"github.com/jinzhu/gorm"
@@ -28,24 +24,58 @@ type Database interface {
 	GetUserRole(email string) (int, error)
 	VerifyAndSetPassword(setPasswordDTO dto.SetPasswordDTO) error
 	TokenValidity(token string) error
-	StoreActiveRefreshToken(token string, email string) error
-	GetActiveRefreshToken(email string) (string, error)
-	ClearActiveRefreshToken(email string) error
+	ResendVerification(userId string, studentUserId string) (models.User, string, error)
+	ResendVerificationByEmail(email string) (models.User, string, error)
+	CreateSession(session models.Session, refreshToken string) error
+	RotateSession(sessionId string, refreshToken string, newRefreshToken string, ipAddress string, expiresAt time.Time) error
+	GetSessions(userId string) ([]models.Session, error)
+	CheckSession(sessionId string) error
+	RevokeSession(userId string, sessionId string) error
+	RevokeSessions(actorId string, userId string, exceptSessionId string) error
+	CreateAPIKey(apiKey models.APIKey, key string) error
+	GetAPIKeys(userId string) ([]models.APIKey, error)
+	RevokeAPIKey(userId string, apiKeyId string) error
+	UseAPIKey(key string) (models.APIKey, error)
+	GetUserByRegNumber(regNumber string) (models.User, error)
+	GetOIDCIdentityUser(issuer string, subject string) (models.User, error)
+	LinkOIDCIdentity(userId string, issuer string, subject string) error
 	ChangePassword(userId string, changePasswordDTO dto.ChangePasswordDTO) error
 	GenerateResetToken(email string) (string, string, error)
 	CheckResetTokenValidity(token string) error
 	ResetPassword(resetPasswordDTO dto.ResetPasswordDTO) error
+	GetUserByEmail(email string) (models.User, error)
+	StoreOTPSecret(userId string, secret string) (string, error)
+	StorePendingTOTPSecret(userId string, secret string) error
+	ConfirmTOTPSecret(userId string, secret string) error
+	SetOTPMethod(userId string, method int) error
+	ReplaceRecoveryCodes(actorId string, userId string, codes []string) error
+	UseRecoveryCode(userId string, code string) error
+	UseOTPStep(userId string, method int, step int64) error
+	GetWebAuthnCredentials(userId string) ([]models.WebAuthnCredential, error)
+	AddWebAuthnCredential(credential models.WebAuthnCredential) error
+	UseWebAuthnCredential(credentialId string, signCount int64) error
+	DeleteWebAuthnCredential(userId string, credentialId string) error
+	CreateWebAuthnChallenge(challenge models.WebAuthnChallenge) error
+	UseWebAuthnChallenge(challengeId string) (models.WebAuthnChallenge, error)
+	GetLoginThrottles(keys []string) ([]models.LoginThrottle, error)
+	RecordLoginFailure(key string, maxFailures int) (models.LoginThrottle, bool, error)
+	ClearLoginFailures(key string) error
+	UnlockAccount(userId string, role int, studentUserId string) error
 
 	// Users
-	RegisterStudent(user models.User) error
+	RegisterStudent(user models.User) (string, error)
+	SyncDirectoryStudent(user models.User) (bool, bool, error)
 	RegisteredStudents(userId string, paginatorParams dto.PaginatorParams) ([]models.User, error)
 	DeleteRegisteredStudent(userId string, studentUserId string) error
 	GetUser(userId string) (models.User, error)
 
 	// Election
-	CreateElection(election models.Election) error
+	CreateElection(election models.Election, positions []models.Position) error
 	EditElection(userId string, election models.Election) error
 	DeleteElection(userId string, electionId string) error
+	AddPosition(userId string, position models.Position) error
+	DeletePosition(userId string, positionId string) error
+	GetPositions(electionId string) ([]models.Position, error)
 	AddParticipant(userId string, electId string, regno string) error
 	DeleteParticipant(userId string, electionId string, participantId string) error
 	GetElectionParticipants(userId string, electionId string) ([]dto.GeneralParticipantDTO, error)
@@ -53,13 +83,62 @@ type Database interface {
 	GetElectionsForAdmins(userId string, paginatorParams dto.PaginatorParams) ([]models.Election, error)
 	GetElectionsForStudents(userId string, paginatorParams dto.PaginatorParams) ([]models.Election, []bool, error)
 	EnrollCandidate(candidate models.Candidate) error
-	CheckCandidateEligibility(userId string, electionId string) error
+	CheckCandidateEligibility(userId string, electionId string, positionId string) error
 	ApproveCandidate(userId string, candidateId string) error
 	UnapproveCandidate(userId string, candidateId string) error
 	GetElectionForAdmins(userId string, electionId string) (models.Election, []dto.GeneralParticipantDTO, []models.Candidate, error)
 	GetElectionForStudents(userId string, electionId string) (models.Election, []models.Candidate, models.Candidate, bool, bool, error)
-	CastVote(userId string, electionId string, candidateId string) error
+	CastVote(userId string, electionId string, choices []dto.BallotChoiceDTO) (string, error)
+	GetBallots(electionId string) ([]models.Ballot, error)
+	GetReceipts(electionId string) (models.Election, []string, error)
 	GetResults(userId string, role int, electionId string) (models.Election, []models.Candidate, []models.Candidate, []models.Candidate, []models.Candidate, int, error)
+	RecountVotes(userId string, electionId string) error
+
+	// Lifecycle
+	GetElectionsToSync() ([]models.Election, error)
+	SyncElectionStatus(electionId string) error
+	OpenElection(userId string, electionId string) error
+	PauseElection(userId string, electionId string) error
+	ResumeElection(userId string, electionId string) error
+	ExtendElection(userId string, electionId string, endingAt time.Time) error
+	PublishResults(userId string, electionId string) error
+	ArchiveElection(userId string, electionId string) error
+
+	// Jobs
+	ScheduleMissingJobs() error
+	ClaimDueJobs(limit int) ([]models.Job, error)
+	FinishJob(jobId string, status int, attempts int, runAt time.Time, lastError string) error
+	GetElection(electionId string) (models.Election, error)
+	GetParticipantUsers(electionId string) ([]models.User, error)
+	FreezeCandidateList(electionId string) error
+
+	// Reminders
+	ScheduleReminder(userId string, electionId string, sendAt time.Time, interval time.Duration) error
+	GetReminders(userId string, electionId string) ([]models.Reminder, []models.ReminderDelivery, error)
+	ClaimDueReminders(limit int) ([]models.Reminder, error)
+	QueueReminderDeliveries(reminderId string, electionId string) error
+	GetPendingReminderRecipients(reminderId string) ([]dto.ReminderRecipientDTO, error)
+	FinishReminderDelivery(reminderDeliveryId string, status int, lastError string) error
+	FinishReminder(reminderId string, status int, sendAt time.Time) error
+
+	// Outbox
+	QueueEmail(message email.Message) error
+	ClaimDueEmails(limit int) ([]models.EmailOutbox, error)
+	FinishEmail(emailOutboxId string, status int, attempts int, nextAttemptAt time.Time, lastError string) error
+	GetFailedEmails(userId string, role int) ([]models.EmailOutbox, error)
+	ResendEmails(userId string, role int, emailOutboxId string) (int, error)
+
+	// Audit
+	VerifyAuditChain(userId string, role int, electionId string) ([]models.AuditEvent, uint, error)
+
+	// Policy
+	GetPolicyRules() ([]models.CasbinRule, error)
+	SeedPolicyRules(rules []models.CasbinRule) error
+	AddPolicyRule(userId string, rule models.CasbinRule, check func(rules []models.CasbinRule) error) (models.CasbinRule, error)
+	EditPolicyRule(userId string, rule models.CasbinRule, check func(rules []models.CasbinRule) error) (models.CasbinRule, error)
+	DeletePolicyRule(userId string, ruleId uint, check func(rules []models.CasbinRule) error) error
+
+	Close() error
 }
 
 func SetUpQORAdmin(db *gorm.DB) *http.ServeMux {
@@ -71,9 +150,9 @@ func SetUpQORAdmin(db *gorm.DB) *http.ServeMux {
 	// User Management
 	usr := adm.AddResource(models.User{}, &admin.Config{Menu: []string{"User Management"}})
 	usr.SearchAttrs("UserID", "RegNumber", "Email", "FirstName")
-	usr.IndexAttrs("-Password", "-VerifyToken", "-ActiveRefreshToken")
-	usr.NewAttrs("-Password", "-ActiveRefreshToken", "-RegisteredBy", "-Verified")
-	usr.EditAttrs("-VerifyToken", "-ActiveRefreshToken", "-RegisteredBy")
+	usr.IndexAttrs("-Password", "-OTPSecret", "-TOTPSecret", "-PendingTOTPSecret", "-LastEmailOTPStep", "-LastTOTPStep")
+	usr.NewAttrs("-Password", "-RegisteredBy", "-Verified", "-OTPMethod", "-OTPSecret", "-TOTPSecret", "-PendingTOTPSecret", "-LastEmailOTPStep", "-LastTOTPStep")
+	usr.EditAttrs("-RegisteredBy", "-OTPSecret", "-TOTPSecret", "-PendingTOTPSecret", "-LastEmailOTPStep", "-LastTOTPStep")
 	usr.Meta(&admin.Meta{
 		Name: "Password",
 		Type: "password",
@@ -92,21 +171,26 @@ func SetUpQORAdmin(db *gorm.DB) *http.ServeMux {
 			}
 		},
 	})
-	usr.Meta(&admin.Meta{
-		Name: "VerifyToken",
-		Type: "hidden",
-		Setter: func(resource interface{}, metaValue *resource.MetaValue, context *qor.Context) {
-			u := resource.(*models.User)
+	// Saving an unverified user sends a new verification link, queued in the same transaction as the save
+	saveUser := usr.SaveHandler
+	usr.SaveHandler = func(record interface{}, context *qor.Context) error {
+		err := saveUser(record, context)
+		if err != nil {
+			return err
+		}
 
-			t := sha256.Sum256([]byte(strconv.Itoa(rand.Int()) + u.Email + strconv.Itoa(rand.Int())))
-			token := t[:]
-			u.VerifyToken = hex.EncodeToString(token)
+		u := record.(*models.User)
+		if u.Verified {
+			return nil
+		}
 
-			if !u.Verified {
-				email.SendVerificationEmail(u.FirstName, u.Email, u.VerifyToken, "template.html")
-			}
-		},
-	})
+		token, err := issueVerifyToken(context.GetDB(), auditActor(context), *u)
+		if err != nil {
+			return err
+		}
+
+		return email.SendVerificationEmail(&outboxMailer{connection: context.GetDB()}, u.FirstName, u.Email, token, "template.html")
+	}
 
 	blacklist := adm.AddResource(models.Blacklist{}, &admin.Config{Menu: []string{"User Management"}})
 	blacklist.IndexAttrs("-User")
@@ -224,6 +308,24 @@ func SetUpQORAdmin(db *gorm.DB) *http.ServeMux {
 		},
 	})
 
+	pos := adm.AddResource(models.Position{}, &admin.Config{Menu: []string{"Election Management"}, IconName: "Election"})
+	pos.IndexAttrs("-Election")
+	pos.NewAttrs("-Election")
+	pos.EditAttrs("-Election")
+	pos.Meta(&admin.Meta{
+		Name: "ElectionID",
+		Type: "string",
+		Setter: func(resource interface{}, metaValue *resource.MetaValue, context *qor.Context) {
+			values := metaValue.Value.([]string)
+			if len(values) > 0 {
+				if id := values[0]; id != "" {
+					p := resource.(*models.Position)
+					p.ElectionID = uuid.FromStringOrNil(id)
+				}
+			}
+		},
+	})
+
 	cand := adm.AddResource(models.Candidate{}, &admin.Config{Menu: []string{"Election Management"}, IconName: "Election"})
 	cand.IndexAttrs("-User", "-Election")
 	cand.NewAttrs("-User", "-Election", "-Votes")
@@ -254,6 +356,26 @@ func SetUpQORAdmin(db *gorm.DB) *http.ServeMux {
 			}
 		},
 	})
+	cand.Meta(&admin.Meta{
+		Name: "PositionID",
+		Type: "string",
+		Setter: func(resource interface{}, metaValue *resource.MetaValue, context *qor.Context) {
+			values := metaValue.Value.([]string)
+			if len(values) > 0 {
+				if id := values[0]; id != "" {
+					p := resource.(*models.Candidate)
+					p.PositionID = uuid.FromStringOrNil(id)
+				}
+			}
+		},
+	})
+
+	auditQORResource(usr, "user")
+	auditQORResource(blacklist, "blacklist")
+	auditQORResource(elect, "election")
+	auditQORResource(part, "participant")
+	auditQORResource(pos, "position")
+	auditQORResource(cand, "candidate")
 
 	validations.RegisterCallbacks(db)
 
@@ -0,0 +1,103 @@
+// Package databasetest gives every test a Postgres schema of its own, the way httptest gives it a server.
+package databasetest
+
+import (
+	"elect/database"
+	"elect/models"
+	"net/url"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/jinzhu/gorm"
+	_ "github.com/jinzhu/gorm/dialects/postgres"
+	uuid "github.com/satori/go.uuid"
+)
+
+// New migrates a new schema in TEST_DATABASE_URL and skips the test when it isn't set. The schema and every row the
+// test wrote are dropped when the test ends, the append-only audit log included. The second return value is a plain
+// connection to the same schema for setting up and inspecting rows.
+func New(t *testing.T) (database.Database, *gorm.DB) {
+	source := os.Getenv("TEST_DATABASE_URL")
+	if source == "" {
+		t.Skip("TEST_DATABASE_URL is not set")
+	}
+
+	admin, err := gorm.Open("postgres", source)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	schema := "test_" + strings.Replace(uuid.NewV4().String(), "-", "", -1)
+	admin.Exec(`CREATE EXTENSION IF NOT EXISTS "uuid-ossp"`)
+	if err := admin.Exec("CREATE SCHEMA " + schema).Error; err != nil {
+		admin.Close()
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := admin.Exec("DROP SCHEMA " + schema + " CASCADE").Error; err != nil {
+			t.Error(err)
+		}
+		admin.Close()
+	})
+
+	// uuid-ossp stays in public, so it is searched after the test's schema
+	source, err = withSearchPath(source, schema+",public")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	os.Setenv("DATABASE_URL", source)
+	os.Setenv("ADMIN_EMAIL", "admin@elect.test")
+	os.Setenv("ADMIN_PASSWORD", "Password@123")
+	db, _ := database.NewPostgresDatabase()
+	t.Cleanup(func() { db.Close() })
+
+	conn, err := gorm.Open("postgres", source)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	return db, conn
+}
+
+// withSearchPath adds search_path to a postgres:// URL or a key=value connection string, lib/pq passes it on to the
+// server as a run-time parameter.
+func withSearchPath(source string, searchPath string) (string, error) {
+	if !strings.HasPrefix(source, "postgres://") && !strings.HasPrefix(source, "postgresql://") {
+		return source + " search_path=" + searchPath, nil
+	}
+
+	sourceURL, err := url.Parse(source)
+	if err != nil {
+		return "", err
+	}
+
+	query := sourceURL.Query()
+	query.Set("search_path", searchPath)
+	sourceURL.RawQuery = query.Encode()
+
+	return sourceURL.String(), nil
+}
+
+// CreateUser stores a verified user with a unique email and registration number.
+func CreateUser(t *testing.T, conn *gorm.DB, role int) models.User {
+	id := uuid.NewV4()
+	user := models.User{
+		UserID:    id,
+		FirstName: "Test",
+		LastName:  "User",
+		RegNumber: id.String()[:12],
+		Email:     id.String() + "@elect.test",
+		Role:      role,
+		Verified:  true,
+	}
+
+	err := conn.Create(&user).Error
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return user
+}
@@ -0,0 +1,101 @@
+package database
+
+import (
+	"elect/models"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/jinzhu/gorm"
+)
+
+func (db *postgresDatabase) CreateAPIKey(apiKey models.APIKey, key string) error {
+	apiKey.KeyHash = hashRefreshToken(key)
+
+	tx := db.connection.Begin()
+
+	res := tx.Create(&apiKey)
+	if res.Error != nil {
+		tx.Rollback()
+		log.Println(res.Error.Error())
+		return res.Error
+	}
+
+	err := recordAuditEvent(tx, "", apiKey.UserID.String(), "apikey.create", "user:"+apiKey.UserID.String(), nil, map[string]interface{}{"APIKeyID": apiKey.APIKeyID.String(), "Name": apiKey.Name, "Prefix": apiKey.Prefix, "Scopes": apiKey.Scopes, "ExpiresAt": apiKey.ExpiresAt})
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit().Error
+}
+
+// GetAPIKeys returns the keys of a user that weren't revoked, expired ones included so the owner can see why they stopped working.
+func (db *postgresDatabase) GetAPIKeys(userId string) ([]models.APIKey, error) {
+	var apiKeys []models.APIKey
+	res := db.connection.Where("user_id = ? AND revoked_at IS NULL", userId).Order("created_at DESC").Find(&apiKeys)
+	if res.Error != nil {
+		log.Println(res.Error.Error())
+		return nil, res.Error
+	}
+
+	return apiKeys, nil
+}
+
+func (db *postgresDatabase) RevokeAPIKey(userId string, apiKeyId string) error {
+	tx := db.connection.Begin()
+
+	var apiKey models.APIKey
+	res := tx.Set("gorm:query_option", "FOR UPDATE").Where("user_id = ? AND api_key_id = ? AND revoked_at IS NULL", userId, apiKeyId).Find(&apiKey)
+	if gorm.IsRecordNotFoundError(res.Error) {
+		tx.Rollback()
+		return errors.New("Invalid API Key!")
+	}
+	if res.Error != nil {
+		tx.Rollback()
+		log.Println(res.Error.Error())
+		return res.Error
+	}
+
+	res = tx.Model(&models.APIKey{}).Where("api_key_id = ?", apiKeyId).UpdateColumn("revoked_at", time.Now().UTC())
+	if res.Error != nil {
+		tx.Rollback()
+		log.Println(res.Error.Error())
+		return res.Error
+	}
+
+	err := recordAuditEvent(tx, "", userId, "apikey.revoke", "user:"+userId, map[string]interface{}{"APIKeyID": apiKeyId, "Name": apiKey.Name, "Prefix": apiKey.Prefix}, nil)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit().Error
+}
+
+// UseAPIKey looks up an active key and records that it was used.
+func (db *postgresDatabase) UseAPIKey(key string) (models.APIKey, error) {
+	now := time.Now().UTC()
+
+	var apiKey models.APIKey
+	res := db.connection.Where("key_hash = ? AND revoked_at IS NULL", hashRefreshToken(key)).Find(&apiKey)
+	if gorm.IsRecordNotFoundError(res.Error) {
+		return models.APIKey{}, errors.New("Invalid API Key!")
+	}
+	if res.Error != nil {
+		log.Println(res.Error.Error())
+		return models.APIKey{}, res.Error
+	}
+
+	if !apiKey.ExpiresAt.After(now) {
+		return models.APIKey{}, errors.New("API Key expired!")
+	}
+
+	res = db.connection.Model(&models.APIKey{}).Where("api_key_id = ?", apiKey.APIKeyID).UpdateColumn("last_used_at", now)
+	if res.Error != nil {
+		log.Println(res.Error.Error())
+		return models.APIKey{}, res.Error
+	}
+
+	return apiKey, nil
+}
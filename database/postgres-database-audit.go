@@ -0,0 +1,303 @@
+package database
+
+import (
+	"context"
+	"crypto/sha256"
+	"elect/models"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"reflect"
+	"time"
+
+	"github.com/jinzhu/gorm"
+	"github.com/qor/admin"
+	"github.com/qor/qor"
+	uuid "github.com/satori/go.uuid"
+)
+
+type auditActorKey struct{}
+
+// WithAuditActor stores the id of the user making changes through the QOR admin, so they can be audited.
+func WithAuditActor(cxt context.Context, userId string) context.Context {
+	return context.WithValue(cxt, auditActorKey{}, userId)
+}
+
+type auditChange struct {
+	Before interface{} `json:"before"`
+	After  interface{} `json:"after"`
+}
+
+// Secrets are replaced with a short fingerprint so that changes are visible without being recoverable.
+var auditSecrets = map[string]bool{"Password": true, "VerifyToken": true, "Token": true, "OTPSecret": true, "TOTPSecret": true, "PendingTOTPSecret": true}
+
+func setUpAuditLog(db *gorm.DB) {
+	db.Exec(`CREATE OR REPLACE FUNCTION audit_events_append_only() RETURNS trigger AS $$
+	BEGIN
+		RAISE EXCEPTION 'audit_events is append-only';
+	END;
+	$$ LANGUAGE plpgsql`)
+	db.Exec("DROP TRIGGER IF EXISTS audit_events_append_only ON audit_events")
+	db.Exec("CREATE TRIGGER audit_events_append_only BEFORE UPDATE OR DELETE ON audit_events FOR EACH ROW EXECUTE PROCEDURE audit_events_append_only()")
+	db.Exec("DROP TRIGGER IF EXISTS audit_events_no_truncate ON audit_events")
+	db.Exec("CREATE TRIGGER audit_events_no_truncate BEFORE TRUNCATE ON audit_events FOR EACH STATEMENT EXECUTE PROCEDURE audit_events_append_only()")
+}
+
+// recordAuditEvent must be called inside the transaction of the change being audited.
+func recordAuditEvent(tx *gorm.DB, electionId string, actor string, action string, target string, before interface{}, after interface{}) error {
+	diff, err := auditDiff(before, after)
+	if err != nil {
+		log.Println(err.Error())
+		return err
+	}
+
+	eid := uuid.FromStringOrNil(electionId)
+
+	// Serialize appends to the same chain until the transaction ends
+	res := tx.Exec("SELECT pg_advisory_xact_lock(hashtext(?))", "audit:"+eid.String())
+	if res.Error != nil {
+		log.Println(res.Error.Error())
+		return res.Error
+	}
+
+	query := tx.Model(&models.AuditEvent{})
+	if eid == uuid.Nil {
+		query = query.Where("election_id IS NULL")
+	} else {
+		query = query.Where("election_id = ?", eid.String())
+	}
+
+	var last models.AuditEvent
+	res = query.Order("audit_event_id DESC").Limit(1).Find(&last)
+	if res.Error != nil && !gorm.IsRecordNotFoundError(res.Error) {
+		log.Println(res.Error.Error())
+		return res.Error
+	}
+
+	event := models.AuditEvent{
+		ElectionID: eid,
+		Actor:      actor,
+		Action:     action,
+		Target:     target,
+		Diff:       diff,
+		PrevHash:   last.Hash,
+		CreatedAt:  time.Now().UTC().Truncate(time.Microsecond),
+	}
+	event.Hash, err = hashAuditEvent(event)
+	if err != nil {
+		log.Println(err.Error())
+		return err
+	}
+
+	res = tx.Model(&models.AuditEvent{}).Create(&event)
+	if res.Error != nil {
+		log.Println(res.Error.Error())
+		return res.Error
+	}
+
+	return nil
+}
+
+func (db *postgresDatabase) VerifyAuditChain(userId string, role int, electionId string) ([]models.AuditEvent, uint, error) {
+	var count int
+	if role != 2 {
+		// Unscoped so that the chain of a deleted election can still be verified
+		res := db.connection.Unscoped().Model(&models.Election{}).Where("election_id = ? AND created_by = ?", electionId, userId).Count(&count)
+		if res.Error != nil {
+			log.Println(res.Error.Error())
+			return nil, 0, res.Error
+		}
+		if count == 0 {
+			log.Println("Unauthorized!")
+			return nil, 0, errors.New("Unauthorized!")
+		}
+	}
+
+	var events []models.AuditEvent
+	res := db.connection.Model(&models.AuditEvent{}).Where("election_id = ?", electionId).Order("audit_event_id ASC").Find(&events)
+	if res.Error != nil {
+		log.Println(res.Error.Error())
+		return nil, 0, res.Error
+	}
+
+	prevHash := ""
+	for _, event := range events {
+		hash, err := hashAuditEvent(event)
+		if err != nil {
+			log.Println(err.Error())
+			return nil, 0, err
+		}
+
+		if event.PrevHash != prevHash || event.Hash != hash {
+			return events, event.AuditEventID, nil
+		}
+		prevHash = event.Hash
+	}
+
+	return events, 0, nil
+}
+
+// auditQORResource wraps the save and delete handlers of a QOR resource so that super admin edits are audited.
+func auditQORResource(res *admin.Resource, name string) {
+	save := res.SaveHandler
+	res.SaveHandler = func(record interface{}, context *qor.Context) error {
+		tx := context.GetDB().Begin()
+		scope := tx.NewScope(record)
+
+		action := "superadmin." + name + ".create"
+		var before interface{}
+		if !scope.PrimaryKeyZero() {
+			action = "superadmin." + name + ".update"
+			before = reflect.New(reflect.TypeOf(record).Elem()).Interface()
+			r := tx.Where(fmt.Sprintf("%v = ?", scope.Quote(scope.PrimaryKey())), scope.PrimaryKeyValue()).First(before)
+			if r.Error != nil {
+				tx.Rollback()
+				return r.Error
+			}
+		}
+
+		txContext := context.Clone()
+		txContext.DB = tx
+		err := save(record, txContext)
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+
+		err = recordAuditEvent(tx, auditElectionID(record), auditActor(context), action, name+":"+fmt.Sprint(tx.NewScope(record).PrimaryKeyValue()), before, record)
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+
+		return tx.Commit().Error
+	}
+
+	del := res.DeleteHandler
+	res.DeleteHandler = func(record interface{}, context *qor.Context) error {
+		tx := context.GetDB().Begin()
+
+		txContext := context.Clone()
+		txContext.DB = tx
+		err := del(record, txContext)
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+
+		err = recordAuditEvent(tx, auditElectionID(record), auditActor(context), "superadmin."+name+".delete", name+":"+context.ResourceID, record, nil)
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+
+		return tx.Commit().Error
+	}
+}
+
+func auditActor(context *qor.Context) string {
+	if context.Request != nil {
+		if userId, ok := context.Request.Context().Value(auditActorKey{}).(string); ok {
+			return userId
+		}
+	}
+
+	return "superadmin"
+}
+
+func auditElectionID(record interface{}) string {
+	switch r := record.(type) {
+	case *models.Election:
+		return r.ElectionID.String()
+	case *models.Participant:
+		return r.ElectionID.String()
+	case *models.Candidate:
+		return r.ElectionID.String()
+	case *models.Position:
+		return r.ElectionID.String()
+	}
+
+	return ""
+}
+
+func hashAuditEvent(event models.AuditEvent) (string, error) {
+	electionId := ""
+	if event.ElectionID != uuid.Nil {
+		electionId = event.ElectionID.String()
+	}
+
+	fields, err := json.Marshal([]string{event.PrevHash, electionId, event.Actor, event.Action, event.Target, event.Diff, event.CreatedAt.UTC().Format(time.RFC3339Nano)})
+	if err != nil {
+		return "", err
+	}
+
+	hash := sha256.Sum256(fields)
+	return hex.EncodeToString(hash[:]), nil
+}
+
+// auditDiff keeps only the fields that changed, a nil before or after records a creation or a deletion.
+func auditDiff(before interface{}, after interface{}) (string, error) {
+	beforeState, err := auditState(before)
+	if err != nil {
+		return "", err
+	}
+	afterState, err := auditState(after)
+	if err != nil {
+		return "", err
+	}
+
+	diff := make(map[string]auditChange)
+	for field, value := range beforeState {
+		if !reflect.DeepEqual(value, afterState[field]) {
+			diff[field] = auditChange{Before: value, After: afterState[field]}
+		}
+	}
+	for field, value := range afterState {
+		if _, ok := beforeState[field]; !ok {
+			diff[field] = auditChange{After: value}
+		}
+	}
+
+	encoded, err := json.Marshal(diff)
+	if err != nil {
+		return "", err
+	}
+
+	return string(encoded), nil
+}
+
+// auditState flattens a record into its field values, leaving out associations and fingerprinting secrets.
+func auditState(record interface{}) (map[string]interface{}, error) {
+	state := make(map[string]interface{})
+	if record == nil {
+		return state, nil
+	}
+
+	encoded, err := json.Marshal(record)
+	if err != nil {
+		return nil, err
+	}
+
+	var fields map[string]interface{}
+	err = json.Unmarshal(encoded, &fields)
+	if err != nil {
+		return nil, err
+	}
+
+	for field, value := range fields {
+		if _, ok := value.(map[string]interface{}); ok {
+			continue
+		}
+
+		if secret, ok := value.(string); ok && auditSecrets[field] && secret != "" {
+			fingerprint := sha256.Sum256([]byte(secret))
+			value = "sha256:" + hex.EncodeToString(fingerprint[:])[:12]
+		}
+
+		state[field] = value
+	}
+
+	return state, nil
+}
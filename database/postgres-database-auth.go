@@ -1,13 +1,18 @@
 package database
 
 import (
+	"crypto/rand"
+	"crypto/sha256"
 	"elect/dto"
 	"elect/mappers"
 	"elect/models"
+	"encoding/hex"
 	"errors"
+	"os"
 	"strings"
 	"time"
 
+	"github.com/jinzhu/gorm"
 	_ "github.com/jinzhu/gorm/dialects/postgres"
 	uuid "github.com/satori/go.uuid"
 	"golang.org/x/crypto/bcrypt"
@@ -28,7 +33,9 @@ func (db *postgresDatabase) FindUserForAuth(email string) (dto.AuthUserDTO, erro
 		return dto.AuthUserDTO{}, errors.New("Account not verified!")
 	}
 
-	if user.Password == "" {
+	// Users verified by single sign-on or synced from the directory may have no password, their password is checked by
+	// the directory or a password login fails for them
+	if user.Password == "" && user.DirectoryDN == "" && !db.hasOIDCIdentity(user.UserID.String()) {
 		return dto.AuthUserDTO{}, errors.New("Account not verified!")
 	}
 
@@ -64,78 +71,113 @@ func (db *postgresDatabase) GetUserRole(email string) (int, error) {
 }
 
 func (db *postgresDatabase) VerifyAndSetPassword(setPasswordDTO dto.SetPasswordDTO) error {
-	var user models.User
-	res := db.connection.Model(&models.User{}).Where("verify_token = ?", setPasswordDTO.Token).Find(&user)
+	tx := db.connection.Begin()
 
-	if res.RowsAffected == 0 {
-		return errors.New("Invalid!")
+	verifyToken, user, err := findVerifyToken(tx.Set("gorm:query_option", "FOR UPDATE"), setPasswordDTO.Token)
+	if err != nil {
+		tx.Rollback()
+		return err
 	}
 
-	if user.Verified == true {
-		return errors.New("Already Verified!")
+	hashedPassword, err := HashPassword(setPasswordDTO.Password)
+	if err != nil {
+		tx.Rollback()
+		return err
 	}
 
-	hashedPassword, err := HashPassword(setPasswordDTO.Password)
+	err = tx.Model(&models.User{}).Where("user_id = ?", user.UserID.String()).Updates(map[string]interface{}{"password": hashedPassword, "verified": true}).Error
 	if err != nil {
+		tx.Rollback()
 		return err
 	}
 
-	err = db.connection.Model(&models.User{}).Where("verify_token = ?", setPasswordDTO.Token).Update("password", hashedPassword).Error
+	err = tx.Model(&models.VerifyToken{}).Where("id = ?", verifyToken.ID).Update("used_at", time.Now().UTC()).Error
 	if err != nil {
+		tx.Rollback()
 		return err
 	}
 
-	err = db.connection.Model(&models.User{}).Where("verify_token = ?", setPasswordDTO.Token).Update("verified", true).Error
+	err = recordAuditEvent(tx, "", user.UserID.String(), "account.verify", "user:"+user.UserID.String(), map[string]interface{}{"Password": user.Password, "Verified": user.Verified}, map[string]interface{}{"Password": hashedPassword, "Verified": true})
 	if err != nil {
+		tx.Rollback()
 		return err
 	}
 
-	return nil
+	return tx.Commit().Error
 }
 
 func (db *postgresDatabase) TokenValidity(token string) error {
-	var user models.User
-	res := db.connection.Model(&models.User{}).Where("verify_token = ?", token).Find(&user)
+	_, _, err := findVerifyToken(db.connection, token)
+	return err
+}
 
-	if res.RowsAffected == 0 {
-		return errors.New("Invalid!")
+// ResendVerification gives a student the admin registered a new verification token.
+func (db *postgresDatabase) ResendVerification(userId string, studentUserId string) (models.User, string, error) {
+	tx := db.connection.Begin()
+
+	var user models.User
+	res := tx.Set("gorm:query_option", "FOR UPDATE").Where("registered_by = ? AND user_id = ?", userId, studentUserId).Find(&user)
+	if gorm.IsRecordNotFoundError(res.Error) {
+		tx.Rollback()
+		return models.User{}, "", errors.New("Invalid Student!")
 	}
-	if user.Verified {
-		return errors.New("Already verified!")
+	if res.Error != nil {
+		tx.Rollback()
+		return models.User{}, "", res.Error
 	}
 
-	return nil
-}
+	token, err := issueVerifyToken(tx, userId, user)
+	if err != nil {
+		tx.Rollback()
+		return models.User{}, "", err
+	}
 
-func (db *postgresDatabase) StoreActiveRefreshToken(token string, email string) error {
-	var user models.User
-	res := db.connection.Model(&user).Where("email = ?", email).Update("active_refresh_token", token)
+	res = tx.Commit()
 	if res.Error != nil {
-		return res.Error
+		return models.User{}, "", res.Error
 	}
 
-	return nil
+	return user, token, nil
 }
 
-func (db *postgresDatabase) GetActiveRefreshToken(email string) (string, error) {
+// ResendVerificationByEmail lets a student ask for a new verification token, at most once every VERIFY_RESEND_INTERVAL.
+func (db *postgresDatabase) ResendVerificationByEmail(email string) (models.User, string, error) {
+	tx := db.connection.Begin()
+
 	var user models.User
-	res := db.connection.Model(&models.User{}).Where("email = ?", email).Find(&user)
+	res := tx.Set("gorm:query_option", "FOR UPDATE").Where("UPPER(email) = ?", strings.ToUpper(email)).Find(&user)
+	if gorm.IsRecordNotFoundError(res.Error) {
+		tx.Rollback()
+		return models.User{}, "", errors.New("Invalid email!")
+	}
 	if res.Error != nil {
-		return "", res.Error
+		tx.Rollback()
+		return models.User{}, "", res.Error
 	}
 
-	return user.ActiveRefreshToken, nil
-}
+	var count int
+	res = tx.Model(&models.VerifyToken{}).Where("user_id = ? AND created_at > ?", user.UserID.String(), time.Now().UTC().Add(-verifyResendInterval())).Count(&count)
+	if res.Error != nil {
+		tx.Rollback()
+		return models.User{}, "", res.Error
+	}
+	if count > 0 {
+		tx.Rollback()
+		return models.User{}, "", errors.New("Verification email sent recently, try again later!")
+	}
 
-func (db *postgresDatabase) ClearActiveRefreshToken(email string) error {
-	var user models.User
+	token, err := issueVerifyToken(tx, user.UserID.String(), user)
+	if err != nil {
+		tx.Rollback()
+		return models.User{}, "", err
+	}
 
-	res := db.connection.Model(&user).Where("email = ?", email).Update("active_refresh_token", "")
+	res = tx.Commit()
 	if res.Error != nil {
-		return res.Error
+		return models.User{}, "", res.Error
 	}
 
-	return nil
+	return user, token, nil
 }
 
 func (db *postgresDatabase) ChangePassword(userId string, changePasswordDTO dto.ChangePasswordDTO) error {
@@ -144,6 +186,9 @@ func (db *postgresDatabase) ChangePassword(userId string, changePasswordDTO dto.
 	if res.Error != nil {
 		return res.Error
 	}
+	if user.DirectoryDN != "" {
+		return errors.New("Password is managed by the directory!")
+	}
 
 	err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(changePasswordDTO.CurrentPassword))
 	if err != nil {
@@ -155,14 +200,24 @@ func (db *postgresDatabase) ChangePassword(userId string, changePasswordDTO dto.
 		return errors.New("Failed to hash password!")
 	}
 
+	oldPassword := user.Password
 	user.Password = newPassword
 
-	res = db.connection.Model(&models.User{}).Where("user_id = ?", userId).Update(&user)
+	tx := db.connection.Begin()
+
+	res = tx.Model(&models.User{}).Where("user_id = ?", userId).Update(&user)
 	if res.Error != nil {
+		tx.Rollback()
 		return res.Error
 	}
 
-	return nil
+	err = recordAuditEvent(tx, "", userId, "password.change", "user:"+userId, map[string]interface{}{"Password": oldPassword}, map[string]interface{}{"Password": newPassword})
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit().Error
 }
 
 func (db *postgresDatabase) GenerateResetToken(email string) (string, string, error) {
@@ -183,11 +238,30 @@ func (db *postgresDatabase) GenerateResetToken(email string) (string, string, er
 	if !user.Verified {
 		return "", "", errors.New("Account not verified yet!")
 	}
+	if user.DirectoryDN != "" {
+		return "", "", errors.New("Password is managed by the directory!")
+	}
 
 	token := uuid.NewV4().String()
 	expiresAt := time.Now().Add(time.Minute * 30).UTC()
 
-	res = db.connection.Model(&models.ResetToken{}).Create(&models.ResetToken{Email: email, Token: token, ExpiresAt: expiresAt})
+	resetToken := models.ResetToken{Email: email, Token: token, ExpiresAt: expiresAt}
+
+	tx := db.connection.Begin()
+
+	res = tx.Model(&models.ResetToken{}).Create(&resetToken)
+	if res.Error != nil {
+		tx.Rollback()
+		return "", "", res.Error
+	}
+
+	err := recordAuditEvent(tx, "", user.UserID.String(), "password.reset_token", "user:"+user.UserID.String(), nil, resetToken)
+	if err != nil {
+		tx.Rollback()
+		return "", "", err
+	}
+
+	res = tx.Commit()
 	if res.Error != nil {
 		return "", "", res.Error
 	}
@@ -249,20 +323,125 @@ func (db *postgresDatabase) ResetPassword(resetPasswordDTO dto.ResetPasswordDTO)
 		return errors.New("Failed to hash password!")
 	}
 
+	oldPassword := user.Password
 	user.Password = newPassword
 
-	res = db.connection.Model(&models.User{}).Update(&user)
+	tx := db.connection.Begin()
+
+	res = tx.Model(&models.User{}).Update(&user)
 	if res.Error != nil {
+		tx.Rollback()
 		return res.Error
 	}
 
 	resetToken.ExpiresAt = time.Now().UTC()
-	res = db.connection.Model(&models.ResetToken{}).Update(&resetToken)
+	res = tx.Model(&models.ResetToken{}).Update(&resetToken)
 	if res.Error != nil {
+		tx.Rollback()
 		return res.Error
 	}
 
-	return nil
+	err = recordAuditEvent(tx, "", user.UserID.String(), "password.reset", "user:"+user.UserID.String(), map[string]interface{}{"Password": oldPassword}, map[string]interface{}{"Password": newPassword})
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit().Error
+}
+
+// issueVerifyToken gives an unverified user a new single use verification token, the tokens issued before it expire right away.
+func issueVerifyToken(tx *gorm.DB, actor string, user models.User) (string, error) {
+	if user.Verified {
+		return "", errors.New("Already verified!")
+	}
+
+	b := make([]byte, 32)
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", err
+	}
+	token := hex.EncodeToString(b)
+
+	now := time.Now().UTC()
+	res := tx.Model(&models.VerifyToken{}).Where("user_id = ? AND used_at IS NULL AND expires_at > ?", user.UserID.String(), now).Update("expires_at", now)
+	if res.Error != nil {
+		return "", res.Error
+	}
+
+	verifyToken := models.VerifyToken{UserID: user.UserID, TokenHash: hashVerifyToken(token), ExpiresAt: now.Add(verifyTokenExpiry())}
+	res = tx.Create(&verifyToken)
+	if res.Error != nil {
+		return "", res.Error
+	}
+
+	err = recordAuditEvent(tx, "", actor, "account.verify_token", "user:"+user.UserID.String(), nil, map[string]interface{}{"Token": token, "ExpiresAt": verifyToken.ExpiresAt})
+	if err != nil {
+		return "", err
+	}
+
+	return token, nil
+}
+
+// findVerifyToken tells invalid, used and expired tokens apart so that the student knows to ask for a new link.
+func findVerifyToken(connection *gorm.DB, token string) (models.VerifyToken, models.User, error) {
+	var verifyToken models.VerifyToken
+	res := connection.Model(&models.VerifyToken{}).Where("token_hash = ?", hashVerifyToken(token)).Find(&verifyToken)
+	if gorm.IsRecordNotFoundError(res.Error) {
+		return models.VerifyToken{}, models.User{}, errors.New("Invalid Verify Token!")
+	}
+	if res.Error != nil {
+		return models.VerifyToken{}, models.User{}, res.Error
+	}
+
+	if verifyToken.UsedAt != nil {
+		return models.VerifyToken{}, models.User{}, errors.New("Verify Token Already Used!")
+	}
+
+	var user models.User
+	res = connection.Model(&models.User{}).Where("user_id = ?", verifyToken.UserID.String()).Find(&user)
+	if gorm.IsRecordNotFoundError(res.Error) {
+		return models.VerifyToken{}, models.User{}, errors.New("Invalid Verify Token!")
+	}
+	if res.Error != nil {
+		return models.VerifyToken{}, models.User{}, res.Error
+	}
+
+	if user.Verified {
+		return models.VerifyToken{}, models.User{}, errors.New("Already Verified!")
+	}
+
+	if !time.Now().UTC().Before(verifyToken.ExpiresAt) {
+		return models.VerifyToken{}, models.User{}, errors.New("Verify Token Expired!")
+	}
+
+	return verifyToken, user, nil
+}
+
+// Only a hash of the verification token is stored, the token itself is only ever in the email.
+func hashVerifyToken(token string) string {
+	h := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(h[:])
+}
+
+// verifyTokenExpiry is how long a verification link works, set by VERIFY_TOKEN_EXPIRY(e.g, 48h).
+func verifyTokenExpiry() time.Duration {
+	expiry, err := time.ParseDuration(os.Getenv("VERIFY_TOKEN_EXPIRY"))
+	if err != nil || expiry <= 0 {
+		return 48 * time.Hour
+	}
+
+	return expiry
+}
+
+// verifyResendInterval is how long a student waits between verification emails, set by VERIFY_RESEND_INTERVAL(e.g, 5m).
+func verifyResendInterval() time.Duration {
+	interval, err := time.ParseDuration(os.Getenv("VERIFY_RESEND_INTERVAL"))
+	if err != nil || interval < 0 {
+		return 5 * time.Minute
+	}
+
+	return interval
 }
 
 //Bcrypt Functions
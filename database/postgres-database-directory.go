@@ -0,0 +1,89 @@
+package database
+
+import (
+	"elect/models"
+	"elect/roles"
+	"errors"
+	"log"
+	"strings"
+)
+
+// SyncDirectoryStudent creates the student of a directory entry, or updates the one with the same DN, register number
+// or email. The directory vouches for its students, so they are verified without an email. It returns whether the
+// student was created or changed.
+func (db *postgresDatabase) SyncDirectoryStudent(user models.User) (bool, bool, error) {
+	tx := db.connection.Begin()
+
+	var matches []models.User
+	res := tx.Set("gorm:query_option", "FOR UPDATE").Where("directory_dn = ? OR UPPER(reg_number) = ? OR UPPER(email) = ?", user.DirectoryDN, strings.ToUpper(user.RegNumber), strings.ToUpper(user.Email)).Find(&matches)
+	if res.Error != nil {
+		tx.Rollback()
+		log.Println(res.Error.Error())
+		return false, false, res.Error
+	}
+	if len(matches) > 1 {
+		tx.Rollback()
+		return false, false, errors.New("Conflicting users!")
+	}
+
+	if len(matches) == 0 {
+		user.Role = roles.Student
+		user.Verified = true
+
+		res = tx.Create(&user)
+		if res.Error != nil {
+			tx.Rollback()
+			log.Println(res.Error.Error())
+			return false, false, res.Error
+		}
+
+		err := recordAuditEvent(tx, "", user.RegisteredBy, "student.register", "user:"+user.UserID.String(), nil, user)
+		if err != nil {
+			tx.Rollback()
+			return false, false, err
+		}
+
+		return true, false, tx.Commit().Error
+	}
+
+	student := matches[0]
+	if student.Role != roles.Student {
+		tx.Rollback()
+		return false, false, errors.New("User already registered!")
+	}
+
+	before := map[string]interface{}{}
+	after := map[string]interface{}{}
+	compare := func(column string, oldValue interface{}, newValue interface{}) {
+		if oldValue != newValue {
+			before[column] = oldValue
+			after[column] = newValue
+		}
+	}
+	compare("first_name", student.FirstName, user.FirstName)
+	compare("last_name", student.LastName, user.LastName)
+	compare("reg_number", student.RegNumber, user.RegNumber)
+	compare("email", student.Email, user.Email)
+	compare("directory_dn", student.DirectoryDN, user.DirectoryDN)
+	compare("verified", student.Verified, true)
+
+	if len(after) == 0 {
+		tx.Rollback()
+		return false, false, nil
+	}
+
+	res = tx.Model(&models.User{}).Where("user_id = ?", student.UserID.String()).Updates(after)
+	if res.Error != nil {
+		tx.Rollback()
+		log.Println(res.Error.Error())
+		return false, false, res.Error
+	}
+
+	err := recordAuditEvent(tx, "", user.RegisteredBy, "student.directory_sync", "user:"+student.UserID.String(), before, after)
+	if err != nil {
+		tx.Rollback()
+		return false, false, err
+	}
+
+	return false, true, tx.Commit().Error
+}
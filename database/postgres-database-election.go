@@ -1,9 +1,13 @@
 package database
 
 import (
+	"crypto/sha256"
 	"elect/dto"
+	"elect/lifecycle"
 	"elect/mappers"
 	"elect/models"
+	"elect/votingmethods"
+	"encoding/hex"
 	"errors"
 	"log"
 	"sort"
@@ -15,8 +19,45 @@ import (
 	uuid "github.com/satori/go.uuid"
 )
 
-func (db *postgresDatabase) CreateElection(election models.Election) error {
-	res := db.connection.Create(&election)
+func (db *postgresDatabase) CreateElection(election models.Election, positions []models.Position) error {
+	tx := db.connection.Begin()
+
+	res := tx.Create(&election)
+	if res.Error != nil {
+		tx.Rollback()
+		log.Println(res.Error.Error())
+		return res.Error
+	}
+
+	err := recordAuditEvent(tx, election.ElectionID.String(), election.CreatedBy, "election.create", "election:"+election.ElectionID.String(), nil, election)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	err = scheduleElectionJobs(tx, election)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	for _, position := range positions {
+		position.ElectionID = election.ElectionID
+		res = tx.Model(&models.Position{}).Create(&position)
+		if res.Error != nil {
+			tx.Rollback()
+			log.Println(res.Error.Error())
+			return res.Error
+		}
+
+		err = recordAuditEvent(tx, election.ElectionID.String(), election.CreatedBy, "position.create", "position:"+position.PositionID.String(), nil, position)
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	res = tx.Commit()
 	if res.Error != nil {
 		log.Println(res.Error.Error())
 		return res.Error
@@ -45,13 +86,42 @@ func (db *postgresDatabase) EditElection(userId string, election models.Election
 		return res.Error
 	}
 
-	if findElection.LockingAt.UTC().Before(time.Now().UTC()) {
+	if !lifecycle.IsEditable(lifecycle.Current(findElection, time.Now())) {
 		log.Println("Election Locked!")
 		return errors.New("Election Locked!")
 	}
 
+	tx := db.connection.Begin()
+
 	election.ElectionID = uuid.Nil
-	res = db.connection.Model(&models.Election{}).Where("election_id = ?", findElection.ElectionID.String()).Update(&election)
+	res = tx.Model(&models.Election{}).Where("election_id = ?", findElection.ElectionID.String()).Update(&election)
+	if res.Error != nil {
+		tx.Rollback()
+		log.Println(res.Error.Error())
+		return res.Error
+	}
+
+	var editedElection models.Election
+	res = tx.Model(&models.Election{}).Where("election_id = ?", findElection.ElectionID.String()).First(&editedElection)
+	if res.Error != nil {
+		tx.Rollback()
+		log.Println(res.Error.Error())
+		return res.Error
+	}
+
+	err := recordAuditEvent(tx, findElection.ElectionID.String(), userId, "election.edit", "election:"+findElection.ElectionID.String(), findElection, editedElection)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	err = scheduleElectionJobs(tx, editedElection)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	res = tx.Commit()
 	if res.Error != nil {
 		log.Println(res.Error.Error())
 		return res.Error
@@ -85,7 +155,147 @@ func (db *postgresDatabase) DeleteElection(userId string, electionId string) err
 	// 	return errors.New("Election Locked!")
 	// }
 
-	res = db.connection.Model(&models.Election{}).Where("election_id = ?", electionId).Delete(&models.Election{ElectionID: uuid.FromStringOrNil(electionId)})
+	tx := db.connection.Begin()
+
+	res = tx.Model(&models.Election{}).Where("election_id = ?", electionId).Delete(&models.Election{ElectionID: uuid.FromStringOrNil(electionId)})
+	if res.Error != nil {
+		tx.Rollback()
+		log.Println(res.Error.Error())
+		return res.Error
+	}
+
+	err := recordAuditEvent(tx, electionId, userId, "election.delete", "election:"+electionId, findElection, nil)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	res = tx.Commit()
+	if res.Error != nil {
+		log.Println(res.Error.Error())
+		return res.Error
+	}
+
+	return nil
+}
+
+func (db *postgresDatabase) AddPosition(userId string, position models.Position) error {
+	var count int
+	res := db.connection.Model(&models.Election{}).Where("election_id = ? AND created_by = ?", position.ElectionID.String(), userId).Count(&count)
+	if res.Error != nil {
+		log.Println(res.Error.Error())
+		return res.Error
+	}
+
+	if count == 0 {
+		log.Println("Unauthorized!")
+		return errors.New("Unauthorized!")
+	}
+
+	var findElection models.Election
+	res = db.connection.Model(&models.Election{}).Where("election_id = ?", position.ElectionID.String()).First(&findElection)
+	if res.Error != nil {
+		log.Println(res.Error.Error())
+		return res.Error
+	}
+
+	if !lifecycle.IsEditable(lifecycle.Current(findElection, time.Now())) {
+		log.Println("Election Locked!")
+		return errors.New("Election Locked!")
+	}
+
+	res = db.connection.Model(&models.Candidate{}).Where("election_id = ? AND position_id IS NULL", position.ElectionID.String()).Count(&count)
+	if res.Error != nil {
+		log.Println(res.Error.Error())
+		return res.Error
+	}
+	if count > 0 {
+		log.Println("Candidates have already enrolled without a position!")
+		return errors.New("Candidates have already enrolled without a position!")
+	}
+
+	tx := db.connection.Begin()
+
+	res = tx.Model(&models.Position{}).Create(&position)
+	if res.Error != nil {
+		tx.Rollback()
+		log.Println(res.Error.Error())
+		return res.Error
+	}
+
+	err := recordAuditEvent(tx, position.ElectionID.String(), userId, "position.create", "position:"+position.PositionID.String(), nil, position)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	res = tx.Commit()
+	if res.Error != nil {
+		log.Println(res.Error.Error())
+		return res.Error
+	}
+
+	return nil
+}
+
+func (db *postgresDatabase) DeletePosition(userId string, positionId string) error {
+	var position models.Position
+	res := db.connection.Model(&models.Position{}).Where("position_id = ?", positionId).Find(&position)
+	if res.Error != nil {
+		log.Println(res.Error.Error())
+		return res.Error
+	}
+
+	var count int
+	res = db.connection.Model(&models.Election{}).Where("election_id = ? AND created_by = ?", position.ElectionID.String(), userId).Count(&count)
+	if res.Error != nil {
+		log.Println(res.Error.Error())
+		return res.Error
+	}
+
+	if count == 0 {
+		log.Println("Unauthorized!")
+		return errors.New("Unauthorized!")
+	}
+
+	var findElection models.Election
+	res = db.connection.Model(&models.Election{}).Where("election_id = ?", position.ElectionID.String()).First(&findElection)
+	if res.Error != nil {
+		log.Println(res.Error.Error())
+		return res.Error
+	}
+
+	if !lifecycle.IsEditable(lifecycle.Current(findElection, time.Now())) {
+		log.Println("Election Locked!")
+		return errors.New("Election Locked!")
+	}
+
+	res = db.connection.Model(&models.Candidate{}).Where("position_id = ?", positionId).Count(&count)
+	if res.Error != nil {
+		log.Println(res.Error.Error())
+		return res.Error
+	}
+	if count > 0 {
+		log.Println("Candidates have enrolled for this position!")
+		return errors.New("Candidates have enrolled for this position!")
+	}
+
+	tx := db.connection.Begin()
+
+	res = tx.Model(&models.Position{}).Where("position_id = ?", positionId).Delete(&models.Position{PositionID: uuid.FromStringOrNil(positionId)})
+	if res.Error != nil {
+		tx.Rollback()
+		log.Println(res.Error.Error())
+		return res.Error
+	}
+
+	err := recordAuditEvent(tx, position.ElectionID.String(), userId, "position.delete", "position:"+positionId, position, nil)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	res = tx.Commit()
 	if res.Error != nil {
 		log.Println(res.Error.Error())
 		return res.Error
@@ -94,6 +304,17 @@ func (db *postgresDatabase) DeleteElection(userId string, electionId string) err
 	return nil
 }
 
+func (db *postgresDatabase) GetPositions(electionId string) ([]models.Position, error) {
+	var positions []models.Position
+	res := db.connection.Model(&models.Position{}).Where("election_id = ?", electionId).Order("created_at ASC").Find(&positions)
+	if res.Error != nil {
+		log.Println(res.Error.Error())
+		return nil, res.Error
+	}
+
+	return positions, nil
+}
+
 func (db *postgresDatabase) AddParticipant(userId string, electId string, regno string) error {
 	var count int
 	res := db.connection.Model(&models.Election{}).Where("election_id = ? AND created_by = ?", electId, userId).Count(&count)
@@ -114,7 +335,7 @@ func (db *postgresDatabase) AddParticipant(userId string, electId string, regno
 		return res.Error
 	}
 
-	if findElection.LockingAt.UTC().Before(time.Now().UTC()) {
+	if !lifecycle.IsEditable(lifecycle.Current(findElection, time.Now())) {
 		log.Println("Election Locked!")
 		return errors.New("Election Locked!")
 	}
@@ -151,7 +372,22 @@ func (db *postgresDatabase) AddParticipant(userId string, electId string, regno
 		ElectionID: uuid.FromStringOrNil(electId),
 	}
 
-	res = db.connection.Model(&models.Participant{}).Create(&participant)
+	tx := db.connection.Begin()
+
+	res = tx.Model(&models.Participant{}).Create(&participant)
+	if res.Error != nil {
+		tx.Rollback()
+		log.Println(res.Error.Error())
+		return res.Error
+	}
+
+	err := recordAuditEvent(tx, electId, userId, "participant.add", "participant:"+participant.ParticipantID.String(), nil, participant)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	res = tx.Commit()
 	if res.Error != nil {
 		log.Println(res.Error.Error())
 		return res.Error
@@ -210,6 +446,9 @@ func (db *postgresDatabase) GetElectionsForStudents(userId string, paginatorPara
 				log.Println(res.Error.Error())
 				return nil, nil, res.Error
 			}
+			if election.Status == lifecycle.Draft {
+				continue
+			}
 
 			elections = append(elections, election)
 			voted = append(voted, eId.Voted)
@@ -229,7 +468,7 @@ func (db *postgresDatabase) GetElectionsForStudents(userId string, paginatorPara
 
 	_ = pagination.Paging(
 		&pagination.Param{
-			DB:      db.connection.Model(&models.Election{}).Where(map[string]interface{}{"election_id": electIdS}),
+			DB:      db.connection.Model(&models.Election{}).Where(map[string]interface{}{"election_id": electIdS}).Where("status <> ?", lifecycle.Draft),
 			Page:    page,
 			Limit:   limit,
 			OrderBy: []string{paginatorParams.OrderBy},
@@ -270,12 +509,34 @@ func (db *postgresDatabase) DeleteParticipant(userId string, electionId string,
 		return res.Error
 	}
 
-	if findElection.LockingAt.UTC().Before(time.Now().UTC()) {
+	if !lifecycle.IsEditable(lifecycle.Current(findElection, time.Now())) {
 		log.Println("Election Locked!")
 		return errors.New("Election Locked!")
 	}
 
-	res = db.connection.Model(&models.Participant{}).Where("participant_id = ?", participantId).Delete(&models.Participant{ParticipantID: uuid.FromStringOrNil(participantId)})
+	var participant models.Participant
+	res = db.connection.Model(&models.Participant{}).Where("participant_id = ? AND election_id = ?", participantId, electionId).Find(&participant)
+	if res.Error != nil {
+		log.Println(res.Error.Error())
+		return res.Error
+	}
+
+	tx := db.connection.Begin()
+
+	res = tx.Model(&models.Participant{}).Where("participant_id = ?", participantId).Delete(&models.Participant{ParticipantID: uuid.FromStringOrNil(participantId)})
+	if res.Error != nil {
+		tx.Rollback()
+		log.Println(res.Error.Error())
+		return res.Error
+	}
+
+	err := recordAuditEvent(tx, electionId, userId, "participant.delete", "participant:"+participantId, participant, nil)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	res = tx.Commit()
 	if res.Error != nil {
 		log.Println(res.Error.Error())
 		return res.Error
@@ -347,7 +608,22 @@ func (db *postgresDatabase) GetTotalElectionParticipants(electionId string, user
 }
 
 func (db *postgresDatabase) EnrollCandidate(candidate models.Candidate) error {
-	res := db.connection.Model(&models.Candidate{}).Create(&candidate)
+	tx := db.connection.Begin()
+
+	res := tx.Model(&models.Candidate{}).Create(&candidate)
+	if res.Error != nil {
+		tx.Rollback()
+		log.Println(res.Error.Error())
+		return res.Error
+	}
+
+	err := recordAuditEvent(tx, candidate.ElectionID.String(), candidate.UserID.String(), "candidate.enroll", "candidate:"+candidate.CandidateID.String(), nil, candidate)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	res = tx.Commit()
 	if res.Error != nil {
 		log.Println(res.Error.Error())
 		return res.Error
@@ -356,7 +632,7 @@ func (db *postgresDatabase) EnrollCandidate(candidate models.Candidate) error {
 	return nil
 }
 
-func (db *postgresDatabase) CheckCandidateEligibility(userId string, electionId string) error {
+func (db *postgresDatabase) CheckCandidateEligibility(userId string, electionId string, positionId string) error {
 	var count int
 	res := db.connection.Model(&models.Blacklist{}).Where("user_id = ?", userId).Count(&count)
 	if res.Error != nil {
@@ -395,11 +671,37 @@ func (db *postgresDatabase) CheckCandidateEligibility(userId string, electionId
 		return res.Error
 	}
 
-	if findElection.LockingAt.UTC().Before(time.Now().UTC()) {
+	status := lifecycle.Current(findElection, time.Now())
+	if status == lifecycle.Draft || findElection.Paused {
+		log.Println("Nominations are not open!")
+		return errors.New("Nominations are not open!")
+	}
+	if status != lifecycle.Nomination {
 		log.Println("Election Locked!")
 		return errors.New("Election Locked!")
 	}
 
+	res = db.connection.Model(&models.Position{}).Where("election_id = ?", electionId).Count(&count)
+	if res.Error != nil {
+		log.Println(res.Error.Error())
+		return res.Error
+	}
+	if count == 0 && positionId != "" {
+		log.Println("Election has no positions!")
+		return errors.New("Election has no positions!")
+	}
+	if count > 0 {
+		res = db.connection.Model(&models.Position{}).Where("election_id = ? AND position_id = ?", electionId, uuid.FromStringOrNil(positionId).String()).Count(&count)
+		if res.Error != nil {
+			log.Println(res.Error.Error())
+			return res.Error
+		}
+		if count == 0 {
+			log.Println("Invalid position!")
+			return errors.New("Invalid position!")
+		}
+	}
+
 	return nil
 }
 
@@ -430,12 +732,27 @@ func (db *postgresDatabase) ApproveCandidate(userId string, candidateId string)
 		return res.Error
 	}
 
-	if findElection.LockingAt.UTC().Before(time.Now().UTC()) {
+	if !lifecycle.IsEditable(lifecycle.Current(findElection, time.Now())) {
 		log.Println("Election Locked!")
 		return errors.New("Election Locked!")
 	}
 
-	res = db.connection.Model(&models.Candidate{}).Where("candidate_id = ? AND election_id = ?", candidateId, candidate.ElectionID.String()).Updates(map[string]interface{}{"approved": true})
+	tx := db.connection.Begin()
+
+	res = tx.Model(&models.Candidate{}).Where("candidate_id = ? AND election_id = ?", candidateId, candidate.ElectionID.String()).Updates(map[string]interface{}{"approved": true})
+	if res.Error != nil {
+		tx.Rollback()
+		log.Println(res.Error.Error())
+		return res.Error
+	}
+
+	err := recordAuditEvent(tx, candidate.ElectionID.String(), userId, "candidate.approve", "candidate:"+candidateId, map[string]interface{}{"Approved": candidate.Approved}, map[string]interface{}{"Approved": true})
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	res = tx.Commit()
 	if res.Error != nil {
 		log.Println(res.Error.Error())
 		return res.Error
@@ -471,12 +788,27 @@ func (db *postgresDatabase) UnapproveCandidate(userId string, candidateId string
 		return res.Error
 	}
 
-	if findElection.LockingAt.UTC().Before(time.Now().UTC()) {
+	if !lifecycle.IsEditable(lifecycle.Current(findElection, time.Now())) {
 		log.Println("Election Locked!")
 		return errors.New("Election Locked!")
 	}
 
-	res = db.connection.Model(&models.Candidate{}).Where("candidate_id = ? AND election_id = ?", candidateId, candidate.ElectionID.String()).Updates(map[string]interface{}{"approved": false})
+	tx := db.connection.Begin()
+
+	res = tx.Model(&models.Candidate{}).Where("candidate_id = ? AND election_id = ?", candidateId, candidate.ElectionID.String()).Updates(map[string]interface{}{"approved": false})
+	if res.Error != nil {
+		tx.Rollback()
+		log.Println(res.Error.Error())
+		return res.Error
+	}
+
+	err := recordAuditEvent(tx, candidate.ElectionID.String(), userId, "candidate.unapprove", "candidate:"+candidateId, map[string]interface{}{"Approved": candidate.Approved}, map[string]interface{}{"Approved": false})
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	res = tx.Commit()
 	if res.Error != nil {
 		log.Println(res.Error.Error())
 		return res.Error
@@ -551,6 +883,10 @@ func (db *postgresDatabase) GetElectionForStudents(userId string, electionId str
 		log.Println(res.Error.Error())
 		return models.Election{}, nil, models.Candidate{}, false, false, res.Error
 	}
+	if election.Status == lifecycle.Draft {
+		log.Println("Unauthorized!")
+		return models.Election{}, nil, models.Candidate{}, false, false, errors.New("Unauthorized!")
+	}
 
 	var candidates []models.Candidate
 	res = db.connection.Model(&models.Candidate{}).Where("election_id = ? AND approved = ?", electionId, true).Find(&candidates)
@@ -596,79 +932,242 @@ func (db *postgresDatabase) GetElectionForStudents(userId string, electionId str
 	return election, candidates, candidate, participant.Voted, false, nil
 }
 
-func (db *postgresDatabase) CastVote(userId string, electionId string, candidateId string) error {
+func (db *postgresDatabase) CastVote(userId string, electionId string, choices []dto.BallotChoiceDTO) (string, error) {
 	var count int
 	res := db.connection.Model(&models.Election{}).Where("election_id = ?", electionId).Count(&count)
 	if res.Error != nil {
 		log.Println(res.Error.Error())
-		return res.Error
+		return "", res.Error
 	}
 	if count == 0 {
 		log.Println("Invalid Election!")
-		return errors.New("Invalid Election!")
+		return "", errors.New("Invalid Election!")
 	}
 
 	res = db.connection.Model(&models.Participant{}).Where("user_id = ? AND election_id = ?", userId, electionId).Count(&count)
 	if res.Error != nil {
 		log.Println(res.Error.Error())
-		return res.Error
+		return "", res.Error
 	}
 	if count == 0 {
 		log.Println("You are not the part of the election!")
-		return errors.New("You are not the part of the election!")
+		return "", errors.New("You are not the part of the election!")
 	}
 
 	var election models.Election
 	res = db.connection.Model(&models.Election{}).Where("election_id = ?", electionId).Find(&election)
 	if res.Error != nil {
 		log.Println(res.Error.Error())
-		return res.Error
+		return "", res.Error
 	}
 
-	if !(time.Now().UTC().After(election.StartingAt.UTC()) && time.Now().UTC().Before(election.EndingAt.UTC())) {
+	if election.Paused {
+		log.Println("Election Paused!")
+		return "", errors.New("Election Paused!")
+	}
+
+	if lifecycle.Current(election, time.Now()) != lifecycle.Voting {
 		log.Println("Election Locked!")
-		return errors.New("Election Locked!")
+		return "", errors.New("Election Locked!")
 	}
 
 	var participant models.Participant
 	res = db.connection.Model(&models.Participant{}).Where("election_id = ? AND user_id = ?", electionId, userId).Find(&participant)
 	if res.Error != nil {
 		log.Println(res.Error.Error())
-		return res.Error
+		return "", res.Error
 	}
 
 	if participant.Voted {
 		log.Println("Already voted!")
-		return errors.New("Already voted!")
+		return "", errors.New("Already voted!")
 	}
 
-	var candidate models.Candidate
-	res = db.connection.Model(&models.Candidate{}).Where("candidate_id = ?", candidateId).Find(&candidate)
+	res = db.connection.Model(&models.Position{}).Where("election_id = ?", electionId).Count(&count)
 	if res.Error != nil {
 		log.Println(res.Error.Error())
-		return res.Error
+		return "", res.Error
+	}
+	if (count == 0 && len(choices) != 1) || (count > 0 && len(choices) != count) {
+		log.Println("Choose one candidate for every position!")
+		return "", errors.New("Choose one candidate for every position!")
+	}
+
+	var candidates []models.Candidate
+	chosen := make(map[uuid.UUID]bool)
+	for i, choice := range choices {
+		if election.VotingMethod != votingmethods.InstantRunoff && len(choice.Preferences) > 1 {
+			log.Println("Ranked ballots are only accepted in instant-runoff elections!")
+			return "", errors.New("Ranked ballots are only accepted in instant-runoff elections!")
+		}
+
+		preferences := choice.Preferences
+		if len(preferences) == 0 {
+			preferences = []string{choice.CandidateId}
+		}
+
+		ranked := make(map[string]bool)
+		for rank, candidateId := range preferences {
+			if ranked[candidateId] {
+				log.Println("A candidate can be ranked only once!")
+				return "", errors.New("A candidate can be ranked only once!")
+			}
+			ranked[candidateId] = true
+
+			var candidate models.Candidate
+			res = db.connection.Model(&models.Candidate{}).Where("candidate_id = ? AND election_id = ?", candidateId, electionId).Find(&candidate)
+			if res.Error != nil {
+				log.Println(res.Error.Error())
+				if errors.Is(res.Error, gorm.ErrRecordNotFound) {
+					return "", errors.New("Invalid candidate!")
+				}
+				return "", res.Error
+			}
+
+			if candidate.Approved == false {
+				return "", errors.New("Unapproved candidate!")
+			}
+
+			if count > 0 && (candidate.PositionID == uuid.Nil || candidate.PositionID.String() != choice.PositionId) {
+				log.Println("Candidate is not contesting for this position!")
+				return "", errors.New("Candidate is not contesting for this position!")
+			}
+
+			// Only the first preference is counted towards the candidate's tally
+			if rank == 0 {
+				if count > 0 {
+					if chosen[candidate.PositionID] {
+						log.Println("Only one candidate can be chosen per position!")
+						return "", errors.New("Only one candidate can be chosen per position!")
+					}
+					chosen[candidate.PositionID] = true
+				}
+
+				candidates = append(candidates, candidate)
+			}
+		}
+
+		choices[i].CandidateId = preferences[0]
+		choices[i].Preferences = preferences
 	}
 
-	if candidate.Approved == false {
-		return errors.New("Unapproved candidate!")
+	ballot, err := mappers.ToBallotFromBallotChoiceDTOs(electionId, choices)
+	if err != nil {
+		log.Println(err.Error())
+		return "", err
 	}
+	ballot.CreatedAt = time.Now().UTC().Truncate(time.Hour)
+
+	// The receipt commits to the ballot contents, the random ballot ID keeps equal ballots apart
+	ballot.BallotID = uuid.NewV4()
+	receipt := sha256.Sum256([]byte(ballot.BallotID.String() + ":" + electionId + ":" + ballot.Choices))
+	ballot.Receipt = hex.EncodeToString(receipt[:])
 
-	participant.Voted = true
-	candidate.Votes++
+	tx := db.connection.Begin()
 
-	res = db.connection.Model(&models.Participant{}).Update(&participant)
+	// Lock the participant row so that concurrent requests from the same voter are serialized
+	var lockedParticipant models.Participant
+	res = tx.Set("gorm:query_option", "FOR UPDATE").Model(&models.Participant{}).Where("participant_id = ?", participant.ParticipantID.String()).First(&lockedParticipant)
 	if res.Error != nil {
+		tx.Rollback()
 		log.Println(res.Error.Error())
-		return res.Error
+		return "", res.Error
 	}
 
-	res = db.connection.Model(&models.Candidate{}).Update(&candidate)
+	if lockedParticipant.Voted {
+		tx.Rollback()
+		log.Println("Already voted!")
+		return "", errors.New("Already voted!")
+	}
+
+	res = tx.Model(&models.Participant{}).Where("participant_id = ? AND voted = ?", participant.ParticipantID.String(), false).UpdateColumn("voted", true)
 	if res.Error != nil {
+		tx.Rollback()
 		log.Println(res.Error.Error())
-		return res.Error
+		return "", res.Error
+	}
+	if res.RowsAffected != 1 {
+		tx.Rollback()
+		log.Println("Already voted!")
+		return "", errors.New("Already voted!")
 	}
 
-	return nil
+	// Only the participant's voted flag is audited, the ballot and the candidates voted for are left out
+	err = recordAuditEvent(tx, electionId, userId, "vote.cast", "participant:"+participant.ParticipantID.String(), map[string]interface{}{"Voted": false}, map[string]interface{}{"Voted": true})
+	if err != nil {
+		tx.Rollback()
+		return "", err
+	}
+
+	res = tx.Model(&models.Ballot{}).Create(&ballot)
+	if res.Error != nil {
+		tx.Rollback()
+		log.Println(res.Error.Error())
+		return "", res.Error
+	}
+
+	for _, candidate := range candidates {
+		res = tx.Model(&models.Candidate{}).Where("candidate_id = ?", candidate.CandidateID.String()).UpdateColumn("votes", gorm.Expr("votes + ?", 1))
+		if res.Error != nil {
+			tx.Rollback()
+			log.Println(res.Error.Error())
+			return "", res.Error
+		}
+	}
+
+	res = tx.Commit()
+	if res.Error != nil {
+		log.Println(res.Error.Error())
+		return "", res.Error
+	}
+
+	return ballot.Receipt, nil
+}
+
+func (db *postgresDatabase) GetBallots(electionId string) ([]models.Ballot, error) {
+	var ballots []models.Ballot
+	res := db.connection.Model(&models.Ballot{}).Where("election_id = ?", electionId).Find(&ballots)
+	if res.Error != nil {
+		log.Println(res.Error.Error())
+		return nil, res.Error
+	}
+
+	return ballots, nil
+}
+
+func (db *postgresDatabase) GetReceipts(electionId string) (models.Election, []string, error) {
+	var count int
+	res := db.connection.Model(&models.Election{}).Where("election_id = ?", electionId).Count(&count)
+	if res.Error != nil {
+		log.Println(res.Error.Error())
+		return models.Election{}, nil, res.Error
+	}
+	if count == 0 {
+		log.Println("Invalid Election!")
+		return models.Election{}, nil, errors.New("Invalid Election!")
+	}
+
+	var election models.Election
+	res = db.connection.Model(&models.Election{}).Where("election_id = ?", electionId).Find(&election)
+	if res.Error != nil {
+		log.Println(res.Error.Error())
+		return models.Election{}, nil, res.Error
+	}
+
+	if !lifecycle.HasEnded(lifecycle.Current(election, time.Now())) {
+		log.Println("Election has not completed!")
+		return models.Election{}, nil, errors.New("Election has not completed!")
+	}
+
+	// Ordered by the receipt itself so the list doesn't reveal the order in which votes were cast
+	var receipts []string
+	res = db.connection.Model(&models.Ballot{}).Where("election_id = ? AND receipt IS NOT NULL", electionId).Order("receipt").Pluck("receipt", &receipts)
+	if res.Error != nil {
+		log.Println(res.Error.Error())
+		return models.Election{}, nil, res.Error
+	}
+
+	return election, receipts, nil
 }
 
 func (db *postgresDatabase) GetResults(userId string, role int, electionId string) (models.Election, []models.Candidate, []models.Candidate, []models.Candidate, []models.Candidate, int, error) {
@@ -712,11 +1211,22 @@ func (db *postgresDatabase) GetResults(userId string, role int, electionId strin
 		return models.Election{}, nil, nil, nil, nil, 0, res.Error
 	}
 
-	if !(time.Now().UTC().After(election.EndingAt.UTC())) {
+	status := lifecycle.Current(election, time.Now())
+	if !lifecycle.HasEnded(status) {
 		log.Println("Election has not completed!")
 		return models.Election{}, nil, nil, nil, nil, 0, errors.New("Election has not completed!")
 	}
 
+	if role == 0 && !lifecycle.HasPublishedResults(status) {
+		log.Println("Results have not been published!")
+		return models.Election{}, nil, nil, nil, nil, 0, errors.New("Results have not been published!")
+	}
+
+	tally, ballotCount, err := db.tallyBallots(electionId)
+	if err != nil {
+		return models.Election{}, nil, nil, nil, nil, 0, err
+	}
+
 	if !election.GenderSpecific {
 		var candidates []models.Candidate
 		res = db.connection.Model(&models.Candidate{}).Where("election_id = ? AND approved = ?", electionId, true).Find(&candidates)
@@ -725,9 +1235,7 @@ func (db *postgresDatabase) GetResults(userId string, role int, electionId strin
 			return models.Election{}, nil, nil, nil, nil, 0, res.Error
 		}
 
-		sort.Slice(candidates, func(i, j int) bool {
-			return candidates[i].Votes > candidates[j].Votes
-		})
+		tallyCandidates(candidates, tally, ballotCount)
 
 		total := 0
 		for _, candidate := range candidates {
@@ -746,6 +1254,8 @@ func (db *postgresDatabase) GetResults(userId string, role int, electionId strin
 			return models.Election{}, nil, nil, nil, nil, 0, res.Error
 		}
 
+		tallyCandidates(mCandidates, tally, ballotCount)
+
 		for _, candidate := range mCandidates {
 			total += candidate.Votes
 		}
@@ -758,6 +1268,8 @@ func (db *postgresDatabase) GetResults(userId string, role int, electionId strin
 			return models.Election{}, nil, nil, nil, nil, 0, res.Error
 		}
 
+		tallyCandidates(fCandidates, tally, ballotCount)
+
 		for _, candidate := range fCandidates {
 			total += candidate.Votes
 		}
@@ -770,6 +1282,8 @@ func (db *postgresDatabase) GetResults(userId string, role int, electionId strin
 			return models.Election{}, nil, nil, nil, nil, 0, res.Error
 		}
 
+		tallyCandidates(oCandidates, tally, ballotCount)
+
 		for _, candidate := range oCandidates {
 			total += candidate.Votes
 		}
@@ -777,3 +1291,112 @@ func (db *postgresDatabase) GetResults(userId string, role int, electionId strin
 		return election, nil, mCandidates, fCandidates, oCandidates, total, nil
 	}
 }
+
+func (db *postgresDatabase) RecountVotes(userId string, electionId string) error {
+	var count int
+	res := db.connection.Model(&models.Election{}).Where("election_id = ? AND created_by = ?", electionId, userId).Count(&count)
+	if res.Error != nil {
+		log.Println(res.Error.Error())
+		return res.Error
+	}
+	if count == 0 {
+		log.Println("Unauthorized!")
+		return errors.New("Unauthorized!")
+	}
+
+	var election models.Election
+	res = db.connection.Model(&models.Election{}).Where("election_id = ?", electionId).Find(&election)
+	if res.Error != nil {
+		log.Println(res.Error.Error())
+		return res.Error
+	}
+
+	if !lifecycle.HasEnded(lifecycle.Current(election, time.Now())) {
+		log.Println("Election has not completed!")
+		return errors.New("Election has not completed!")
+	}
+
+	tally, ballotCount, err := db.tallyBallots(electionId)
+	if err != nil {
+		return err
+	}
+	if ballotCount == 0 {
+		log.Println("No ballots to recount!")
+		return errors.New("No ballots to recount!")
+	}
+
+	var candidates []models.Candidate
+	res = db.connection.Model(&models.Candidate{}).Where("election_id = ?", electionId).Find(&candidates)
+	if res.Error != nil {
+		log.Println(res.Error.Error())
+		return res.Error
+	}
+
+	before := make(map[string]interface{})
+	after := make(map[string]interface{})
+	tx := db.connection.Begin()
+	for _, candidate := range candidates {
+		before[candidate.CandidateID.String()] = candidate.Votes
+		after[candidate.CandidateID.String()] = tally[candidate.CandidateID.String()]
+
+		res = tx.Model(&candidate).Updates(map[string]interface{}{"votes": tally[candidate.CandidateID.String()]})
+		if res.Error != nil {
+			tx.Rollback()
+			log.Println(res.Error.Error())
+			return res.Error
+		}
+	}
+
+	err = recordAuditEvent(tx, electionId, userId, "votes.recount", "election:"+electionId, before, after)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	res = tx.Commit()
+	if res.Error != nil {
+		log.Println(res.Error.Error())
+		return res.Error
+	}
+
+	return nil
+}
+
+// Private functions
+
+// tallyBallots counts the first preference of every choice on the stored ballots of an election
+func (db *postgresDatabase) tallyBallots(electionId string) (map[string]int, int, error) {
+	ballots, err := db.GetBallots(electionId)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	tally := make(map[string]int)
+	for _, ballot := range ballots {
+		choices, err := mappers.ToBallotChoiceDTOsFromBallot(ballot)
+		if err != nil {
+			log.Println(err.Error())
+			return nil, 0, err
+		}
+
+		for _, choice := range choices {
+			tally[choice.CandidateId]++
+		}
+	}
+
+	return tally, len(ballots), nil
+}
+
+// tallyCandidates replaces the stored vote counters with the ballot tally and sorts the candidates by votes.
+// Elections which were voted on before ballots were stored have no ballots and keep their counters.
+func tallyCandidates(candidates []models.Candidate, tally map[string]int, ballotCount int) {
+	if ballotCount > 0 {
+		for i := range candidates {
+			candidates[i].Votes = tally[candidates[i].CandidateID.String()]
+		}
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].Votes > candidates[j].Votes
+	})
+}
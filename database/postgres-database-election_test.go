@@ -0,0 +1,122 @@
+package database_test
+
+import (
+	"elect/database/databasetest"
+	"elect/dto"
+	"elect/lifecycle"
+	"elect/models"
+	"elect/roles"
+	"sync"
+	"testing"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+)
+
+func TestCastVoteConcurrently(t *testing.T) {
+	db, conn := databasetest.New(t)
+
+	admin := databasetest.CreateUser(t, conn, roles.Admin)
+	voter := databasetest.CreateUser(t, conn, roles.Student)
+
+	now := time.Now().UTC()
+	election := models.Election{
+		ElectionID: uuid.NewV4(),
+		Title:      "Concurrent Votes",
+		LockingAt:  now.Add(-2 * time.Hour),
+		StartingAt: now.Add(-time.Hour),
+		EndingAt:   now.Add(time.Hour),
+		Status:     lifecycle.Voting,
+		CreatedBy:  admin.UserID.String(),
+	}
+	if err := conn.Create(&election).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	participant := models.Participant{ParticipantID: uuid.NewV4(), UserID: voter.UserID, ElectionID: election.ElectionID}
+	if err := conn.Create(&participant).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	// Two positions with two candidates each, the voter chooses the first candidate of both
+	var positions []models.Position
+	var candidates []models.Candidate
+	for i := 0; i < 2; i++ {
+		position := models.Position{PositionID: uuid.NewV4(), ElectionID: election.ElectionID, Title: "Position"}
+		if err := conn.Create(&position).Error; err != nil {
+			t.Fatal(err)
+		}
+		positions = append(positions, position)
+
+		for j := 0; j < 2; j++ {
+			candidate := models.Candidate{
+				CandidateID:    uuid.NewV4(),
+				UserID:         databasetest.CreateUser(t, conn, roles.Student).UserID,
+				ElectionID:     election.ElectionID,
+				PositionID:     position.PositionID,
+				DisplayPicture: "picture",
+				Poster:         "poster",
+				IDProof:        "proof",
+				Approved:       true,
+			}
+			if err := conn.Create(&candidate).Error; err != nil {
+				t.Fatal(err)
+			}
+			candidates = append(candidates, candidate)
+		}
+	}
+
+	const voters = 10
+	var wg sync.WaitGroup
+	errs := make(chan error, voters)
+	for i := 0; i < voters; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := db.CastVote(voter.UserID.String(), election.ElectionID.String(), []dto.BallotChoiceDTO{
+				{PositionId: positions[0].PositionID.String(), CandidateId: candidates[0].CandidateID.String()},
+				{PositionId: positions[1].PositionID.String(), CandidateId: candidates[2].CandidateID.String()},
+			})
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	succeeded := 0
+	for err := range errs {
+		if err == nil {
+			succeeded++
+		} else if err.Error() != "Already voted!" {
+			t.Errorf("CastVote returned %q, want \"Already voted!\"", err.Error())
+		}
+	}
+	if succeeded != 1 {
+		t.Errorf("%d votes were cast, want 1", succeeded)
+	}
+
+	var ballots int
+	conn.Model(&models.Ballot{}).Where("election_id = ?", election.ElectionID.String()).Count(&ballots)
+	if ballots != 1 {
+		t.Errorf("%d ballots were stored, want 1", ballots)
+	}
+
+	for i, candidate := range candidates {
+		want := 0
+		if i == 0 || i == 2 {
+			want = 1
+		}
+
+		var stored models.Candidate
+		conn.Where("candidate_id = ?", candidate.CandidateID.String()).First(&stored)
+		if stored.Votes != want {
+			t.Errorf("candidate %d has %d votes, want %d", i, stored.Votes, want)
+		}
+	}
+
+	var stored models.Participant
+	conn.Where("participant_id = ?", participant.ParticipantID.String()).First(&stored)
+	if !stored.Voted {
+		t.Error("participant is not marked as voted")
+	}
+}
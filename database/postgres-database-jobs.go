@@ -0,0 +1,167 @@
+package database
+
+import (
+	"elect/jobs"
+	"elect/models"
+	"errors"
+	"log"
+	"sort"
+	"time"
+
+	"github.com/jinzhu/gorm"
+)
+
+func (db *postgresDatabase) ScheduleMissingJobs() error {
+	var elections []models.Election
+	res := db.connection.Model(&models.Election{}).Where("ending_at > ?", time.Now().UTC()).Find(&elections)
+	if res.Error != nil {
+		log.Println(res.Error.Error())
+		return res.Error
+	}
+
+	for _, election := range elections {
+		tx := db.connection.Begin()
+
+		err := scheduleElectionJobs(tx, election)
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+
+		res = tx.Commit()
+		if res.Error != nil {
+			log.Println(res.Error.Error())
+			return res.Error
+		}
+	}
+
+	return nil
+}
+
+// ClaimDueJobs marks due jobs as running and returns them, jobs whose lease ran out are claimed again.
+func (db *postgresDatabase) ClaimDueJobs(limit int) ([]models.Job, error) {
+	now := time.Now().UTC()
+
+	var dueJobs []models.Job
+	res := db.connection.Raw(`UPDATE jobs SET status = ?, locked_until = ?, updated_at = ?
+		WHERE job_id IN (
+			SELECT job_id FROM jobs
+			WHERE deleted_at IS NULL AND run_at <= ? AND (status = ? OR (status = ? AND locked_until < ?))
+			ORDER BY run_at ASC
+			LIMIT ?
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING *`, jobs.Running, now.Add(jobs.Lease), now, now, jobs.Pending, jobs.Running, now, limit).Scan(&dueJobs)
+	if res.Error != nil && !gorm.IsRecordNotFoundError(res.Error) {
+		log.Println(res.Error.Error())
+		return nil, res.Error
+	}
+
+	return dueJobs, nil
+}
+
+func (db *postgresDatabase) FinishJob(jobId string, status int, attempts int, runAt time.Time, lastError string) error {
+	res := db.connection.Model(&models.Job{}).Where("job_id = ?", jobId).Updates(map[string]interface{}{
+		"status":       status,
+		"attempts":     attempts,
+		"run_at":       runAt,
+		"last_error":   lastError,
+		"locked_until": nil,
+	})
+	if res.Error != nil {
+		log.Println(res.Error.Error())
+		return res.Error
+	}
+
+	return nil
+}
+
+func (db *postgresDatabase) GetElection(electionId string) (models.Election, error) {
+	var election models.Election
+	res := db.connection.Model(&models.Election{}).Where("election_id = ?", electionId).Find(&election)
+	if gorm.IsRecordNotFoundError(res.Error) {
+		log.Println("Invalid Election!")
+		return models.Election{}, errors.New("Invalid Election!")
+	}
+	if res.Error != nil {
+		log.Println(res.Error.Error())
+		return models.Election{}, res.Error
+	}
+
+	return election, nil
+}
+
+func (db *postgresDatabase) GetParticipantUsers(electionId string) ([]models.User, error) {
+	var users []models.User
+	res := db.connection.Model(&models.User{}).Joins("JOIN participants ON participants.user_id = users.user_id AND participants.deleted_at IS NULL").Where("participants.election_id = ?", electionId).Find(&users)
+	if res.Error != nil {
+		log.Println(res.Error.Error())
+		return nil, res.Error
+	}
+
+	return users, nil
+}
+
+// FreezeCandidateList commits the approved candidates of the election to its audit chain once it locks.
+func (db *postgresDatabase) FreezeCandidateList(electionId string) error {
+	var candidates []models.Candidate
+	res := db.connection.Model(&models.Candidate{}).Where("election_id = ? AND approved = ?", electionId, true).Find(&candidates)
+	if res.Error != nil {
+		log.Println(res.Error.Error())
+		return res.Error
+	}
+
+	candidateIds := make([]string, 0, len(candidates))
+	for _, candidate := range candidates {
+		candidateIds = append(candidateIds, candidate.CandidateID.String())
+	}
+	sort.Strings(candidateIds)
+
+	tx := db.connection.Begin()
+
+	err := recordAuditEvent(tx, electionId, "scheduler", "candidates.freeze", "election:"+electionId, nil, map[string]interface{}{"Candidates": candidateIds})
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	res = tx.Commit()
+	if res.Error != nil {
+		log.Println(res.Error.Error())
+		return res.Error
+	}
+
+	return nil
+}
+
+// scheduleElectionJobs creates the phase jobs of the election, jobs whose time changed are queued again.
+func scheduleElectionJobs(tx *gorm.DB, election models.Election) error {
+	for _, kind := range jobs.Kinds {
+		runAt := jobs.RunAt(election, kind)
+
+		var job models.Job
+		res := tx.Model(&models.Job{}).Where("election_id = ? AND kind = ?", election.ElectionID.String(), kind).Find(&job)
+		if gorm.IsRecordNotFoundError(res.Error) {
+			res = tx.Model(&models.Job{}).Create(&models.Job{ElectionID: election.ElectionID, Kind: kind, RunAt: runAt, Status: jobs.Pending})
+			if res.Error != nil {
+				log.Println(res.Error.Error())
+				return res.Error
+			}
+			continue
+		}
+		if res.Error != nil {
+			log.Println(res.Error.Error())
+			return res.Error
+		}
+
+		if !job.RunAt.Equal(runAt) {
+			res = tx.Model(&models.Job{}).Where("job_id = ?", job.JobID.String()).Updates(map[string]interface{}{"run_at": runAt, "status": jobs.Pending, "attempts": 0, "last_error": ""})
+			if res.Error != nil {
+				log.Println(res.Error.Error())
+				return res.Error
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,365 @@
+package database
+
+import (
+	"elect/lifecycle"
+	"elect/models"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/jinzhu/gorm"
+)
+
+func (db *postgresDatabase) GetElectionsToSync() ([]models.Election, error) {
+	var elections []models.Election
+	res := db.connection.Model(&models.Election{}).Where("paused = ? AND status IN (?)", false, []int{lifecycle.Nomination, lifecycle.Locked, lifecycle.Voting, lifecycle.Closed}).Find(&elections)
+	if res.Error != nil {
+		log.Println(res.Error.Error())
+		return nil, res.Error
+	}
+
+	return elections, nil
+}
+
+func (db *postgresDatabase) SyncElectionStatus(electionId string) error {
+	tx := db.connection.Begin()
+
+	election, err := lockElection(tx, "", electionId)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	_, err = syncElection(tx, "scheduler", election)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	res := tx.Commit()
+	if res.Error != nil {
+		log.Println(res.Error.Error())
+		return res.Error
+	}
+
+	return nil
+}
+
+func (db *postgresDatabase) OpenElection(userId string, electionId string) error {
+	tx := db.connection.Begin()
+
+	election, err := lockElection(tx, userId, electionId)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if election.Status != lifecycle.Draft {
+		tx.Rollback()
+		log.Println("Election is not a draft!")
+		return errors.New("Election is not a draft!")
+	}
+
+	if !time.Now().UTC().Before(election.EndingAt.UTC()) {
+		tx.Rollback()
+		log.Println("Election has already ended!")
+		return errors.New("Election has already ended!")
+	}
+
+	opened := election
+	opened.Status = lifecycle.Nomination
+	err = transitionElection(tx, userId, election, lifecycle.Current(opened, time.Now()))
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	res := tx.Commit()
+	if res.Error != nil {
+		log.Println(res.Error.Error())
+		return res.Error
+	}
+
+	return nil
+}
+
+func (db *postgresDatabase) PauseElection(userId string, electionId string) error {
+	tx := db.connection.Begin()
+
+	election, err := lockElection(tx, userId, electionId)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if election.Paused {
+		tx.Rollback()
+		log.Println("Election already paused!")
+		return errors.New("Election already paused!")
+	}
+
+	election, err = syncElection(tx, userId, election)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if election.Status != lifecycle.Nomination && election.Status != lifecycle.Locked && election.Status != lifecycle.Voting {
+		tx.Rollback()
+		log.Println("Election can't be paused!")
+		return errors.New("Election can't be paused!")
+	}
+
+	err = setElectionPaused(tx, userId, election, true)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	res := tx.Commit()
+	if res.Error != nil {
+		log.Println(res.Error.Error())
+		return res.Error
+	}
+
+	return nil
+}
+
+func (db *postgresDatabase) ResumeElection(userId string, electionId string) error {
+	tx := db.connection.Begin()
+
+	election, err := lockElection(tx, userId, electionId)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if !election.Paused {
+		tx.Rollback()
+		log.Println("Election is not paused!")
+		return errors.New("Election is not paused!")
+	}
+
+	err = setElectionPaused(tx, userId, election, false)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	election.Paused = false
+	_, err = syncElection(tx, userId, election)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	res := tx.Commit()
+	if res.Error != nil {
+		log.Println(res.Error.Error())
+		return res.Error
+	}
+
+	return nil
+}
+
+func (db *postgresDatabase) ExtendElection(userId string, electionId string, endingAt time.Time) error {
+	tx := db.connection.Begin()
+
+	election, err := lockElection(tx, userId, electionId)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	status := lifecycle.Current(election, time.Now())
+	if status != lifecycle.Locked && status != lifecycle.Voting && status != lifecycle.Closed {
+		tx.Rollback()
+		log.Println("Election can't be extended!")
+		return errors.New("Election can't be extended!")
+	}
+
+	if !endingAt.After(election.EndingAt.UTC()) || !endingAt.After(time.Now().UTC()) {
+		tx.Rollback()
+		log.Println("Invalid ending time!")
+		return errors.New("Invalid ending time!")
+	}
+
+	res := tx.Model(&models.Election{}).Where("election_id = ?", electionId).Update("ending_at", endingAt)
+	if res.Error != nil {
+		tx.Rollback()
+		log.Println(res.Error.Error())
+		return res.Error
+	}
+
+	err = recordAuditEvent(tx, electionId, userId, "election.extend", "election:"+electionId, map[string]interface{}{"EndingAt": election.EndingAt.UTC()}, map[string]interface{}{"EndingAt": endingAt})
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	election.EndingAt = endingAt
+	_, err = syncElection(tx, userId, election)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	err = scheduleElectionJobs(tx, election)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	res = tx.Commit()
+	if res.Error != nil {
+		log.Println(res.Error.Error())
+		return res.Error
+	}
+
+	return nil
+}
+
+func (db *postgresDatabase) PublishResults(userId string, electionId string) error {
+	tx := db.connection.Begin()
+
+	election, err := lockElection(tx, userId, electionId)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	election, err = syncElection(tx, userId, election)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if lifecycle.HasPublishedResults(election.Status) {
+		tx.Rollback()
+		log.Println("Results already published!")
+		return errors.New("Results already published!")
+	}
+
+	if election.Status != lifecycle.Closed {
+		tx.Rollback()
+		log.Println("Election has not completed!")
+		return errors.New("Election has not completed!")
+	}
+
+	err = transitionElection(tx, userId, election, lifecycle.ResultsPublished)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	res := tx.Commit()
+	if res.Error != nil {
+		log.Println(res.Error.Error())
+		return res.Error
+	}
+
+	return nil
+}
+
+func (db *postgresDatabase) ArchiveElection(userId string, electionId string) error {
+	tx := db.connection.Begin()
+
+	election, err := lockElection(tx, userId, electionId)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	election, err = syncElection(tx, userId, election)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if election.Status != lifecycle.ResultsPublished {
+		tx.Rollback()
+		log.Println("Results have not been published!")
+		return errors.New("Results have not been published!")
+	}
+
+	err = transitionElection(tx, userId, election, lifecycle.Archived)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	res := tx.Commit()
+	if res.Error != nil {
+		log.Println(res.Error.Error())
+		return res.Error
+	}
+
+	return nil
+}
+
+// lockElection locks the election row for the rest of the transaction, an empty userId skips the ownership check.
+func lockElection(tx *gorm.DB, userId string, electionId string) (models.Election, error) {
+	query := tx.Set("gorm:query_option", "FOR UPDATE").Model(&models.Election{}).Where("election_id = ?", electionId)
+	if userId != "" {
+		query = query.Where("created_by = ?", userId)
+	}
+
+	var election models.Election
+	res := query.Find(&election)
+	if gorm.IsRecordNotFoundError(res.Error) {
+		log.Println("Unauthorized!")
+		return models.Election{}, errors.New("Unauthorized!")
+	}
+	if res.Error != nil {
+		log.Println(res.Error.Error())
+		return models.Election{}, res.Error
+	}
+
+	return election, nil
+}
+
+// syncElection moves the election to the status its timings call for.
+func syncElection(tx *gorm.DB, actor string, election models.Election) (models.Election, error) {
+	status := lifecycle.Current(election, time.Now())
+	if status == election.Status {
+		return election, nil
+	}
+
+	err := transitionElection(tx, actor, election, status)
+	if err != nil {
+		return models.Election{}, err
+	}
+
+	election.Status = status
+	return election, nil
+}
+
+func transitionElection(tx *gorm.DB, actor string, election models.Election, status int) error {
+	if !lifecycle.CanTransition(election.Status, status) {
+		log.Println("Invalid transition: " + lifecycle.Name(election.Status) + " to " + lifecycle.Name(status))
+		return errors.New("Invalid status transition!")
+	}
+
+	res := tx.Model(&models.Election{}).Where("election_id = ?", election.ElectionID.String()).Update("status", status)
+	if res.Error != nil {
+		log.Println(res.Error.Error())
+		return res.Error
+	}
+
+	return recordAuditEvent(tx, election.ElectionID.String(), actor, "election.transition", "election:"+election.ElectionID.String(), map[string]interface{}{"Status": lifecycle.Name(election.Status)}, map[string]interface{}{"Status": lifecycle.Name(status)})
+}
+
+func setElectionPaused(tx *gorm.DB, actor string, election models.Election, paused bool) error {
+	res := tx.Model(&models.Election{}).Where("election_id = ?", election.ElectionID.String()).Update("paused", paused)
+	if res.Error != nil {
+		log.Println(res.Error.Error())
+		return res.Error
+	}
+
+	action := "election.resume"
+	if paused {
+		action = "election.pause"
+	}
+
+	return recordAuditEvent(tx, election.ElectionID.String(), actor, action, "election:"+election.ElectionID.String(), map[string]interface{}{"Paused": election.Paused}, map[string]interface{}{"Paused": paused})
+}
@@ -0,0 +1,143 @@
+package database
+
+import (
+	"elect/lockout"
+	"elect/models"
+	"elect/roles"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/jinzhu/gorm"
+)
+
+func (db *postgresDatabase) GetLoginThrottles(keys []string) ([]models.LoginThrottle, error) {
+	var throttles []models.LoginThrottle
+	res := db.connection.Where("key IN (?)", keys).Find(&throttles)
+	if res.Error != nil {
+		log.Println(res.Error.Error())
+		return nil, res.Error
+	}
+
+	return throttles, nil
+}
+
+// RecordLoginFailure counts a failure against the key and sets its backoff, the key is locked once it reaches maxFailures.
+// The returned bool tells whether this failure started the lockout.
+func (db *postgresDatabase) RecordLoginFailure(key string, maxFailures int) (models.LoginThrottle, bool, error) {
+	now := time.Now().UTC()
+
+	tx := db.connection.Begin()
+
+	res := tx.Exec("INSERT INTO login_throttles (key, failures, last_failure_at, blocked_until, created_at, updated_at) VALUES (?, 0, ?, ?, ?, ?) ON CONFLICT (key) DO NOTHING", key, now, now, now, now)
+	if res.Error != nil {
+		tx.Rollback()
+		log.Println(res.Error.Error())
+		return models.LoginThrottle{}, false, res.Error
+	}
+
+	var throttle models.LoginThrottle
+	res = tx.Set("gorm:query_option", "FOR UPDATE").Where("key = ?", key).Find(&throttle)
+	if res.Error != nil {
+		tx.Rollback()
+		log.Println(res.Error.Error())
+		return models.LoginThrottle{}, false, res.Error
+	}
+
+	// Counting starts over once a lockout has run out or the last failure is older than the window
+	if throttle.LockedUntil != nil && !throttle.LockedUntil.After(now) {
+		throttle.Failures = 0
+		throttle.LockedUntil = nil
+	}
+	if throttle.LockedUntil == nil && now.Sub(throttle.LastFailureAt) > lockout.Window() {
+		throttle.Failures = 0
+	}
+
+	throttle.Failures++
+	throttle.LastFailureAt = now
+	throttle.BlockedUntil = now.Add(lockout.Backoff(throttle.Failures, maxFailures))
+
+	locked := false
+	if throttle.LockedUntil == nil && throttle.Failures >= maxFailures {
+		lockedUntil := now.Add(lockout.Duration())
+		throttle.LockedUntil = &lockedUntil
+		locked = true
+	}
+
+	res = tx.Model(&models.LoginThrottle{}).Where("key = ?", key).UpdateColumns(map[string]interface{}{
+		"failures":        throttle.Failures,
+		"last_failure_at": throttle.LastFailureAt,
+		"blocked_until":   throttle.BlockedUntil,
+		"locked_until":    throttle.LockedUntil,
+		"updated_at":      now,
+	})
+	if res.Error != nil {
+		tx.Rollback()
+		log.Println(res.Error.Error())
+		return models.LoginThrottle{}, false, res.Error
+	}
+
+	res = tx.Commit()
+	if res.Error != nil {
+		log.Println(res.Error.Error())
+		return models.LoginThrottle{}, false, res.Error
+	}
+
+	return throttle, locked, nil
+}
+
+func (db *postgresDatabase) ClearLoginFailures(key string) error {
+	res := db.connection.Where("key = ?", key).Delete(&models.LoginThrottle{})
+	if res.Error != nil {
+		log.Println(res.Error.Error())
+		return res.Error
+	}
+
+	return nil
+}
+
+// UnlockAccount clears the failures of a student's account, admins can only unlock the students they registered.
+func (db *postgresDatabase) UnlockAccount(userId string, role int, studentUserId string) error {
+	tx := db.connection.Begin()
+
+	student, err := lockUser(tx, studentUserId)
+	if err != nil {
+		tx.Rollback()
+		return errors.New("Invalid Student!")
+	}
+
+	if role != roles.SuperAdmin && (student.Role != roles.Student || student.RegisteredBy != userId) {
+		tx.Rollback()
+		log.Println("Invalid Student!")
+		return errors.New("Invalid Student!")
+	}
+
+	key := lockout.AccountKey(student.Email)
+
+	var throttle models.LoginThrottle
+	res := tx.Set("gorm:query_option", "FOR UPDATE").Where("key = ?", key).Find(&throttle)
+	if gorm.IsRecordNotFoundError(res.Error) {
+		tx.Rollback()
+		return errors.New("Account not locked!")
+	}
+	if res.Error != nil {
+		tx.Rollback()
+		log.Println(res.Error.Error())
+		return res.Error
+	}
+
+	res = tx.Where("key = ?", key).Delete(&models.LoginThrottle{})
+	if res.Error != nil {
+		tx.Rollback()
+		log.Println(res.Error.Error())
+		return res.Error
+	}
+
+	err = recordAuditEvent(tx, "", userId, "account.unlock", "user:"+studentUserId, map[string]interface{}{"Failures": throttle.Failures, "LockedUntil": throttle.LockedUntil}, nil)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit().Error
+}
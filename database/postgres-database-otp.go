@@ -0,0 +1,285 @@
+package database
+
+import (
+	"elect/models"
+	"elect/otpmethods"
+	"errors"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/jinzhu/gorm"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func (db *postgresDatabase) GetUserByEmail(email string) (models.User, error) {
+	var user models.User
+	res := db.connection.Where("UPPER(email) = ?", strings.ToUpper(email)).Find(&user)
+	if gorm.IsRecordNotFoundError(res.Error) {
+		return models.User{}, errors.New("Invalid user!")
+	}
+	if res.Error != nil {
+		log.Println(res.Error.Error())
+		return models.User{}, res.Error
+	}
+
+	return user, nil
+}
+
+// StoreOTPSecret sets the email OTP secret of a user that has none yet and returns the one that ends up stored.
+func (db *postgresDatabase) StoreOTPSecret(userId string, secret string) (string, error) {
+	tx := db.connection.Begin()
+
+	res := tx.Model(&models.User{}).Where("user_id = ? AND otp_secret IS NULL", userId).UpdateColumn("otp_secret", secret)
+	if res.Error != nil {
+		tx.Rollback()
+		log.Println(res.Error.Error())
+		return "", res.Error
+	}
+
+	if res.RowsAffected > 0 {
+		err := recordAuditEvent(tx, "", userId, "account.otp_secret", "user:"+userId, nil, map[string]interface{}{"OTPSecret": secret})
+		if err != nil {
+			tx.Rollback()
+			return "", err
+		}
+	}
+
+	var user models.User
+	res = tx.Where("user_id = ?", userId).Find(&user)
+	if res.Error != nil {
+		tx.Rollback()
+		log.Println(res.Error.Error())
+		return "", res.Error
+	}
+
+	res = tx.Commit()
+	if res.Error != nil {
+		log.Println(res.Error.Error())
+		return "", res.Error
+	}
+
+	return user.OTPSecret, nil
+}
+
+func (db *postgresDatabase) StorePendingTOTPSecret(userId string, secret string) error {
+	tx := db.connection.Begin()
+
+	user, err := lockUser(tx, userId)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	res := tx.Model(&models.User{}).Where("user_id = ?", userId).UpdateColumn("pending_totp_secret", secret)
+	if res.Error != nil {
+		tx.Rollback()
+		log.Println(res.Error.Error())
+		return res.Error
+	}
+
+	err = recordAuditEvent(tx, "", userId, "account.totp_enroll", "user:"+userId, map[string]interface{}{"PendingTOTPSecret": user.PendingTOTPSecret}, map[string]interface{}{"PendingTOTPSecret": secret})
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit().Error
+}
+
+// ConfirmTOTPSecret makes the pending authenticator secret the active one and switches the user to authenticator OTPs.
+func (db *postgresDatabase) ConfirmTOTPSecret(userId string, secret string) error {
+	tx := db.connection.Begin()
+
+	user, err := lockUser(tx, userId)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if user.PendingTOTPSecret == "" || user.PendingTOTPSecret != secret {
+		tx.Rollback()
+		log.Println("No pending authenticator enrollment!")
+		return errors.New("No pending authenticator enrollment!")
+	}
+
+	res := tx.Model(&models.User{}).Where("user_id = ?", userId).UpdateColumns(map[string]interface{}{
+		"totp_secret":         secret,
+		"pending_totp_secret": gorm.Expr("NULL"),
+		"otp_method":          otpmethods.Authenticator,
+	})
+	if res.Error != nil {
+		tx.Rollback()
+		log.Println(res.Error.Error())
+		return res.Error
+	}
+
+	err = recordAuditEvent(tx, "", userId, "account.totp_confirm", "user:"+userId, map[string]interface{}{"TOTPSecret": user.TOTPSecret, "OTPMethod": user.OTPMethod}, map[string]interface{}{"TOTPSecret": secret, "OTPMethod": otpmethods.Authenticator})
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit().Error
+}
+
+func (db *postgresDatabase) SetOTPMethod(userId string, method int) error {
+	tx := db.connection.Begin()
+
+	user, err := lockUser(tx, userId)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if method == otpmethods.Authenticator && user.TOTPSecret == "" {
+		tx.Rollback()
+		log.Println("Authenticator not enrolled!")
+		return errors.New("Authenticator not enrolled!")
+	}
+
+	res := tx.Model(&models.User{}).Where("user_id = ?", userId).UpdateColumn("otp_method", method)
+	if res.Error != nil {
+		tx.Rollback()
+		log.Println(res.Error.Error())
+		return res.Error
+	}
+
+	err = recordAuditEvent(tx, "", userId, "account.otp_method", "user:"+userId, map[string]interface{}{"OTPMethod": user.OTPMethod}, map[string]interface{}{"OTPMethod": method})
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit().Error
+}
+
+func lockUser(tx *gorm.DB, userId string) (models.User, error) {
+	var user models.User
+	res := tx.Set("gorm:query_option", "FOR UPDATE").Where("user_id = ?", userId).Find(&user)
+	if gorm.IsRecordNotFoundError(res.Error) {
+		log.Println("Invalid user!")
+		return models.User{}, errors.New("Invalid user!")
+	}
+	if res.Error != nil {
+		log.Println(res.Error.Error())
+		return models.User{}, res.Error
+	}
+
+	return user, nil
+}
+
+// ReplaceRecoveryCodes removes the user's old recovery codes and stores the new ones hashed.
+func (db *postgresDatabase) ReplaceRecoveryCodes(actorId string, userId string, codes []string) error {
+	hashes := []string{}
+	for _, code := range codes {
+		hash, err := bcrypt.GenerateFromPassword([]byte(code), bcrypt.DefaultCost)
+		if err != nil {
+			log.Println(err.Error())
+			return err
+		}
+		hashes = append(hashes, string(hash))
+	}
+
+	tx := db.connection.Begin()
+
+	user, err := lockUser(tx, userId)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	var remaining int
+	res := tx.Model(&models.RecoveryCode{}).Where("user_id = ? AND used_at IS NULL", userId).Count(&remaining)
+	if res.Error != nil {
+		tx.Rollback()
+		log.Println(res.Error.Error())
+		return res.Error
+	}
+
+	res = tx.Where("user_id = ?", userId).Delete(&models.RecoveryCode{})
+	if res.Error != nil {
+		tx.Rollback()
+		log.Println(res.Error.Error())
+		return res.Error
+	}
+
+	for _, hash := range hashes {
+		res = tx.Create(&models.RecoveryCode{
+			UserID:   user.UserID,
+			CodeHash: hash,
+		})
+		if res.Error != nil {
+			tx.Rollback()
+			log.Println(res.Error.Error())
+			return res.Error
+		}
+	}
+
+	err = recordAuditEvent(tx, "", actorId, "account.recovery_codes", "user:"+userId, map[string]interface{}{"RecoveryCodes": remaining}, map[string]interface{}{"RecoveryCodes": len(hashes)})
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit().Error
+}
+
+// UseOTPStep records the time step of an accepted OTP, a code from the same or an earlier step is turned down so it
+// can't be replayed while it is still valid.
+func (db *postgresDatabase) UseOTPStep(userId string, method int, step int64) error {
+	column := "last_email_otp_step"
+	if method == otpmethods.Authenticator {
+		column = "last_totp_step"
+	}
+
+	res := db.connection.Model(&models.User{}).Where("user_id = ? AND "+column+" < ?", userId, step).UpdateColumn(column, step)
+	if res.Error != nil {
+		log.Println(res.Error.Error())
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		log.Println("OTP already used!")
+		return errors.New("Invalid OTP!")
+	}
+
+	return nil
+}
+
+// UseRecoveryCode marks the matching unused recovery code as used, every code works only once.
+func (db *postgresDatabase) UseRecoveryCode(userId string, code string) error {
+	tx := db.connection.Begin()
+
+	var recoveryCodes []models.RecoveryCode
+	res := tx.Set("gorm:query_option", "FOR UPDATE").Where("user_id = ? AND used_at IS NULL", userId).Find(&recoveryCodes)
+	if res.Error != nil {
+		tx.Rollback()
+		log.Println(res.Error.Error())
+		return res.Error
+	}
+
+	for _, recoveryCode := range recoveryCodes {
+		if bcrypt.CompareHashAndPassword([]byte(recoveryCode.CodeHash), []byte(code)) != nil {
+			continue
+		}
+
+		res = tx.Model(&models.RecoveryCode{}).Where("id = ?", recoveryCode.ID).UpdateColumn("used_at", time.Now().UTC())
+		if res.Error != nil {
+			tx.Rollback()
+			log.Println(res.Error.Error())
+			return res.Error
+		}
+
+		err := recordAuditEvent(tx, "", userId, "account.recovery_code_used", "user:"+userId, map[string]interface{}{"RecoveryCodes": len(recoveryCodes)}, map[string]interface{}{"RecoveryCodes": len(recoveryCodes) - 1})
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+
+		return tx.Commit().Error
+	}
+
+	tx.Rollback()
+	log.Println("Invalid OTP!")
+	return errors.New("Invalid OTP!")
+}
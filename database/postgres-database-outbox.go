@@ -0,0 +1,176 @@
+package database
+
+import (
+	"elect/email"
+	"elect/jobs"
+	"elect/models"
+	"elect/roles"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/jinzhu/gorm"
+)
+
+// outboxMailer queues emails in the outbox, the outbox worker sends them with the configured transport.
+type outboxMailer struct {
+	connection *gorm.DB
+}
+
+func (mailer *outboxMailer) Send(message email.Message) error {
+	return queueEmail(mailer.connection, message)
+}
+
+func (db *postgresDatabase) QueueEmail(message email.Message) error {
+	return queueEmail(db.connection, message)
+}
+
+// ClaimDueEmails marks due emails as sending and returns them, emails whose lease ran out are claimed again.
+func (db *postgresDatabase) ClaimDueEmails(limit int) ([]models.EmailOutbox, error) {
+	now := time.Now().UTC()
+
+	var emails []models.EmailOutbox
+	res := db.connection.Raw(`UPDATE email_outboxes SET status = ?, locked_until = ?, updated_at = ?
+		WHERE email_outbox_id IN (
+			SELECT email_outbox_id FROM email_outboxes
+			WHERE deleted_at IS NULL AND next_attempt_at <= ? AND (status = ? OR (status = ? AND locked_until < ?))
+			ORDER BY next_attempt_at ASC
+			LIMIT ?
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING *`, jobs.Running, now.Add(jobs.Lease), now, now, jobs.Pending, jobs.Running, now, limit).Scan(&emails)
+	if res.Error != nil && !gorm.IsRecordNotFoundError(res.Error) {
+		log.Println(res.Error.Error())
+		return nil, res.Error
+	}
+
+	return emails, nil
+}
+
+func (db *postgresDatabase) FinishEmail(emailOutboxId string, status int, attempts int, nextAttemptAt time.Time, lastError string) error {
+	updates := map[string]interface{}{
+		"status":          status,
+		"attempts":        attempts,
+		"next_attempt_at": nextAttemptAt,
+		"last_error":      lastError,
+		"locked_until":    nil,
+	}
+	if status == jobs.Done {
+		updates["sent_at"] = time.Now().UTC()
+	}
+
+	res := db.connection.Model(&models.EmailOutbox{}).Where("email_outbox_id = ?", emailOutboxId).Updates(updates)
+	if res.Error != nil {
+		log.Println(res.Error.Error())
+		return res.Error
+	}
+
+	return nil
+}
+
+// GetFailedEmails returns the failed emails of the students the admin registered, super admins see all of them.
+func (db *postgresDatabase) GetFailedEmails(userId string, role int) ([]models.EmailOutbox, error) {
+	var emails []models.EmailOutbox
+	res := failedEmails(db.connection, userId, role).Order("email_outboxes.updated_at DESC").Find(&emails)
+	if res.Error != nil {
+		log.Println(res.Error.Error())
+		return nil, res.Error
+	}
+
+	return emails, nil
+}
+
+// ResendEmails queues failed emails again with a fresh set of attempts, an empty emailOutboxId resends all of them.
+func (db *postgresDatabase) ResendEmails(userId string, role int, emailOutboxId string) (int, error) {
+	tx := db.connection.Begin()
+
+	query := failedEmails(tx, userId, role)
+	if emailOutboxId != "" {
+		query = query.Where("email_outboxes.email_outbox_id = ?", emailOutboxId)
+	}
+
+	var emails []models.EmailOutbox
+	res := query.Set("gorm:query_option", "FOR UPDATE OF email_outboxes").Find(&emails)
+	if res.Error != nil {
+		tx.Rollback()
+		log.Println(res.Error.Error())
+		return 0, res.Error
+	}
+
+	if emailOutboxId != "" && len(emails) == 0 {
+		tx.Rollback()
+		log.Println("Invalid Email!")
+		return 0, errors.New("Invalid Email!")
+	}
+
+	for _, e := range emails {
+		res = tx.Model(&models.EmailOutbox{}).Where("email_outbox_id = ?", e.EmailOutboxID.String()).Updates(map[string]interface{}{
+			"status":          jobs.Pending,
+			"attempts":        0,
+			"next_attempt_at": time.Now().UTC(),
+			"last_error":      "",
+		})
+		if res.Error != nil {
+			tx.Rollback()
+			log.Println(res.Error.Error())
+			return 0, res.Error
+		}
+
+		err := recordAuditEvent(tx, "", userId, "email.resend", "email:"+e.EmailOutboxID.String(), map[string]interface{}{"Status": jobs.Name(e.Status), "Attempts": e.Attempts}, map[string]interface{}{"Status": jobs.Name(jobs.Pending), "Attempts": 0})
+		if err != nil {
+			tx.Rollback()
+			return 0, err
+		}
+	}
+
+	res = tx.Commit()
+	if res.Error != nil {
+		log.Println(res.Error.Error())
+		return 0, res.Error
+	}
+
+	return len(emails), nil
+}
+
+func queueEmail(connection *gorm.DB, message email.Message) error {
+	outbox := models.EmailOutbox{
+		Recipient:     message.To,
+		Subject:       message.Subject,
+		Body:          message.Body,
+		Status:        jobs.Pending,
+		NextAttemptAt: time.Now().UTC(),
+	}
+	if !message.ExpiresAt.IsZero() {
+		expiresAt := message.ExpiresAt.UTC()
+		outbox.ExpiresAt = &expiresAt
+	}
+
+	if message.IdempotencyKey != "" {
+		// A message that was queued before under the same key is left as it is
+		now := time.Now().UTC()
+		res := connection.Exec("INSERT INTO email_outboxes (recipient, subject, body, status, attempts, next_attempt_at, expires_at, idempotency_key, created_at, updated_at) VALUES (?, ?, ?, ?, 0, ?, ?, ?, ?, ?) ON CONFLICT (idempotency_key) DO NOTHING", outbox.Recipient, outbox.Subject, outbox.Body, outbox.Status, outbox.NextAttemptAt, outbox.ExpiresAt, message.IdempotencyKey, now, now)
+		if res.Error != nil {
+			log.Println(res.Error.Error())
+			return res.Error
+		}
+
+		return nil
+	}
+
+	res := connection.Create(&outbox)
+	if res.Error != nil {
+		log.Println(res.Error.Error())
+		return res.Error
+	}
+
+	return nil
+}
+
+func failedEmails(connection *gorm.DB, userId string, role int) *gorm.DB {
+	query := connection.Model(&models.EmailOutbox{}).Where("email_outboxes.status = ?", jobs.Failed)
+	if role != roles.SuperAdmin {
+		query = query.Joins("JOIN users ON users.email = email_outboxes.recipient AND users.deleted_at IS NULL").Where("users.registered_by = ?", userId)
+	}
+
+	return query
+}
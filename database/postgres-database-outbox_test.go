@@ -0,0 +1,39 @@
+package database_test
+
+import (
+	"elect/database/databasetest"
+	"elect/email"
+	"elect/models"
+	"testing"
+)
+
+func TestQueueEmailOncePerIdempotencyKey(t *testing.T) {
+	db, conn := databasetest.New(t)
+
+	message := email.Message{To: "student@example.com", Subject: "Voting is open for Test.", Body: "body", IdempotencyKey: "election.started:election:student"}
+	for i := 0; i < 2; i++ {
+		err := db.QueueEmail(message)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	err := db.QueueEmail(email.Message{To: "student@example.com", Subject: "Voting is open for Test.", Body: "body"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = db.QueueEmail(email.Message{To: "student@example.com", Subject: "Voting is open for Test.", Body: "body"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var keyed, unkeyed int
+	conn.Model(&models.EmailOutbox{}).Where("idempotency_key = ?", message.IdempotencyKey).Count(&keyed)
+	conn.Model(&models.EmailOutbox{}).Where("idempotency_key IS NULL").Count(&unkeyed)
+	if keyed != 1 {
+		t.Fatalf("got %d emails for the key, want 1", keyed)
+	}
+	if unkeyed != 2 {
+		t.Fatalf("got %d emails without a key, want 2", unkeyed)
+	}
+}
@@ -0,0 +1,325 @@
+package database
+
+import (
+	"elect/models"
+	"encoding/json"
+	"errors"
+	"log"
+	"strconv"
+	"strings"
+
+	"github.com/jinzhu/gorm"
+)
+
+func (db *postgresDatabase) GetPolicyRules() ([]models.CasbinRule, error) {
+	var rules []models.CasbinRule
+	res := db.connection.Order("id").Find(&rules)
+	if res.Error != nil {
+		log.Println(res.Error.Error())
+		return nil, res.Error
+	}
+
+	return rules, nil
+}
+
+// SeedPolicyRules adds the rules of policy.csv that haven't been added before, so a new deployment gets all of them and
+// a running one gets the rules added to policy.csv since. A rule is added once, the ones super admins edited or deleted
+// aren't brought back. The table is locked so instances starting together don't both seed it.
+func (db *postgresDatabase) SeedPolicyRules(rules []models.CasbinRule) error {
+	tx := db.connection.Begin()
+
+	current, err := lockPolicyRules(tx)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	var seeds []models.PolicySeed
+	res := tx.Find(&seeds)
+	if res.Error != nil {
+		tx.Rollback()
+		log.Println(res.Error.Error())
+		return res.Error
+	}
+
+	seeded := map[string]bool{}
+	for _, seed := range seeds {
+		seeded[policyKey(seed.PType, seed.V0, seed.V1, seed.V2, seed.V3, seed.V4, seed.V5)] = true
+	}
+	present := map[string]bool{}
+	for _, rule := range current {
+		present[policyKey(rule.PType, rule.V0, rule.V1, rule.V2, rule.V3, rule.V4, rule.V5)] = true
+	}
+
+	// The table was seeded before seeds were recorded, the audit log tells the defaults removed since from new ones
+	removed := map[string]bool{}
+	if len(seeds) == 0 && len(current) > 0 {
+		removed, err = removedPolicyRules(tx, current)
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	added := 0
+	for _, rule := range rules {
+		key := policyKey(rule.PType, rule.V0, rule.V1, rule.V2, rule.V3, rule.V4, rule.V5)
+		if seeded[key] {
+			continue
+		}
+		seeded[key] = true
+
+		if !present[key] && !removed[key] {
+			res = tx.Create(&rule)
+			if res.Error != nil {
+				tx.Rollback()
+				log.Println(res.Error.Error())
+				return res.Error
+			}
+			added++
+		}
+
+		res = tx.Create(&models.PolicySeed{PType: rule.PType, V0: rule.V0, V1: rule.V1, V2: rule.V2, V3: rule.V3, V4: rule.V4, V5: rule.V5})
+		if res.Error != nil {
+			tx.Rollback()
+			log.Println(res.Error.Error())
+			return res.Error
+		}
+	}
+
+	if added > 0 {
+		err = recordAuditEvent(tx, "", "", "policy.seed", "policy", nil, map[string]interface{}{"Rules": added})
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	return tx.Commit().Error
+}
+
+// AddPolicyRule stores the rule if check accepts the rules it is added to. The table stays locked until the rule is
+// stored, so concurrent changes are checked against each other.
+func (db *postgresDatabase) AddPolicyRule(userId string, rule models.CasbinRule, check func(rules []models.CasbinRule) error) (models.CasbinRule, error) {
+	tx := db.connection.Begin()
+
+	rules, err := lockPolicyRules(tx)
+	if err != nil {
+		tx.Rollback()
+		return models.CasbinRule{}, err
+	}
+
+	err = checkDuplicatePolicyRule(tx, rule)
+	if err != nil {
+		tx.Rollback()
+		return models.CasbinRule{}, err
+	}
+
+	err = check(rules)
+	if err != nil {
+		tx.Rollback()
+		return models.CasbinRule{}, err
+	}
+
+	res := tx.Create(&rule)
+	if res.Error != nil {
+		tx.Rollback()
+		log.Println(res.Error.Error())
+		return models.CasbinRule{}, res.Error
+	}
+
+	err = recordAuditEvent(tx, "", userId, "policy.add", "policy:"+strconv.FormatUint(uint64(rule.ID), 10), nil, rule)
+	if err != nil {
+		tx.Rollback()
+		return models.CasbinRule{}, err
+	}
+
+	return rule, tx.Commit().Error
+}
+
+// EditPolicyRule replaces the rule if check accepts the rules it is edited in, like AddPolicyRule.
+func (db *postgresDatabase) EditPolicyRule(userId string, rule models.CasbinRule, check func(rules []models.CasbinRule) error) (models.CasbinRule, error) {
+	tx := db.connection.Begin()
+
+	rules, err := lockPolicyRules(tx)
+	if err != nil {
+		tx.Rollback()
+		return models.CasbinRule{}, err
+	}
+
+	before, err := lockPolicyRule(tx, rule.ID)
+	if err != nil {
+		tx.Rollback()
+		return models.CasbinRule{}, err
+	}
+
+	err = checkDuplicatePolicyRule(tx, rule)
+	if err != nil {
+		tx.Rollback()
+		return models.CasbinRule{}, err
+	}
+
+	err = check(rules)
+	if err != nil {
+		tx.Rollback()
+		return models.CasbinRule{}, err
+	}
+
+	res := tx.Save(&rule)
+	if res.Error != nil {
+		tx.Rollback()
+		log.Println(res.Error.Error())
+		return models.CasbinRule{}, res.Error
+	}
+
+	err = recordAuditEvent(tx, "", userId, "policy.edit", "policy:"+strconv.FormatUint(uint64(rule.ID), 10), before, rule)
+	if err != nil {
+		tx.Rollback()
+		return models.CasbinRule{}, err
+	}
+
+	return rule, tx.Commit().Error
+}
+
+// DeletePolicyRule deletes the rule if check accepts the rules it is deleted from, like AddPolicyRule.
+func (db *postgresDatabase) DeletePolicyRule(userId string, ruleId uint, check func(rules []models.CasbinRule) error) error {
+	tx := db.connection.Begin()
+
+	rules, err := lockPolicyRules(tx)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	before, err := lockPolicyRule(tx, ruleId)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	err = check(rules)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	res := tx.Where("id = ?", ruleId).Delete(&models.CasbinRule{})
+	if res.Error != nil {
+		tx.Rollback()
+		log.Println(res.Error.Error())
+		return res.Error
+	}
+
+	err = recordAuditEvent(tx, "", userId, "policy.delete", "policy:"+strconv.FormatUint(uint64(ruleId), 10), before, nil)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit().Error
+}
+
+// lockPolicyRules locks the table against other changes until the transaction ends, reads stay allowed, and returns
+// the rules.
+func lockPolicyRules(tx *gorm.DB) ([]models.CasbinRule, error) {
+	res := tx.Exec("LOCK TABLE casbin_rule IN SHARE ROW EXCLUSIVE MODE")
+	if res.Error != nil {
+		log.Println(res.Error.Error())
+		return nil, res.Error
+	}
+
+	var rules []models.CasbinRule
+	res = tx.Order("id").Find(&rules)
+	if res.Error != nil {
+		log.Println(res.Error.Error())
+		return nil, res.Error
+	}
+
+	return rules, nil
+}
+
+// removedPolicyRules replays the policy audit events backwards from the current rules, and returns every rule that was
+// edited or deleted.
+func removedPolicyRules(tx *gorm.DB, rules []models.CasbinRule) (map[string]bool, error) {
+	states := map[string]map[string]interface{}{}
+	for _, rule := range rules {
+		state, err := auditState(rule)
+		if err != nil {
+			return nil, err
+		}
+		states["policy:"+strconv.FormatUint(uint64(rule.ID), 10)] = state
+	}
+
+	var events []models.AuditEvent
+	res := tx.Where("action IN (?)", []string{"policy.add", "policy.edit", "policy.delete"}).Order("audit_event_id DESC").Find(&events)
+	if res.Error != nil {
+		log.Println(res.Error.Error())
+		return nil, res.Error
+	}
+
+	removed := map[string]bool{}
+	for _, event := range events {
+		diff := map[string]auditChange{}
+		err := json.Unmarshal([]byte(event.Diff), &diff)
+		if err != nil {
+			log.Println(err.Error())
+			return nil, err
+		}
+
+		if event.Action == "policy.add" {
+			delete(states, event.Target)
+			continue
+		}
+
+		state, ok := states[event.Target]
+		if !ok {
+			state = map[string]interface{}{}
+		}
+		for field, change := range diff {
+			state[field] = change.Before
+		}
+		states[event.Target] = state
+
+		values := []string{}
+		for _, field := range []string{"PType", "V0", "V1", "V2", "V3", "V4", "V5"} {
+			value, _ := state[field].(string)
+			values = append(values, value)
+		}
+		removed[policyKey(values...)] = true
+	}
+
+	return removed, nil
+}
+
+// policyKey identifies a rule by its values(ptype, v0 to v5).
+func policyKey(values ...string) string {
+	return strings.Join(values, ", ")
+}
+
+func lockPolicyRule(tx *gorm.DB, ruleId uint) (models.CasbinRule, error) {
+	var rule models.CasbinRule
+	res := tx.Set("gorm:query_option", "FOR UPDATE").Where("id = ?", ruleId).Find(&rule)
+	if gorm.IsRecordNotFoundError(res.Error) {
+		return models.CasbinRule{}, errors.New("Invalid Policy!")
+	}
+	if res.Error != nil {
+		log.Println(res.Error.Error())
+		return models.CasbinRule{}, res.Error
+	}
+
+	return rule, nil
+}
+
+func checkDuplicatePolicyRule(tx *gorm.DB, rule models.CasbinRule) error {
+	var count int
+	res := tx.Model(&models.CasbinRule{}).Where("ptype = ? AND v0 = ? AND v1 = ? AND v2 = ? AND v3 = ? AND v4 = ? AND v5 = ? AND id <> ?", rule.PType, rule.V0, rule.V1, rule.V2, rule.V3, rule.V4, rule.V5, rule.ID).Count(&count)
+	if res.Error != nil {
+		log.Println(res.Error.Error())
+		return res.Error
+	}
+	if count > 0 {
+		return errors.New("Policy already exists!")
+	}
+
+	return nil
+}
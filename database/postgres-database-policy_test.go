@@ -0,0 +1,172 @@
+package database_test
+
+import (
+	"elect/database"
+	"elect/database/databasetest"
+	"elect/models"
+	"errors"
+	"sync"
+	"testing"
+
+	uuid "github.com/satori/go.uuid"
+)
+
+// testPolicyRule is a rule for a path no other test uses.
+func testPolicyRule(role string) models.CasbinRule {
+	return models.CasbinRule{PType: "p", V0: role, V1: "/test/" + uuid.NewV4().String(), V2: "GET", V3: "allow"}
+}
+
+func hasPolicyRule(t *testing.T, db database.Database, rule models.CasbinRule) bool {
+	rules, err := db.GetPolicyRules()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, stored := range rules {
+		if stored.PType == rule.PType && stored.V0 == rule.V0 && stored.V1 == rule.V1 && stored.V2 == rule.V2 && stored.V3 == rule.V3 {
+			return true
+		}
+	}
+	return false
+}
+
+func findPolicyRule(t *testing.T, db database.Database, rule models.CasbinRule) models.CasbinRule {
+	rules, err := db.GetPolicyRules()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, stored := range rules {
+		if stored.V1 == rule.V1 {
+			return stored
+		}
+	}
+	t.Fatalf("rule for %s is missing", rule.V1)
+	return models.CasbinRule{}
+}
+
+func acceptPolicy(rules []models.CasbinRule) error {
+	return nil
+}
+
+func TestSeedPolicyRulesAddsNewDefaults(t *testing.T) {
+	db, _ := databasetest.New(t)
+
+	kept := testPolicyRule("1")
+	deleted := testPolicyRule("1")
+	err := db.SeedPolicyRules([]models.CasbinRule{kept, deleted})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = db.DeletePolicyRule("", findPolicyRule(t, db, deleted).ID, acceptPolicy)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	added := testPolicyRule("1")
+	err = db.SeedPolicyRules([]models.CasbinRule{kept, deleted, added})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !hasPolicyRule(t, db, kept) || !hasPolicyRule(t, db, added) {
+		t.Error("default rules are missing")
+	}
+	if hasPolicyRule(t, db, deleted) {
+		t.Error("deleted default rule was added back")
+	}
+}
+
+// A table seeded before seeds were recorded gets the new defaults, but not the ones super admins deleted or edited.
+func TestSeedPolicyRulesBeforeSeedsWereRecorded(t *testing.T) {
+	db, conn := databasetest.New(t)
+
+	kept := testPolicyRule("1")
+	deleted := testPolicyRule("1")
+	edited := testPolicyRule("1")
+	for _, rule := range []models.CasbinRule{kept, deleted, edited} {
+		rule := rule
+		if err := conn.Create(&rule).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	err := db.DeletePolicyRule("", findPolicyRule(t, db, deleted).ID, acceptPolicy)
+	if err != nil {
+		t.Fatal(err)
+	}
+	change := findPolicyRule(t, db, edited)
+	change.V2 = "POST"
+	_, err = db.EditPolicyRule("", change, acceptPolicy)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := conn.Delete(&models.PolicySeed{}).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	added := testPolicyRule("1")
+	err = db.SeedPolicyRules([]models.CasbinRule{kept, deleted, edited, added})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !hasPolicyRule(t, db, kept) || !hasPolicyRule(t, db, added) {
+		t.Error("default rules are missing")
+	}
+	if hasPolicyRule(t, db, deleted) || hasPolicyRule(t, db, edited) {
+		t.Error("removed default rule was added back")
+	}
+}
+
+// Two super admins deleting one of the last two rules each, only one of them may succeed.
+func TestDeletePolicyRuleChecksInTransaction(t *testing.T) {
+	db, conn := databasetest.New(t)
+
+	first := testPolicyRule("2")
+	second := first
+	second.V2 = "POST"
+	for _, rule := range []*models.CasbinRule{&first, &second} {
+		if err := conn.Create(rule).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	keepOne := func(ruleId uint) func(rules []models.CasbinRule) error {
+		return func(rules []models.CasbinRule) error {
+			for _, rule := range rules {
+				if rule.V1 == first.V1 && rule.ID != ruleId {
+					return nil
+				}
+			}
+			return errors.New("Super admins would lose access to policies!")
+		}
+	}
+
+	var wg sync.WaitGroup
+	results := make(chan error, 2)
+	for _, rule := range []models.CasbinRule{first, second} {
+		wg.Add(1)
+		go func(ruleId uint) {
+			defer wg.Done()
+			results <- db.DeletePolicyRule("", ruleId, keepOne(ruleId))
+		}(rule.ID)
+	}
+	wg.Wait()
+	close(results)
+
+	failed := 0
+	for err := range results {
+		if err != nil {
+			failed++
+		}
+	}
+	if failed != 1 {
+		t.Errorf("%d deletes failed, want 1", failed)
+	}
+	if !hasPolicyRule(t, db, first) && !hasPolicyRule(t, db, second) {
+		t.Error("both rules were deleted")
+	}
+}
@@ -0,0 +1,192 @@
+package database
+
+import (
+	"elect/dto"
+	"elect/jobs"
+	"elect/lifecycle"
+	"elect/models"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/jinzhu/gorm"
+)
+
+func (db *postgresDatabase) ScheduleReminder(userId string, electionId string, sendAt time.Time, interval time.Duration) error {
+	tx := db.connection.Begin()
+
+	election, err := lockElection(tx, userId, electionId)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	status := lifecycle.Current(election, time.Now())
+	if status == lifecycle.Draft || lifecycle.HasEnded(status) {
+		tx.Rollback()
+		log.Println("Election is not open for voting!")
+		return errors.New("Election is not open for voting!")
+	}
+
+	if sendAt.Before(election.StartingAt.UTC()) || !sendAt.Before(election.EndingAt.UTC()) {
+		tx.Rollback()
+		log.Println("Reminder must be sent while voting is open!")
+		return errors.New("Reminder must be sent while voting is open!")
+	}
+
+	var count int
+	res := tx.Model(&models.Reminder{}).Where("election_id = ? AND status != ? AND send_at > ? AND send_at < ?", electionId, jobs.Failed, sendAt.Add(-interval), sendAt.Add(interval)).Count(&count)
+	if res.Error != nil {
+		tx.Rollback()
+		log.Println(res.Error.Error())
+		return res.Error
+	}
+	if count > 0 {
+		tx.Rollback()
+		log.Println("Another reminder is too close to this one!")
+		return errors.New("Another reminder is too close to this one!")
+	}
+
+	reminder := models.Reminder{
+		ElectionID: election.ElectionID,
+		SendAt:     sendAt,
+		Status:     jobs.Pending,
+		CreatedBy:  userId,
+	}
+	res = tx.Create(&reminder)
+	if res.Error != nil {
+		tx.Rollback()
+		log.Println(res.Error.Error())
+		return res.Error
+	}
+
+	err = recordAuditEvent(tx, electionId, userId, "reminder.schedule", "reminder:"+reminder.ReminderID.String(), nil, map[string]interface{}{"SendAt": sendAt})
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	res = tx.Commit()
+	if res.Error != nil {
+		log.Println(res.Error.Error())
+		return res.Error
+	}
+
+	return nil
+}
+
+func (db *postgresDatabase) GetReminders(userId string, electionId string) ([]models.Reminder, []models.ReminderDelivery, error) {
+	var election models.Election
+	res := db.connection.Model(&models.Election{}).Where("election_id = ? AND created_by = ?", electionId, userId).Find(&election)
+	if gorm.IsRecordNotFoundError(res.Error) {
+		log.Println("Unauthorized!")
+		return nil, nil, errors.New("Unauthorized!")
+	}
+	if res.Error != nil {
+		log.Println(res.Error.Error())
+		return nil, nil, res.Error
+	}
+
+	var reminders []models.Reminder
+	res = db.connection.Model(&models.Reminder{}).Where("election_id = ?", electionId).Order("send_at DESC").Find(&reminders)
+	if res.Error != nil {
+		log.Println(res.Error.Error())
+		return nil, nil, res.Error
+	}
+
+	var deliveries []models.ReminderDelivery
+	res = db.connection.Model(&models.ReminderDelivery{}).Joins("JOIN reminders ON reminders.reminder_id = reminder_deliveries.reminder_id").Where("reminders.election_id = ? AND reminders.deleted_at IS NULL", electionId).Order("reminder_deliveries.email ASC").Find(&deliveries)
+	if res.Error != nil {
+		log.Println(res.Error.Error())
+		return nil, nil, res.Error
+	}
+
+	return reminders, deliveries, nil
+}
+
+// ClaimDueReminders marks due reminders as running and returns them, reminders whose lease ran out are claimed again.
+func (db *postgresDatabase) ClaimDueReminders(limit int) ([]models.Reminder, error) {
+	now := time.Now().UTC()
+
+	var reminders []models.Reminder
+	res := db.connection.Raw(`UPDATE reminders SET status = ?, locked_until = ?, updated_at = ?
+		WHERE reminder_id IN (
+			SELECT reminder_id FROM reminders
+			WHERE deleted_at IS NULL AND send_at <= ? AND (status = ? OR (status = ? AND locked_until < ?))
+			ORDER BY send_at ASC
+			LIMIT ?
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING *`, jobs.Running, now.Add(jobs.Lease), now, now, jobs.Pending, jobs.Running, now, limit).Scan(&reminders)
+	if res.Error != nil && !gorm.IsRecordNotFoundError(res.Error) {
+		log.Println(res.Error.Error())
+		return nil, res.Error
+	}
+
+	return reminders, nil
+}
+
+// QueueReminderDeliveries adds a pending delivery for every participant who hasn't voted yet, a reclaimed reminder keeps the ones it already has.
+func (db *postgresDatabase) QueueReminderDeliveries(reminderId string, electionId string) error {
+	now := time.Now().UTC()
+
+	res := db.connection.Exec(`INSERT INTO reminder_deliveries (reminder_id, participant_id, email, status, created_at, updated_at)
+		SELECT ?, participants.participant_id, users.email, ?, ?, ?
+		FROM participants JOIN users ON users.user_id = participants.user_id AND users.deleted_at IS NULL
+		WHERE participants.election_id = ? AND participants.voted = ? AND participants.deleted_at IS NULL
+		ON CONFLICT DO NOTHING`, reminderId, jobs.Pending, now, now, electionId, false)
+	if res.Error != nil {
+		log.Println(res.Error.Error())
+		return res.Error
+	}
+
+	return nil
+}
+
+func (db *postgresDatabase) GetPendingReminderRecipients(reminderId string) ([]dto.ReminderRecipientDTO, error) {
+	var recipients []dto.ReminderRecipientDTO
+	res := db.connection.Raw(`SELECT reminder_deliveries.reminder_delivery_id, users.first_name, reminder_deliveries.email, participants.voted
+		FROM reminder_deliveries
+		JOIN participants ON participants.participant_id = reminder_deliveries.participant_id
+		JOIN users ON users.user_id = participants.user_id
+		WHERE reminder_deliveries.reminder_id = ? AND reminder_deliveries.status = ?
+		ORDER BY reminder_deliveries.email ASC`, reminderId, jobs.Pending).Scan(&recipients)
+	if res.Error != nil && !gorm.IsRecordNotFoundError(res.Error) {
+		log.Println(res.Error.Error())
+		return nil, res.Error
+	}
+
+	return recipients, nil
+}
+
+func (db *postgresDatabase) FinishReminderDelivery(reminderDeliveryId string, status int, lastError string) error {
+	updates := map[string]interface{}{
+		"status": status,
+		"error":  lastError,
+	}
+	if status == jobs.Done {
+		updates["sent_at"] = time.Now().UTC()
+	}
+
+	res := db.connection.Model(&models.ReminderDelivery{}).Where("reminder_delivery_id = ?", reminderDeliveryId).Updates(updates)
+	if res.Error != nil {
+		log.Println(res.Error.Error())
+		return res.Error
+	}
+
+	return nil
+}
+
+func (db *postgresDatabase) FinishReminder(reminderId string, status int, sendAt time.Time) error {
+	res := db.connection.Model(&models.Reminder{}).Where("reminder_id = ?", reminderId).Updates(map[string]interface{}{
+		"status":       status,
+		"send_at":      sendAt,
+		"locked_until": nil,
+	})
+	if res.Error != nil {
+		log.Println(res.Error.Error())
+		return res.Error
+	}
+
+	return nil
+}
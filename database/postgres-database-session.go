@@ -0,0 +1,223 @@
+package database
+
+import (
+	"crypto/sha256"
+	"elect/models"
+	"encoding/hex"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/jinzhu/gorm"
+)
+
+func (db *postgresDatabase) CreateSession(session models.Session, refreshToken string) error {
+	now := time.Now().UTC()
+	session.RefreshTokenHash = hashRefreshToken(refreshToken)
+	session.LastUsedAt = now
+
+	tx := db.connection.Begin()
+
+	res := tx.Create(&session)
+	if res.Error != nil {
+		tx.Rollback()
+		log.Println(res.Error.Error())
+		return res.Error
+	}
+
+	res = tx.Create(&models.RefreshToken{
+		SessionID: session.SessionID,
+		TokenHash: session.RefreshTokenHash,
+	})
+	if res.Error != nil {
+		tx.Rollback()
+		log.Println(res.Error.Error())
+		return res.Error
+	}
+
+	err := recordAuditEvent(tx, "", session.UserID.String(), "session.create", "user:"+session.UserID.String(), nil, map[string]interface{}{"SessionID": session.SessionID.String(), "Device": session.Device, "IPAddress": session.IPAddress})
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit().Error
+}
+
+// RotateSession swaps the refresh token of a session for the one that was just issued, recording the old one as its parent.
+// A token that was already rotated means it was copied, so the whole session is revoked.
+func (db *postgresDatabase) RotateSession(sessionId string, refreshToken string, newRefreshToken string, ipAddress string, expiresAt time.Time) error {
+	now := time.Now().UTC()
+
+	tx := db.connection.Begin()
+
+	var token models.RefreshToken
+	res := tx.Set("gorm:query_option", "FOR UPDATE").Where("session_id = ? AND token_hash = ?", sessionId, hashRefreshToken(refreshToken)).Find(&token)
+	if gorm.IsRecordNotFoundError(res.Error) {
+		tx.Rollback()
+		return errors.New("Session revoked!")
+	}
+	if res.Error != nil {
+		tx.Rollback()
+		log.Println(res.Error.Error())
+		return res.Error
+	}
+
+	var session models.Session
+	res = tx.Set("gorm:query_option", "FOR UPDATE").Where("session_id = ?", sessionId).Find(&session)
+	if res.Error != nil {
+		tx.Rollback()
+		log.Println(res.Error.Error())
+		return res.Error
+	}
+
+	if session.RevokedAt != nil || !session.ExpiresAt.After(now) {
+		tx.Rollback()
+		return errors.New("Session revoked!")
+	}
+
+	if token.RotatedAt != nil {
+		res = tx.Model(&models.Session{}).Where("session_id = ?", sessionId).UpdateColumn("revoked_at", now)
+		if res.Error != nil {
+			tx.Rollback()
+			log.Println(res.Error.Error())
+			return res.Error
+		}
+
+		err := recordAuditEvent(tx, "", session.UserID.String(), "session.token_reuse", "user:"+session.UserID.String(), map[string]interface{}{"SessionID": sessionId, "Device": session.Device, "IPAddress": session.IPAddress}, map[string]interface{}{"IPAddress": ipAddress})
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+
+		res = tx.Commit()
+		if res.Error != nil {
+			log.Println(res.Error.Error())
+			return res.Error
+		}
+
+		log.Println("Possible refresh token theft, rotated token reused from " + ipAddress + ", revoked session " + sessionId)
+		return errors.New("Refresh token reused!")
+	}
+
+	res = tx.Model(&models.RefreshToken{}).Where("id = ?", token.ID).UpdateColumn("rotated_at", now)
+	if res.Error != nil {
+		tx.Rollback()
+		log.Println(res.Error.Error())
+		return res.Error
+	}
+
+	res = tx.Create(&models.RefreshToken{
+		SessionID: session.SessionID,
+		ParentID:  &token.ID,
+		TokenHash: hashRefreshToken(newRefreshToken),
+	})
+	if res.Error != nil {
+		tx.Rollback()
+		log.Println(res.Error.Error())
+		return res.Error
+	}
+
+	res = tx.Model(&models.Session{}).Where("session_id = ?", sessionId).UpdateColumns(map[string]interface{}{
+		"refresh_token_hash": hashRefreshToken(newRefreshToken),
+		"ip_address":         ipAddress,
+		"last_used_at":       now,
+		"expires_at":         expiresAt,
+		"updated_at":         now,
+	})
+	if res.Error != nil {
+		tx.Rollback()
+		log.Println(res.Error.Error())
+		return res.Error
+	}
+
+	return tx.Commit().Error
+}
+
+// CheckSession fails unless the session is neither revoked nor expired, so a revoked session can't keep using the
+// access tokens issued to it.
+func (db *postgresDatabase) CheckSession(sessionId string) error {
+	var count int
+	res := db.connection.Model(&models.Session{}).Where("session_id = ? AND revoked_at IS NULL AND expires_at > ?", sessionId, time.Now().UTC()).Count(&count)
+	if res.Error != nil {
+		log.Println(res.Error.Error())
+		return res.Error
+	}
+	if count == 0 {
+		return errors.New("Session revoked!")
+	}
+
+	return nil
+}
+
+func (db *postgresDatabase) GetSessions(userId string) ([]models.Session, error) {
+	var sessions []models.Session
+	res := db.connection.Where("user_id = ? AND revoked_at IS NULL AND expires_at > ?", userId, time.Now().UTC()).Order("last_used_at DESC").Find(&sessions)
+	if res.Error != nil {
+		log.Println(res.Error.Error())
+		return nil, res.Error
+	}
+
+	return sessions, nil
+}
+
+func (db *postgresDatabase) RevokeSession(userId string, sessionId string) error {
+	tx := db.connection.Begin()
+
+	var session models.Session
+	res := tx.Set("gorm:query_option", "FOR UPDATE").Where("user_id = ? AND session_id = ? AND revoked_at IS NULL", userId, sessionId).Find(&session)
+	if gorm.IsRecordNotFoundError(res.Error) {
+		tx.Rollback()
+		return errors.New("Invalid Session!")
+	}
+	if res.Error != nil {
+		tx.Rollback()
+		log.Println(res.Error.Error())
+		return res.Error
+	}
+
+	res = tx.Model(&models.Session{}).Where("session_id = ?", sessionId).UpdateColumn("revoked_at", time.Now().UTC())
+	if res.Error != nil {
+		tx.Rollback()
+		log.Println(res.Error.Error())
+		return res.Error
+	}
+
+	err := recordAuditEvent(tx, "", userId, "session.revoke", "user:"+userId, map[string]interface{}{"SessionID": sessionId, "Device": session.Device}, nil)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit().Error
+}
+
+// RevokeSessions logs a user out of every session except exceptSessionId, which can be empty to log out everywhere.
+func (db *postgresDatabase) RevokeSessions(actorId string, userId string, exceptSessionId string) error {
+	tx := db.connection.Begin()
+
+	query := tx.Model(&models.Session{}).Where("user_id = ? AND revoked_at IS NULL", userId)
+	if exceptSessionId != "" {
+		query = query.Where("session_id <> ?", exceptSessionId)
+	}
+
+	res := query.UpdateColumn("revoked_at", time.Now().UTC())
+	if res.Error != nil {
+		tx.Rollback()
+		log.Println(res.Error.Error())
+		return res.Error
+	}
+
+	err := recordAuditEvent(tx, "", actorId, "session.revoke_all", "user:"+userId, map[string]interface{}{"Sessions": res.RowsAffected}, nil)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit().Error
+}
+
+func hashRefreshToken(token string) string {
+	h := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(h[:])
+}
@@ -0,0 +1,89 @@
+package database
+
+import (
+	"elect/models"
+	"errors"
+	"log"
+	"strings"
+
+	"github.com/jinzhu/gorm"
+)
+
+func (db *postgresDatabase) GetUserByRegNumber(regNumber string) (models.User, error) {
+	var user models.User
+	res := db.connection.Where("UPPER(reg_number) = ?", strings.ToUpper(regNumber)).Find(&user)
+	if gorm.IsRecordNotFoundError(res.Error) {
+		return models.User{}, errors.New("Invalid user!")
+	}
+	if res.Error != nil {
+		log.Println(res.Error.Error())
+		return models.User{}, res.Error
+	}
+
+	return user, nil
+}
+
+func (db *postgresDatabase) GetOIDCIdentityUser(issuer string, subject string) (models.User, error) {
+	var identity models.OIDCIdentity
+	res := db.connection.Where("issuer = ? AND subject = ?", issuer, subject).Find(&identity)
+	if gorm.IsRecordNotFoundError(res.Error) {
+		return models.User{}, errors.New("Invalid user!")
+	}
+	if res.Error != nil {
+		log.Println(res.Error.Error())
+		return models.User{}, res.Error
+	}
+
+	return db.GetUser(identity.UserID.String())
+}
+
+// LinkOIDCIdentity links the provider's account to the user. The provider vouches for the user, so an account that
+// was never verified by email counts as verified from now on.
+func (db *postgresDatabase) LinkOIDCIdentity(userId string, issuer string, subject string) error {
+	tx := db.connection.Begin()
+
+	user, err := lockUser(tx, userId)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	res := tx.Create(&models.OIDCIdentity{
+		UserID:  user.UserID,
+		Issuer:  issuer,
+		Subject: subject,
+	})
+	if res.Error != nil {
+		tx.Rollback()
+		log.Println(res.Error.Error())
+		return res.Error
+	}
+
+	if !user.Verified {
+		res = tx.Model(&models.User{}).Where("user_id = ?", userId).UpdateColumn("verified", true)
+		if res.Error != nil {
+			tx.Rollback()
+			log.Println(res.Error.Error())
+			return res.Error
+		}
+	}
+
+	err = recordAuditEvent(tx, "", userId, "account.sso_link", "user:"+userId, map[string]interface{}{"Verified": user.Verified}, map[string]interface{}{"Issuer": issuer, "Subject": subject, "Verified": true})
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit().Error
+}
+
+func (db *postgresDatabase) hasOIDCIdentity(userId string) bool {
+	var count int
+	res := db.connection.Model(&models.OIDCIdentity{}).Where("user_id = ?", userId).Count(&count)
+	if res.Error != nil {
+		log.Println(res.Error.Error())
+		return false
+	}
+
+	return count > 0
+}
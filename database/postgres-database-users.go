@@ -11,22 +11,37 @@ import (
 	uuid "github.com/satori/go.uuid"
 )
 
-func (db *postgresDatabase) RegisterStudent(user models.User) error {
+func (db *postgresDatabase) RegisterStudent(user models.User) (string, error) {
 	var count int
 	res := db.connection.Model(&models.User{}).Where("UPPER(email) = ? OR reg_number = ?", strings.ToUpper(user.Email), user.RegNumber).Count(&count)
 	if res.Error != nil {
-		return res.Error
+		return "", res.Error
 	}
 	if count > 0 {
-		return errors.New("User already registered!")
+		return "", errors.New("User already registered!")
 	}
 
-	res = db.connection.Create(&user)
+	tx := db.connection.Begin()
+
+	res = tx.Create(&user)
 	if res.Error != nil {
-		return res.Error
+		tx.Rollback()
+		return "", res.Error
+	}
+
+	err := recordAuditEvent(tx, "", user.RegisteredBy, "student.register", "user:"+user.UserID.String(), nil, user)
+	if err != nil {
+		tx.Rollback()
+		return "", err
+	}
+
+	token, err := issueVerifyToken(tx, user.RegisteredBy, user)
+	if err != nil {
+		tx.Rollback()
+		return "", err
 	}
 
-	return nil
+	return token, tx.Commit().Error
 }
 
 func (db *postgresDatabase) RegisteredStudents(userId string, paginatorParams dto.PaginatorParams) ([]models.User, error) {
@@ -65,13 +80,28 @@ func (db *postgresDatabase) DeleteRegisteredStudent(userId string, studentUserId
 		return errors.New("Invalid Student!")
 	}
 
+	res := db.connection.Where("registered_by = ? AND user_id = ?", userId, studentUserId).Find(&user)
+	if res.Error != nil {
+		return res.Error
+	}
+	before := user
+
+	tx := db.connection.Begin()
+
 	user.UserID = uuid.FromStringOrNil(studentUserId)
-	res := db.connection.Where("registered_by = ? AND user_id = ?", userId, studentUserId).Delete(&user)
+	res = tx.Where("registered_by = ? AND user_id = ?", userId, studentUserId).Delete(&user)
 	if res.Error != nil {
+		tx.Rollback()
 		return res.Error
 	}
 
-	return nil
+	err := recordAuditEvent(tx, "", userId, "student.delete", "user:"+studentUserId, before, nil)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit().Error
 }
 
 func (db *postgresDatabase) GetUser(userId string) (models.User, error) {
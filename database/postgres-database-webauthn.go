@@ -0,0 +1,147 @@
+package database
+
+import (
+	"elect/models"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/jinzhu/gorm"
+)
+
+func (db *postgresDatabase) GetWebAuthnCredentials(userId string) ([]models.WebAuthnCredential, error) {
+	var credentials []models.WebAuthnCredential
+	res := db.connection.Where("user_id = ?", userId).Order("created_at ASC").Find(&credentials)
+	if res.Error != nil {
+		log.Println(res.Error.Error())
+		return nil, res.Error
+	}
+
+	return credentials, nil
+}
+
+func (db *postgresDatabase) AddWebAuthnCredential(credential models.WebAuthnCredential) error {
+	tx := db.connection.Begin()
+
+	res := tx.Create(&credential)
+	if res.Error != nil {
+		tx.Rollback()
+		log.Println(res.Error.Error())
+		return res.Error
+	}
+
+	err := recordAuditEvent(tx, "", credential.UserID.String(), "account.passkey_add", "user:"+credential.UserID.String(), nil, map[string]interface{}{"Name": credential.Name, "CredentialID": credential.CredentialID})
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit().Error
+}
+
+// UseWebAuthnCredential stores the new signature counter, a counter that didn't go up means the passkey may have been cloned.
+// Authenticators without a counter always report 0, an answer of theirs can't be replayed because its challenge is
+// deleted on first use(UseWebAuthnChallenge). Once a passkey has reported a counter, 0 is refused like any lower one.
+func (db *postgresDatabase) UseWebAuthnCredential(credentialId string, signCount int64) error {
+	res := db.connection.Model(&models.WebAuthnCredential{}).
+		Where("credential_id = ? AND (sign_count < ? OR (sign_count = 0 AND ? = 0))", credentialId, signCount, signCount).
+		UpdateColumns(map[string]interface{}{
+			"sign_count":   signCount,
+			"last_used_at": time.Now().UTC(),
+		})
+	if res.Error != nil {
+		log.Println(res.Error.Error())
+		return res.Error
+	}
+
+	if res.RowsAffected == 0 {
+		log.Println("Passkey may be cloned: " + credentialId)
+		return errors.New("Invalid Passkey!")
+	}
+
+	return nil
+}
+
+func (db *postgresDatabase) DeleteWebAuthnCredential(userId string, credentialId string) error {
+	tx := db.connection.Begin()
+
+	var credential models.WebAuthnCredential
+	res := tx.Set("gorm:query_option", "FOR UPDATE").Where("user_id = ? AND id = ?", userId, credentialId).Find(&credential)
+	if gorm.IsRecordNotFoundError(res.Error) {
+		tx.Rollback()
+		return errors.New("Invalid Passkey!")
+	}
+	if res.Error != nil {
+		tx.Rollback()
+		log.Println(res.Error.Error())
+		return res.Error
+	}
+
+	res = tx.Delete(&credential)
+	if res.Error != nil {
+		tx.Rollback()
+		log.Println(res.Error.Error())
+		return res.Error
+	}
+
+	err := recordAuditEvent(tx, "", userId, "account.passkey_delete", "user:"+userId, map[string]interface{}{"Name": credential.Name, "CredentialID": credential.CredentialID}, nil)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit().Error
+}
+
+// CreateWebAuthnChallenge stores the challenge of a ceremony, challenges that expired unanswered are cleared on the way.
+func (db *postgresDatabase) CreateWebAuthnChallenge(challenge models.WebAuthnChallenge) error {
+	res := db.connection.Where("expires_at <= ?", time.Now().UTC()).Delete(&models.WebAuthnChallenge{})
+	if res.Error != nil {
+		log.Println(res.Error.Error())
+		return res.Error
+	}
+
+	res = db.connection.Create(&challenge)
+	if res.Error != nil {
+		log.Println(res.Error.Error())
+		return res.Error
+	}
+
+	return nil
+}
+
+// UseWebAuthnChallenge deletes the challenge and returns it, a challenge that was already used or expired is refused.
+func (db *postgresDatabase) UseWebAuthnChallenge(challengeId string) (models.WebAuthnChallenge, error) {
+	tx := db.connection.Begin()
+
+	var challenge models.WebAuthnChallenge
+	res := tx.Set("gorm:query_option", "FOR UPDATE").Where("challenge_id = ?", challengeId).Find(&challenge)
+	if gorm.IsRecordNotFoundError(res.Error) {
+		tx.Rollback()
+		return models.WebAuthnChallenge{}, errors.New("Passkey session expired!")
+	}
+	if res.Error != nil {
+		tx.Rollback()
+		log.Println(res.Error.Error())
+		return models.WebAuthnChallenge{}, res.Error
+	}
+
+	res = tx.Where("challenge_id = ?", challengeId).Delete(&models.WebAuthnChallenge{})
+	if res.Error != nil {
+		tx.Rollback()
+		log.Println(res.Error.Error())
+		return models.WebAuthnChallenge{}, res.Error
+	}
+
+	res = tx.Commit()
+	if res.Error != nil {
+		log.Println(res.Error.Error())
+		return models.WebAuthnChallenge{}, res.Error
+	}
+
+	if !challenge.ExpiresAt.After(time.Now().UTC()) {
+		return models.WebAuthnChallenge{}, errors.New("Passkey session expired!")
+	}
+
+	return challenge, nil
+}
@@ -1,14 +1,12 @@
 package database
 
 import (
-	"crypto/sha256"
+	"elect/lifecycle"
 	"elect/models"
 	"elect/roles"
-	"encoding/hex"
-	"math/rand"
 	"net/http"
 	"os"
-	"strconv"
+	"time"
 
 	"github.com/jinzhu/gorm"
 	_ "github.com/jinzhu/gorm/dialects/postgres"
@@ -25,7 +23,27 @@ func NewPostgresDatabase() (Database, *http.ServeMux) {
 		panic(err.Error())
 	}
 
-	db.AutoMigrate(&models.User{}, &models.Election{}, &models.Participant{}, &models.Blacklist{}, &models.Candidate{}, &models.ResetToken{})
+	// Elections created before statuses existed are moved out of Draft, the scheduler then catches them up
+	hasStatus := db.Dialect().HasColumn("elections", "status")
+
+	db.AutoMigrate(&models.User{}, &models.Election{}, &models.Participant{}, &models.Blacklist{}, &models.Candidate{}, &models.ResetToken{}, &models.VerifyToken{}, &models.RecoveryCode{}, &models.WebAuthnCredential{}, &models.WebAuthnChallenge{}, &models.Session{}, &models.RefreshToken{}, &models.APIKey{}, &models.OIDCIdentity{}, &models.CasbinRule{}, &models.PolicySeed{}, &models.LoginThrottle{}, &models.Position{}, &models.Ballot{}, &models.AuditEvent{}, &models.Job{}, &models.Reminder{}, &models.ReminderDelivery{}, &models.EmailOutbox{})
+	setUpAuditLog(db)
+
+	if !hasStatus {
+		db.Model(&models.Election{}).Where("status = ?", lifecycle.Draft).UpdateColumn("status", lifecycle.Nomination)
+	}
+
+	if db.Dialect().HasColumn("users", "verify_token") {
+		migrateVerifyTokens(db)
+	}
+
+	// Sessions replaced the single refresh token of a user, users logged in with one have to log in again
+	if db.Dialect().HasColumn("users", "active_refresh_token") {
+		ret := db.Exec("ALTER TABLE users DROP COLUMN active_refresh_token")
+		if ret.Error != nil {
+			panic(ret.Error.Error())
+		}
+	}
 
 	count := 0
 	if db.Model(models.User{}).Where("email = ?", os.Getenv("ADMIN_EMAIL")).Count(&count); count == 0 {
@@ -34,17 +52,13 @@ func NewPostgresDatabase() (Database, *http.ServeMux) {
 			panic("Failed to initialize database!")
 		}
 
-		t := sha256.Sum256([]byte(strconv.Itoa(rand.Int()) + os.Getenv("ADMIN_EMAIL") + strconv.Itoa(rand.Int())))
-		token := t[:]
-
 		ret := db.Create(&models.User{
-			FirstName:   "Suraj",
-			LastName:    "N M",
-			Email:       os.Getenv("ADMIN_EMAIL"),
-			Password:    hashedPassword,
-			Role:        roles.SuperAdmin,
-			VerifyToken: hex.EncodeToString(token),
-			Verified:    true})
+			FirstName: "Suraj",
+			LastName:  "N M",
+			Email:     os.Getenv("ADMIN_EMAIL"),
+			Password:  hashedPassword,
+			Role:      roles.SuperAdmin,
+			Verified:  true})
 		if ret.Error != nil {
 			panic(ret.Error.Error())
 		}
@@ -56,3 +70,55 @@ func NewPostgresDatabase() (Database, *http.ServeMux) {
 		connection: db,
 	}, mux
 }
+
+func (db *postgresDatabase) Close() error {
+	return db.connection.Close()
+}
+
+// migrateVerifyTokens moves the pending verification tokens off the users table, they keep working for another VERIFY_TOKEN_EXPIRY.
+func migrateVerifyTokens(db *gorm.DB) {
+	rows, err := db.Table("users").Select("user_id, verify_token").Where("verified = ? AND verify_token IS NOT NULL AND deleted_at IS NULL", false).Rows()
+	if err != nil {
+		panic(err.Error())
+	}
+
+	var verifyTokens []models.VerifyToken
+	for rows.Next() {
+		var verifyToken models.VerifyToken
+		var token string
+		err = rows.Scan(&verifyToken.UserID, &token)
+		if err != nil {
+			rows.Close()
+			panic(err.Error())
+		}
+
+		verifyToken.TokenHash = hashVerifyToken(token)
+		verifyToken.ExpiresAt = time.Now().UTC().Add(verifyTokenExpiry())
+		verifyTokens = append(verifyTokens, verifyToken)
+	}
+	rows.Close()
+
+	tx := db.Begin()
+	for _, verifyToken := range verifyTokens {
+		ret := tx.Create(&verifyToken)
+		if ret.Error != nil {
+			tx.Rollback()
+			panic(ret.Error.Error())
+		}
+	}
+
+	for _, column := range []string{"verify_token", "verify_expires_at", "verify_sent_at"} {
+		if tx.Dialect().HasColumn("users", column) {
+			ret := tx.Exec("ALTER TABLE users DROP COLUMN " + column)
+			if ret.Error != nil {
+				tx.Rollback()
+				panic(ret.Error.Error())
+			}
+		}
+	}
+
+	ret := tx.Commit()
+	if ret.Error != nil {
+		panic(ret.Error.Error())
+	}
+}
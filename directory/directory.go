@@ -0,0 +1,231 @@
+package directory
+
+import (
+	"crypto/tls"
+	"errors"
+	"log"
+	"net"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/go-ldap/ldap/v3"
+)
+
+// Config is the institution's LDAP or Active Directory server. ELECT binds as BindDN to look users up, students are the
+// entries under BaseDN matching Filter, and the attributes hold their register number, names and email.
+type Config struct {
+	URL            string
+	StartTLS       bool
+	BindDN         string
+	BindPassword   string
+	BaseDN         string
+	Filter         string
+	RegNumberAttr  string
+	FirstNameAttr  string
+	LastNameAttr   string
+	EmailAttr      string
+	RequestTimeout time.Duration
+}
+
+// ConfigFromEnv reads LDAP_URL(e.g, ldaps://ldap.college.edu), LDAP_START_TLS, LDAP_BIND_DN, LDAP_BIND_PASSWORD,
+// LDAP_BASE_DN, LDAP_FILTER, LDAP_REG_NUMBER_ATTR, LDAP_FIRST_NAME_ATTR, LDAP_LAST_NAME_ATTR and LDAP_EMAIL_ATTR. The
+// directory stays off until the URL and base DN are set.
+func ConfigFromEnv() Config {
+	config := Config{
+		URL:            os.Getenv("LDAP_URL"),
+		StartTLS:       os.Getenv("LDAP_START_TLS") == "true",
+		BindDN:         os.Getenv("LDAP_BIND_DN"),
+		BindPassword:   os.Getenv("LDAP_BIND_PASSWORD"),
+		BaseDN:         os.Getenv("LDAP_BASE_DN"),
+		Filter:         os.Getenv("LDAP_FILTER"),
+		RegNumberAttr:  os.Getenv("LDAP_REG_NUMBER_ATTR"),
+		FirstNameAttr:  os.Getenv("LDAP_FIRST_NAME_ATTR"),
+		LastNameAttr:   os.Getenv("LDAP_LAST_NAME_ATTR"),
+		EmailAttr:      os.Getenv("LDAP_EMAIL_ATTR"),
+		RequestTimeout: 10 * time.Second,
+	}
+	if config.Filter == "" {
+		config.Filter = "(objectClass=person)"
+	}
+	if config.RegNumberAttr == "" {
+		config.RegNumberAttr = "employeeNumber"
+	}
+	if config.FirstNameAttr == "" {
+		config.FirstNameAttr = "givenName"
+	}
+	if config.LastNameAttr == "" {
+		config.LastNameAttr = "sn"
+	}
+	if config.EmailAttr == "" {
+		config.EmailAttr = "mail"
+	}
+
+	return config
+}
+
+func (config Config) Enabled() bool {
+	return config.URL != "" && config.BaseDN != ""
+}
+
+// Entry is a student in the directory, after the attribute mapping.
+type Entry struct {
+	DN        string
+	RegNumber string
+	FirstName string
+	LastName  string
+	Email     string
+}
+
+// Client is what ELECT needs from the directory, Directory implements it.
+type Client interface {
+	Config() Config
+	Authenticate(email string, password string) (Entry, error)
+	Entries() ([]Entry, error)
+}
+
+// Directory opens a connection for every login or sync, so ELECT starts even while the server is unreachable.
+type Directory struct {
+	config Config
+}
+
+func New(config Config) *Directory {
+	return &Directory{config: config}
+}
+
+func (directory *Directory) Config() Config {
+	return directory.config
+}
+
+// Authenticate looks the user up by email and binds as them with the password, the directory decides if it is right.
+func (directory *Directory) Authenticate(email string, password string) (Entry, error) {
+	// An empty password would be an unauthenticated bind, which many servers accept for any DN
+	if email == "" || password == "" {
+		return Entry{}, errors.New("Invalid Email or Password!")
+	}
+
+	conn, err := directory.connect()
+	if err != nil {
+		return Entry{}, err
+	}
+	defer conn.Close()
+
+	filter := "(&" + directory.config.Filter + "(" + directory.config.EmailAttr + "=" + ldap.EscapeFilter(email) + "))"
+	entries, err := directory.search(conn, filter, 2)
+	if err != nil {
+		return Entry{}, err
+	}
+	if len(entries) != 1 {
+		if len(entries) > 1 {
+			log.Println("More than one directory entry for " + email)
+		}
+		return Entry{}, errors.New("Invalid Email or Password!")
+	}
+
+	err = conn.Bind(entries[0].DN, password)
+	if err != nil {
+		if ldap.IsErrorWithCode(err, ldap.LDAPResultInvalidCredentials) {
+			return Entry{}, errors.New("Invalid Email or Password!")
+		}
+		log.Println(err.Error())
+		return Entry{}, errors.New("Directory unreachable!")
+	}
+
+	return entries[0], nil
+}
+
+// Entries returns every student under the base DN, read in pages so Active Directory's size limit doesn't cut it short.
+func (directory *Directory) Entries() ([]Entry, error) {
+	conn, err := directory.connect()
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	return directory.search(conn, directory.config.Filter, 0)
+}
+
+// connect dials the server and binds as the service account, or stays anonymous when there is none.
+func (directory *Directory) connect() (*ldap.Conn, error) {
+	if !directory.config.Enabled() {
+		return nil, errors.New("Directory not configured!")
+	}
+
+	dialer := &net.Dialer{Timeout: directory.config.RequestTimeout}
+	conn, err := ldap.DialURL(directory.config.URL, ldap.DialWithDialer(dialer))
+	if err != nil {
+		log.Println(err.Error())
+		return nil, errors.New("Directory unreachable!")
+	}
+	conn.SetTimeout(directory.config.RequestTimeout)
+
+	if directory.config.StartTLS {
+		serverName := ""
+		if parsed, err := url.Parse(directory.config.URL); err == nil {
+			serverName = parsed.Hostname()
+		}
+
+		err = conn.StartTLS(&tls.Config{ServerName: serverName})
+		if err != nil {
+			conn.Close()
+			log.Println(err.Error())
+			return nil, errors.New("Directory unreachable!")
+		}
+	}
+
+	if directory.config.BindDN != "" {
+		err = conn.Bind(directory.config.BindDN, directory.config.BindPassword)
+		if err != nil {
+			conn.Close()
+			log.Println("Directory service bind failed: " + err.Error())
+			return nil, errors.New("Directory unreachable!")
+		}
+	}
+
+	return conn, nil
+}
+
+// search reads the mapped attributes of the entries matching the filter, sizeLimit 0 reads all of them page by page.
+func (directory *Directory) search(conn *ldap.Conn, filter string, sizeLimit int) ([]Entry, error) {
+	config := directory.config
+	request := ldap.NewSearchRequest(
+		config.BaseDN,
+		ldap.ScopeWholeSubtree,
+		ldap.NeverDerefAliases,
+		sizeLimit,
+		int(config.RequestTimeout.Seconds()),
+		false,
+		filter,
+		[]string{config.RegNumberAttr, config.FirstNameAttr, config.LastNameAttr, config.EmailAttr},
+		nil,
+	)
+
+	var result *ldap.SearchResult
+	var err error
+	if sizeLimit == 0 {
+		result, err = conn.SearchWithPaging(request, 500)
+	} else {
+		result, err = conn.Search(request)
+	}
+	if err != nil && !(sizeLimit > 0 && ldap.IsErrorWithCode(err, ldap.LDAPResultSizeLimitExceeded)) {
+		log.Println(err.Error())
+		return nil, errors.New("Directory search failed!")
+	}
+	if result == nil {
+		return nil, errors.New("Directory search failed!")
+	}
+
+	entries := []Entry{}
+	for _, entry := range result.Entries {
+		entries = append(entries, Entry{
+			DN:        entry.DN,
+			RegNumber: strings.TrimSpace(entry.GetEqualFoldAttributeValue(config.RegNumberAttr)),
+			FirstName: strings.TrimSpace(entry.GetEqualFoldAttributeValue(config.FirstNameAttr)),
+			LastName:  strings.TrimSpace(entry.GetEqualFoldAttributeValue(config.LastNameAttr)),
+			Email:     strings.TrimSpace(entry.GetEqualFoldAttributeValue(config.EmailAttr)),
+		})
+	}
+
+	return entries, nil
+}
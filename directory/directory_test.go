@@ -0,0 +1,105 @@
+package directory_test
+
+import (
+	"elect/directory"
+	"elect/directory/directorytest"
+	"testing"
+	"time"
+)
+
+func testDirectory(server *directorytest.Server) *directory.Directory {
+	return directory.New(directory.Config{
+		URL:            server.URL,
+		BindDN:         server.BindDN,
+		BindPassword:   server.BindPassword,
+		BaseDN:         "ou=students,dc=elect,dc=test",
+		Filter:         "(objectClass=person)",
+		RegNumberAttr:  "employeeNumber",
+		FirstNameAttr:  "givenName",
+		LastNameAttr:   "sn",
+		EmailAttr:      "mail",
+		RequestTimeout: 5 * time.Second,
+	})
+}
+
+func student(uid string, regNumber string, email string, password string) directorytest.Entry {
+	return directorytest.Entry{
+		DN:       "uid=" + uid + ",ou=students,dc=elect,dc=test",
+		Password: password,
+		Attributes: map[string][]string{
+			"objectClass":    {"person"},
+			"employeeNumber": {regNumber},
+			"givenName":      {"Test"},
+			"sn":             {"Student"},
+			"mail":           {email},
+		},
+	}
+}
+
+func TestAuthenticate(t *testing.T) {
+	server := directorytest.NewServer()
+	defer server.Close()
+
+	server.Add(student("alice", "CB.EN.U4001", "alice@elect.test", "alice-password"))
+	server.Add(student("bob", "CB.EN.U4002", "shared@elect.test", "bob-password"))
+	server.Add(student("carol", "CB.EN.U4003", "shared@elect.test", "carol-password"))
+
+	directory := testDirectory(server)
+
+	entry, err := directory.Authenticate("alice@elect.test", "alice-password")
+	if err != nil {
+		t.Fatalf("login failed: %v", err)
+	}
+	if entry.RegNumber != "CB.EN.U4001" || entry.Email != "alice@elect.test" {
+		t.Errorf("entry is %+v, want alice's", entry)
+	}
+
+	cases := map[string][2]string{
+		"wrong password":  {"alice@elect.test", "bob-password"},
+		"empty password":  {"alice@elect.test", ""},
+		"duplicate email": {"shared@elect.test", "bob-password"},
+		"unknown email":   {"dave@elect.test", "alice-password"},
+		"filter in email": {"*", "alice-password"},
+	}
+	for name, login := range cases {
+		t.Run(name, func(t *testing.T) {
+			_, err := directory.Authenticate(login[0], login[1])
+			if err == nil || err.Error() != "Invalid Email or Password!" {
+				t.Errorf("login returned %v, want Invalid Email or Password!", err)
+			}
+		})
+	}
+}
+
+func TestEntries(t *testing.T) {
+	server := directorytest.NewServer()
+	defer server.Close()
+
+	server.Add(student("alice", "CB.EN.U4001", "alice@elect.test", ""))
+	server.Add(student("bob", "CB.EN.U4002", "bob@elect.test", ""))
+	server.Add(directorytest.Entry{
+		DN:         "uid=staff,ou=staff,dc=elect,dc=test",
+		Attributes: map[string][]string{"objectClass": {"person"}, "mail": {"staff@elect.test"}},
+	})
+
+	entries, err := testDirectory(server).Entries()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 || entries[0].RegNumber != "CB.EN.U4001" || entries[1].Email != "bob@elect.test" {
+		t.Errorf("entries are %+v, want alice and bob", entries)
+	}
+}
+
+func TestServiceBindFails(t *testing.T) {
+	server := directorytest.NewServer()
+	defer server.Close()
+
+	config := testDirectory(server).Config()
+	config.BindPassword = "another-password"
+
+	_, err := directory.New(config).Entries()
+	if err == nil || err.Error() != "Directory unreachable!" {
+		t.Errorf("sync returned %v, want Directory unreachable!", err)
+	}
+}
@@ -0,0 +1,244 @@
+// Package directorytest provides an in-process LDAP server for testing the directory without a real one. It answers
+// simple binds, searches with and, or, not, equality and presence filters, and unbinds.
+package directorytest
+
+import (
+	"net"
+	"strings"
+	"sync"
+
+	ber "github.com/go-asn1-ber/asn1-ber"
+	"github.com/go-ldap/ldap/v3"
+)
+
+const (
+	applicationBindRequest      = 0
+	applicationBindResponse     = 1
+	applicationUnbindRequest    = 2
+	applicationSearchRequest    = 3
+	applicationSearchResultItem = 4
+	applicationSearchResultDone = 5
+)
+
+// Entry is an entry of the server, Password is what a bind as its DN has to use.
+type Entry struct {
+	DN         string
+	Password   string
+	Attributes map[string][]string
+}
+
+// Server listens on a random local port, URL is the ldap:// URL to dial. Binds as BindDN with BindPassword are the
+// service account, anonymous searches are refused.
+type Server struct {
+	URL          string
+	BindDN       string
+	BindPassword string
+
+	listener net.Listener
+	mutex    sync.Mutex
+	entries  []Entry
+}
+
+// NewServer starts a server, the caller closes it.
+func NewServer() *Server {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		panic(err)
+	}
+
+	server := &Server{
+		URL:          "ldap://" + listener.Addr().String(),
+		BindDN:       "cn=elect,dc=elect,dc=test",
+		BindPassword: "service-password",
+		listener:     listener,
+	}
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go server.serve(conn)
+		}
+	}()
+
+	return server
+}
+
+func (server *Server) Close() {
+	server.listener.Close()
+}
+
+// Add adds an entry, or replaces the one with the same DN.
+func (server *Server) Add(entry Entry) {
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+
+	for i := range server.entries {
+		if strings.EqualFold(server.entries[i].DN, entry.DN) {
+			server.entries[i] = entry
+			return
+		}
+	}
+	server.entries = append(server.entries, entry)
+}
+
+func (server *Server) serve(conn net.Conn) {
+	defer conn.Close()
+
+	bound := false
+	for {
+		packet, err := ber.ReadPacket(conn)
+		if err != nil || len(packet.Children) < 2 {
+			return
+		}
+
+		messageId, _ := packet.Children[0].Value.(int64)
+		request := packet.Children[1]
+
+		switch request.Tag {
+		case applicationBindRequest:
+			name := request.Children[1].Data.String()
+			password := request.Children[2].Data.String()
+			bound = password != "" && server.checkPassword(name, password)
+
+			code := uint16(ldap.LDAPResultSuccess)
+			if !bound {
+				code = ldap.LDAPResultInvalidCredentials
+			}
+			conn.Write(result(messageId, applicationBindResponse, code).Bytes())
+
+		case applicationSearchRequest:
+			if !bound {
+				conn.Write(result(messageId, applicationSearchResultDone, ldap.LDAPResultInsufficientAccessRights).Bytes())
+				continue
+			}
+
+			baseDN := strings.ToLower(request.Children[0].Data.String())
+			sizeLimit, _ := request.Children[3].Value.(int64)
+			filter := request.Children[6]
+
+			sent := int64(0)
+			code := uint16(ldap.LDAPResultSuccess)
+			for _, entry := range server.snapshot() {
+				if !strings.HasSuffix(strings.ToLower(entry.DN), baseDN) || !matches(filter, entry) {
+					continue
+				}
+				if sizeLimit > 0 && sent == sizeLimit {
+					code = ldap.LDAPResultSizeLimitExceeded
+					break
+				}
+
+				conn.Write(searchEntry(messageId, entry).Bytes())
+				sent++
+			}
+			conn.Write(result(messageId, applicationSearchResultDone, code).Bytes())
+
+		case applicationUnbindRequest:
+			return
+		}
+	}
+}
+
+func (server *Server) checkPassword(dn string, password string) bool {
+	if strings.EqualFold(dn, server.BindDN) {
+		return password == server.BindPassword
+	}
+
+	for _, entry := range server.snapshot() {
+		if strings.EqualFold(entry.DN, dn) {
+			return entry.Password != "" && password == entry.Password
+		}
+	}
+
+	return false
+}
+
+func (server *Server) snapshot() []Entry {
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+
+	return append([]Entry{}, server.entries...)
+}
+
+// matches evaluates the filter packet of a search against the entry, attribute names and values ignore case.
+func matches(filter *ber.Packet, entry Entry) bool {
+	switch filter.Tag {
+	case ldap.FilterAnd:
+		for _, child := range filter.Children {
+			if !matches(child, entry) {
+				return false
+			}
+		}
+		return true
+	case ldap.FilterOr:
+		for _, child := range filter.Children {
+			if matches(child, entry) {
+				return true
+			}
+		}
+		return false
+	case ldap.FilterNot:
+		return len(filter.Children) == 1 && !matches(filter.Children[0], entry)
+	case ldap.FilterEqualityMatch:
+		for _, value := range values(entry, filter.Children[0].Data.String()) {
+			if strings.EqualFold(value, filter.Children[1].Data.String()) {
+				return true
+			}
+		}
+		return false
+	case ldap.FilterPresent:
+		return len(values(entry, filter.Data.String())) > 0
+	}
+
+	return false
+}
+
+func values(entry Entry, attribute string) []string {
+	for name, values := range entry.Attributes {
+		if strings.EqualFold(name, attribute) {
+			return values
+		}
+	}
+
+	return nil
+}
+
+func message(messageId int64, op *ber.Packet) *ber.Packet {
+	packet := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "LDAP Response")
+	packet.AppendChild(ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive, ber.TagInteger, messageId, "MessageID"))
+	packet.AppendChild(op)
+
+	return packet
+}
+
+func result(messageId int64, application ber.Tag, code uint16) *ber.Packet {
+	op := ber.Encode(ber.ClassApplication, ber.TypeConstructed, application, nil, "Result")
+	op.AppendChild(ber.NewInteger(ber.ClassUniversal, ber.TypePrimitive, ber.TagEnumerated, int64(code), "resultCode"))
+	op.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, "", "matchedDN"))
+	op.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, "", "diagnosticMessage"))
+
+	return message(messageId, op)
+}
+
+func searchEntry(messageId int64, entry Entry) *ber.Packet {
+	op := ber.Encode(ber.ClassApplication, ber.TypeConstructed, applicationSearchResultItem, nil, "Search Result Entry")
+	op.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, entry.DN, "objectName"))
+
+	attributes := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "attributes")
+	for name, values := range entry.Attributes {
+		attribute := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSequence, nil, "attribute")
+		attribute.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, name, "type"))
+
+		set := ber.Encode(ber.ClassUniversal, ber.TypeConstructed, ber.TagSet, nil, "vals")
+		for _, value := range values {
+			set.AppendChild(ber.NewString(ber.ClassUniversal, ber.TypePrimitive, ber.TagOctetString, value, "value"))
+		}
+		attribute.AppendChild(set)
+		attributes.AppendChild(attribute)
+	}
+	op.AppendChild(attributes)
+
+	return message(messageId, op)
+}
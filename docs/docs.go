@@ -27,6 +27,190 @@ var doc = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
+        "/.well-known/jwks.json": {
+            "get": {
+                "description": "Other services can verify ELECT access tokens with these keys, the kid header of a token names its key. The set is empty while tokens are signed with HS256.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "auth"
+                ],
+                "summary": "Get the public keys access tokens are signed with",
+                "operationId": "getJWKS",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/jwtkeys.JSONWebKeySet"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/apikey/{id}": {
+            "delete": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "apikeys"
+                ],
+                "summary": "Revoke one of your API keys",
+                "operationId": "revokeAPIKey",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "API Key ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/apikeys": {
+            "get": {
+                "description": "Revoked keys are left out, expired ones are listed until they are revoked.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "apikeys"
+                ],
+                "summary": "Get your API keys",
+                "operationId": "getAPIKeys",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/dto.APIKeyDTO"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "The key is only shown in this response, send it as \"Authorization: Bearer \u003ckey\u003e\". Each scope is a path and method like the ones in policy.csv(e.g, /api/participants/* and POST), expires_in defaults to API_KEY_MAX_EXPIRY. Keys can't be created with another key.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "apikeys"
+                ],
+                "summary": "Create an API key for scripts",
+                "operationId": "createAPIKey",
+                "parameters": [
+                    {
+                        "description": "API key",
+                        "name": "apikey",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.CreateAPIKeyDTO"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dto.NewAPIKeyDTO"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/audit/{id}": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "election"
+                ],
+                "summary": "Verify the hash chain of the audit log of the election you created",
+                "operationId": "verifyAuditChain",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Election ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dto.AuditChainDTO"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    }
+                }
+            }
+        },
         "/api/candidate": {
             "post": {
                 "produces": [
@@ -44,6 +228,11 @@ var doc = `{
                         "in": "formData",
                         "required": true
                     },
+                    {
+                        "type": "string",
+                        "name": "position_id",
+                        "in": "formData"
+                    },
                     {
                         "type": "integer",
                         "name": "sex",
@@ -176,6 +365,39 @@ var doc = `{
                 }
             }
         },
+        "/api/directory/sync": {
+            "post": {
+                "description": "Creates or updates a verified student for every entry under LDAP_BASE_DN matching LDAP_FILTER, in place of uploading them. New students are registered by you and log in with their directory password. Entries without a valid register number, first name or email, or clashing with another user, are skipped.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "user"
+                ],
+                "summary": "Sync students from the LDAP directory",
+                "operationId": "syncStudents",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dto.DirectorySyncDTO"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    }
+                }
+            }
+        },
         "/api/election": {
             "put": {
                 "produces": [
@@ -340,50 +562,30 @@ var doc = `{
                 }
             }
         },
-        "/api/elections": {
-            "get": {
+        "/api/election/{id}/archive": {
+            "post": {
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "election"
+                    "lifecycle"
                 ],
-                "summary": "Get a list of election you are part of OR you have created",
-                "operationId": "elections",
+                "summary": "Archive the election you created after its results are published",
+                "operationId": "archiveElection",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Page",
-                        "name": "page",
-                        "in": "query"
-                    },
-                    {
-                        "type": "string",
-                        "description": "Limit",
-                        "name": "limit",
-                        "in": "query"
-                    },
-                    {
-                        "type": "string",
-                        "description": "Order By - starting_at",
-                        "name": "orderby",
-                        "in": "query"
-                    },
-                    {
-                        "type": "string",
-                        "description": "Order - asc or desc",
-                        "name": "order",
-                        "in": "query"
+                        "description": "Election ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/dto.Elections"
-                            }
+                            "$ref": "#/definitions/dto.Response"
                         }
                     },
                     "400": {
@@ -401,24 +603,31 @@ var doc = `{
                 }
             }
         },
-        "/api/participant": {
-            "delete": {
+        "/api/election/{id}/extend": {
+            "post": {
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "participant"
+                    "lifecycle"
                 ],
-                "summary": "Delete the participant of the election you created",
-                "operationId": "participant",
+                "summary": "Extend the voting of the election you created",
+                "operationId": "extendElection",
                 "parameters": [
                     {
-                        "description": "Delete Participant",
-                        "name": "participant",
+                        "type": "string",
+                        "description": "Election ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "New Ending Time",
+                        "name": "ending_at",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/dto.DeleteParticipantDTO"
+                            "$ref": "#/definitions/dto.ExtendElectionDTO"
                         }
                     }
                 ],
@@ -444,16 +653,16 @@ var doc = `{
                 }
             }
         },
-        "/api/participants/{id}": {
+        "/api/election/{id}/open": {
             "post": {
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "participant"
+                    "lifecycle"
                 ],
-                "summary": "Add participants to the election you created",
-                "operationId": "addParticipants",
+                "summary": "Open the nominations of a draft election you created",
+                "operationId": "openElection",
                 "parameters": [
                     {
                         "type": "string",
@@ -461,13 +670,6 @@ var doc = `{
                         "name": "id",
                         "in": "path",
                         "required": true
-                    },
-                    {
-                        "type": "file",
-                        "description": "Participants List",
-                        "name": "participants",
-                        "in": "formData",
-                        "required": true
                     }
                 ],
                 "responses": {
@@ -492,20 +694,20 @@ var doc = `{
                 }
             }
         },
-        "/api/registeredstudent/{id}": {
-            "delete": {
+        "/api/election/{id}/pause": {
+            "post": {
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "user"
+                    "lifecycle"
                 ],
-                "summary": "Delete the student you have registered",
-                "operationId": "deleteRegisteredStudent",
+                "summary": "Pause the election you created",
+                "operationId": "pauseElection",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Student ID",
+                        "description": "Election ID",
                         "name": "id",
                         "in": "path",
                         "required": true
@@ -533,50 +735,30 @@ var doc = `{
                 }
             }
         },
-        "/api/registeredstudents": {
-            "get": {
+        "/api/election/{id}/publish": {
+            "post": {
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "user"
+                    "lifecycle"
                 ],
-                "summary": "Get a list of Students you've registered",
-                "operationId": "registeredStudents",
+                "summary": "Publish the results of the completed election you created ahead of time",
+                "operationId": "publishResults",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "Page",
-                        "name": "page",
-                        "in": "query"
-                    },
-                    {
-                        "type": "string",
-                        "description": "Limit",
-                        "name": "limit",
-                        "in": "query"
-                    },
-                    {
-                        "type": "string",
-                        "description": "Order By - reg_number",
-                        "name": "orderby",
-                        "in": "query"
-                    },
-                    {
-                        "type": "string",
-                        "description": "Order - asc or desc",
-                        "name": "order",
-                        "in": "query"
+                        "description": "Election ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/dto.GeneralStudentDTO"
-                            }
+                            "$ref": "#/definitions/dto.Response"
                         }
                     },
                     "400": {
@@ -594,22 +776,22 @@ var doc = `{
                 }
             }
         },
-        "/api/registerstudents": {
-            "post": {
+        "/api/election/{id}/reminders": {
+            "get": {
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "user"
+                    "reminders"
                 ],
-                "summary": "Register Students if you are an Admin",
-                "operationId": "registerStudents",
+                "summary": "Get the reminders of the election you created with the delivery status of every participant",
+                "operationId": "getReminders",
                 "parameters": [
                     {
-                        "type": "file",
-                        "description": "Student List",
-                        "name": "register",
-                        "in": "formData",
+                        "type": "string",
+                        "description": "Election ID",
+                        "name": "id",
+                        "in": "path",
                         "required": true
                     }
                 ],
@@ -617,7 +799,10 @@ var doc = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/dto.Response"
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/dto.ReminderDTO"
+                            }
                         }
                     },
                     "400": {
@@ -633,18 +818,16 @@ var doc = `{
                         }
                     }
                 }
-            }
-        },
-        "/api/results/{id}": {
-            "get": {
+            },
+            "post": {
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "election"
+                    "reminders"
                 ],
-                "summary": "Get the results of the election you were part of or you created",
-                "operationId": "getElectionResults",
+                "summary": "Email a reminder to the participants who haven't voted in the election you created, now or at a later time",
+                "operationId": "scheduleReminder",
                 "parameters": [
                     {
                         "type": "string",
@@ -652,13 +835,22 @@ var doc = `{
                         "name": "id",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "description": "Sending Time",
+                        "name": "reminder",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.ScheduleReminderDTO"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/dto.GeneralElectionResultsDTO"
+                            "$ref": "#/definitions/dto.Response"
                         }
                     },
                     "400": {
@@ -676,25 +868,23 @@ var doc = `{
                 }
             }
         },
-        "/api/vote": {
+        "/api/election/{id}/resume": {
             "post": {
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "participant"
+                    "lifecycle"
                 ],
-                "summary": "Cast vote to the candidate of the election you are part of",
-                "operationId": "castVote",
+                "summary": "Resume the paused election you created",
+                "operationId": "resumeElection",
                 "parameters": [
                     {
-                        "description": "Cast Vote",
-                        "name": "vote",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/dto.CastVoteDTO"
-                        }
+                        "type": "string",
+                        "description": "Election ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
@@ -719,32 +909,50 @@ var doc = `{
                 }
             }
         },
-        "/changepassword": {
-            "post": {
+        "/api/elections": {
+            "get": {
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "auth"
+                    "election"
                 ],
-                "summary": "Change your password",
-                "operationId": "changePassword",
+                "summary": "Get a list of election you are part of OR you have created",
+                "operationId": "elections",
                 "parameters": [
                     {
-                        "description": "Change Password",
-                        "name": "changePassword",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/dto.ChangePasswordDTO"
-                        }
+                        "type": "string",
+                        "description": "Page",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Limit",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Order By - starting_at",
+                        "name": "orderby",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Order - asc or desc",
+                        "name": "order",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/dto.Response"
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/dto.Elections"
+                            }
                         }
                     },
                     "400": {
@@ -762,32 +970,24 @@ var doc = `{
                 }
             }
         },
-        "/createresettoken": {
-            "post": {
+        "/api/emails/failed": {
+            "get": {
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "auth"
-                ],
-                "summary": "Create a reset token and send email to reset password",
-                "operationId": "createResetToken",
-                "parameters": [
-                    {
-                        "description": "Email",
-                        "name": "createResetToken",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/dto.CreateResetTokenDTO"
-                        }
-                    }
+                    "emails"
                 ],
+                "summary": "Get the emails to the students you registered that failed to send",
+                "operationId": "getFailedEmails",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/dto.Response"
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/dto.OutboxEmailDTO"
+                            }
                         }
                     },
                     "400": {
@@ -805,24 +1005,24 @@ var doc = `{
                 }
             }
         },
-        "/login": {
+        "/api/emails/resend": {
             "post": {
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "auth"
+                    "emails"
                 ],
-                "summary": "User Login",
-                "operationId": "login",
+                "summary": "Resend a failed email, or all of them when no ID is given",
+                "operationId": "resendEmails",
                 "parameters": [
                     {
-                        "description": "User Login",
-                        "name": "login",
+                        "description": "Email ID",
+                        "name": "email",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/dto.Login"
+                            "$ref": "#/definitions/dto.ResendEmailsDTO"
                         }
                     }
                 ],
@@ -830,7 +1030,7 @@ var doc = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/dto.LoginResponse"
+                            "$ref": "#/definitions/dto.Response"
                         }
                     },
                     "400": {
@@ -848,24 +1048,24 @@ var doc = `{
                 }
             }
         },
-        "/otp": {
+        "/api/otp/confirm": {
             "post": {
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "auth"
+                    "otp"
                 ],
-                "summary": "Submit OTP",
-                "operationId": "submitOTP",
+                "summary": "Confirm the authenticator app with a code from it, it becomes your OTP method for logging in",
+                "operationId": "confirmAuthenticator",
                 "parameters": [
                     {
-                        "description": "Verify OTP",
+                        "description": "Authenticator OTP",
                         "name": "otp",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/dto.OTP"
+                            "$ref": "#/definitions/dto.ConfirmTOTPDTO"
                         }
                     }
                 ],
@@ -873,7 +1073,7 @@ var doc = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/dto.OTPResponse"
+                            "$ref": "#/definitions/dto.Response"
                         }
                     },
                     "400": {
@@ -891,22 +1091,21 @@ var doc = `{
                 }
             }
         },
-        "/refresh": {
+        "/api/otp/enroll": {
             "post": {
-                "description": "A user needs a valid refresh token to access this endpoint.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "auth"
+                    "otp"
                 ],
-                "summary": "Refresh Token",
-                "operationId": "refresh",
+                "summary": "Start adding an authenticator app, scan the QR code(base64 PNG) or open the otpauth URL and confirm with a code from the app",
+                "operationId": "enrollAuthenticator",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/dto.Response"
+                            "$ref": "#/definitions/dto.TOTPEnrollmentDTO"
                         }
                     },
                     "400": {
@@ -924,24 +1123,24 @@ var doc = `{
                 }
             }
         },
-        "/resetpassword": {
-            "post": {
+        "/api/otp/method": {
+            "put": {
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "auth"
+                    "otp"
                 ],
-                "summary": "Reset password if you have a valid token",
-                "operationId": "resetPassword",
+                "summary": "Choose how you get the OTP when logging in, 0 for email and 1 for the authenticator app",
+                "operationId": "setOTPMethod",
                 "parameters": [
                     {
-                        "description": "Reset Password",
-                        "name": "createResetToken",
+                        "description": "OTP Method",
+                        "name": "method",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/dto.ResetPasswordDTO"
+                            "$ref": "#/definitions/dto.OTPMethodDTO"
                         }
                     }
                 ],
@@ -967,30 +1166,21 @@ var doc = `{
                 }
             }
         },
-        "/resettoken/{token}": {
+        "/api/otp/recovery-codes": {
             "post": {
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "auth"
-                ],
-                "summary": "Check if reset token is valid or not",
-                "operationId": "checkResetTokenValidity",
-                "parameters": [
-                    {
-                        "type": "string",
-                        "description": "Reset Token",
-                        "name": "token",
-                        "in": "path",
-                        "required": true
-                    }
+                    "otp"
                 ],
+                "summary": "Get 10 new single use recovery codes for logging in without an OTP, the old ones stop working and the new ones are only shown this once",
+                "operationId": "generateRecoveryCodes",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/dto.Response"
+                            "$ref": "#/definitions/dto.RecoveryCodesDTO"
                         }
                     },
                     "400": {
@@ -1008,24 +1198,24 @@ var doc = `{
                 }
             }
         },
-        "/setpassword": {
-            "post": {
+        "/api/participant": {
+            "delete": {
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "auth"
+                    "participant"
                 ],
-                "summary": "Verify Email and Set Password",
-                "operationId": "verify",
+                "summary": "Delete the participant of the election you created",
+                "operationId": "participant",
                 "parameters": [
                     {
-                        "description": "Verify",
-                        "name": "password",
+                        "description": "Delete Participant",
+                        "name": "participant",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/dto.Verify"
+                            "$ref": "#/definitions/dto.DeleteParticipantDTO"
                         }
                     }
                 ],
@@ -1051,17 +1241,32 @@ var doc = `{
                 }
             }
         },
-        "/ulogout": {
+        "/api/participants/{id}": {
             "post": {
-                "description": "A user has to be logged in currently to access this endpoint.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "auth"
+                    "participant"
+                ],
+                "summary": "Add participants to the election you created",
+                "operationId": "addParticipants",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Election ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "file",
+                        "description": "Participants List",
+                        "name": "participants",
+                        "in": "formData",
+                        "required": true
+                    }
                 ],
-                "summary": "User Logout",
-                "operationId": "logout",
                 "responses": {
                     "200": {
                         "description": "OK",
@@ -1084,30 +1289,21 @@ var doc = `{
                 }
             }
         },
-        "/verifytoken/{token}": {
+        "/api/passkey/register/begin": {
             "post": {
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "auth"
-                ],
-                "summary": "Check if verify token is valid or not",
-                "operationId": "checkVerifyTokenValidity",
-                "parameters": [
-                    {
-                        "type": "string",
-                        "description": "Verify Token",
-                        "name": "token",
-                        "in": "path",
-                        "required": true
-                    }
+                    "passkeys"
                 ],
+                "summary": "Start adding a passkey, pass the options to navigator.credentials.create()",
+                "operationId": "beginPasskeyRegistration",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/dto.Response"
+                            "type": "object"
                         }
                     },
                     "400": {
@@ -1124,255 +1320,2509 @@ var doc = `{
                     }
                 }
             }
-        }
-    },
-    "definitions": {
+        },
+        "/api/passkey/register/finish": {
+            "post": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "passkeys"
+                ],
+                "summary": "Add the passkey with the credential returned by navigator.credentials.create()",
+                "operationId": "finishPasskeyRegistration",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Passkey Name",
+                        "name": "name",
+                        "in": "query"
+                    },
+                    {
+                        "description": "Public Key Credential",
+                        "name": "credential",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "object"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/passkey/{id}": {
+            "delete": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "passkeys"
+                ],
+                "summary": "Remove one of your passkeys",
+                "operationId": "deletePasskey",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Passkey ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/passkeys": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "passkeys"
+                ],
+                "summary": "Get your passkeys",
+                "operationId": "getPasskeys",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/dto.PasskeyDTO"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/policies": {
+            "get": {
+                "description": "Each policy allows or denies a role(-2 waiting for OTP, -1 anonymous, 0 student, 1 admin, 2 super admin or * for anyone) a path and method, the paths and methods may use * as a wildcard. Deny wins over allow.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "policies"
+                ],
+                "summary": "Get the authorization policies",
+                "operationId": "getPolicies",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/dto.PolicyDTO"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Takes effect right away on this instance and within POLICY_RELOAD_INTERVAL on the others. Policies that would stop super admins from managing policies are refused.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "policies"
+                ],
+                "summary": "Add an authorization policy",
+                "operationId": "addPolicy",
+                "parameters": [
+                    {
+                        "description": "Policy",
+                        "name": "policy",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.PolicyDTO"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dto.PolicyDTO"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/policies/check": {
+            "post": {
+                "description": "A dry run of the authorization check against the policies in effect, matched is the policy that decided it and is left out when none matched.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "policies"
+                ],
+                "summary": "Check if a role may make a request",
+                "operationId": "checkPolicy",
+                "parameters": [
+                    {
+                        "description": "Role, path and method",
+                        "name": "check",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.PolicyCheckDTO"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dto.PolicyCheckResultDTO"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/policies/reload": {
+            "post": {
+                "description": "Only this instance is reloaded, the others reload every POLICY_RELOAD_INTERVAL.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "policies"
+                ],
+                "summary": "Reload the authorization policies from the database",
+                "operationId": "reloadPolicies",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/policy/{id}": {
+            "put": {
+                "description": "Takes effect right away on this instance and within POLICY_RELOAD_INTERVAL on the others. Changes that would stop super admins from managing policies are refused.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "policies"
+                ],
+                "summary": "Edit an authorization policy",
+                "operationId": "editPolicy",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Policy ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Policy",
+                        "name": "policy",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.PolicyDTO"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dto.PolicyDTO"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "policies"
+                ],
+                "summary": "Delete an authorization policy",
+                "operationId": "deletePolicy",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Policy ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/position": {
+            "post": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "position"
+                ],
+                "summary": "Add a position to the election you created",
+                "operationId": "position",
+                "parameters": [
+                    {
+                        "description": "Position Details",
+                        "name": "position",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.CreatePositionDTO"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/position/{id}": {
+            "delete": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "position"
+                ],
+                "summary": "Delete a position of the election you created",
+                "operationId": "positionP",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Position ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/registeredstudent/{id}": {
+            "delete": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "user"
+                ],
+                "summary": "Delete the student you have registered",
+                "operationId": "deleteRegisteredStudent",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Student ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/registeredstudent/{id}/recovery-codes": {
+            "post": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "otp"
+                ],
+                "summary": "Generate new recovery codes for a student you registered who can't get an OTP, the student is emailed that it happened",
+                "operationId": "regenerateStudentRecoveryCodes",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Student User ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dto.RecoveryCodesDTO"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/registeredstudent/{id}/resend-verification": {
+            "post": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "user"
+                ],
+                "summary": "Send a new verification link to the student you have registered",
+                "operationId": "resendVerification",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Student ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/registeredstudent/{id}/sessions": {
+            "delete": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "sessions"
+                ],
+                "summary": "Log a student you have registered out of every device",
+                "operationId": "revokeStudentSessions",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Student ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/registeredstudent/{id}/unlock": {
+            "post": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "user"
+                ],
+                "summary": "Unlock the account of a student you have registered after too many failed logins",
+                "operationId": "unlockAccount",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Student ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/registeredstudents": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "user"
+                ],
+                "summary": "Get a list of Students you've registered",
+                "operationId": "registeredStudents",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Page",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Limit",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Order By - reg_number",
+                        "name": "orderby",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Order - asc or desc",
+                        "name": "order",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/dto.GeneralStudentDTO"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/registerstudents": {
+            "post": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "user"
+                ],
+                "summary": "Register Students if you are an Admin",
+                "operationId": "registerStudents",
+                "parameters": [
+                    {
+                        "type": "file",
+                        "description": "Student List",
+                        "name": "register",
+                        "in": "formData",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/results/{id}": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "election"
+                ],
+                "summary": "Get the results of the election you were part of or you created",
+                "operationId": "getElectionResults",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Election ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dto.GeneralElectionResultsDTO"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/results/{id}/receipts": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "election"
+                ],
+                "summary": "Get the ballot receipts of a completed election to verify your vote was counted",
+                "operationId": "getElectionReceipts",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Election ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dto.ElectionReceiptsDTO"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/results/{id}/recount": {
+            "post": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "election"
+                ],
+                "summary": "Recount the stored ballots of the election you created",
+                "operationId": "recountVotes",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Election ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dto.GeneralElectionResultsDTO"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/session/{id}": {
+            "delete": {
+                "description": "The device is logged out once its access token expires.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "sessions"
+                ],
+                "summary": "Log out one of your sessions",
+                "operationId": "revokeSession",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Session ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/sessions": {
+            "get": {
+                "description": "The session you are using has current set to true.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "sessions"
+                ],
+                "summary": "Get the devices you are logged in on",
+                "operationId": "getSessions",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/dto.SessionDTO"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/vote": {
+            "post": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "participant"
+                ],
+                "summary": "Cast vote to the candidate of the election you are part of",
+                "operationId": "castVote",
+                "parameters": [
+                    {
+                        "description": "Cast Vote",
+                        "name": "vote",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.CastVoteDTO"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dto.CastVoteResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/changepassword": {
+            "post": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "auth"
+                ],
+                "summary": "Change your password",
+                "operationId": "changePassword",
+                "parameters": [
+                    {
+                        "description": "Change Password",
+                        "name": "changePassword",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.ChangePasswordDTO"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/createresettoken": {
+            "post": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "auth"
+                ],
+                "summary": "Create a reset token and send email to reset password",
+                "operationId": "createResetToken",
+                "parameters": [
+                    {
+                        "description": "Email",
+                        "name": "createResetToken",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.CreateResetTokenDTO"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/login": {
+            "post": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "auth"
+                ],
+                "summary": "User Login",
+                "operationId": "login",
+                "parameters": [
+                    {
+                        "description": "User Login",
+                        "name": "login",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.Login"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dto.LoginResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    },
+                    "423": {
+                        "description": "Locked",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/otp": {
+            "post": {
+                "description": "The OTP is checked for the user the otp cookie was issued to at login, the email is optional and has to match. The OTP can also be one of your recovery codes, each of them works once. Method is 0 for email, 1 for the authenticator app and 2 for a recovery code, only your own OTP method or 2 is accepted and it defaults to your OTP method.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "auth"
+                ],
+                "summary": "Submit OTP",
+                "operationId": "submitOTP",
+                "parameters": [
+                    {
+                        "description": "Verify OTP",
+                        "name": "otp",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.OTP"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dto.OTPResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    },
+                    "423": {
+                        "description": "Locked",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    },
+                    "429": {
+                        "description": "Too Many Requests",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/passkey/login/begin": {
+            "post": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "passkeys"
+                ],
+                "summary": "Start logging in with a passkey, pass the options to navigator.credentials.get()",
+                "operationId": "beginPasskeyLogin",
+                "parameters": [
+                    {
+                        "description": "Email",
+                        "name": "login",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.PasskeyLoginDTO"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/passkey/login/finish": {
+            "post": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "passkeys"
+                ],
+                "summary": "Log in with the assertion returned by navigator.credentials.get(), no OTP is needed",
+                "operationId": "finishPasskeyLogin",
+                "parameters": [
+                    {
+                        "description": "Public Key Credential",
+                        "name": "credential",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "object"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dto.OTPResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/refresh": {
+            "post": {
+                "description": "A user needs a valid refresh token to access this endpoint. Every refresh token works once, presenting one that was already used logs the session out.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "auth"
+                ],
+                "summary": "Refresh Token",
+                "operationId": "refresh",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/resendverification": {
+            "post": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "auth"
+                ],
+                "summary": "Send a new verification link to your email if your account is not verified yet, the answer is the same for any email",
+                "operationId": "resendVerificationByEmail",
+                "parameters": [
+                    {
+                        "description": "Email",
+                        "name": "resendVerification",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.ResendVerificationDTO"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/resetpassword": {
+            "post": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "auth"
+                ],
+                "summary": "Reset password if you have a valid token",
+                "operationId": "resetPassword",
+                "parameters": [
+                    {
+                        "description": "Reset Password",
+                        "name": "createResetToken",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.ResetPasswordDTO"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/resettoken/{token}": {
+            "post": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "auth"
+                ],
+                "summary": "Check if reset token is valid or not",
+                "operationId": "checkResetTokenValidity",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Reset Token",
+                        "name": "token",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/setpassword": {
+            "post": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "auth"
+                ],
+                "summary": "Verify Email and Set Password",
+                "operationId": "verify",
+                "parameters": [
+                    {
+                        "description": "Verify",
+                        "name": "password",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/dto.Verify"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/sso/callback": {
+            "get": {
+                "description": "The first login links the provider's account to the user with the same register number or email, then sets the token cookie and redirects to the web app.",
+                "tags": [
+                    "sso"
+                ],
+                "summary": "Where the OpenID Connect provider sends the user back to, no OTP is needed",
+                "operationId": "finishSSOLogin",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Authorization Code",
+                        "name": "code",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "State",
+                        "name": "state",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "302": {
+                        "description": ""
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/sso/login": {
+            "get": {
+                "description": "Redirects to the OpenID Connect provider set by OIDC_ISSUER, which sends the user back to /sso/callback.",
+                "tags": [
+                    "sso"
+                ],
+                "summary": "Log in with the institution's account",
+                "operationId": "beginSSOLogin",
+                "responses": {
+                    "302": {
+                        "description": ""
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/ulogout": {
+            "post": {
+                "description": "A user has to be logged in currently to access this endpoint.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "auth"
+                ],
+                "summary": "User Logout",
+                "operationId": "logout",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/verifytoken/{token}": {
+            "post": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "auth"
+                ],
+                "summary": "Check if verify token is valid or not",
+                "operationId": "checkVerifyTokenValidity",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Verify Token",
+                        "name": "token",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/dto.Response"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "dto.APIKeyDTO": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "expires_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "last_used_at": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "prefix": {
+                    "type": "string"
+                },
+                "scopes": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.APIKeyScopeDTO"
+                    }
+                }
+            }
+        },
+        "dto.APIKeyScopeDTO": {
+            "type": "object",
+            "required": [
+                "method",
+                "path"
+            ],
+            "properties": {
+                "method": {
+                    "type": "string"
+                },
+                "path": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.AuditChainDTO": {
+            "type": "object",
+            "properties": {
+                "broken_at": {
+                    "type": "integer"
+                },
+                "election_id": {
+                    "type": "string"
+                },
+                "events": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.AuditEventDTO"
+                    }
+                },
+                "total_events": {
+                    "type": "integer"
+                },
+                "valid": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "dto.AuditEventDTO": {
+            "type": "object",
+            "properties": {
+                "action": {
+                    "type": "string"
+                },
+                "actor": {
+                    "type": "string"
+                },
+                "audit_event_id": {
+                    "type": "integer"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "diff": {
+                    "type": "string"
+                },
+                "hash": {
+                    "type": "string"
+                },
+                "prev_hash": {
+                    "type": "string"
+                },
+                "target": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.BallotChoiceDTO": {
+            "type": "object",
+            "properties": {
+                "candidate_id": {
+                    "type": "string"
+                },
+                "position_id": {
+                    "type": "string"
+                },
+                "preferences": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
         "dto.CandidateResultsDTO": {
             "type": "object",
             "properties": {
                 "candidate_id": {
                     "type": "string"
                 },
-                "display_picture": {
+                "display_picture": {
+                    "type": "string"
+                },
+                "election_id": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "position_id": {
+                    "type": "string"
+                },
+                "sex": {
+                    "type": "integer"
+                },
+                "user_id": {
+                    "type": "string"
+                },
+                "votes": {
+                    "type": "integer"
+                }
+            }
+        },
+        "dto.CastVoteDTO": {
+            "type": "object",
+            "required": [
+                "election_id"
+            ],
+            "properties": {
+                "candidate_id": {
+                    "type": "string"
+                },
+                "choices": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.BallotChoiceDTO"
+                    }
+                },
+                "election_id": {
+                    "type": "string"
+                },
+                "preferences": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "dto.CastVoteResponse": {
+            "type": "object",
+            "properties": {
+                "message": {
+                    "type": "string"
+                },
+                "receipt": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.ChangePasswordDTO": {
+            "type": "object",
+            "required": [
+                "current_password",
+                "new_password"
+            ],
+            "properties": {
+                "current_password": {
+                    "type": "string"
+                },
+                "new_password": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.ConfirmTOTPDTO": {
+            "type": "object",
+            "required": [
+                "otp"
+            ],
+            "properties": {
+                "otp": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.CreateAPIKeyDTO": {
+            "type": "object",
+            "required": [
+                "name",
+                "scopes"
+            ],
+            "properties": {
+                "expires_in": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "scopes": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.APIKeyScopeDTO"
+                    }
+                }
+            }
+        },
+        "dto.CreateElectionDTO": {
+            "type": "object",
+            "required": [
+                "ending_at",
+                "locking_at",
+                "starting_at",
+                "title"
+            ],
+            "properties": {
+                "draft": {
+                    "type": "boolean"
+                },
+                "ending_at": {
+                    "type": "string"
+                },
+                "gender_specific": {
+                    "type": "boolean"
+                },
+                "locking_at": {
+                    "type": "string"
+                },
+                "positions": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "starting_at": {
+                    "type": "string"
+                },
+                "title": {
+                    "type": "string"
+                },
+                "voting_method": {
+                    "type": "integer"
+                }
+            }
+        },
+        "dto.CreatePositionDTO": {
+            "type": "object",
+            "required": [
+                "election_id",
+                "title"
+            ],
+            "properties": {
+                "election_id": {
+                    "type": "string"
+                },
+                "title": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.CreateResetTokenDTO": {
+            "type": "object",
+            "required": [
+                "email"
+            ],
+            "properties": {
+                "email": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.DeleteParticipantDTO": {
+            "type": "object",
+            "required": [
+                "election_id",
+                "participant_id"
+            ],
+            "properties": {
+                "election_id": {
+                    "type": "string"
+                },
+                "participant_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.DirectorySyncDTO": {
+            "type": "object",
+            "properties": {
+                "created": {
+                    "type": "integer"
+                },
+                "skipped": {
+                    "type": "integer"
+                },
+                "unchanged": {
+                    "type": "integer"
+                },
+                "updated": {
+                    "type": "integer"
+                }
+            }
+        },
+        "dto.EditElectionDTO": {
+            "type": "object",
+            "required": [
+                "election_id"
+            ],
+            "properties": {
+                "election_id": {
+                    "type": "string"
+                },
+                "ending_at": {
+                    "type": "string"
+                },
+                "gender_specific": {
+                    "type": "boolean"
+                },
+                "locking_at": {
+                    "type": "string"
+                },
+                "starting_at": {
+                    "type": "string"
+                },
+                "title": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.ElectionReceiptsDTO": {
+            "type": "object",
+            "properties": {
+                "election_id": {
+                    "type": "string"
+                },
+                "ending_at": {
+                    "type": "string"
+                },
+                "receipts": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "title": {
+                    "type": "string"
+                },
+                "total_receipts": {
+                    "type": "integer"
+                }
+            }
+        },
+        "dto.Elections": {
+            "type": "object",
+            "properties": {
+                "blacklisted": {
+                    "type": "boolean"
+                },
+                "election_id": {
+                    "type": "string"
+                },
+                "ending_at": {
+                    "type": "string"
+                },
+                "gender_specific": {
+                    "type": "boolean"
+                },
+                "locking_at": {
+                    "type": "string"
+                },
+                "paused": {
+                    "type": "boolean"
+                },
+                "starting_at": {
+                    "type": "string"
+                },
+                "status": {
+                    "type": "integer"
+                },
+                "title": {
+                    "type": "string"
+                },
+                "voted": {
+                    "type": "boolean"
+                },
+                "voting_method": {
+                    "type": "integer"
+                }
+            }
+        },
+        "dto.ExtendElectionDTO": {
+            "type": "object",
+            "required": [
+                "ending_at"
+            ],
+            "properties": {
+                "ending_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.GeneralCandidateDTO": {
+            "type": "object",
+            "properties": {
+                "approved": {
+                    "type": "boolean"
+                },
+                "candidate_id": {
+                    "type": "string"
+                },
+                "display_picture": {
+                    "type": "string"
+                },
+                "election_id": {
+                    "type": "string"
+                },
+                "first_name": {
+                    "type": "string"
+                },
+                "id_proof": {
+                    "type": "string"
+                },
+                "last_name": {
+                    "type": "string"
+                },
+                "position_id": {
+                    "type": "string"
+                },
+                "poster": {
+                    "type": "string"
+                },
+                "register_no": {
+                    "type": "string"
+                },
+                "sex": {
+                    "type": "integer"
+                },
+                "user_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.GeneralElectionDTO": {
+            "type": "object",
+            "properties": {
+                "blacklisted": {
+                    "type": "boolean"
+                },
+                "candidate": {
+                    "$ref": "#/definitions/dto.GeneralCandidateDTO"
+                },
+                "candidates": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.GeneralCandidateDTO"
+                    }
+                },
+                "election_id": {
+                    "type": "string"
+                },
+                "ending_at": {
                     "type": "string"
                 },
-                "election_id": {
+                "gender_specific": {
+                    "type": "boolean"
+                },
+                "locking_at": {
                     "type": "string"
                 },
-                "name": {
+                "participants": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.GeneralParticipantDTO"
+                    }
+                },
+                "paused": {
+                    "type": "boolean"
+                },
+                "positions": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.PositionDTO"
+                    }
+                },
+                "starting_at": {
                     "type": "string"
                 },
-                "sex": {
+                "status": {
                     "type": "integer"
                 },
-                "user_id": {
+                "title": {
                     "type": "string"
                 },
-                "votes": {
+                "voted": {
+                    "type": "boolean"
+                },
+                "voting_method": {
                     "type": "integer"
                 }
             }
         },
-        "dto.CastVoteDTO": {
+        "dto.GeneralElectionResultsDTO": {
             "type": "object",
-            "required": [
-                "candidate_id",
-                "election_id"
-            ],
             "properties": {
-                "candidate_id": {
-                    "type": "string"
+                "candidate_results": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.CandidateResultsDTO"
+                    }
                 },
                 "election_id": {
                     "type": "string"
+                },
+                "ending_at": {
+                    "type": "string"
+                },
+                "fcandidate_results": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.CandidateResultsDTO"
+                    }
+                },
+                "gender_specific": {
+                    "type": "boolean"
+                },
+                "locking_at": {
+                    "type": "string"
+                },
+                "mcandidate_results": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.CandidateResultsDTO"
+                    }
+                },
+                "ocandidate_results": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.CandidateResultsDTO"
+                    }
+                },
+                "position_results": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.PositionResultsDTO"
+                    }
+                },
+                "runoffs": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.RunoffResultsDTO"
+                    }
+                },
+                "starting_at": {
+                    "type": "string"
+                },
+                "title": {
+                    "type": "string"
+                },
+                "total_participants": {
+                    "type": "integer"
+                },
+                "total_votes": {
+                    "type": "integer"
+                },
+                "voting_method": {
+                    "type": "integer"
                 }
             }
         },
-        "dto.ChangePasswordDTO": {
+        "dto.GeneralParticipantDTO": {
             "type": "object",
-            "required": [
-                "current_password",
-                "new_password"
-            ],
             "properties": {
-                "current_password": {
+                "first_name": {
                     "type": "string"
                 },
-                "new_password": {
+                "last_name": {
+                    "type": "string"
+                },
+                "participant_id": {
+                    "type": "string"
+                },
+                "register_number": {
+                    "type": "string"
+                },
+                "user_id": {
                     "type": "string"
+                },
+                "voted": {
+                    "type": "boolean"
                 }
             }
         },
-        "dto.CreateElectionDTO": {
+        "dto.GeneralStudentDTO": {
             "type": "object",
-            "required": [
-                "ending_at",
-                "locking_at",
-                "starting_at",
-                "title"
-            ],
             "properties": {
-                "ending_at": {
+                "email": {
                     "type": "string"
                 },
-                "gender_specific": {
-                    "type": "boolean"
+                "first_name": {
+                    "type": "string"
                 },
-                "locking_at": {
+                "last_name": {
                     "type": "string"
                 },
-                "starting_at": {
+                "reg_number": {
                     "type": "string"
                 },
-                "title": {
+                "user_id": {
                     "type": "string"
+                },
+                "verified": {
+                    "type": "boolean"
                 }
             }
         },
-        "dto.CreateResetTokenDTO": {
+        "dto.Login": {
             "type": "object",
             "required": [
-                "email"
+                "email",
+                "password"
             ],
             "properties": {
                 "email": {
                     "type": "string"
+                },
+                "password": {
+                    "type": "string"
                 }
             }
         },
-        "dto.DeleteParticipantDTO": {
+        "dto.LoginResponse": {
             "type": "object",
-            "required": [
-                "election_id",
-                "participant_id"
-            ],
             "properties": {
-                "election_id": {
+                "email": {
                     "type": "string"
                 },
-                "participant_id": {
+                "message": {
                     "type": "string"
+                },
+                "otp_method": {
+                    "type": "integer"
                 }
             }
         },
-        "dto.EditElectionDTO": {
+        "dto.NewAPIKeyDTO": {
             "type": "object",
-            "required": [
-                "election_id"
-            ],
             "properties": {
-                "election_id": {
+                "created_at": {
                     "type": "string"
                 },
-                "ending_at": {
+                "expires_at": {
                     "type": "string"
                 },
-                "gender_specific": {
-                    "type": "boolean"
+                "id": {
+                    "type": "string"
                 },
-                "locking_at": {
+                "key": {
                     "type": "string"
                 },
-                "starting_at": {
+                "last_used_at": {
                     "type": "string"
                 },
-                "title": {
+                "name": {
+                    "type": "string"
+                },
+                "prefix": {
                     "type": "string"
+                },
+                "scopes": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.APIKeyScopeDTO"
+                    }
                 }
             }
         },
-        "dto.Elections": {
+        "dto.OTP": {
             "type": "object",
+            "required": [
+                "otp"
+            ],
             "properties": {
-                "blacklisted": {
-                    "type": "boolean"
-                },
-                "election_id": {
-                    "type": "string"
-                },
-                "ending_at": {
+                "email": {
                     "type": "string"
                 },
-                "gender_specific": {
-                    "type": "boolean"
+                "method": {
+                    "type": "integer"
                 },
-                "locking_at": {
+                "otp": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.OTPMethodDTO": {
+            "type": "object",
+            "required": [
+                "method"
+            ],
+            "properties": {
+                "method": {
+                    "type": "integer"
+                }
+            }
+        },
+        "dto.OTPResponse": {
+            "type": "object",
+            "properties": {
+                "email": {
                     "type": "string"
                 },
-                "starting_at": {
+                "message": {
                     "type": "string"
                 },
-                "title": {
+                "role": {
                     "type": "string"
                 },
-                "voted": {
-                    "type": "boolean"
+                "user_id": {
+                    "type": "string"
                 }
             }
         },
-        "dto.GeneralCandidateDTO": {
+        "dto.OutboxEmailDTO": {
             "type": "object",
             "properties": {
-                "approved": {
-                    "type": "boolean"
+                "attempts": {
+                    "type": "integer"
                 },
-                "candidate_id": {
+                "created_at": {
                     "type": "string"
                 },
-                "display_picture": {
+                "email_outbox_id": {
                     "type": "string"
                 },
-                "election_id": {
+                "last_error": {
                     "type": "string"
                 },
-                "first_name": {
+                "subject": {
                     "type": "string"
                 },
-                "id_proof": {
+                "to": {
                     "type": "string"
                 },
-                "last_name": {
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.PasskeyDTO": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "last_used_at": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.PasskeyLoginDTO": {
+            "type": "object",
+            "required": [
+                "email"
+            ],
+            "properties": {
+                "email": {
                     "type": "string"
-                },
-                "poster": {
+                }
+            }
+        },
+        "dto.PolicyCheckDTO": {
+            "type": "object",
+            "required": [
+                "method",
+                "path",
+                "role"
+            ],
+            "properties": {
+                "method": {
                     "type": "string"
                 },
-                "register_no": {
+                "path": {
                     "type": "string"
                 },
-                "sex": {
-                    "type": "integer"
-                },
-                "user_id": {
+                "role": {
                     "type": "string"
                 }
             }
         },
-        "dto.GeneralElectionDTO": {
+        "dto.PolicyCheckResultDTO": {
             "type": "object",
             "properties": {
-                "blacklisted": {
+                "allowed": {
                     "type": "boolean"
                 },
-                "candidate": {
-                    "$ref": "#/definitions/dto.GeneralCandidateDTO"
+                "matched": {
+                    "$ref": "#/definitions/dto.PolicyDTO"
+                }
+            }
+        },
+        "dto.PolicyDTO": {
+            "type": "object",
+            "required": [
+                "effect",
+                "method",
+                "path",
+                "role"
+            ],
+            "properties": {
+                "effect": {
+                    "type": "string"
                 },
-                "candidates": {
-                    "type": "array",
-                    "items": {
-                        "$ref": "#/definitions/dto.GeneralCandidateDTO"
-                    }
+                "id": {
+                    "type": "integer"
                 },
-                "election_id": {
+                "method": {
                     "type": "string"
                 },
-                "ending_at": {
+                "path": {
                     "type": "string"
                 },
-                "gender_specific": {
-                    "type": "boolean"
-                },
-                "locking_at": {
+                "role": {
                     "type": "string"
-                },
-                "participants": {
-                    "type": "array",
-                    "items": {
-                        "$ref": "#/definitions/dto.GeneralParticipantDTO"
-                    }
-                },
-                "starting_at": {
+                }
+            }
+        },
+        "dto.PositionDTO": {
+            "type": "object",
+            "properties": {
+                "position_id": {
                     "type": "string"
                 },
                 "title": {
                     "type": "string"
-                },
-                "voted": {
-                    "type": "boolean"
                 }
             }
         },
-        "dto.GeneralElectionResultsDTO": {
+        "dto.PositionResultsDTO": {
             "type": "object",
             "properties": {
                 "candidate_results": {
@@ -1381,24 +3831,12 @@ var doc = `{
                         "$ref": "#/definitions/dto.CandidateResultsDTO"
                     }
                 },
-                "election_id": {
-                    "type": "string"
-                },
-                "ending_at": {
-                    "type": "string"
-                },
                 "fcandidate_results": {
                     "type": "array",
                     "items": {
                         "$ref": "#/definitions/dto.CandidateResultsDTO"
                     }
                 },
-                "gender_specific": {
-                    "type": "boolean"
-                },
-                "locking_at": {
-                    "type": "string"
-                },
                 "mcandidate_results": {
                     "type": "array",
                     "items": {
@@ -1411,143 +3849,255 @@ var doc = `{
                         "$ref": "#/definitions/dto.CandidateResultsDTO"
                     }
                 },
-                "starting_at": {
+                "position_id": {
                     "type": "string"
                 },
+                "runoffs": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.RunoffResultsDTO"
+                    }
+                },
                 "title": {
                     "type": "string"
                 },
-                "total_participants": {
-                    "type": "integer"
-                },
                 "total_votes": {
                     "type": "integer"
+                },
+                "winners": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.CandidateResultsDTO"
+                    }
                 }
             }
         },
-        "dto.GeneralParticipantDTO": {
+        "dto.RecoveryCodesDTO": {
             "type": "object",
             "properties": {
-                "first_name": {
-                    "type": "string"
+                "recovery_codes": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "dto.ReminderDTO": {
+            "type": "object",
+            "properties": {
+                "deliveries": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.ReminderDeliveryDTO"
+                    }
                 },
-                "last_name": {
-                    "type": "string"
+                "failed": {
+                    "type": "integer"
                 },
-                "participant_id": {
+                "reminder_id": {
                     "type": "string"
                 },
-                "register_number": {
+                "send_at": {
                     "type": "string"
                 },
-                "user_id": {
+                "sent": {
+                    "type": "integer"
+                },
+                "skipped": {
+                    "type": "integer"
+                },
+                "status": {
                     "type": "string"
                 },
-                "voted": {
-                    "type": "boolean"
+                "total": {
+                    "type": "integer"
                 }
             }
         },
-        "dto.GeneralStudentDTO": {
+        "dto.ReminderDeliveryDTO": {
             "type": "object",
             "properties": {
                 "email": {
                     "type": "string"
                 },
-                "first_name": {
+                "error": {
                     "type": "string"
                 },
-                "last_name": {
+                "sent_at": {
                     "type": "string"
                 },
-                "reg_number": {
+                "status": {
                     "type": "string"
-                },
-                "user_id": {
+                }
+            }
+        },
+        "dto.ResendEmailsDTO": {
+            "type": "object",
+            "properties": {
+                "email_outbox_id": {
                     "type": "string"
-                },
-                "verified": {
-                    "type": "boolean"
                 }
             }
         },
-        "dto.Login": {
+        "dto.ResendVerificationDTO": {
             "type": "object",
             "required": [
-                "email",
-                "password"
+                "email"
             ],
             "properties": {
                 "email": {
                     "type": "string"
-                },
-                "password": {
-                    "type": "string"
                 }
             }
         },
-        "dto.LoginResponse": {
+        "dto.ResetPasswordDTO": {
             "type": "object",
+            "required": [
+                "new_password",
+                "token"
+            ],
             "properties": {
-                "email": {
+                "new_password": {
                     "type": "string"
                 },
+                "token": {
+                    "type": "string"
+                }
+            }
+        },
+        "dto.Response": {
+            "type": "object",
+            "properties": {
                 "message": {
                     "type": "string"
                 }
             }
         },
-        "dto.OTP": {
+        "dto.RunoffResultsDTO": {
             "type": "object",
-            "required": [
-                "email",
-                "otp"
-            ],
             "properties": {
-                "email": {
+                "group": {
                     "type": "string"
                 },
-                "otp": {
+                "rounds": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.RunoffRoundDTO"
+                    }
+                },
+                "winners": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.CandidateResultsDTO"
+                    }
+                }
+            }
+        },
+        "dto.RunoffRoundDTO": {
+            "type": "object",
+            "properties": {
+                "eliminated": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "exhausted": {
+                    "type": "integer"
+                },
+                "round": {
+                    "type": "integer"
+                },
+                "tallies": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.RunoffTallyDTO"
+                    }
+                },
+                "transfers": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/dto.RunoffTransferDTO"
+                    }
+                }
+            }
+        },
+        "dto.RunoffTallyDTO": {
+            "type": "object",
+            "properties": {
+                "candidate_id": {
+                    "type": "string"
+                },
+                "name": {
                     "type": "string"
+                },
+                "votes": {
+                    "type": "integer"
                 }
             }
         },
-        "dto.OTPResponse": {
+        "dto.RunoffTransferDTO": {
             "type": "object",
             "properties": {
-                "email": {
+                "from": {
                     "type": "string"
                 },
-                "message": {
+                "to": {
                     "type": "string"
                 },
-                "role": {
+                "votes": {
+                    "type": "integer"
+                }
+            }
+        },
+        "dto.ScheduleReminderDTO": {
+            "type": "object",
+            "properties": {
+                "before_end": {
                     "type": "string"
                 },
-                "user_id": {
+                "send_at": {
                     "type": "string"
                 }
             }
         },
-        "dto.ResetPasswordDTO": {
+        "dto.SessionDTO": {
             "type": "object",
-            "required": [
-                "new_password",
-                "token"
-            ],
             "properties": {
-                "new_password": {
+                "created_at": {
                     "type": "string"
                 },
-                "token": {
+                "current": {
+                    "type": "boolean"
+                },
+                "device": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "ip_address": {
+                    "type": "string"
+                },
+                "last_used_at": {
+                    "type": "string"
+                },
+                "user_agent": {
                     "type": "string"
                 }
             }
         },
-        "dto.Response": {
+        "dto.TOTPEnrollmentDTO": {
             "type": "object",
             "properties": {
-                "message": {
+                "otpauth_url": {
+                    "type": "string"
+                },
+                "qr_code": {
+                    "type": "string"
+                },
+                "secret": {
                     "type": "string"
                 }
             }
@@ -1566,6 +4116,46 @@ var doc = `{
                     "type": "string"
                 }
             }
+        },
+        "jwtkeys.JSONWebKey": {
+            "type": "object",
+            "properties": {
+                "alg": {
+                    "type": "string"
+                },
+                "crv": {
+                    "type": "string"
+                },
+                "e": {
+                    "type": "string"
+                },
+                "kid": {
+                    "type": "string"
+                },
+                "kty": {
+                    "type": "string"
+                },
+                "n": {
+                    "type": "string"
+                },
+                "use": {
+                    "type": "string"
+                },
+                "x": {
+                    "type": "string"
+                }
+            }
+        },
+        "jwtkeys.JSONWebKeySet": {
+            "type": "object",
+            "properties": {
+                "keys": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/jwtkeys.JSONWebKey"
+                    }
+                }
+            }
         }
     }
 }`
@@ -18,9 +18,10 @@ type GeneralStudentDTO struct {
 
 // Auth DTOs
 type AuthUserDTO struct {
-	UserID   string `json:"user_id"`
-	Email    string `json:"email" binding:"required,email"`
-	Password string `json:"password" binding:"required"`
+	UserID    string `json:"user_id"`
+	Email     string `json:"email" binding:"required,email"`
+	Password  string `json:"password" binding:"required"`
+	Directory bool   `json:"-"`
 }
 
 type SetPasswordDTO struct {
@@ -29,8 +30,9 @@ type SetPasswordDTO struct {
 }
 
 type OTPDTO struct {
-	Email string `json:"email" binding:"email,required"`
-	OTP   string `json:"otp" binding:"required"`
+	Email  string `json:"email,omitempty" binding:"omitempty,email"`
+	OTP    string `json:"otp" binding:"required"`
+	Method *int   `json:"method,omitempty"`
 }
 
 type ChangePasswordDTO struct {
@@ -42,6 +44,10 @@ type CreateResetTokenDTO struct {
 	Email string `json:"email" binding:"email,required"`
 }
 
+type ResendVerificationDTO struct {
+	Email string `json:"email" binding:"email,required"`
+}
+
 type ResetPasswordDTO struct {
 	Token       string `json:"token" binding:"required"`
 	NewPassword string `json:"new_password" binding:"required"`
@@ -61,11 +67,17 @@ type Response struct {
 	Message string `json:"message"`
 }
 
-type LoginResponse struct {
-	Email   string `json:"email"`
+type CastVoteResponse struct {
+	Receipt string `json:"receipt"`
 	Message string `json:"message"`
 }
 
+type LoginResponse struct {
+	Email     string `json:"email"`
+	OTPMethod int    `json:"otp_method"`
+	Message   string `json:"message"`
+}
+
 type OTPResponse struct {
 	UserId  string `json:"user_id"`
 	Email   string `json:"email"`
@@ -82,11 +94,18 @@ type PaginatorParams struct {
 
 // Election DTOs
 type CreateElectionDTO struct {
-	Title          string `json:"title" binding:"required"`
-	StartingAt     string `json:"starting_at" binding:"required"`
-	EndingAt       string `json:"ending_at" binding:"required"`
-	LockingAt      string `json:"locking_at" binding:"required"`
-	GenderSpecific bool   `json:"gender_specific"`
+	Title          string   `json:"title" binding:"required"`
+	StartingAt     string   `json:"starting_at" binding:"required"`
+	EndingAt       string   `json:"ending_at" binding:"required"`
+	LockingAt      string   `json:"locking_at" binding:"required"`
+	GenderSpecific bool     `json:"gender_specific"`
+	VotingMethod   int      `json:"voting_method"`
+	Positions      []string `json:"positions,omitempty"`
+	Draft          bool     `json:"draft,omitempty"`
+}
+
+type ExtendElectionDTO struct {
+	EndingAt string `json:"ending_at" binding:"required"`
 }
 
 type EditElectionDTO struct {
@@ -98,6 +117,16 @@ type EditElectionDTO struct {
 	GenderSpecific bool   `json:"gender_specific,omitempty"`
 }
 
+type CreatePositionDTO struct {
+	ElectionId string `json:"election_id" binding:"required"`
+	Title      string `json:"title" binding:"required"`
+}
+
+type PositionDTO struct {
+	PositionID string `json:"position_id"`
+	Title      string `json:"title"`
+}
+
 type CreateParticipantDTO struct {
 	RegisterNumber string `json:"register_number"`
 }
@@ -109,6 +138,7 @@ type DeleteParticipantDTO struct {
 
 type CreateCandidateDTO struct {
 	ElectionId     string `json:"election_id"`
+	PositionId     string `json:"position_id"`
 	Sex            int    `json:"sex"`
 	DisplayPicture string `json:"display_picture"`
 	Poster         string `json:"poster"`
@@ -116,8 +146,16 @@ type CreateCandidateDTO struct {
 }
 
 type CastVoteDTO struct {
-	ElectionId  string `json:"election_id" binding:"required"`
-	CandidateId string `json:"candidate_id" binding:"required"`
+	ElectionId  string            `json:"election_id" binding:"required"`
+	CandidateId string            `json:"candidate_id,omitempty"`
+	Preferences []string          `json:"preferences,omitempty"`
+	Choices     []BallotChoiceDTO `json:"choices,omitempty"`
+}
+
+type BallotChoiceDTO struct {
+	PositionId  string   `json:"position_id"`
+	CandidateId string   `json:"candidate_id,omitempty"`
+	Preferences []string `json:"preferences,omitempty"`
 }
 
 type CandidateResultsDTO struct {
@@ -126,6 +164,7 @@ type CandidateResultsDTO struct {
 	Name           string `json:"name"`
 	Sex            int    `json:"sex"`
 	ElectionID     string `json:"election_id"`
+	PositionID     string `json:"position_id,omitempty"`
 	DisplayPicture string `json:"display_picture"`
 	Votes          int    `json:"votes"`
 }
@@ -134,6 +173,7 @@ type GeneralCandidateDTO struct {
 	CandidateID    string `json:"candidate_id"`
 	UserID         string `json:"user_id"`
 	ElectionID     string `json:"election_id"`
+	PositionID     string `json:"position_id,omitempty"`
 	RegisterNo     string `json:"register_no"`
 	FirstName      string `json:"first_name"`
 	LastName       string `json:"last_name"`
@@ -162,6 +202,10 @@ type GeneralElectionDTO struct {
 	Voted          bool                    `json:"voted,omitempty"`
 	Blacklisted    bool                    `json:"blacklisted,omitempty"`
 	GenderSpecific bool                    `json:"gender_specific,omitempty"`
+	VotingMethod   int                     `json:"voting_method"`
+	Status         int                     `json:"status"`
+	Paused         bool                    `json:"paused,omitempty"`
+	Positions      []PositionDTO           `json:"positions,omitempty"`
 	Participants   []GeneralParticipantDTO `json:"participants,omitempty"`
 	Candidates     []GeneralCandidateDTO   `json:"candidates,omitempty"`
 	Candidate      *GeneralCandidateDTO    `json:"candidate,omitempty"`
@@ -174,10 +218,221 @@ type GeneralElectionResultsDTO struct {
 	EndingAt          string                `json:"ending_at"`
 	LockingAt         string                `json:"locking_at"`
 	GenderSpecific    bool                  `json:"gender_specific"`
+	VotingMethod      int                   `json:"voting_method"`
 	TotalVotes        int                   `json:"total_votes"`
 	TotalParticipants int                   `json:"total_participants"`
 	CandidateResults  []CandidateResultsDTO `json:"candidate_results,omitempty"`
 	MCandidateResults []CandidateResultsDTO `json:"mcandidate_results,omitempty"`
 	FCandidateResults []CandidateResultsDTO `json:"fcandidate_results,omitempty"`
 	OCandidateResults []CandidateResultsDTO `json:"ocandidate_results,omitempty"`
+	PositionResults   []PositionResultsDTO  `json:"position_results,omitempty"`
+	Runoffs           []RunoffResultsDTO    `json:"runoffs,omitempty"`
+}
+
+type ElectionReceiptsDTO struct {
+	ElectionID    string   `json:"election_id"`
+	Title         string   `json:"title"`
+	EndingAt      string   `json:"ending_at"`
+	TotalReceipts int      `json:"total_receipts"`
+	Receipts      []string `json:"receipts"`
+}
+
+type ScheduleReminderDTO struct {
+	SendAt    string `json:"send_at,omitempty"`
+	BeforeEnd string `json:"before_end,omitempty"`
+}
+
+type ReminderDeliveryDTO struct {
+	Email  string `json:"email"`
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+	SentAt string `json:"sent_at,omitempty"`
+}
+
+type ReminderDTO struct {
+	ReminderID string                `json:"reminder_id"`
+	SendAt     string                `json:"send_at"`
+	Status     string                `json:"status"`
+	Total      int                   `json:"total"`
+	Sent       int                   `json:"sent"`
+	Failed     int                   `json:"failed"`
+	Skipped    int                   `json:"skipped"`
+	Deliveries []ReminderDeliveryDTO `json:"deliveries"`
+}
+
+type ReminderRecipientDTO struct {
+	ReminderDeliveryID string
+	FirstName          string
+	Email              string
+	Voted              bool
+}
+
+type OutboxEmailDTO struct {
+	EmailOutboxID string `json:"email_outbox_id"`
+	To            string `json:"to"`
+	Subject       string `json:"subject"`
+	Attempts      int    `json:"attempts"`
+	LastError     string `json:"last_error"`
+	CreatedAt     string `json:"created_at"`
+	UpdatedAt     string `json:"updated_at"`
+}
+
+type ResendEmailsDTO struct {
+	EmailOutboxID string `json:"email_outbox_id,omitempty"`
+}
+
+type ElectionEventDTO struct {
+	Event      string `json:"event"`
+	ElectionID string `json:"election_id"`
+	Status     int    `json:"status"`
+}
+
+type AuditEventDTO struct {
+	AuditEventID uint   `json:"audit_event_id"`
+	Actor        string `json:"actor"`
+	Action       string `json:"action"`
+	Target       string `json:"target"`
+	Diff         string `json:"diff"`
+	PrevHash     string `json:"prev_hash"`
+	Hash         string `json:"hash"`
+	CreatedAt    string `json:"created_at"`
+}
+
+type AuditChainDTO struct {
+	ElectionID  string          `json:"election_id"`
+	Valid       bool            `json:"valid"`
+	TotalEvents int             `json:"total_events"`
+	BrokenAt    uint            `json:"broken_at,omitempty"`
+	Events      []AuditEventDTO `json:"events"`
+}
+
+type PositionResultsDTO struct {
+	PositionID        string                `json:"position_id"`
+	Title             string                `json:"title"`
+	TotalVotes        int                   `json:"total_votes"`
+	CandidateResults  []CandidateResultsDTO `json:"candidate_results,omitempty"`
+	MCandidateResults []CandidateResultsDTO `json:"mcandidate_results,omitempty"`
+	FCandidateResults []CandidateResultsDTO `json:"fcandidate_results,omitempty"`
+	OCandidateResults []CandidateResultsDTO `json:"ocandidate_results,omitempty"`
+	Winners           []CandidateResultsDTO `json:"winners"`
+	Runoffs           []RunoffResultsDTO    `json:"runoffs,omitempty"`
+}
+
+type RunoffResultsDTO struct {
+	Group   string                `json:"group"`
+	Rounds  []RunoffRoundDTO      `json:"rounds"`
+	Winners []CandidateResultsDTO `json:"winners"`
+}
+
+type RunoffRoundDTO struct {
+	Round      int                 `json:"round"`
+	Tallies    []RunoffTallyDTO    `json:"tallies"`
+	Exhausted  int                 `json:"exhausted"`
+	Eliminated []string            `json:"eliminated,omitempty"`
+	Transfers  []RunoffTransferDTO `json:"transfers,omitempty"`
+}
+
+type RunoffTallyDTO struct {
+	CandidateID string `json:"candidate_id"`
+	Name        string `json:"name"`
+	Votes       int    `json:"votes"`
+}
+
+type RunoffTransferDTO struct {
+	From  string `json:"from"`
+	To    string `json:"to,omitempty"`
+	Votes int    `json:"votes"`
+}
+
+type TOTPEnrollmentDTO struct {
+	Secret     string `json:"secret"`
+	OTPAuthURL string `json:"otpauth_url"`
+	QRCode     string `json:"qr_code"`
+}
+
+type ConfirmTOTPDTO struct {
+	OTP string `json:"otp" binding:"required"`
+}
+
+type OTPMethodDTO struct {
+	Method *int `json:"method" binding:"required"`
+}
+
+type RecoveryCodesDTO struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+type PasskeyDTO struct {
+	ID         uint   `json:"id"`
+	Name       string `json:"name"`
+	CreatedAt  string `json:"created_at"`
+	LastUsedAt string `json:"last_used_at,omitempty"`
+}
+
+type SessionDTO struct {
+	ID         string `json:"id"`
+	Device     string `json:"device"`
+	IPAddress  string `json:"ip_address"`
+	UserAgent  string `json:"user_agent"`
+	CreatedAt  string `json:"created_at"`
+	LastUsedAt string `json:"last_used_at"`
+	Current    bool   `json:"current"`
+}
+
+type APIKeyScopeDTO struct {
+	Path   string `json:"path" binding:"required"`
+	Method string `json:"method" binding:"required"`
+}
+
+type CreateAPIKeyDTO struct {
+	Name      string           `json:"name" binding:"required"`
+	Scopes    []APIKeyScopeDTO `json:"scopes" binding:"required,dive"`
+	ExpiresIn string           `json:"expires_in,omitempty"`
+}
+
+type APIKeyDTO struct {
+	ID         string           `json:"id"`
+	Name       string           `json:"name"`
+	Prefix     string           `json:"prefix"`
+	Scopes     []APIKeyScopeDTO `json:"scopes"`
+	ExpiresAt  string           `json:"expires_at"`
+	CreatedAt  string           `json:"created_at"`
+	LastUsedAt string           `json:"last_used_at,omitempty"`
+}
+
+// NewAPIKeyDTO is the only time the key itself is shown.
+type NewAPIKeyDTO struct {
+	APIKeyDTO
+	Key string `json:"key"`
+}
+
+type PasskeyLoginDTO struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+type DirectorySyncDTO struct {
+	Created   int `json:"created"`
+	Updated   int `json:"updated"`
+	Unchanged int `json:"unchanged"`
+	Skipped   int `json:"skipped"`
+}
+
+// PolicyDTO is a rule of the authorization policy, role is a role number or *.
+type PolicyDTO struct {
+	ID     uint   `json:"id"`
+	Role   string `json:"role" binding:"required"`
+	Path   string `json:"path" binding:"required"`
+	Method string `json:"method" binding:"required"`
+	Effect string `json:"effect" binding:"required"`
+}
+
+type PolicyCheckDTO struct {
+	Role   string `json:"role" binding:"required"`
+	Path   string `json:"path" binding:"required"`
+	Method string `json:"method" binding:"required"`
+}
+
+type PolicyCheckResultDTO struct {
+	Allowed bool       `json:"allowed"`
+	Matched *PolicyDTO `json:"matched,omitempty"`
 }
@@ -11,8 +11,9 @@ type Verify struct {
 }
 
 type OTP struct {
-	Email string `json:"email" binding:"email,required"`
-	OTP   string `json:"otp" binding:"required"`
+	Email  string `json:"email,omitempty" binding:"omitempty,email"`
+	OTP    string `json:"otp" binding:"required"`
+	Method int    `json:"method,omitempty"`
 }
 
 type Elections struct {
@@ -22,11 +23,15 @@ type Elections struct {
 	EndingAt       string `json:"ending_at"`
 	LockingAt      string `json:"locking_at"`
 	GenderSpecific bool   `json:"gender_specific,omitempty"`
+	VotingMethod   int    `json:"voting_method"`
+	Status         int    `json:"status"`
+	Paused         bool   `json:"paused,omitempty"`
 	Voted          bool   `json:"voted,omitempty"`
 	Blacklisted    bool   `json:"blacklisted,omitempty"`
 }
 
 type CandidateInputs struct {
 	ElectionId string `json:"election_id" binding:"required"`
+	PositionId string `json:"position_id"`
 	Sex        int    `json:"sex" binding:"required"`
 }
@@ -0,0 +1,232 @@
+package email
+
+import (
+	"crypto/tls"
+	"errors"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"gopkg.in/gomail.v2"
+)
+
+// Mailer delivers a rendered email, the services get one injected instead of dialing SendPulse themselves.
+type Mailer interface {
+	Send(message Message) error
+}
+
+// Message is dropped instead of sent once ExpiresAt has passed, the zero value never expires.
+// A message with an IdempotencyKey is only queued once, later ones with the same key are dropped.
+type Message struct {
+	To             string
+	Subject        string
+	Body           string
+	ExpiresAt      time.Time
+	IdempotencyKey string
+}
+
+// MaxAttempts is how many times an email is tried before it is marked failed, set by EMAIL_MAX_ATTEMPTS.
+func MaxAttempts() int {
+	attempts, err := strconv.Atoi(os.Getenv("EMAIL_MAX_ATTEMPTS"))
+	if err != nil || attempts <= 0 {
+		return 8
+	}
+
+	return attempts
+}
+
+// Backoff doubles the wait after every failed attempt, starting at 30 seconds and capped at an hour.
+func Backoff(attempts int) time.Duration {
+	backoff := 30 * time.Second
+	for i := 1; i < attempts && backoff < time.Hour; i++ {
+		backoff *= 2
+	}
+	if backoff > time.Hour {
+		return time.Hour
+	}
+
+	return backoff
+}
+
+// Config picks the transport with MAIL_TRANSPORT(smtp, file or memory) and configures it.
+type Config struct {
+	Transport string
+	Host      string
+	Port      int
+	Username  string
+	Password  string
+	From      string
+	FromName  string
+	TLS       string
+	Dir       string
+}
+
+// ConfigFromEnv reads the mail settings, the SendPulse defaults are kept so existing deployments don't change.
+func ConfigFromEnv() Config {
+	config := Config{
+		Transport: getEnv("MAIL_TRANSPORT", "smtp"),
+		Host:      getEnv("SMTP_HOST", "smtp-pulse.com"),
+		Port:      587,
+		Username:  getEnv("SMTP_USERNAME", os.Getenv("SENDPULSE_EMAIL")),
+		Password:  getEnv("SMTP_PASSWORD", os.Getenv("SENDPULSE_PASSWORD")),
+		From:      getEnv("MAIL_FROM", "noreply@blobber.tk"),
+		FromName:  getEnv("MAIL_FROM_NAME", "ELECT Team"),
+		TLS:       getEnv("SMTP_TLS", "starttls"),
+		Dir:       getEnv("MAIL_DIR", "mail"),
+	}
+
+	port, err := strconv.Atoi(os.Getenv("SMTP_PORT"))
+	if err == nil {
+		config.Port = port
+	}
+
+	return config
+}
+
+func NewMailer(config Config) (Mailer, error) {
+	switch config.Transport {
+	case "smtp":
+		return newSMTPMailer(config)
+	case "file":
+		return newFileMailer(config)
+	case "memory":
+		return NewMemoryMailer(), nil
+	}
+
+	return nil, errors.New("Invalid mail transport: " + config.Transport)
+}
+
+type smtpMailer struct {
+	config Config
+	dialer *gomail.Dialer
+}
+
+// newSMTPMailer supports starttls(upgraded when the server offers it), tls(implicit, usually port 465) and insecure(starttls without verifying the certificate).
+func newSMTPMailer(config Config) (Mailer, error) {
+	dialer := gomail.NewDialer(config.Host, config.Port, config.Username, config.Password)
+
+	switch config.TLS {
+	case "starttls":
+	case "tls":
+		dialer.SSL = true
+	case "insecure":
+		dialer.TLSConfig = &tls.Config{ServerName: config.Host, InsecureSkipVerify: true}
+	default:
+		return nil, errors.New("Invalid SMTP TLS mode: " + config.TLS)
+	}
+
+	return &smtpMailer{
+		config: config,
+		dialer: dialer,
+	}, nil
+}
+
+func (mailer *smtpMailer) Send(message Message) error {
+	return mailer.dialer.DialAndSend(toGomailMessage(mailer.config, message))
+}
+
+var deliveries uint64
+
+type fileMailer struct {
+	config Config
+}
+
+// newFileMailer writes every email into a maildir for local development, any mail client can open it.
+func newFileMailer(config Config) (Mailer, error) {
+	for _, dir := range []string{"tmp", "new", "cur"} {
+		err := os.MkdirAll(filepath.Join(config.Dir, dir), 0755)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &fileMailer{
+		config: config,
+	}, nil
+}
+
+func (mailer *fileMailer) Send(message Message) error {
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = "localhost"
+	}
+	name := strconv.FormatInt(time.Now().UnixNano(), 10) + "." + strconv.Itoa(os.Getpid()) + "_" + strconv.FormatUint(atomic.AddUint64(&deliveries, 1), 10) + "." + hostname
+
+	// Maildir readers only pick up complete files, so the email is written to tmp and then moved to new
+	tmpPath := filepath.Join(mailer.config.Dir, "tmp", name)
+	file, err := os.Create(tmpPath)
+	if err != nil {
+		return err
+	}
+
+	_, err = toGomailMessage(mailer.config, message).WriteTo(file)
+	if err != nil {
+		file.Close()
+		os.Remove(tmpPath)
+		return err
+	}
+
+	err = file.Close()
+	if err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+
+	return os.Rename(tmpPath, filepath.Join(mailer.config.Dir, "new", name))
+}
+
+// MemoryMailer keeps the emails it is given so that tests can assert on them.
+type MemoryMailer struct {
+	mutex    sync.Mutex
+	messages []Message
+}
+
+func NewMemoryMailer() *MemoryMailer {
+	return &MemoryMailer{}
+}
+
+func (mailer *MemoryMailer) Send(message Message) error {
+	mailer.mutex.Lock()
+	defer mailer.mutex.Unlock()
+
+	mailer.messages = append(mailer.messages, message)
+	return nil
+}
+
+func (mailer *MemoryMailer) Messages() []Message {
+	mailer.mutex.Lock()
+	defer mailer.mutex.Unlock()
+
+	return append([]Message{}, mailer.messages...)
+}
+
+func (mailer *MemoryMailer) Reset() {
+	mailer.mutex.Lock()
+	defer mailer.mutex.Unlock()
+
+	mailer.messages = nil
+}
+
+func toGomailMessage(config Config, message Message) *gomail.Message {
+	m := gomail.NewMessage()
+	m.SetHeader("MIME-version", "1.0")
+	m.SetHeader("charset", "UTF-8")
+	m.SetHeader("From", m.FormatAddress(config.From, config.FromName))
+	m.SetHeader("To", message.To)
+	m.SetHeader("Subject", message.Subject)
+	m.SetBody("text/html", message.Body)
+
+	return m
+}
+
+func getEnv(key string, fallback string) string {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback
+	}
+
+	return value
+}
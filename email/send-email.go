@@ -3,27 +3,43 @@ package email
 import (
 	"bytes"
 	"html/template"
-	"os"
-
-	"gopkg.in/gomail.v2"
+	"time"
 )
 
-func SendVerificationEmail(name string, email string, token string, tmpl string) error {
-	m := gomail.NewMessage()
-	m.SetHeader("MIME-version", "1.0")
-	m.SetHeader("charset", "UTF-8")
-	m.SetHeader("From", m.FormatAddress("noreply@blobber.tk", "ELECT Team"))
-	m.SetHeader("To", email)
-	m.SetHeader("Subject", "Verify and Set Password for your ELECT account.")
+func SendVerificationEmail(mailer Mailer, name string, email string, token string, tmpl string) error {
+	body, err := render(tmpl, map[string]string{
+		"name":  name,
+		"token": token,
+	})
+	if err != nil {
+		return err
+	}
 
-	var body bytes.Buffer
+	return mailer.Send(Message{
+		To:      email,
+		Subject: "Verify and Set Password for your ELECT account.",
+		Body:    body,
+	})
+}
 
-	t, err := template.ParseFiles("email/" + tmpl)
+func SendOTPEmail(mailer Mailer, email string, otp string, tmpl string) error {
+	body, err := render(tmpl, map[string]string{
+		"otp": otp,
+	})
 	if err != nil {
 		return err
 	}
 
-	err = t.Execute(&body, map[string]string{
+	return mailer.Send(Message{
+		To:        email,
+		Subject:   "OTP for Login.",
+		Body:      body,
+		ExpiresAt: time.Now().Add(240 * time.Second),
+	})
+}
+
+func SendResetPasswordEmail(mailer Mailer, name string, email string, token string, tmpl string) error {
+	body, err := render(tmpl, map[string]string{
 		"name":  name,
 		"token": token,
 	})
@@ -31,80 +47,90 @@ func SendVerificationEmail(name string, email string, token string, tmpl string)
 		return err
 	}
 
-	m.SetBody("text/html", string(body.Bytes()))
-
-	d := gomail.NewDialer("smtp-pulse.com", 587, os.Getenv("SENDPULSE_EMAIL"), os.Getenv("SENDPULSE_PASSWORD"))
+	return mailer.Send(Message{
+		To:      email,
+		Subject: "Reset Password for your ELECT account.",
+		Body:    body,
+	})
+}
 
-	if err := d.DialAndSend(m); err != nil {
+func SendVotingOpenEmail(mailer Mailer, name string, email string, title string, idempotencyKey string, tmpl string) error {
+	body, err := render(tmpl, map[string]string{
+		"name":  name,
+		"title": title,
+	})
+	if err != nil {
 		return err
 	}
 
-	return nil
+	return mailer.Send(Message{
+		To:             email,
+		Subject:        "Voting is open for " + title + ".",
+		Body:           body,
+		IdempotencyKey: idempotencyKey,
+	})
 }
 
-func SendOTPEmail(email string, otp string, tmpl string) error {
-	m := gomail.NewMessage()
-	m.SetHeader("MIME-version", "1.0")
-	m.SetHeader("charset", "UTF-8")
-	m.SetHeader("From", m.FormatAddress("noreply@blobber.tk", "ELECT Team"))
-	m.SetHeader("To", email)
-	m.SetHeader("Subject", "OTP for Login.")
-
-	var body bytes.Buffer
-
-	t, err := template.ParseFiles("email/" + tmpl)
+func SendReminderEmail(mailer Mailer, name string, email string, title string, endingAt string, tmpl string) error {
+	body, err := render(tmpl, map[string]string{
+		"name":      name,
+		"title":     title,
+		"ending_at": endingAt,
+	})
 	if err != nil {
 		return err
 	}
 
-	err = t.Execute(&body, map[string]string{
-		"otp": otp,
+	return mailer.Send(Message{
+		To:      email,
+		Subject: "Reminder: You haven't voted in " + title + " yet.",
+		Body:    body,
+	})
+}
+
+func SendAccountLockedEmail(mailer Mailer, name string, email string, lockedUntil string, tmpl string) error {
+	body, err := render(tmpl, map[string]string{
+		"name":         name,
+		"locked_until": lockedUntil,
 	})
 	if err != nil {
 		return err
 	}
 
-	m.SetBody("text/html", string(body.Bytes()))
-
-	d := gomail.NewDialer("smtp-pulse.com", 587, os.Getenv("SENDPULSE_EMAIL"), os.Getenv("SENDPULSE_PASSWORD"))
+	return mailer.Send(Message{
+		To:      email,
+		Subject: "Your ELECT account has been locked.",
+		Body:    body,
+	})
+}
 
-	if err := d.DialAndSend(m); err != nil {
+func SendRecoveryCodesRegeneratedEmail(mailer Mailer, name string, email string, tmpl string) error {
+	body, err := render(tmpl, map[string]string{
+		"name": name,
+	})
+	if err != nil {
 		return err
 	}
 
-	return nil
+	return mailer.Send(Message{
+		To:      email,
+		Subject: "New recovery codes for your ELECT account.",
+		Body:    body,
+	})
 }
 
-func SendResetPasswordEmail(name string, email string, token string, tmpl string) error {
-	m := gomail.NewMessage()
-	m.SetHeader("MIME-version", "1.0")
-	m.SetHeader("charset", "UTF-8")
-	m.SetHeader("From", m.FormatAddress("noreply@blobber.tk", "ELECT Team"))
-	m.SetHeader("To", email)
-	m.SetHeader("Subject", "Reset Password for your ELECT account.")
-
+func render(tmpl string, data map[string]string) (string, error) {
 	var body bytes.Buffer
 
 	t, err := template.ParseFiles("email/" + tmpl)
 	if err != nil {
-		return err
+		return "", err
 	}
 
-	err = t.Execute(&body, map[string]string{
-		"name":  name,
-		"token": token,
-	})
+	err = t.Execute(&body, data)
 	if err != nil {
-		return err
-	}
-
-	m.SetBody("text/html", string(body.Bytes()))
-
-	d := gomail.NewDialer("smtp-pulse.com", 587, os.Getenv("SENDPULSE_EMAIL"), os.Getenv("SENDPULSE_PASSWORD"))
-
-	if err := d.DialAndSend(m); err != nil {
-		return err
+		return "", err
 	}
 
-	return nil
+	return body.String(), nil
 }
@@ -10,8 +10,11 @@ require (
 	github.com/aws/aws-sdk-go v1.40.23 // indirect
 	github.com/biezhi/gorm-paginator/pagination v0.0.0-20190124091837-7a5c8ed20334
 	github.com/casbin/casbin/v2 v2.36.1
+	github.com/fxamacker/cbor/v2 v2.2.0
 	github.com/gin-contrib/static v0.0.1
 	github.com/gin-gonic/gin v1.7.4
+	github.com/go-asn1-ber/asn1-ber v1.5.1
+	github.com/go-ldap/ldap/v3 v3.4.1
 	github.com/go-openapi/jsonreference v0.19.6 // indirect
 	github.com/go-openapi/swag v0.19.15 // indirect
 	github.com/go-playground/validator/v10 v10.9.0 // indirect
@@ -22,7 +25,6 @@ require (
 	github.com/gorilla/sessions v1.2.1 // indirect
 	github.com/gorilla/websocket v1.4.2
 	github.com/gosimple/slug v1.10.0 // indirect
-	github.com/hgfischer/go-otp v1.0.0
 	github.com/jinzhu/configor v1.2.1 // indirect
 	github.com/jinzhu/gorm v1.9.16
 	github.com/jinzhu/now v1.1.2 // indirect
@@ -32,6 +34,7 @@ require (
 	github.com/mailru/easyjson v0.7.7 // indirect
 	github.com/mattn/go-isatty v0.0.13 // indirect
 	github.com/microcosm-cc/bluemonday v1.0.17 // indirect
+	github.com/pquerna/otp v1.4.0
 	github.com/qor/admin v1.2.0
 	github.com/qor/qor v1.2.0
 	github.com/qor/responder v0.0.0-20201015104727-4f3a345378c2 // indirect
@@ -0,0 +1,48 @@
+package jobs
+
+import (
+	"elect/models"
+	"time"
+)
+
+var Pending int = 0
+var Running int = 1
+var Done int = 2
+var Failed int = 3
+var Skipped int = 4
+
+var names = map[int]string{
+	Pending: "Pending",
+	Running: "Running",
+	Done:    "Done",
+	Failed:  "Failed",
+	Skipped: "Skipped",
+}
+
+var ElectionLocked string = "election.locked"
+var ElectionStarted string = "election.started"
+var ElectionEnded string = "election.ended"
+
+// Kinds are the jobs every election gets.
+var Kinds = []string{ElectionLocked, ElectionStarted, ElectionEnded}
+
+var MaxAttempts int = 5
+
+// Lease is how long a claimed job is held before another worker may pick it up again.
+var Lease time.Duration = 10 * time.Minute
+
+// RunAt returns when a job of the given kind is due for the election.
+func RunAt(election models.Election, kind string) time.Time {
+	switch kind {
+	case ElectionLocked:
+		return election.LockingAt.UTC()
+	case ElectionStarted:
+		return election.StartingAt.UTC()
+	}
+
+	return election.EndingAt.UTC()
+}
+
+func Name(status int) string {
+	return names[status]
+}
@@ -0,0 +1,236 @@
+package jwtkeys
+
+import (
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"os"
+	"strings"
+
+	"github.com/golang-jwt/jwt"
+)
+
+// Key is one key of the set, keys that are only used to verify have no private key.
+type Key struct {
+	ID         string
+	Method     jwt.SigningMethod
+	privateKey interface{}
+	publicKey  interface{}
+}
+
+// KeySet signs access tokens with one key and verifies them with any key of the set, so a new key can be rolled out
+// while tokens signed with the old one are still around.
+type KeySet struct {
+	signing *Key
+	keys    []*Key
+}
+
+// JSONWebKey is the public half of a key as published in the JWKS.
+type JSONWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+type JSONWebKeySet struct {
+	Keys []JSONWebKey `json:"keys"`
+}
+
+// FromEnv builds the key set from JWT_SIGNING_ALG(HS256, RS256 or EdDSA), the PEM private key in JWT_SIGNING_KEY and
+// the PEM keys in JWT_VERIFICATION_KEYS that are still accepted. HS256 keeps signing with ACCESS_TOKEN_SECRET.
+func FromEnv() (*KeySet, error) {
+	alg := os.Getenv("JWT_SIGNING_ALG")
+	if alg == "" || alg == jwt.SigningMethodHS256.Alg() {
+		return &KeySet{signing: &Key{Method: jwt.SigningMethodHS256}}, nil
+	}
+
+	if alg != jwt.SigningMethodRS256.Alg() && alg != jwt.SigningMethodEdDSA.Alg() {
+		return nil, errors.New("Unsupported JWT_SIGNING_ALG " + alg)
+	}
+
+	keys, err := parseKeys(os.Getenv("JWT_SIGNING_KEY"))
+	if err != nil {
+		return nil, err
+	}
+	if len(keys) != 1 || keys[0].privateKey == nil || keys[0].Method.Alg() != alg {
+		return nil, errors.New("JWT_SIGNING_KEY has to be a single " + alg + " private key")
+	}
+
+	set := &KeySet{signing: keys[0], keys: keys}
+
+	keys, err = parseKeys(os.Getenv("JWT_VERIFICATION_KEYS"))
+	if err != nil {
+		return nil, err
+	}
+	for _, key := range keys {
+		if set.find(key.ID) == nil {
+			key.privateKey = nil
+			set.keys = append(set.keys, key)
+		}
+	}
+
+	return set, nil
+}
+
+// Sign signs the claims with the signing key and names it in the kid header.
+func (set *KeySet) Sign(claims jwt.Claims) (string, error) {
+	token := jwt.NewWithClaims(set.signing.Method, claims)
+
+	if _, ok := set.signing.Method.(*jwt.SigningMethodHMAC); ok {
+		return token.SignedString([]byte(os.Getenv("ACCESS_TOKEN_SECRET")))
+	}
+
+	token.Header["kid"] = set.signing.ID
+	return token.SignedString(set.signing.privateKey)
+}
+
+// Keyfunc picks the key a token names in its kid header for jwt.Parse, it has to match the algorithm of the token.
+func (set *KeySet) Keyfunc(token *jwt.Token) (interface{}, error) {
+	if _, ok := set.signing.Method.(*jwt.SigningMethodHMAC); ok {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, errors.New("Unexpected signing method.")
+		}
+
+		return []byte(os.Getenv("ACCESS_TOKEN_SECRET")), nil
+	}
+
+	kid, _ := token.Header["kid"].(string)
+	key := set.find(kid)
+	if key == nil {
+		return nil, errors.New("Unknown signing key.")
+	}
+
+	if token.Method.Alg() != key.Method.Alg() {
+		return nil, errors.New("Unexpected signing method.")
+	}
+
+	return key.publicKey, nil
+}
+
+// JWKS lists the public keys of the set, it is empty with HS256 since the secret can't be published.
+func (set *KeySet) JWKS() JSONWebKeySet {
+	jwks := JSONWebKeySet{Keys: []JSONWebKey{}}
+	for _, key := range set.keys {
+		jwks.Keys = append(jwks.Keys, key.jwk())
+	}
+
+	return jwks
+}
+
+func (set *KeySet) find(id string) *Key {
+	for _, key := range set.keys {
+		if key.ID == id {
+			return key
+		}
+	}
+
+	return nil
+}
+
+func (key *Key) jwk() JSONWebKey {
+	switch publicKey := key.publicKey.(type) {
+	case *rsa.PublicKey:
+		return JSONWebKey{
+			Kty: "RSA",
+			Kid: key.ID,
+			Use: "sig",
+			Alg: key.Method.Alg(),
+			N:   base64.RawURLEncoding.EncodeToString(publicKey.N.Bytes()),
+			E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(publicKey.E)).Bytes()),
+		}
+	case ed25519.PublicKey:
+		return JSONWebKey{
+			Kty: "OKP",
+			Kid: key.ID,
+			Use: "sig",
+			Alg: key.Method.Alg(),
+			Crv: "Ed25519",
+			X:   base64.RawURLEncoding.EncodeToString(publicKey),
+		}
+	}
+
+	return JSONWebKey{}
+}
+
+// thumbprint is the RFC 7638 thumbprint of the public key, used as its kid so the same key always gets the same ID.
+func (key *Key) thumbprint() string {
+	jwk := key.jwk()
+
+	var members string
+	if jwk.Kty == "RSA" {
+		members = `{"e":"` + jwk.E + `","kty":"RSA","n":"` + jwk.N + `"}`
+	} else {
+		members = `{"crv":"Ed25519","kty":"OKP","x":"` + jwk.X + `"}`
+	}
+
+	sum := sha256.Sum256([]byte(members))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// parseKeys reads every PEM block of an environment variable, escaped newlines are accepted for one line values.
+func parseKeys(value string) ([]*Key, error) {
+	rest := []byte(strings.ReplaceAll(value, `\n`, "\n"))
+
+	keys := []*Key{}
+	for {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+
+		key, err := parseKey(block)
+		if err != nil {
+			return nil, err
+		}
+		key.ID = key.thumbprint()
+
+		keys = append(keys, key)
+	}
+
+	return keys, nil
+}
+
+func parseKey(block *pem.Block) (*Key, error) {
+	var parsed interface{}
+	var err error
+
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PRIVATE KEY":
+		parsed, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	case "RSA PUBLIC KEY":
+		parsed, err = x509.ParsePKCS1PublicKey(block.Bytes)
+	case "PUBLIC KEY":
+		parsed, err = x509.ParsePKIXPublicKey(block.Bytes)
+	default:
+		return nil, errors.New("Unsupported PEM block " + block.Type)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	switch key := parsed.(type) {
+	case *rsa.PrivateKey:
+		return &Key{Method: jwt.SigningMethodRS256, privateKey: key, publicKey: &key.PublicKey}, nil
+	case *rsa.PublicKey:
+		return &Key{Method: jwt.SigningMethodRS256, publicKey: key}, nil
+	case ed25519.PrivateKey:
+		return &Key{Method: jwt.SigningMethodEdDSA, privateKey: key, publicKey: key.Public()}, nil
+	case ed25519.PublicKey:
+		return &Key{Method: jwt.SigningMethodEdDSA, publicKey: key}, nil
+	}
+
+	return nil, errors.New("Unsupported key type, only RSA and Ed25519 keys can sign tokens")
+}
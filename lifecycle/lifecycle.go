@@ -0,0 +1,97 @@
+package lifecycle
+
+import (
+	"elect/models"
+	"os"
+	"time"
+)
+
+var Draft int = 0
+var Nomination int = 1
+var Locked int = 2
+var Voting int = 3
+var Closed int = 4
+var ResultsPublished int = 5
+var Archived int = 6
+
+var names = map[int]string{
+	Draft:            "Draft",
+	Nomination:       "Nomination",
+	Locked:           "Locked",
+	Voting:           "Voting",
+	Closed:           "Closed",
+	ResultsPublished: "ResultsPublished",
+	Archived:         "Archived",
+}
+
+// transitions lists the statuses an election can move to from each status.
+// Time based statuses may be skipped when the scheduler catches up late, Closed can go back to Voting when extended.
+var transitions = map[int][]int{
+	Draft:            {Nomination, Locked, Voting},
+	Nomination:       {Locked, Voting, Closed, ResultsPublished},
+	Locked:           {Voting, Closed, ResultsPublished},
+	Voting:           {Closed, ResultsPublished},
+	Closed:           {Voting, ResultsPublished},
+	ResultsPublished: {Archived},
+}
+
+// Current is the single source of truth for the phase of an election.
+// Draft, paused, published and archived elections only move through explicit transitions, the others follow their timings.
+func Current(election models.Election, now time.Time) int {
+	if election.Paused || election.Status == Draft || election.Status == ResultsPublished || election.Status == Archived {
+		return election.Status
+	}
+
+	now = now.UTC()
+	if now.Before(election.LockingAt.UTC()) {
+		return Nomination
+	}
+	if now.Before(election.StartingAt.UTC()) {
+		return Locked
+	}
+	if now.Before(election.EndingAt.UTC()) {
+		return Voting
+	}
+	if !now.Before(election.EndingAt.UTC().Add(PublishDelay())) {
+		return ResultsPublished
+	}
+
+	return Closed
+}
+
+func CanTransition(from int, to int) bool {
+	for _, status := range transitions[from] {
+		if status == to {
+			return true
+		}
+	}
+
+	return false
+}
+
+// IsEditable reports whether the details, positions, participants and candidates of the election can still change.
+func IsEditable(status int) bool {
+	return status == Draft || status == Nomination
+}
+
+func HasEnded(status int) bool {
+	return status == Closed || status == ResultsPublished || status == Archived
+}
+
+func HasPublishedResults(status int) bool {
+	return status == ResultsPublished || status == Archived
+}
+
+func Name(status int) string {
+	return names[status]
+}
+
+// PublishDelay is how long results stay with the admins after voting ends, set by RESULTS_PUBLISH_DELAY(e.g, 24h).
+func PublishDelay() time.Duration {
+	delay, err := time.ParseDuration(os.Getenv("RESULTS_PUBLISH_DELAY"))
+	if err != nil || delay < 0 {
+		return 0
+	}
+
+	return delay
+}
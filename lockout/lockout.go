@@ -0,0 +1,88 @@
+package lockout
+
+import (
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Key of the failure counter of an account, emails are compared case-insensitively.
+func AccountKey(email string) string {
+	return "account:" + strings.ToLower(strings.TrimSpace(email))
+}
+
+// Key of the failure counter of an IP address.
+func IPKey(ip string) string {
+	return "ip:" + ip
+}
+
+// Key of the counter of verification emails asked for by an email address.
+func ResendKey(email string) string {
+	return "resend:" + strings.ToLower(strings.TrimSpace(email))
+}
+
+// Key of the counter of verification emails asked for from an IP address.
+func ResendIPKey(ip string) string {
+	return "resend-ip:" + ip
+}
+
+// MaxFailures is how many failed logins or OTPs lock an account, set by LOGIN_MAX_FAILURES.
+func MaxFailures() int {
+	failures, err := strconv.Atoi(os.Getenv("LOGIN_MAX_FAILURES"))
+	if err != nil || failures <= 0 {
+		return 5
+	}
+
+	return failures
+}
+
+// IPMaxFailures is how many failures lock out an IP address, set by LOGIN_IP_MAX_FAILURES. It is higher than MaxFailures
+// since a whole campus can share one address.
+func IPMaxFailures() int {
+	failures, err := strconv.Atoi(os.Getenv("LOGIN_IP_MAX_FAILURES"))
+	if err != nil || failures <= 0 {
+		return 50
+	}
+
+	return failures
+}
+
+// Duration is how long a lockout lasts, set by LOGIN_LOCKOUT_DURATION.
+func Duration() time.Duration {
+	duration, err := time.ParseDuration(os.Getenv("LOGIN_LOCKOUT_DURATION"))
+	if err != nil || duration <= 0 {
+		return 15 * time.Minute
+	}
+
+	return duration
+}
+
+// Window is how long failures are remembered after the last one, set by LOGIN_FAILURE_WINDOW.
+func Window() time.Duration {
+	window, err := time.ParseDuration(os.Getenv("LOGIN_FAILURE_WINDOW"))
+	if err != nil || window <= 0 {
+		return time.Hour
+	}
+
+	return window
+}
+
+// Backoff is the wait after a failure, it starts at a second once half of maxFailures is used up and doubles with every
+// failure after that, capped at the lockout duration.
+func Backoff(failures int, maxFailures int) time.Duration {
+	excess := failures - maxFailures/2
+	if excess <= 0 {
+		return 0
+	}
+
+	backoff := time.Second
+	for i := 1; i < excess && backoff < Duration(); i++ {
+		backoff *= 2
+	}
+	if backoff > Duration() {
+		return Duration()
+	}
+
+	return backoff
+}
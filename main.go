@@ -4,15 +4,19 @@ import (
 	"elect/apis"
 	"elect/controllers"
 	"elect/database"
+	"elect/directory"
+	"elect/email"
+	"elect/jwtkeys"
 	"elect/middlewares"
+	"elect/oidc"
 	"elect/services"
+	"elect/webauthn"
 	"log"
 	"net/http"
 	"os"
 
 	_ "elect/docs"
 
-	"github.com/casbin/casbin/v2"
 	"github.com/gin-contrib/static"
 
 	//"github.com/gin-gonic/contrib/secure"
@@ -40,25 +44,78 @@ func main() {
 		port = "8080"
 	}
 
-	authEnforcer, err := casbin.NewEnforcer("./model.conf", "./policy.csv")
+	log.SetFlags(log.LstdFlags | log.Lshortfile)
+
+	gin.SetMode(gin.ReleaseMode)
+
+	transport, err := email.NewMailer(email.ConfigFromEnv())
 	if err != nil {
 		panic(err)
 	}
 
-	log.SetFlags(log.LstdFlags | log.Lshortfile)
-
-	gin.SetMode(gin.ReleaseMode)
+	keySet, err := jwtkeys.FromEnv()
+	if err != nil {
+		panic(err)
+	}
 
 	//Declaring all layers
 	postgresDatabase, mux := database.NewPostgresDatabase()
-	userService := services.NewUserService(postgresDatabase)
+	policyService, err := services.NewPolicyService(postgresDatabase, "./model.conf", "./policy.csv")
+	if err != nil {
+		panic(err)
+	}
+	authEnforcer := policyService.Enforcer()
+	outboxService := services.NewOutboxService(postgresDatabase, transport)
+	userService := services.NewUserService(postgresDatabase, outboxService)
 	electionService := services.NewElectionService(postgresDatabase)
-	jwtService := services.NewJWTService("e1ect.herokuapp.com", postgresDatabase)
-	userController := controllers.NewUserController(userService, jwtService)
-	electionController := controllers.NewElectionController(electionService, jwtService)
+	lifecycleService := services.NewLifecycleService(postgresDatabase)
+	jobService := services.NewJobService(postgresDatabase, lifecycleService, outboxService, apis.PushElectionEvent)
+	reminderService := services.NewReminderService(postgresDatabase, transport)
+	otpService, err := services.NewOTPService(postgresDatabase, outboxService, services.OTPEncryptionKeyFromEnv())
+	if err != nil {
+		panic(err)
+	}
+	lockoutService := services.NewLockoutService(postgresDatabase, outboxService)
+	sessionService := services.NewSessionService(postgresDatabase)
+	apiKeyService := services.NewAPIKeyService(postgresDatabase)
+	webAuthnService := services.NewWebAuthnService(postgresDatabase, webauthn.ConfigFromEnv())
+	ssoService := services.NewSSOService(postgresDatabase, oidc.ConfigFromEnv())
+	directoryService := services.NewDirectoryService(postgresDatabase, directory.ConfigFromEnv())
+	jwtService := services.NewJWTService("e1ect.herokuapp.com", postgresDatabase, keySet)
+	userController := controllers.NewUserController(userService, otpService, lockoutService, sessionService, directoryService, jwtService)
+	electionController := controllers.NewElectionController(electionService)
+	lifecycleController := controllers.NewLifecycleController(lifecycleService)
+	reminderController := controllers.NewReminderController(reminderService)
+	outboxController := controllers.NewOutboxController(outboxService)
+	otpController := controllers.NewOTPController(otpService)
+	webAuthnController := controllers.NewWebAuthnController(webAuthnService, userService, sessionService, jwtService)
+	ssoController := controllers.NewSSOController(ssoService, userService, sessionService, jwtService)
+	sessionController := controllers.NewSessionController(sessionService)
+	apiKeyController := controllers.NewAPIKeyController(apiKeyService)
+	jwksController := controllers.NewJWKSController(jwtService)
+	directoryController := controllers.NewDirectoryController(directoryService)
+	policyController := controllers.NewPolicyController(policyService)
 	authAPI := apis.NewAuthAPI(userController)
 	userAPI := apis.NewUserAPI(userController)
 	electionAPI := apis.NewElectionAPI(electionController)
+	lifecycleAPI := apis.NewLifecycleAPI(lifecycleController)
+	reminderAPI := apis.NewReminderAPI(reminderController)
+	outboxAPI := apis.NewOutboxAPI(outboxController)
+	otpAPI := apis.NewOTPAPI(otpController)
+	webAuthnAPI := apis.NewWebAuthnAPI(webAuthnController)
+	ssoAPI := apis.NewSSOAPI(ssoController)
+	sessionAPI := apis.NewSessionAPI(sessionController)
+	apiKeyAPI := apis.NewAPIKeyAPI(apiKeyController)
+	jwksAPI := apis.NewJWKSAPI(jwksController)
+	directoryAPI := apis.NewDirectoryAPI(directoryController)
+	policyAPI := apis.NewPolicyAPI(policyController)
+
+	//Election status and job scheduler
+	jobService.StartScheduler()
+	reminderService.StartWorker()
+	outboxService.StartWorker()
+	directoryService.StartSync()
+	policyService.StartWatcher()
 
 	port = os.Getenv("PORT")
 
@@ -93,83 +150,170 @@ func main() {
 	server.Use(static.Serve("/404", static.LocalFile("./web", true)))
 
 	//Login
-	server.POST("/login", middlewares.Authorizer(jwtService, authEnforcer), authAPI.LoginHandler)
+	server.POST("/login", middlewares.Authorizer(jwtService, apiKeyService, authEnforcer), authAPI.LoginHandler)
 	//Logout
-	server.POST("/ulogout", middlewares.Authorizer(jwtService, authEnforcer), authAPI.LogoutHandler)
+	server.POST("/ulogout", middlewares.Authorizer(jwtService, apiKeyService, authEnforcer), authAPI.LogoutHandler)
 	//Logout for Super Admin
 	server.POST("/slogout", middlewares.SuperAdminLogoutMiddleware(jwtService), authAPI.LogoutHandler)
 	//Refresh
 	server.POST("/refresh", middlewares.EnsureValidity(jwtService), authAPI.RefreshHandler)
+	//Public Keys of Access Tokens
+	server.GET("/.well-known/jwks.json", jwksAPI.GetJWKSHandler)
 	//VerifyFrontEnd
 	server.GET("/verify/:token", func(cxt *gin.Context) {
 		cxt.HTML(http.StatusOK, "index.html", nil)
 	})
 	//Check Verify Token Validity
-	server.POST("/verifytoken/:token", middlewares.Authorizer(jwtService, authEnforcer), authAPI.CheckVerifyTokenValidityHandler)
+	server.POST("/verifytoken/:token", middlewares.Authorizer(jwtService, apiKeyService, authEnforcer), authAPI.CheckVerifyTokenValidityHandler)
 	//Verify Account
-	server.POST("/setpassword", middlewares.Authorizer(jwtService, authEnforcer), authAPI.VerifyHandler)
+	server.POST("/setpassword", middlewares.Authorizer(jwtService, apiKeyService, authEnforcer), authAPI.VerifyHandler)
 	//OTP Verification FrontEnd
-	//server.GET("/otp", middlewares.Authorizer(jwtService, authEnforcer), authAPI.OTPGETHandler)
+	//server.GET("/otp", middlewares.Authorizer(jwtService, apiKeyService, authEnforcer), authAPI.OTPGETHandler)
 	//OTP Verification
-	server.POST("/otp", middlewares.Authorizer(jwtService, authEnforcer), authAPI.OTPHandler)
+	server.POST("/otp", middlewares.Authorizer(jwtService, apiKeyService, authEnforcer), authAPI.OTPHandler)
+	//Passkey Login
+	server.POST("/passkey/login/begin", middlewares.Authorizer(jwtService, apiKeyService, authEnforcer), webAuthnAPI.BeginLoginHandler)
+	server.POST("/passkey/login/finish", middlewares.Authorizer(jwtService, apiKeyService, authEnforcer), webAuthnAPI.FinishLoginHandler)
+	//Single Sign-On
+	server.GET("/sso/login", middlewares.Authorizer(jwtService, apiKeyService, authEnforcer), ssoAPI.BeginLoginHandler)
+	server.GET("/sso/callback", middlewares.Authorizer(jwtService, apiKeyService, authEnforcer), ssoAPI.FinishLoginHandler)
 	//Change Password
-	server.POST("/changepassword", middlewares.Authorizer(jwtService, authEnforcer), middlewares.Authorization(jwtService), authAPI.ChangePasswordHandler)
+	server.POST("/changepassword", middlewares.Authorizer(jwtService, apiKeyService, authEnforcer), middlewares.Authorization(jwtService, sessionService), authAPI.ChangePasswordHandler)
+	//Resend Verification Email
+	server.POST("/resendverification", middlewares.Authorizer(jwtService, apiKeyService, authEnforcer), authAPI.ResendVerificationByEmailHandler)
 	//Reset Password
-	server.POST("/resetpassword", middlewares.Authorizer(jwtService, authEnforcer), authAPI.ResetPasswordHandler)
+	server.POST("/resetpassword", middlewares.Authorizer(jwtService, apiKeyService, authEnforcer), authAPI.ResetPasswordHandler)
 	//Reset Password FrontEnd
 	server.GET("/resetpassword/:token", func(cxt *gin.Context) {
 		cxt.HTML(http.StatusOK, "index.html", nil)
 	})
 	//Create Reset Token
-	server.POST("/createresettoken", middlewares.Authorizer(jwtService, authEnforcer), authAPI.CreateResetTokenHandler)
+	server.POST("/createresettoken", middlewares.Authorizer(jwtService, apiKeyService, authEnforcer), authAPI.CreateResetTokenHandler)
 	//Check Reset Token Validity
-	server.POST("/resettoken/:token", middlewares.Authorizer(jwtService, authEnforcer), authAPI.CheckResetTokenValidityHandler)
+	server.POST("/resettoken/:token", middlewares.Authorizer(jwtService, apiKeyService, authEnforcer), authAPI.CheckResetTokenValidityHandler)
 
 	apiRoutes := server.Group("/api")
 	//Register Students
-	apiRoutes.POST("/registerstudents", middlewares.MultipartMiddleware(), middlewares.Authorizer(jwtService, authEnforcer), middlewares.Authorization(jwtService), userAPI.RegisterStudentsHandler)
+	apiRoutes.POST("/registerstudents", middlewares.MultipartMiddleware(), middlewares.Authorizer(jwtService, apiKeyService, authEnforcer), middlewares.Authorization(jwtService, sessionService), userAPI.RegisterStudentsHandler)
+	//Sync Students from Directory
+	apiRoutes.POST("/directory/sync", middlewares.Authorizer(jwtService, apiKeyService, authEnforcer), middlewares.Authorization(jwtService, sessionService), directoryAPI.SyncStudentsHandler)
 	//Registered Students
-	apiRoutes.GET("/registeredstudents", middlewares.Authorizer(jwtService, authEnforcer), middlewares.Authorization(jwtService), userAPI.RegisteredStudentsHandler)
+	apiRoutes.GET("/registeredstudents", middlewares.Authorizer(jwtService, apiKeyService, authEnforcer), middlewares.Authorization(jwtService, sessionService), userAPI.RegisteredStudentsHandler)
+	//Enroll Authenticator App
+	apiRoutes.POST("/otp/enroll", middlewares.Authorizer(jwtService, apiKeyService, authEnforcer), middlewares.Authorization(jwtService, sessionService), otpAPI.EnrollAuthenticatorHandler)
+	//Confirm Authenticator App
+	apiRoutes.POST("/otp/confirm", middlewares.Authorizer(jwtService, apiKeyService, authEnforcer), middlewares.Authorization(jwtService, sessionService), otpAPI.ConfirmAuthenticatorHandler)
+	//Change OTP Method
+	apiRoutes.PUT("/otp/method", middlewares.Authorizer(jwtService, apiKeyService, authEnforcer), middlewares.Authorization(jwtService, sessionService), otpAPI.SetOTPMethodHandler)
+	//Generate Recovery Codes
+	apiRoutes.POST("/otp/recovery-codes", middlewares.Authorizer(jwtService, apiKeyService, authEnforcer), middlewares.Authorization(jwtService, sessionService), otpAPI.GenerateRecoveryCodesHandler)
+	//Add Passkey
+	apiRoutes.POST("/passkey/register/begin", middlewares.Authorizer(jwtService, apiKeyService, authEnforcer), middlewares.Authorization(jwtService, sessionService), webAuthnAPI.BeginRegistrationHandler)
+	apiRoutes.POST("/passkey/register/finish", middlewares.Authorizer(jwtService, apiKeyService, authEnforcer), middlewares.Authorization(jwtService, sessionService), webAuthnAPI.FinishRegistrationHandler)
+	//Passkeys
+	apiRoutes.GET("/passkeys", middlewares.Authorizer(jwtService, apiKeyService, authEnforcer), middlewares.Authorization(jwtService, sessionService), webAuthnAPI.GetPasskeysHandler)
+	//Delete Passkey
+	apiRoutes.DELETE("/passkey/:id", middlewares.Authorizer(jwtService, apiKeyService, authEnforcer), middlewares.Authorization(jwtService, sessionService), webAuthnAPI.DeletePasskeyHandler)
+	//Get Sessions
+	apiRoutes.GET("/sessions", middlewares.Authorizer(jwtService, apiKeyService, authEnforcer), middlewares.Authorization(jwtService, sessionService), sessionAPI.GetSessionsHandler)
+	//Revoke Session
+	apiRoutes.DELETE("/session/:id", middlewares.Authorizer(jwtService, apiKeyService, authEnforcer), middlewares.Authorization(jwtService, sessionService), sessionAPI.RevokeSessionHandler)
+	//Create API Key
+	apiRoutes.POST("/apikeys", middlewares.Authorizer(jwtService, apiKeyService, authEnforcer), middlewares.Authorization(jwtService, sessionService), apiKeyAPI.CreateAPIKeyHandler)
+	//Get API Keys
+	apiRoutes.GET("/apikeys", middlewares.Authorizer(jwtService, apiKeyService, authEnforcer), middlewares.Authorization(jwtService, sessionService), apiKeyAPI.GetAPIKeysHandler)
+	//Revoke API Key
+	apiRoutes.DELETE("/apikey/:id", middlewares.Authorizer(jwtService, apiKeyService, authEnforcer), middlewares.Authorization(jwtService, sessionService), apiKeyAPI.RevokeAPIKeyHandler)
+	//Get Policies
+	apiRoutes.GET("/policies", middlewares.Authorizer(jwtService, apiKeyService, authEnforcer), middlewares.Authorization(jwtService, sessionService), policyAPI.GetPoliciesHandler)
+	//Add Policy
+	apiRoutes.POST("/policies", middlewares.Authorizer(jwtService, apiKeyService, authEnforcer), middlewares.Authorization(jwtService, sessionService), policyAPI.AddPolicyHandler)
+	//Edit Policy
+	apiRoutes.PUT("/policy/:id", middlewares.Authorizer(jwtService, apiKeyService, authEnforcer), middlewares.Authorization(jwtService, sessionService), policyAPI.EditPolicyHandler)
+	//Delete Policy
+	apiRoutes.DELETE("/policy/:id", middlewares.Authorizer(jwtService, apiKeyService, authEnforcer), middlewares.Authorization(jwtService, sessionService), policyAPI.DeletePolicyHandler)
+	//Reload Policies
+	apiRoutes.POST("/policies/reload", middlewares.Authorizer(jwtService, apiKeyService, authEnforcer), middlewares.Authorization(jwtService, sessionService), policyAPI.ReloadPoliciesHandler)
+	//Check Policy
+	apiRoutes.POST("/policies/check", middlewares.Authorizer(jwtService, apiKeyService, authEnforcer), middlewares.Authorization(jwtService, sessionService), policyAPI.CheckPolicyHandler)
 	//Delete Registered Student
-	apiRoutes.DELETE("/registeredstudent/:id", middlewares.Authorizer(jwtService, authEnforcer), middlewares.Authorization(jwtService), userAPI.DeleteRegisteredStudentHandler)
+	apiRoutes.DELETE("/registeredstudent/:id", middlewares.Authorizer(jwtService, apiKeyService, authEnforcer), middlewares.Authorization(jwtService, sessionService), userAPI.DeleteRegisteredStudentHandler)
+	//Resend Verification Email to Registered Student
+	apiRoutes.POST("/registeredstudent/:id/resend-verification", middlewares.Authorizer(jwtService, apiKeyService, authEnforcer), middlewares.Authorization(jwtService, sessionService), userAPI.ResendVerificationHandler)
+	//Log Registered Student out Everywhere
+	apiRoutes.DELETE("/registeredstudent/:id/sessions", middlewares.Authorizer(jwtService, apiKeyService, authEnforcer), middlewares.Authorization(jwtService, sessionService), sessionAPI.RevokeStudentSessionsHandler)
+	//Unlock Registered Student's Account
+	apiRoutes.POST("/registeredstudent/:id/unlock", middlewares.Authorizer(jwtService, apiKeyService, authEnforcer), middlewares.Authorization(jwtService, sessionService), userAPI.UnlockAccountHandler)
+	//Regenerate Recovery Codes for Registered Student
+	apiRoutes.POST("/registeredstudent/:id/recovery-codes", middlewares.Authorizer(jwtService, apiKeyService, authEnforcer), middlewares.Authorization(jwtService, sessionService), otpAPI.RegenerateStudentRecoveryCodesHandler)
 
 	//Get Elections
-	apiRoutes.GET("/elections", middlewares.Authorizer(jwtService, authEnforcer), middlewares.Authorization(jwtService), electionAPI.GetElectionsHandler)
+	apiRoutes.GET("/elections", middlewares.Authorizer(jwtService, apiKeyService, authEnforcer), middlewares.Authorization(jwtService, sessionService), electionAPI.GetElectionsHandler)
 	//Get Election
-	apiRoutes.GET("/election/:id", middlewares.Authorizer(jwtService, authEnforcer), middlewares.Authorization(jwtService), electionAPI.GetElectionHandler)
+	apiRoutes.GET("/election/:id", middlewares.Authorizer(jwtService, apiKeyService, authEnforcer), middlewares.Authorization(jwtService, sessionService), electionAPI.GetElectionHandler)
 	//Create Election
-	apiRoutes.POST("/election", middlewares.Authorizer(jwtService, authEnforcer), middlewares.Authorization(jwtService), electionAPI.CreateElectionHandler)
+	apiRoutes.POST("/election", middlewares.Authorizer(jwtService, apiKeyService, authEnforcer), middlewares.Authorization(jwtService, sessionService), electionAPI.CreateElectionHandler)
 	//Edit Election
-	apiRoutes.PUT("/election", middlewares.Authorizer(jwtService, authEnforcer), middlewares.Authorization(jwtService), electionAPI.EditElectionHandler)
+	apiRoutes.PUT("/election", middlewares.Authorizer(jwtService, apiKeyService, authEnforcer), middlewares.Authorization(jwtService, sessionService), electionAPI.EditElectionHandler)
 	//Delete Election
-	apiRoutes.DELETE("/election/:id", middlewares.Authorizer(jwtService, authEnforcer), middlewares.Authorization(jwtService), electionAPI.DeleteElectionHandler)
+	apiRoutes.DELETE("/election/:id", middlewares.Authorizer(jwtService, apiKeyService, authEnforcer), middlewares.Authorization(jwtService, sessionService), electionAPI.DeleteElectionHandler)
+	//Open Draft Election
+	apiRoutes.POST("/election/:id/open", middlewares.Authorizer(jwtService, apiKeyService, authEnforcer), middlewares.Authorization(jwtService, sessionService), lifecycleAPI.OpenElectionHandler)
+	//Pause Election
+	apiRoutes.POST("/election/:id/pause", middlewares.Authorizer(jwtService, apiKeyService, authEnforcer), middlewares.Authorization(jwtService, sessionService), lifecycleAPI.PauseElectionHandler)
+	//Resume Election
+	apiRoutes.POST("/election/:id/resume", middlewares.Authorizer(jwtService, apiKeyService, authEnforcer), middlewares.Authorization(jwtService, sessionService), lifecycleAPI.ResumeElectionHandler)
+	//Extend Election
+	apiRoutes.POST("/election/:id/extend", middlewares.Authorizer(jwtService, apiKeyService, authEnforcer), middlewares.Authorization(jwtService, sessionService), lifecycleAPI.ExtendElectionHandler)
+	//Publish Results Early
+	apiRoutes.POST("/election/:id/publish", middlewares.Authorizer(jwtService, apiKeyService, authEnforcer), middlewares.Authorization(jwtService, sessionService), lifecycleAPI.PublishResultsHandler)
+	//Archive Election
+	apiRoutes.POST("/election/:id/archive", middlewares.Authorizer(jwtService, apiKeyService, authEnforcer), middlewares.Authorization(jwtService, sessionService), lifecycleAPI.ArchiveElectionHandler)
+	//Schedule Reminder
+	apiRoutes.POST("/election/:id/reminders", middlewares.Authorizer(jwtService, apiKeyService, authEnforcer), middlewares.Authorization(jwtService, sessionService), reminderAPI.ScheduleReminderHandler)
+	//Reminders
+	apiRoutes.GET("/election/:id/reminders", middlewares.Authorizer(jwtService, apiKeyService, authEnforcer), middlewares.Authorization(jwtService, sessionService), reminderAPI.GetRemindersHandler)
+	//Failed Emails
+	apiRoutes.GET("/emails/failed", middlewares.Authorizer(jwtService, apiKeyService, authEnforcer), middlewares.Authorization(jwtService, sessionService), outboxAPI.GetFailedEmailsHandler)
+	//Resend Emails
+	apiRoutes.POST("/emails/resend", middlewares.Authorizer(jwtService, apiKeyService, authEnforcer), middlewares.Authorization(jwtService, sessionService), outboxAPI.ResendEmailsHandler)
+	//Add Position
+	apiRoutes.POST("/position", middlewares.Authorizer(jwtService, apiKeyService, authEnforcer), middlewares.Authorization(jwtService, sessionService), electionAPI.AddPositionHandler)
+	//Delete Position
+	apiRoutes.DELETE("/position/:id", middlewares.Authorizer(jwtService, apiKeyService, authEnforcer), middlewares.Authorization(jwtService, sessionService), electionAPI.DeletePositionHandler)
 	//Add Participants
-	apiRoutes.POST("/participants/:id", middlewares.MultipartMiddleware(), middlewares.Authorizer(jwtService, authEnforcer), middlewares.Authorization(jwtService), electionAPI.AddParticipantsHandler)
+	apiRoutes.POST("/participants/:id", middlewares.MultipartMiddleware(), middlewares.Authorizer(jwtService, apiKeyService, authEnforcer), middlewares.Authorization(jwtService, sessionService), electionAPI.AddParticipantsHandler)
 	//Delete Participant
-	apiRoutes.DELETE("/participant", middlewares.Authorizer(jwtService, authEnforcer), middlewares.Authorization(jwtService), electionAPI.DeleteParticipantHandler)
+	apiRoutes.DELETE("/participant", middlewares.Authorizer(jwtService, apiKeyService, authEnforcer), middlewares.Authorization(jwtService, sessionService), electionAPI.DeleteParticipantHandler)
 	//Enroll Candidate
-	apiRoutes.POST("/candidate", middlewares.MultipartMiddleware(), middlewares.Authorizer(jwtService, authEnforcer), middlewares.Authorization(jwtService), electionAPI.EnrollCandidateHandler)
+	apiRoutes.POST("/candidate", middlewares.MultipartMiddleware(), middlewares.Authorizer(jwtService, apiKeyService, authEnforcer), middlewares.Authorization(jwtService, sessionService), electionAPI.EnrollCandidateHandler)
 	//Approve Candidate
-	apiRoutes.POST("/candidate/approve/:id", middlewares.Authorizer(jwtService, authEnforcer), middlewares.Authorization(jwtService), electionAPI.ApproveCandidateHandler)
+	apiRoutes.POST("/candidate/approve/:id", middlewares.Authorizer(jwtService, apiKeyService, authEnforcer), middlewares.Authorization(jwtService, sessionService), electionAPI.ApproveCandidateHandler)
 	//Unapprove Candidate
-	apiRoutes.POST("/candidate/unapprove/:id", middlewares.Authorizer(jwtService, authEnforcer), middlewares.Authorization(jwtService), electionAPI.UnapproveCandidateHandler)
+	apiRoutes.POST("/candidate/unapprove/:id", middlewares.Authorizer(jwtService, apiKeyService, authEnforcer), middlewares.Authorization(jwtService, sessionService), electionAPI.UnapproveCandidateHandler)
 	//Cast Vote
-	apiRoutes.POST("/vote", middlewares.Authorizer(jwtService, authEnforcer), middlewares.Authorization(jwtService), electionAPI.CastVoteHandler)
+	apiRoutes.POST("/vote", middlewares.Authorizer(jwtService, apiKeyService, authEnforcer), middlewares.Authorization(jwtService, sessionService), electionAPI.CastVoteHandler)
 	//Get Election Results
-	apiRoutes.GET("/results/:id", middlewares.Authorizer(jwtService, authEnforcer), middlewares.Authorization(jwtService), electionAPI.GetElectionResultsHandler)
+	apiRoutes.GET("/results/:id", middlewares.Authorizer(jwtService, apiKeyService, authEnforcer), middlewares.Authorization(jwtService, sessionService), electionAPI.GetElectionResultsHandler)
+	//Recount Election Votes
+	apiRoutes.POST("/results/:id/recount", middlewares.Authorizer(jwtService, apiKeyService, authEnforcer), middlewares.Authorization(jwtService, sessionService), electionAPI.RecountVotesHandler)
+	//Election Ballot Receipts
+	apiRoutes.GET("/results/:id/receipts", middlewares.Authorizer(jwtService, apiKeyService, authEnforcer), middlewares.OptionalAuthorization(jwtService, sessionService), electionAPI.GetElectionReceiptsHandler)
+	//Verify Election Audit Log
+	apiRoutes.GET("/audit/:id", middlewares.Authorizer(jwtService, apiKeyService, authEnforcer), middlewares.Authorization(jwtService, sessionService), electionAPI.VerifyAuditChainHandler)
 
 	//Elections Update WebSocket
-	apiRoutes.GET("/ws/election" /*middlewares.Authorizer(jwtService, authEnforcer), middlewares.Authorization(jwtService),*/, electionAPI.ElectionUpdatesHandler)
+	apiRoutes.GET("/ws/election" /*middlewares.Authorizer(jwtService, apiKeyService, authEnforcer), middlewares.Authorization(jwtService, sessionService),*/, electionAPI.ElectionUpdatesHandler)
 
 	//Swagger Endpoint Integration
-	server.GET("/docs", middlewares.Authorizer(jwtService, authEnforcer), func(cxt *gin.Context) {
+	server.GET("/docs", middlewares.Authorizer(jwtService, apiKeyService, authEnforcer), func(cxt *gin.Context) {
 		cxt.Redirect(http.StatusPermanentRedirect, "/swagger/index.html")
 	})
 	url := ginSwagger.URL("/swagger/doc.json") // The url pointing to API definition
-	server.GET("/swagger/*any", middlewares.Authorizer(jwtService, authEnforcer), ginSwagger.WrapHandler(swaggerFiles.Handler, url))
+	server.GET("/swagger/*any", middlewares.Authorizer(jwtService, apiKeyService, authEnforcer), ginSwagger.WrapHandler(swaggerFiles.Handler, url))
 
 	//QOR Admin Endpoint Integration
-	server.Any("/superadmin/*resources", middlewares.SuperAdminMiddleware(jwtService), gin.WrapH(mux))
+	server.Any("/superadmin/*resources", middlewares.SuperAdminMiddleware(jwtService, sessionService), gin.WrapH(mux))
 
 	server.NoRoute(func(cxt *gin.Context) {
 		cxt.Redirect(http.StatusPermanentRedirect, "/404")
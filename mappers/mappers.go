@@ -1,8 +1,12 @@
 package mappers
 
 import (
+	"elect/directory"
 	"elect/dto"
+	"elect/jobs"
+	"elect/lifecycle"
 	"elect/models"
+	"encoding/json"
 	"strings"
 	"time"
 
@@ -11,9 +15,10 @@ import (
 
 func ToAuthUserDTO(user models.User) dto.AuthUserDTO {
 	return dto.AuthUserDTO{
-		UserID:   user.UserID.String(),
-		Email:    user.Email,
-		Password: user.Password,
+		UserID:    user.UserID.String(),
+		Email:     user.Email,
+		Password:  user.Password,
+		Directory: user.DirectoryDN != "",
 	}
 }
 
@@ -45,6 +50,17 @@ func ToUserFromRegisterStudentDTO(registerStudentDTO dto.RegisterStudentDTO) mod
 	}
 }
 
+func ToUserFromDirectoryEntry(entry directory.Entry, registeredBy string) models.User {
+	return models.User{
+		Email:        entry.Email,
+		FirstName:    entry.FirstName,
+		LastName:     entry.LastName,
+		RegNumber:    entry.RegNumber,
+		RegisteredBy: registeredBy,
+		DirectoryDN:  entry.DN,
+	}
+}
+
 func ToGeneralStudentDTOFromUser(user models.User) dto.GeneralStudentDTO {
 	return dto.GeneralStudentDTO{
 		UserID:         user.UserID.String(),
@@ -79,15 +95,46 @@ func ToElectionFromCreateElectionDTO(electionDTO dto.CreateElectionDTO) models.E
 		lTime, _ = time.Parse("Mon Jan 02 2006 15:04:05 GMT-0700", electionDTO.LockingAt)
 	}
 
+	status := lifecycle.Nomination
+	if electionDTO.Draft {
+		status = lifecycle.Draft
+	}
+
 	return models.Election{
 		Title:          electionDTO.Title,
 		StartingAt:     sTime,
 		EndingAt:       eTime,
 		LockingAt:      lTime,
 		GenderSpecific: electionDTO.GenderSpecific,
+		VotingMethod:   electionDTO.VotingMethod,
+		Status:         status,
 	}
 }
 
+func ToEndingAtFromExtendElectionDTO(extendElectionDTO dto.ExtendElectionDTO) time.Time {
+	var eTime time.Time
+	if strings.Contains(extendElectionDTO.EndingAt, "(") {
+		eT := strings.SplitAfter(extendElectionDTO.EndingAt, "(")[0]
+		eTime, _ = time.Parse("Mon Jan 02 2006 15:04:05 GMT-0700", eT[:len(eT)-2])
+	} else {
+		eTime, _ = time.Parse("Mon Jan 02 2006 15:04:05 GMT-0700", extendElectionDTO.EndingAt)
+	}
+
+	return eTime.UTC()
+}
+
+func ToSendAtFromScheduleReminderDTO(scheduleReminderDTO dto.ScheduleReminderDTO) time.Time {
+	var sTime time.Time
+	if strings.Contains(scheduleReminderDTO.SendAt, "(") {
+		sT := strings.SplitAfter(scheduleReminderDTO.SendAt, "(")[0]
+		sTime, _ = time.Parse("Mon Jan 02 2006 15:04:05 GMT-0700", sT[:len(sT)-2])
+	} else {
+		sTime, _ = time.Parse("Mon Jan 02 2006 15:04:05 GMT-0700", scheduleReminderDTO.SendAt)
+	}
+
+	return sTime.UTC()
+}
+
 func ToElectionFromEditElectionDTO(editElectionDTO dto.EditElectionDTO) models.Election {
 	var sTime, eTime, lTime time.Time
 	if editElectionDTO.StartingAt != "" {
@@ -138,13 +185,31 @@ func ToGeneralElectionDTOFromElection(election models.Election, voted bool) dto.
 		EndingAt:       election.EndingAt.String(),
 		LockingAt:      election.LockingAt.String(),
 		GenderSpecific: election.GenderSpecific,
+		VotingMethod:   election.VotingMethod,
+		Status:         lifecycle.Current(election, time.Now()),
+		Paused:         election.Paused,
 		Voted:          voted,
 	}
 }
 
+func ToPositionFromCreatePositionDTO(createPositionDTO dto.CreatePositionDTO) models.Position {
+	return models.Position{
+		ElectionID: uuid.FromStringOrNil(createPositionDTO.ElectionId),
+		Title:      createPositionDTO.Title,
+	}
+}
+
+func ToPositionDTOFromPosition(position models.Position) dto.PositionDTO {
+	return dto.PositionDTO{
+		PositionID: position.PositionID.String(),
+		Title:      position.Title,
+	}
+}
+
 func ToCandidateFromCreateCandidateDTO(createCandidateDTO dto.CreateCandidateDTO) models.Candidate {
 	return models.Candidate{
 		ElectionID:     uuid.FromStringOrNil(createCandidateDTO.ElectionId),
+		PositionID:     uuid.FromStringOrNil(createCandidateDTO.PositionId),
 		Sex:            createCandidateDTO.Sex,
 		DisplayPicture: createCandidateDTO.DisplayPicture,
 		Poster:         createCandidateDTO.Poster,
@@ -168,6 +233,7 @@ func ToGeneralCandidateDTOFromCandidate(candidate models.Candidate, user models.
 		CandidateID:    candidate.CandidateID.String(),
 		UserID:         candidate.UserID.String(),
 		ElectionID:     candidate.ElectionID.String(),
+		PositionID:     positionIDString(candidate.PositionID),
 		RegisterNo:     user.RegNumber,
 		FirstName:      user.FirstName,
 		LastName:       user.LastName,
@@ -184,6 +250,7 @@ func ToGeneralCandidateDTOFromCandidateForStudents(candidate models.Candidate, u
 		CandidateID:    candidate.CandidateID.String(),
 		UserID:         candidate.UserID.String(),
 		ElectionID:     candidate.ElectionID.String(),
+		PositionID:     positionIDString(candidate.PositionID),
 		RegisterNo:     user.RegNumber,
 		FirstName:      user.FirstName,
 		LastName:       user.LastName,
@@ -195,7 +262,7 @@ func ToGeneralCandidateDTOFromCandidateForStudents(candidate models.Candidate, u
 	}
 }
 
-func ToGeneralElectionDTOForAdmins(election models.Election, generalParticipantDTOs []dto.GeneralParticipantDTO, generalCandidateDTOs []dto.GeneralCandidateDTO) dto.GeneralElectionDTO {
+func ToGeneralElectionDTOForAdmins(election models.Election, positionDTOs []dto.PositionDTO, generalParticipantDTOs []dto.GeneralParticipantDTO, generalCandidateDTOs []dto.GeneralCandidateDTO) dto.GeneralElectionDTO {
 	return dto.GeneralElectionDTO{
 		ElectionID:     election.ElectionID.String(),
 		Title:          election.Title,
@@ -203,12 +270,16 @@ func ToGeneralElectionDTOForAdmins(election models.Election, generalParticipantD
 		EndingAt:       election.EndingAt.String(),
 		LockingAt:      election.LockingAt.String(),
 		GenderSpecific: election.GenderSpecific,
+		VotingMethod:   election.VotingMethod,
+		Status:         lifecycle.Current(election, time.Now()),
+		Paused:         election.Paused,
+		Positions:      positionDTOs,
 		Participants:   generalParticipantDTOs,
 		Candidates:     generalCandidateDTOs,
 	}
 }
 
-func ToGeneralElectionDTOForStudents(election models.Election, generalCandidateDTOs []dto.GeneralCandidateDTO, generalCandidateDTO dto.GeneralCandidateDTO, voted bool, blacklisted bool) dto.GeneralElectionDTO {
+func ToGeneralElectionDTOForStudents(election models.Election, positionDTOs []dto.PositionDTO, generalCandidateDTOs []dto.GeneralCandidateDTO, generalCandidateDTO dto.GeneralCandidateDTO, voted bool, blacklisted bool) dto.GeneralElectionDTO {
 	return dto.GeneralElectionDTO{
 		ElectionID:     election.ElectionID.String(),
 		Title:          election.Title,
@@ -216,14 +287,18 @@ func ToGeneralElectionDTOForStudents(election models.Election, generalCandidateD
 		EndingAt:       election.EndingAt.String(),
 		LockingAt:      election.LockingAt.String(),
 		GenderSpecific: election.GenderSpecific,
+		VotingMethod:   election.VotingMethod,
+		Status:         lifecycle.Current(election, time.Now()),
+		Paused:         election.Paused,
 		Voted:          voted,
 		Blacklisted:    blacklisted,
+		Positions:      positionDTOs,
 		Candidates:     generalCandidateDTOs,
 		Candidate:      &generalCandidateDTO,
 	}
 }
 
-func ToGeneralElectionResultsDTOForAdmins(election models.Election, totalParticipants int, candidateResultsDTOs []dto.CandidateResultsDTO, mCandidateResultsDTOs []dto.CandidateResultsDTO, fCandidateResultsDTOs []dto.CandidateResultsDTO, oCandidateResultsDTOs []dto.CandidateResultsDTO, total int) dto.GeneralElectionResultsDTO {
+func ToGeneralElectionResultsDTOForAdmins(election models.Election, totalParticipants int, candidateResultsDTOs []dto.CandidateResultsDTO, mCandidateResultsDTOs []dto.CandidateResultsDTO, fCandidateResultsDTOs []dto.CandidateResultsDTO, oCandidateResultsDTOs []dto.CandidateResultsDTO, positionResultsDTOs []dto.PositionResultsDTO, runoffResultsDTOs []dto.RunoffResultsDTO, total int) dto.GeneralElectionResultsDTO {
 	return dto.GeneralElectionResultsDTO{
 		ElectionID:        election.ElectionID.String(),
 		Title:             election.Title,
@@ -231,16 +306,19 @@ func ToGeneralElectionResultsDTOForAdmins(election models.Election, totalPartici
 		EndingAt:          election.EndingAt.String(),
 		LockingAt:         election.LockingAt.String(),
 		GenderSpecific:    election.GenderSpecific,
+		VotingMethod:      election.VotingMethod,
 		TotalVotes:        total,
 		TotalParticipants: totalParticipants,
 		CandidateResults:  candidateResultsDTOs,
 		MCandidateResults: mCandidateResultsDTOs,
 		FCandidateResults: fCandidateResultsDTOs,
 		OCandidateResults: oCandidateResultsDTOs,
+		PositionResults:   positionResultsDTOs,
+		Runoffs:           runoffResultsDTOs,
 	}
 }
 
-func ToGeneralElectionResultsDTOForStudents(election models.Election, totalParticipants int, candidateResultsDTOs []dto.CandidateResultsDTO, mCandidateResultsDTOs []dto.CandidateResultsDTO, fCandidateResultsDTOs []dto.CandidateResultsDTO, oCandidateResultsDTOs []dto.CandidateResultsDTO, total int) dto.GeneralElectionResultsDTO {
+func ToGeneralElectionResultsDTOForStudents(election models.Election, totalParticipants int, candidateResultsDTOs []dto.CandidateResultsDTO, mCandidateResultsDTOs []dto.CandidateResultsDTO, fCandidateResultsDTOs []dto.CandidateResultsDTO, oCandidateResultsDTOs []dto.CandidateResultsDTO, positionResultsDTOs []dto.PositionResultsDTO, runoffResultsDTOs []dto.RunoffResultsDTO, total int) dto.GeneralElectionResultsDTO {
 	return dto.GeneralElectionResultsDTO{
 		ElectionID:        election.ElectionID.String(),
 		Title:             election.Title,
@@ -248,12 +326,15 @@ func ToGeneralElectionResultsDTOForStudents(election models.Election, totalParti
 		EndingAt:          election.EndingAt.String(),
 		LockingAt:         election.LockingAt.String(),
 		GenderSpecific:    election.GenderSpecific,
+		VotingMethod:      election.VotingMethod,
 		TotalVotes:        total,
 		TotalParticipants: totalParticipants,
 		CandidateResults:  candidateResultsDTOs,
 		MCandidateResults: mCandidateResultsDTOs,
 		FCandidateResults: fCandidateResultsDTOs,
 		OCandidateResults: oCandidateResultsDTOs,
+		PositionResults:   positionResultsDTOs,
+		Runoffs:           runoffResultsDTOs,
 	}
 }
 
@@ -264,7 +345,220 @@ func ToCandidateResultsDTOFromCandidate(candidate models.Candidate, name string)
 		Name:           name,
 		Sex:            candidate.Sex,
 		ElectionID:     candidate.ElectionID.String(),
+		PositionID:     positionIDString(candidate.PositionID),
 		DisplayPicture: candidate.DisplayPicture,
 		Votes:          candidate.Votes,
 	}
 }
+
+func ToPositionResultsDTO(position models.Position, candidateResultsDTOs []dto.CandidateResultsDTO, mCandidateResultsDTOs []dto.CandidateResultsDTO, fCandidateResultsDTOs []dto.CandidateResultsDTO, oCandidateResultsDTOs []dto.CandidateResultsDTO, winners []dto.CandidateResultsDTO, runoffResultsDTOs []dto.RunoffResultsDTO, total int) dto.PositionResultsDTO {
+	return dto.PositionResultsDTO{
+		PositionID:        position.PositionID.String(),
+		Title:             position.Title,
+		TotalVotes:        total,
+		CandidateResults:  candidateResultsDTOs,
+		MCandidateResults: mCandidateResultsDTOs,
+		FCandidateResults: fCandidateResultsDTOs,
+		OCandidateResults: oCandidateResultsDTOs,
+		Winners:           winners,
+		Runoffs:           runoffResultsDTOs,
+	}
+}
+
+func ToRunoffResultsDTO(group string, runoffRoundDTOs []dto.RunoffRoundDTO, winners []dto.CandidateResultsDTO) dto.RunoffResultsDTO {
+	return dto.RunoffResultsDTO{
+		Group:   group,
+		Rounds:  runoffRoundDTOs,
+		Winners: winners,
+	}
+}
+
+func ToElectionReceiptsDTO(election models.Election, receipts []string) dto.ElectionReceiptsDTO {
+	return dto.ElectionReceiptsDTO{
+		ElectionID:    election.ElectionID.String(),
+		Title:         election.Title,
+		EndingAt:      election.EndingAt.String(),
+		TotalReceipts: len(receipts),
+		Receipts:      receipts,
+	}
+}
+
+func ToReminderDTO(reminder models.Reminder, deliveries []models.ReminderDelivery) dto.ReminderDTO {
+	reminderDTO := dto.ReminderDTO{
+		ReminderID: reminder.ReminderID.String(),
+		SendAt:     reminder.SendAt.String(),
+		Status:     jobs.Name(reminder.Status),
+		Deliveries: []dto.ReminderDeliveryDTO{},
+	}
+
+	for _, delivery := range deliveries {
+		if delivery.ReminderID != reminder.ReminderID {
+			continue
+		}
+
+		deliveryDTO := dto.ReminderDeliveryDTO{
+			Email:  delivery.Email,
+			Status: jobs.Name(delivery.Status),
+			Error:  delivery.Error,
+		}
+		if delivery.SentAt != nil {
+			deliveryDTO.SentAt = delivery.SentAt.String()
+		}
+
+		switch delivery.Status {
+		case jobs.Done:
+			reminderDTO.Sent++
+		case jobs.Failed:
+			reminderDTO.Failed++
+		case jobs.Skipped:
+			reminderDTO.Skipped++
+		}
+
+		reminderDTO.Total++
+		reminderDTO.Deliveries = append(reminderDTO.Deliveries, deliveryDTO)
+	}
+
+	return reminderDTO
+}
+
+func ToOutboxEmailDTO(outbox models.EmailOutbox) dto.OutboxEmailDTO {
+	return dto.OutboxEmailDTO{
+		EmailOutboxID: outbox.EmailOutboxID.String(),
+		To:            outbox.Recipient,
+		Subject:       outbox.Subject,
+		Attempts:      outbox.Attempts,
+		LastError:     outbox.LastError,
+		CreatedAt:     outbox.CreatedAt.String(),
+		UpdatedAt:     outbox.UpdatedAt.String(),
+	}
+}
+
+func ToPasskeyDTO(credential models.WebAuthnCredential) dto.PasskeyDTO {
+	passkeyDTO := dto.PasskeyDTO{
+		ID:        credential.ID,
+		Name:      credential.Name,
+		CreatedAt: credential.CreatedAt.String(),
+	}
+	if credential.LastUsedAt != nil {
+		passkeyDTO.LastUsedAt = credential.LastUsedAt.String()
+	}
+
+	return passkeyDTO
+}
+
+func ToElectionEventDTO(event string, election models.Election) dto.ElectionEventDTO {
+	return dto.ElectionEventDTO{
+		Event:      event,
+		ElectionID: election.ElectionID.String(),
+		Status:     lifecycle.Current(election, time.Now()),
+	}
+}
+
+func ToAuditEventDTO(event models.AuditEvent) dto.AuditEventDTO {
+	return dto.AuditEventDTO{
+		AuditEventID: event.AuditEventID,
+		Actor:        event.Actor,
+		Action:       event.Action,
+		Target:       event.Target,
+		Diff:         event.Diff,
+		PrevHash:     event.PrevHash,
+		Hash:         event.Hash,
+		CreatedAt:    event.CreatedAt.String(),
+	}
+}
+
+func ToAuditChainDTO(electionId string, auditEventDTOs []dto.AuditEventDTO, brokenAt uint) dto.AuditChainDTO {
+	return dto.AuditChainDTO{
+		ElectionID:  electionId,
+		Valid:       brokenAt == 0,
+		TotalEvents: len(auditEventDTOs),
+		BrokenAt:    brokenAt,
+		Events:      auditEventDTOs,
+	}
+}
+
+func ToBallotFromBallotChoiceDTOs(electionId string, ballotChoiceDTOs []dto.BallotChoiceDTO) (models.Ballot, error) {
+	choices, err := json.Marshal(ballotChoiceDTOs)
+	if err != nil {
+		return models.Ballot{}, err
+	}
+
+	return models.Ballot{
+		ElectionID: uuid.FromStringOrNil(electionId),
+		Choices:    string(choices),
+	}, nil
+}
+
+func ToBallotChoiceDTOsFromBallot(ballot models.Ballot) ([]dto.BallotChoiceDTO, error) {
+	var ballotChoiceDTOs []dto.BallotChoiceDTO
+	err := json.Unmarshal([]byte(ballot.Choices), &ballotChoiceDTOs)
+	if err != nil {
+		return nil, err
+	}
+
+	return ballotChoiceDTOs, nil
+}
+
+// Private functions
+func positionIDString(positionId uuid.UUID) string {
+	if positionId == uuid.Nil {
+		return ""
+	}
+
+	return positionId.String()
+}
+
+func ToSessionDTO(session models.Session, current bool) dto.SessionDTO {
+	return dto.SessionDTO{
+		ID:         session.SessionID.String(),
+		Device:     session.Device,
+		IPAddress:  session.IPAddress,
+		UserAgent:  session.UserAgent,
+		CreatedAt:  session.CreatedAt.String(),
+		LastUsedAt: session.LastUsedAt.String(),
+		Current:    current,
+	}
+}
+
+func ToAPIKeyDTO(apiKey models.APIKey) (dto.APIKeyDTO, error) {
+	var scopes []dto.APIKeyScopeDTO
+	err := json.Unmarshal([]byte(apiKey.Scopes), &scopes)
+	if err != nil {
+		return dto.APIKeyDTO{}, err
+	}
+
+	apiKeyDTO := dto.APIKeyDTO{
+		ID:        apiKey.APIKeyID.String(),
+		Name:      apiKey.Name,
+		Prefix:    apiKey.Prefix,
+		Scopes:    scopes,
+		ExpiresAt: apiKey.ExpiresAt.String(),
+		CreatedAt: apiKey.CreatedAt.String(),
+	}
+	if apiKey.LastUsedAt != nil {
+		apiKeyDTO.LastUsedAt = apiKey.LastUsedAt.String()
+	}
+
+	return apiKeyDTO, nil
+}
+
+func ToPolicyDTO(rule models.CasbinRule) dto.PolicyDTO {
+	return dto.PolicyDTO{
+		ID:     rule.ID,
+		Role:   rule.V0,
+		Path:   rule.V1,
+		Method: rule.V2,
+		Effect: rule.V3,
+	}
+}
+
+func ToCasbinRuleFromPolicyDTO(policyDTO dto.PolicyDTO) models.CasbinRule {
+	return models.CasbinRule{
+		ID:    policyDTO.ID,
+		PType: "p",
+		V0:    policyDTO.Role,
+		V1:    policyDTO.Path,
+		V2:    policyDTO.Method,
+		V3:    policyDTO.Effect,
+	}
+}
@@ -4,26 +4,20 @@ import (
 	"elect/dto"
 	"elect/services"
 	"net/http"
-	"os"
 
 	"github.com/gin-gonic/gin"
-	"github.com/gorilla/securecookie"
 )
 
-func Authorization(jwtService services.JWTService) gin.HandlerFunc {
+// Authorization makes sure the access token of the request is valid and its session is still active, so a logout or a
+// revoked session shuts the token out right away instead of when it expires.
+func Authorization(jwtService services.JWTService, sessionService services.SessionService) gin.HandlerFunc {
 	return func(cxt *gin.Context) {
-		var s = securecookie.New([]byte(os.Getenv("COOKIE_HASH_SECRET")), nil)
-
-		cookie, err := cxt.Cookie("token")
-		if err != nil {
-			cxt.AbortWithStatusJSON(http.StatusBadRequest, dto.Response{
-				Message: "Invalid Request",
-			})
+		// Requests made with an API key were already checked by the Authorizer
+		if cxt.GetBool(apiKeyKey) {
 			return
 		}
 
-		value := make(map[string]string)
-		err = s.Decode("tokens", cookie, &value)
+		token, refresh, err := requestTokens(cxt)
 		if err != nil {
 			cxt.AbortWithStatusJSON(http.StatusBadRequest, dto.Response{
 				Message: "Invalid Request",
@@ -31,7 +25,7 @@ func Authorization(jwtService services.JWTService) gin.HandlerFunc {
 			return
 		}
 
-		accessToken, err := jwtService.ValidateAccessToken(value["access_token"])
+		accessToken, err := jwtService.ValidateAccessToken(token)
 		if err != nil && err.Error() != "Token is expired" {
 			cxt.AbortWithStatusJSON(http.StatusUnauthorized, dto.Response{
 				Message: "Session Invalid",
@@ -39,7 +33,15 @@ func Authorization(jwtService services.JWTService) gin.HandlerFunc {
 			return
 		}
 		if !accessToken.Valid {
-			refreshToken, err := jwtService.ValidateRefreshToken(value["refresh_token"])
+			// Bearer tokens can't be refreshed, the caller has to get a new one
+			if refresh == "" {
+				cxt.AbortWithStatusJSON(http.StatusUnauthorized, dto.Response{
+					Message: "Session Expired",
+				})
+				return
+			}
+
+			refreshToken, err := jwtService.ValidateRefreshToken(refresh)
 			if err != nil && err.Error() != "Token is expired" {
 				cxt.AbortWithStatusJSON(http.StatusUnauthorized, dto.Response{
 					Message: "Session Invalid",
@@ -60,6 +62,27 @@ func Authorization(jwtService services.JWTService) gin.HandlerFunc {
 			return
 		}
 
+		if sessionService.CheckSession(cxt.GetString(sessionIDKey)) != nil {
+			cxt.AbortWithStatusJSON(http.StatusUnauthorized, dto.Response{
+				Message: "Session Invalid",
+			})
+			return
+		}
+
 		return
 	}
 }
+
+// OptionalAuthorization guards public routes, requests without a token get through as anonymous. A request that does
+// carry one gets the checks of Authorization, so an expired token has to be refreshed first.
+func OptionalAuthorization(jwtService services.JWTService, sessionService services.SessionService) gin.HandlerFunc {
+	authorization := Authorization(jwtService, sessionService)
+
+	return func(cxt *gin.Context) {
+		if _, _, err := requestTokens(cxt); err == http.ErrNoCookie {
+			return
+		}
+
+		authorization(cxt)
+	}
+}
@@ -0,0 +1,71 @@
+package middlewares
+
+import (
+	"errors"
+	"os"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/securecookie"
+)
+
+// Keys the Authorizer stores the identity of the caller under.
+const (
+	userIDKey    = "userId"
+	roleKey      = "role"
+	sessionIDKey = "sessionId"
+	apiKeyKey    = "apiKey"
+)
+
+// requestTokens returns the access token of an "Authorization: Bearer" header, or the access and refresh token of the
+// token cookie. Bearer callers have no refresh token, http.ErrNoCookie means the request carries neither.
+func requestTokens(cxt *gin.Context) (string, string, error) {
+	header := cxt.GetHeader("Authorization")
+	if header != "" {
+		if !strings.HasPrefix(header, "Bearer ") || strings.TrimSpace(strings.TrimPrefix(header, "Bearer ")) == "" {
+			return "", "", errors.New("Invalid Authorization header!")
+		}
+
+		return strings.TrimSpace(strings.TrimPrefix(header, "Bearer ")), "", nil
+	}
+
+	cookie, err := cxt.Cookie("token")
+	if err != nil {
+		return "", "", err
+	}
+
+	var s = securecookie.New([]byte(os.Getenv("COOKIE_HASH_SECRET")), nil)
+	value := make(map[string]string)
+	err = s.Decode("tokens", cookie, &value)
+	if err != nil {
+		return "", "", err
+	}
+
+	return value["access_token"], value["refresh_token"], nil
+}
+
+func setIdentity(cxt *gin.Context, userId string, role int, sessionId string) {
+	cxt.Set(userIDKey, userId)
+	cxt.Set(roleKey, role)
+	cxt.Set(sessionIDKey, sessionId)
+}
+
+// GetIdentity returns the user ID and role the Authorizer resolved for the request.
+func GetIdentity(cxt *gin.Context) (string, int, error) {
+	userId := cxt.GetString(userIDKey)
+	if userId == "" {
+		return "", 0, errors.New("Not logged in!")
+	}
+
+	return userId, cxt.GetInt(roleKey), nil
+}
+
+// GetSessionID returns the session of the request, only tokens issued at a login carry one.
+func GetSessionID(cxt *gin.Context) (string, error) {
+	sessionId := cxt.GetString(sessionIDKey)
+	if sessionId == "" {
+		return "", errors.New("Invalid Session!")
+	}
+
+	return sessionId, nil
+}
@@ -7,13 +7,14 @@ import (
 	"net/http"
 	"os"
 	"strconv"
+	"strings"
 
 	"github.com/casbin/casbin/v2"
 	"github.com/gin-gonic/gin"
 	"github.com/gorilla/securecookie"
 )
 
-func Authorizer(jwtService services.JWTService, e *casbin.Enforcer) gin.HandlerFunc {
+func Authorizer(jwtService services.JWTService, apiKeyService services.APIKeyService, e *casbin.SyncedEnforcer) gin.HandlerFunc {
 	return func(cxt *gin.Context) {
 		role := ""
 
@@ -43,7 +44,7 @@ func Authorizer(jwtService services.JWTService, e *casbin.Enforcer) gin.HandlerF
 		}
 
 		if role == "" {
-			cookie, err = cxt.Cookie("token")
+			accessToken, refresh, err := requestTokens(cxt)
 			if err == http.ErrNoCookie {
 				role = strconv.Itoa(roles.Anonymous)
 			}
@@ -53,28 +54,49 @@ func Authorizer(jwtService services.JWTService, e *casbin.Enforcer) gin.HandlerF
 				})
 				return
 			}
-		}
 
-		if role == "" {
-			value := make(map[string]string)
-			err = s.Decode("tokens", cookie, &value)
+			// API keys have no session, they stand in for a login as long as the request is in one of their scopes
+			if err == nil && strings.HasPrefix(accessToken, services.APIKeyPrefix) {
+				userId, roleInt, err := apiKeyService.Authenticate(accessToken, cxt.Request.URL.Path, cxt.Request.Method)
+				if err != nil {
+					if err.Error() == "Out of scope!" {
+						cxt.AbortWithStatusJSON(http.StatusForbidden, dto.Response{
+							Message: "Forbidden",
+						})
+						return
+					}
 
-			roleInt, err := jwtService.GetRole(value["access_token"])
-			if err != nil {
-				if err.Error() == "Token is expired" {
-					cxt.AbortWithStatusJSON(http.StatusNotAcceptable, dto.Response{
-						Message: "Refresh Required",
+					cxt.AbortWithStatusJSON(http.StatusUnauthorized, dto.Response{
+						Message: err.Error(),
 					})
 					return
-				} else {
+				}
+
+				setIdentity(cxt, userId, roleInt, "")
+				cxt.Set(apiKeyKey, true)
+				role = strconv.Itoa(roleInt)
+			}
+
+			// An expired token only names the caller while the cookie can refresh it, Authorization then asks for the
+			// refresh. Routes without Authorization get no identity from a token that can't be renewed.
+			if err == nil && role == "" {
+				userId, roleInt, sessionId, err := jwtService.GetIdentity(accessToken, refresh)
+				if err != nil && err.Error() == "Token is expired" {
+					cxt.AbortWithStatusJSON(http.StatusUnauthorized, dto.Response{
+						Message: "Session Expired",
+					})
+					return
+				}
+				if err != nil {
 					cxt.AbortWithStatusJSON(http.StatusBadRequest, dto.Response{
 						Message: "Invalid Request",
 					})
 					return
 				}
-			}
 
-			role = strconv.Itoa(roleInt)
+				setIdentity(cxt, userId, roleInt, sessionId)
+				role = strconv.Itoa(roleInt)
+			}
 		}
 
 		res, err := e.Enforce(role, cxt.Request.URL.Path, cxt.Request.Method)
@@ -0,0 +1,180 @@
+package middlewares
+
+import (
+	"elect/database"
+	"elect/dto"
+	"elect/jwtkeys"
+	"elect/services"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/casbin/casbin/v2"
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt"
+	"github.com/gorilla/securecookie"
+)
+
+// identityDatabase knows the one user the refresh tokens of the tests are signed for.
+type identityDatabase struct {
+	database.Database
+	user dto.AuthUserDTO
+}
+
+func (db *identityDatabase) FindUserForAuth(email string) (dto.AuthUserDTO, error) {
+	if email != db.user.Email {
+		return dto.AuthUserDTO{}, errors.New("Invalid user!")
+	}
+	return db.user, nil
+}
+
+// activeSessions treats every session in it as active.
+type activeSessions struct {
+	services.SessionService
+	active map[string]bool
+}
+
+func (sessions *activeSessions) CheckSession(sessionId string) error {
+	if !sessions.active[sessionId] {
+		return errors.New("Invalid Session!")
+	}
+	return nil
+}
+
+type identityTest struct {
+	server     *gin.Engine
+	jwtService services.JWTService
+	keySet     *jwtkeys.KeySet
+	user       dto.AuthUserDTO
+	sessions   *activeSessions
+}
+
+func newIdentityTest(t *testing.T) *identityTest {
+	os.Setenv("ACCESS_TOKEN_SECRET", "access-secret")
+	os.Setenv("REFRESH_TOKEN_SECRET", "refresh-secret")
+	os.Setenv("COOKIE_HASH_SECRET", "cookie-hash-secret")
+	os.Setenv("JWT_SIGNING_ALG", "")
+
+	keySet, err := jwtkeys.FromEnv()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	user := dto.AuthUserDTO{UserID: "5b3f0e6a-8c1d-4a8e-9b7e-0c2f1d3e4a5b", Email: "student@elect.test", Password: "hash"}
+	jwtService := services.NewJWTService("elect.test", &identityDatabase{user: user}, keySet)
+	sessions := &activeSessions{active: map[string]bool{"session": true}}
+
+	enforcer, err := casbin.NewSyncedEnforcer("../model.conf", "../policy.csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	gin.SetMode(gin.TestMode)
+	server := gin.New()
+	ok := func(cxt *gin.Context) {
+		userId, _, err := GetIdentity(cxt)
+		if err != nil {
+			userId = "anonymous"
+		}
+		cxt.String(http.StatusOK, userId)
+	}
+	server.POST("/ulogout", Authorizer(jwtService, nil, enforcer), ok)
+	server.GET("/api/elections", Authorizer(jwtService, nil, enforcer), Authorization(jwtService, sessions), ok)
+	server.GET("/api/results/:id/receipts", Authorizer(jwtService, nil, enforcer), OptionalAuthorization(jwtService, sessions), ok)
+
+	return &identityTest{server: server, jwtService: jwtService, keySet: keySet, user: user, sessions: sessions}
+}
+
+func (test *identityTest) accessToken(t *testing.T, sessionId string, expiresIn time.Duration) string {
+	token, err := test.keySet.Sign(jwt.MapClaims{
+		"userid": test.user.UserID,
+		"email":  test.user.Email,
+		"role":   0,
+		"sid":    sessionId,
+		"exp":    time.Now().Add(expiresIn).Unix(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return token
+}
+
+func (test *identityTest) request(t *testing.T, method string, path string, accessToken string, refreshToken string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, nil)
+	if accessToken != "" && refreshToken == "" {
+		req.Header.Set("Authorization", "Bearer "+accessToken)
+	}
+	if refreshToken != "" {
+		encoded, err := securecookie.New([]byte(os.Getenv("COOKIE_HASH_SECRET")), nil).Encode("tokens", map[string]string{
+			"access_token":  accessToken,
+			"refresh_token": refreshToken,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.AddCookie(&http.Cookie{Name: "token", Value: encoded})
+	}
+
+	res := httptest.NewRecorder()
+	test.server.ServeHTTP(res, req)
+	return res
+}
+
+func TestExpiredTokenIsNoIdentityWithoutRefresh(t *testing.T) {
+	test := newIdentityTest(t)
+	expired := test.accessToken(t, "session", -time.Minute)
+
+	// Bearer tokens can't be refreshed, so routes without Authorization don't get a caller out of them either
+	if res := test.request(t, "POST", "/ulogout", expired, ""); res.Code != http.StatusUnauthorized {
+		t.Fatalf("expired Bearer token: got %d, want 401", res.Code)
+	}
+
+	refresh := test.jwtService.GenerateRefreshToken(test.user, "session")
+	if res := test.request(t, "POST", "/ulogout", expired, refresh); res.Code != http.StatusOK || res.Body.String() != test.user.UserID {
+		t.Fatalf("expired cookie with a refresh token: got %d %s, want 200", res.Code, res.Body.String())
+	}
+	if res := test.request(t, "GET", "/api/elections", expired, refresh); res.Code != http.StatusNotAcceptable {
+		t.Fatalf("expired cookie on a route with Authorization: got %d, want 406", res.Code)
+	}
+
+	other := test.jwtService.GenerateRefreshToken(test.user, "other-session")
+	if res := test.request(t, "POST", "/ulogout", expired, other); res.Code != http.StatusBadRequest {
+		t.Fatalf("refresh token of another session: got %d, want 400", res.Code)
+	}
+}
+
+func TestReceiptsStayPublic(t *testing.T) {
+	test := newIdentityTest(t)
+
+	if res := test.request(t, "GET", "/api/results/1/receipts", "", ""); res.Code != http.StatusOK || res.Body.String() != "anonymous" {
+		t.Fatalf("anonymous: got %d %s, want 200", res.Code, res.Body.String())
+	}
+
+	expired := test.accessToken(t, "session", -time.Minute)
+	refresh := test.jwtService.GenerateRefreshToken(test.user, "session")
+	if res := test.request(t, "GET", "/api/results/1/receipts", expired, refresh); res.Code != http.StatusNotAcceptable {
+		t.Fatalf("expired cookie: got %d, want 406", res.Code)
+	}
+
+	valid := test.accessToken(t, "session", time.Minute)
+	if res := test.request(t, "GET", "/api/results/1/receipts", valid, ""); res.Code != http.StatusOK {
+		t.Fatalf("valid token: got %d, want 200", res.Code)
+	}
+}
+
+func TestRevokedSessionIsShutOutForEveryRole(t *testing.T) {
+	test := newIdentityTest(t)
+	valid := test.accessToken(t, "session", time.Minute)
+
+	if res := test.request(t, "GET", "/api/elections", valid, ""); res.Code != http.StatusOK {
+		t.Fatalf("active session: got %d, want 200", res.Code)
+	}
+
+	test.sessions.active["session"] = false
+	if res := test.request(t, "GET", "/api/elections", valid, ""); res.Code != http.StatusUnauthorized {
+		t.Fatalf("student token of a revoked session: got %d, want 401", res.Code)
+	}
+}
@@ -1,59 +1,51 @@
 package middlewares
 
 import (
+	"elect/database"
 	"elect/services"
 	"net/http"
-	"os"
 
 	"github.com/gin-gonic/gin"
-	"github.com/gorilla/securecookie"
 )
 
-func SuperAdminMiddleware(jwtService services.JWTService) gin.HandlerFunc {
+// SuperAdminMiddleware guards the admin panel, the super admin's session has to be active as well as their tokens.
+func SuperAdminMiddleware(jwtService services.JWTService, sessionService services.SessionService) gin.HandlerFunc {
 	return func(cxt *gin.Context) {
-		var s = securecookie.New([]byte(os.Getenv("COOKIE_HASH_SECRET")), nil)
-
-		cookie, err := cxt.Cookie("token")
+		token, refresh, err := requestTokens(cxt)
 		if err != nil {
 			cxt.Redirect(http.StatusTemporaryRedirect, "https://e1ect.herokuapp.com/logout")
+			cxt.Abort()
 			return
 		}
 
-		value := make(map[string]string)
-		err = s.Decode("tokens", cookie, &value)
-		if err != nil {
+		// An expired access token is fine while the refresh token of its session can still renew it
+		userId, role, sessionId, err := jwtService.GetIdentity(token, refresh)
+		if err != nil && err.Error() == "Token is expired" {
 			cxt.Redirect(http.StatusTemporaryRedirect, "https://e1ect.herokuapp.com/logout")
+			cxt.Abort()
 			return
 		}
-
-		_, role, err := jwtService.GetUserIDAndRole(value["access_token"])
 		if err != nil {
 			cxt.Redirect(http.StatusTemporaryRedirect, "https://e1ect.herokuapp.com/")
+			cxt.Abort()
 			return
 		}
 
 		if role != 2 {
 			cxt.Redirect(http.StatusTemporaryRedirect, "https://e1ect.herokuapp.com/")
+			cxt.Abort()
 			return
 		}
 
-		accessToken, err := jwtService.ValidateAccessToken(value["access_token"])
-		if err != nil && err.Error() != "Token is expired" {
+		err = sessionService.CheckSession(sessionId)
+		if err != nil {
 			cxt.Redirect(http.StatusTemporaryRedirect, "https://e1ect.herokuapp.com/logout")
+			cxt.Abort()
 			return
 		}
-		if !accessToken.Valid {
-			refreshToken, err := jwtService.ValidateRefreshToken(value["refresh_token"])
-			if err != nil && err.Error() != "Token is expired" {
-				cxt.Redirect(http.StatusTemporaryRedirect, "https://e1ect.herokuapp.com/logout")
-				return
-			}
-
-			if !refreshToken.Valid {
-				cxt.Redirect(http.StatusTemporaryRedirect, "https://e1ect.herokuapp.com/logout")
-				return
-			}
-		}
+
+		setIdentity(cxt, userId, role, sessionId)
+		cxt.Request = cxt.Request.WithContext(database.WithAuditActor(cxt.Request.Context(), userId))
 
 		return
 	}
@@ -15,17 +15,22 @@ type Base struct {
 }
 
 type User struct {
-	UserID             uuid.UUID `gorm:"primary_key; type:uuid; default:uuid_generate_v4()"`
-	FirstName          string    `gorm:"not null; type: varchar(64)"`
-	LastName           string    `gorm:"not null; type: varchar(64)"`
-	RegNumber          string    `gorm:"type: varchar(12); default:null; unique"`
-	Email              string    `validate:"email,optional" gorm:"not null; unique; type: varchar(384)"`
-	Password           string    `gorm:"type: varchar(64); default:null"`
-	Role               int       `gorm:"not null;"`
-	VerifyToken        string    `gorm:"type: varchar(64); default:null"`
-	RegisteredBy       string    `gorm:"default:null"`
-	Verified           bool      `gorm:"default:false"`
-	ActiveRefreshToken string    `gorm:"default:null"`
+	UserID            uuid.UUID `gorm:"primary_key; type:uuid; default:uuid_generate_v4()"`
+	FirstName         string    `gorm:"not null; type: varchar(64)"`
+	LastName          string    `gorm:"not null; type: varchar(64)"`
+	RegNumber         string    `gorm:"type: varchar(12); default:null; unique"`
+	Email             string    `validate:"email,optional" gorm:"not null; unique; type: varchar(384)"`
+	Password          string    `gorm:"type: varchar(64); default:null"`
+	Role              int       `gorm:"not null;"`
+	RegisteredBy      string    `gorm:"default:null"`
+	Verified          bool      `gorm:"default:false"`
+	OTPMethod         int       `gorm:"not null; default:0"`
+	OTPSecret         string    `gorm:"type:text; default:null"`
+	TOTPSecret        string    `gorm:"type:text; default:null"`
+	PendingTOTPSecret string    `gorm:"type:text; default:null"`
+	LastEmailOTPStep  int64     `gorm:"not null; default:0"`
+	LastTOTPStep      int64     `gorm:"not null; default:0"`
+	DirectoryDN       string    `gorm:"type: varchar(512); default:null"`
 	Base
 }
 
@@ -51,6 +56,48 @@ func (user *User) AfterDelete(db *gorm.DB) error {
 		return err
 	}
 
+	err = db.Model(&RecoveryCode{}).Where("user_id = ?", user.UserID.String()).Delete(&RecoveryCode{}).Error
+	if err != nil {
+		log.Println("gorm:")
+		log.Println(err)
+		return err
+	}
+
+	err = db.Model(&WebAuthnCredential{}).Where("user_id = ?", user.UserID.String()).Delete(&WebAuthnCredential{}).Error
+	if err != nil {
+		log.Println("gorm:")
+		log.Println(err)
+		return err
+	}
+
+	err = db.Where("session_id IN (?)", db.Model(&Session{}).Select("session_id").Where("user_id = ?", user.UserID.String()).QueryExpr()).Delete(&RefreshToken{}).Error
+	if err != nil {
+		log.Println("gorm:")
+		log.Println(err)
+		return err
+	}
+
+	err = db.Model(&Session{}).Where("user_id = ?", user.UserID.String()).Delete(&Session{}).Error
+	if err != nil {
+		log.Println("gorm:")
+		log.Println(err)
+		return err
+	}
+
+	err = db.Model(&APIKey{}).Where("user_id = ?", user.UserID.String()).Delete(&APIKey{}).Error
+	if err != nil {
+		log.Println("gorm:")
+		log.Println(err)
+		return err
+	}
+
+	err = db.Model(&OIDCIdentity{}).Where("user_id = ?", user.UserID.String()).Delete(&OIDCIdentity{}).Error
+	if err != nil {
+		log.Println("gorm:")
+		log.Println(err)
+		return err
+	}
+
 	return nil
 }
 
@@ -61,6 +108,9 @@ type Election struct {
 	EndingAt       time.Time `gorm:"not null"`
 	LockingAt      time.Time `gorm:"not null"`
 	GenderSpecific bool      `gorm:"not null; default:false"`
+	VotingMethod   int       `gorm:"not null; default:0"`
+	Status         int       `gorm:"not null; default:0"`
+	Paused         bool      `gorm:"not null; default:false"`
 	CreatedBy      string    `gorm:"not null"`
 	Base
 }
@@ -80,9 +130,45 @@ func (election *Election) AfterDelete(db *gorm.DB) error {
 		return err
 	}
 
+	err = db.Model(&Position{}).Where("election_id = ?", election.ElectionID.String()).Delete(&Position{}).Error
+	if err != nil {
+		log.Println("gorm:")
+		log.Println(err)
+		return err
+	}
+
+	err = db.Model(&Ballot{}).Where("election_id = ?", election.ElectionID.String()).Delete(&Ballot{}).Error
+	if err != nil {
+		log.Println("gorm:")
+		log.Println(err)
+		return err
+	}
+
+	err = db.Model(&Job{}).Where("election_id = ?", election.ElectionID.String()).Delete(&Job{}).Error
+	if err != nil {
+		log.Println("gorm:")
+		log.Println(err)
+		return err
+	}
+
+	err = db.Model(&Reminder{}).Where("election_id = ?", election.ElectionID.String()).Delete(&Reminder{}).Error
+	if err != nil {
+		log.Println("gorm:")
+		log.Println(err)
+		return err
+	}
+
 	return nil
 }
 
+type Position struct {
+	PositionID uuid.UUID `gorm:"primary_key; type:uuid; default:uuid_generate_v4()"`
+	Election   Election  `gorm:"foreignKey: ElectionID; constraint:OnDelete:CASCADE;"`
+	ElectionID uuid.UUID `gorm:"not null"`
+	Title      string    `gorm:"not null"`
+	Base
+}
+
 type Participant struct {
 	ParticipantID uuid.UUID `gorm:"primary_key; type:uuid; default:uuid_generate_v4()"`
 	User          User      `gorm:"foreignKey: UserID; constraint:OnDelete:CASCADE;"`
@@ -105,6 +191,7 @@ type Candidate struct {
 	UserID         uuid.UUID `gorm:"uniqueIndex:idx_user_election"`
 	Election       Election  `gorm:"foreignKey: ElectionID; constraint:OnDelete:CASCADE; unique"`
 	ElectionID     uuid.UUID `gorm:"uniqueIndex:idx_user_election"`
+	PositionID     uuid.UUID `gorm:"type:uuid; default:null"`
 	Sex            int       `gorm:"not null"`
 	DisplayPicture string    `gorm:"not null"`
 	Poster         string    `gorm:"not null"`
@@ -114,6 +201,212 @@ type Candidate struct {
 	Base
 }
 
+// Ballot is deliberately not linked to the voter, choices hold the JSON encoded preferences per position.
+// CreatedAt is truncated to the hour so it can't be matched against the participant's UpdatedAt.
+// Receipt is the hash commitment handed to the voter, published once the election has ended.
+type Ballot struct {
+	BallotID   uuid.UUID `gorm:"primary_key; type:uuid; default:uuid_generate_v4()"`
+	ElectionID uuid.UUID `gorm:"not null; index"`
+	Choices    string    `gorm:"type:text; not null"`
+	Receipt    string    `gorm:"type: varchar(64); unique; default:null"`
+	CreatedAt  time.Time
+}
+
+// AuditEvent rows are append-only, each one stores the hash of the previous event of the same election.
+// Events that don't belong to an election are chained together with a null ElectionID.
+type AuditEvent struct {
+	AuditEventID uint      `gorm:"primary_key"`
+	ElectionID   uuid.UUID `gorm:"type:uuid; index; default:null"`
+	Actor        string    `gorm:"not null"`
+	Action       string    `gorm:"not null; type: varchar(64)"`
+	Target       string    `gorm:"not null"`
+	Diff         string    `gorm:"type:text; not null"`
+	PrevHash     string    `gorm:"type: varchar(64); default:null"`
+	Hash         string    `gorm:"type: varchar(64); not null; unique"`
+	CreatedAt    time.Time `gorm:"not null"`
+}
+
+// Job is a persisted unit of background work, Status and Kind take the values of the jobs package.
+type Job struct {
+	JobID       uuid.UUID  `gorm:"primary_key; type:uuid; default:uuid_generate_v4()"`
+	ElectionID  uuid.UUID  `gorm:"not null; unique_index:idx_job_election_kind"`
+	Kind        string     `gorm:"not null; type: varchar(64); unique_index:idx_job_election_kind"`
+	RunAt       time.Time  `gorm:"not null; index"`
+	Status      int        `gorm:"not null; default:0"`
+	Attempts    int        `gorm:"not null; default:0"`
+	LockedUntil *time.Time `gorm:"default:null"`
+	LastError   string     `gorm:"type:text; default:null"`
+	Base
+}
+
+type Reminder struct {
+	ReminderID  uuid.UUID  `gorm:"primary_key; type:uuid; default:uuid_generate_v4()"`
+	ElectionID  uuid.UUID  `gorm:"not null; index"`
+	SendAt      time.Time  `gorm:"not null; index"`
+	Status      int        `gorm:"not null; default:0"`
+	LockedUntil *time.Time `gorm:"default:null"`
+	CreatedBy   string     `gorm:"not null"`
+	Base
+}
+
+type ReminderDelivery struct {
+	ReminderDeliveryID uuid.UUID  `gorm:"primary_key; type:uuid; default:uuid_generate_v4()"`
+	ReminderID         uuid.UUID  `gorm:"not null; unique_index:idx_delivery_reminder_participant"`
+	ParticipantID      uuid.UUID  `gorm:"not null; unique_index:idx_delivery_reminder_participant"`
+	Email              string     `gorm:"not null; type: varchar(384)"`
+	Status             int        `gorm:"not null; default:0"`
+	Error              string     `gorm:"type:text; default:null"`
+	SentAt             *time.Time `gorm:"default:null"`
+	Base
+}
+
+type EmailOutbox struct {
+	EmailOutboxID  uuid.UUID  `gorm:"primary_key; type:uuid; default:uuid_generate_v4()"`
+	Recipient      string     `gorm:"not null; type: varchar(384); index"`
+	Subject        string     `gorm:"not null"`
+	Body           string     `gorm:"not null; type:text"`
+	Status         int        `gorm:"not null; default:0; index"`
+	Attempts       int        `gorm:"not null; default:0"`
+	NextAttemptAt  time.Time  `gorm:"not null; index"`
+	LockedUntil    *time.Time `gorm:"default:null"`
+	ExpiresAt      *time.Time `gorm:"default:null"`
+	LastError      string     `gorm:"type:text; default:null"`
+	SentAt         *time.Time `gorm:"default:null"`
+	IdempotencyKey *string    `gorm:"type: varchar(200); default:null; unique_index"`
+	Base
+}
+
+type VerifyToken struct {
+	gorm.Model
+	UserID    uuid.UUID  `gorm:"not null; index"`
+	TokenHash string     `gorm:"not null; unique; type: varchar(64)"`
+	ExpiresAt time.Time  `gorm:"not null"`
+	UsedAt    *time.Time `gorm:"default:null"`
+}
+
+type RecoveryCode struct {
+	gorm.Model
+	UserID   uuid.UUID  `gorm:"not null; index"`
+	CodeHash string     `gorm:"not null; type: varchar(60)"`
+	UsedAt   *time.Time `gorm:"default:null"`
+}
+
+type WebAuthnCredential struct {
+	gorm.Model
+	UserID          uuid.UUID  `gorm:"not null; index"`
+	Name            string     `gorm:"not null; type: varchar(64)"`
+	CredentialID    string     `gorm:"not null; unique_index"`
+	PublicKey       []byte     `gorm:"not null"`
+	AttestationType string     `gorm:"default:null"`
+	AAGUID          []byte     `gorm:"default:null"`
+	SignCount       int64      `gorm:"not null; default:0"`
+	LastUsedAt      *time.Time `gorm:"default:null"`
+}
+
+// WebAuthnChallenge is the challenge of a passkey registration or login until the authenticator answers it, it is
+// deleted on first use so an answer can't be replayed. Session holds the JSON encoded webauthn.SessionData.
+type WebAuthnChallenge struct {
+	ChallengeID uuid.UUID `gorm:"primary_key; type:uuid"`
+	UserID      uuid.UUID `gorm:"not null; index"`
+	Session     string    `gorm:"type:text; not null"`
+	ExpiresAt   time.Time `gorm:"not null; index"`
+	CreatedAt   time.Time
+}
+
+// Session is one logged in device, the refresh token in its cookie is stored hashed.
+type Session struct {
+	SessionID        uuid.UUID  `gorm:"primary_key; type:uuid"`
+	UserID           uuid.UUID  `gorm:"not null; index"`
+	Device           string     `gorm:"type: varchar(64)"`
+	IPAddress        string     `gorm:"type: varchar(64)"`
+	UserAgent        string     `gorm:"type: varchar(512)"`
+	RefreshTokenHash string     `gorm:"not null; type: varchar(64)"`
+	LastUsedAt       time.Time  `gorm:"not null"`
+	ExpiresAt        time.Time  `gorm:"not null"`
+	RevokedAt        *time.Time `gorm:"default:null"`
+	CreatedAt        time.Time
+	UpdatedAt        time.Time
+}
+
+// RefreshToken is one refresh token of a session, the session is its family. Rotating a token records it as the
+// parent of the new one, so a rotated token that shows up again can be recognised.
+type RefreshToken struct {
+	gorm.Model
+	SessionID uuid.UUID  `gorm:"not null; index"`
+	ParentID  *uint      `gorm:"default:null"`
+	TokenHash string     `gorm:"not null; unique; type: varchar(64)"`
+	RotatedAt *time.Time `gorm:"default:null"`
+}
+
+// APIKey lets an admin call the API from scripts without logging in, only for the paths and methods in Scopes(JSON
+// encoded). The key itself is stored hashed, Prefix is kept so the owner can tell their keys apart.
+type APIKey struct {
+	APIKeyID   uuid.UUID  `gorm:"primary_key; type:uuid"`
+	UserID     uuid.UUID  `gorm:"not null; index"`
+	Name       string     `gorm:"not null; type: varchar(64)"`
+	Prefix     string     `gorm:"not null; type: varchar(16)"`
+	KeyHash    string     `gorm:"not null; unique; type: varchar(64)"`
+	Scopes     string     `gorm:"type:text; not null"`
+	ExpiresAt  time.Time  `gorm:"not null"`
+	LastUsedAt *time.Time `gorm:"default:null"`
+	RevokedAt  *time.Time `gorm:"default:null"`
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+}
+
+// OIDCIdentity links an account at the single sign-on provider to a user, so later logins don't depend on the email or
+// register number it was first matched by.
+type OIDCIdentity struct {
+	OIDCIdentityID uint      `gorm:"primary_key"`
+	UserID         uuid.UUID `gorm:"not null; index"`
+	Issuer         string    `gorm:"not null; unique_index:idx_oidc_identity"`
+	Subject        string    `gorm:"not null; unique_index:idx_oidc_identity"`
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+}
+
+// CasbinRule is a rule of the authorization policy, in the table layout of casbin's gorm-adapter. PType is always "p",
+// V0 to V3 are the role, path, method and effect like the lines of policy.csv.
+type CasbinRule struct {
+	ID    uint   `gorm:"primary_key"`
+	PType string `gorm:"column:ptype; not null; type: varchar(100); unique_index:idx_casbin_rule"`
+	V0    string `gorm:"not null; type: varchar(255); unique_index:idx_casbin_rule"`
+	V1    string `gorm:"not null; type: varchar(255); unique_index:idx_casbin_rule"`
+	V2    string `gorm:"not null; type: varchar(255); unique_index:idx_casbin_rule"`
+	V3    string `gorm:"not null; type: varchar(255); unique_index:idx_casbin_rule"`
+	V4    string `gorm:"not null; type: varchar(255); unique_index:idx_casbin_rule"`
+	V5    string `gorm:"not null; type: varchar(255); unique_index:idx_casbin_rule"`
+}
+
+func (CasbinRule) TableName() string {
+	return "casbin_rule"
+}
+
+// PolicySeed is a rule of policy.csv that has been added to casbin_rule. A default rule is added once, so rules added
+// to policy.csv reach running deployments while the ones super admins edited or deleted stay that way.
+type PolicySeed struct {
+	PolicySeedID uint   `gorm:"primary_key"`
+	PType        string `gorm:"column:ptype; not null; type: varchar(100); unique_index:idx_policy_seed"`
+	V0           string `gorm:"not null; type: varchar(255); unique_index:idx_policy_seed"`
+	V1           string `gorm:"not null; type: varchar(255); unique_index:idx_policy_seed"`
+	V2           string `gorm:"not null; type: varchar(255); unique_index:idx_policy_seed"`
+	V3           string `gorm:"not null; type: varchar(255); unique_index:idx_policy_seed"`
+	V4           string `gorm:"not null; type: varchar(255); unique_index:idx_policy_seed"`
+	V5           string `gorm:"not null; type: varchar(255); unique_index:idx_policy_seed"`
+	CreatedAt    time.Time
+}
+
+// LoginThrottle counts the failed logins and OTPs of an account or an IP address.
+type LoginThrottle struct {
+	Key           string     `gorm:"primary_key; type: varchar(400)"`
+	Failures      int        `gorm:"not null; default:0"`
+	LastFailureAt time.Time  `gorm:"not null"`
+	BlockedUntil  time.Time  `gorm:"not null"`
+	LockedUntil   *time.Time `gorm:"default:null"`
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+}
+
 type ResetToken struct {
 	gorm.Model
 	Email     string    `validate:"email,optional" gorm:"not null; type: varchar(384)"`
@@ -0,0 +1,382 @@
+package oidc
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+)
+
+// Config is the client registered at the identity provider. Providers name their claims differently, EmailClaim and
+// RegNumberClaim pick the ones that hold the email and register number, RegNumberClaim is empty when there is none.
+type Config struct {
+	Issuer         string
+	ClientID       string
+	ClientSecret   string
+	RedirectURL    string
+	Scopes         []string
+	EmailClaim     string
+	RegNumberClaim string
+}
+
+// ConfigFromEnv reads OIDC_ISSUER, OIDC_CLIENT_ID, OIDC_CLIENT_SECRET, OIDC_REDIRECT_URL, OIDC_SCOPES, OIDC_EMAIL_CLAIM
+// and OIDC_REG_NUMBER_CLAIM. Single sign-on stays off until the issuer and client ID are set.
+func ConfigFromEnv() Config {
+	config := Config{
+		Issuer:         strings.TrimSuffix(os.Getenv("OIDC_ISSUER"), "/"),
+		ClientID:       os.Getenv("OIDC_CLIENT_ID"),
+		ClientSecret:   os.Getenv("OIDC_CLIENT_SECRET"),
+		RedirectURL:    os.Getenv("OIDC_REDIRECT_URL"),
+		Scopes:         strings.Fields(os.Getenv("OIDC_SCOPES")),
+		EmailClaim:     os.Getenv("OIDC_EMAIL_CLAIM"),
+		RegNumberClaim: os.Getenv("OIDC_REG_NUMBER_CLAIM"),
+	}
+	if config.RedirectURL == "" {
+		config.RedirectURL = "https://e1ect.herokuapp.com/sso/callback"
+	}
+	if len(config.Scopes) == 0 {
+		config.Scopes = []string{"openid", "email", "profile"}
+	}
+	if config.EmailClaim == "" {
+		config.EmailClaim = "email"
+	}
+
+	return config
+}
+
+func (config Config) Enabled() bool {
+	return config.Issuer != "" && config.ClientID != ""
+}
+
+// SessionData is kept in the sso cookie between sending the user to the provider and their return.
+type SessionData struct {
+	State        string
+	Nonce        string
+	CodeVerifier string
+}
+
+// Identity is what the ID token says about the user, after the claim mapping.
+type Identity struct {
+	Subject       string
+	Email         string
+	EmailVerified bool
+	RegNumber     string
+}
+
+type metadata struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+type tokenResponse struct {
+	IDToken          string `json:"id_token"`
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+}
+
+// Provider runs the authorization code flow with PKCE. The discovery document and signing keys are fetched on first
+// use, so ELECT starts even while the provider is unreachable.
+type Provider struct {
+	config Config
+	client *http.Client
+
+	mutex         sync.Mutex
+	metadata      *metadata
+	keys          map[string]interface{}
+	keysFetchedAt time.Time
+}
+
+func New(config Config) *Provider {
+	return &Provider{
+		config: config,
+		client: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+func (provider *Provider) Config() Config {
+	return provider.config
+}
+
+// BeginLogin returns the URL of the provider's login page and the session to check the user's return against.
+func (provider *Provider) BeginLogin() (string, SessionData, error) {
+	metadata, err := provider.discover()
+	if err != nil {
+		return "", SessionData{}, err
+	}
+
+	session := SessionData{}
+	for _, value := range []*string{&session.State, &session.Nonce, &session.CodeVerifier} {
+		*value, err = randomString()
+		if err != nil {
+			return "", SessionData{}, err
+		}
+	}
+
+	challenge := sha256.Sum256([]byte(session.CodeVerifier))
+	query := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {provider.config.ClientID},
+		"redirect_uri":          {provider.config.RedirectURL},
+		"scope":                 {strings.Join(provider.config.Scopes, " ")},
+		"state":                 {session.State},
+		"nonce":                 {session.Nonce},
+		"code_challenge":        {base64.RawURLEncoding.EncodeToString(challenge[:])},
+		"code_challenge_method": {"S256"},
+	}
+
+	separator := "?"
+	if strings.Contains(metadata.AuthorizationEndpoint, "?") {
+		separator = "&"
+	}
+
+	return metadata.AuthorizationEndpoint + separator + query.Encode(), session, nil
+}
+
+// FinishLogin swaps the code the provider sent the user back with for an ID token and returns the identity in it.
+func (provider *Provider) FinishLogin(session SessionData, state string, code string) (Identity, error) {
+	if session.State == "" || subtle.ConstantTimeCompare([]byte(session.State), []byte(state)) != 1 {
+		return Identity{}, errors.New("Invalid SSO state!")
+	}
+	if code == "" {
+		return Identity{}, errors.New("Invalid SSO login!")
+	}
+
+	metadata, err := provider.discover()
+	if err != nil {
+		return Identity{}, err
+	}
+
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {provider.config.RedirectURL},
+		"code_verifier": {session.CodeVerifier},
+	}
+
+	req, err := http.NewRequest(http.MethodPost, metadata.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		log.Println(err.Error())
+		return Identity{}, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	req.SetBasicAuth(url.QueryEscape(provider.config.ClientID), url.QueryEscape(provider.config.ClientSecret))
+
+	res, err := provider.client.Do(req)
+	if err != nil {
+		log.Println(err.Error())
+		return Identity{}, errors.New("SSO provider unreachable!")
+	}
+	defer res.Body.Close()
+
+	var token tokenResponse
+	err = json.NewDecoder(res.Body).Decode(&token)
+	if err != nil || res.StatusCode != http.StatusOK || token.IDToken == "" {
+		log.Println(fmt.Sprintf("SSO token request failed with %d: %s %s", res.StatusCode, token.Error, token.ErrorDescription))
+		return Identity{}, errors.New("Invalid SSO login!")
+	}
+
+	return provider.verify(token.IDToken, session.Nonce)
+}
+
+// verify checks the signature, issuer, audience, expiry and nonce of an ID token before mapping its claims.
+func (provider *Provider) verify(idToken string, nonce string) (Identity, error) {
+	parser := jwt.Parser{ValidMethods: []string{"RS256", "ES256"}, UseJSONNumber: true}
+
+	claims := jwt.MapClaims{}
+	_, err := parser.ParseWithClaims(idToken, claims, provider.keyfunc)
+	if err != nil {
+		log.Println(err.Error())
+		return Identity{}, errors.New("Invalid SSO login!")
+	}
+
+	if !claims.VerifyIssuer(provider.config.Issuer, true) || !claims.VerifyExpiresAt(time.Now().Unix(), true) {
+		return Identity{}, errors.New("Invalid SSO login!")
+	}
+
+	// VerifyAudience of this jwt version doesn't handle lists
+	audiences := []string{}
+	switch aud := claims["aud"].(type) {
+	case string:
+		audiences = append(audiences, aud)
+	case []interface{}:
+		for _, value := range aud {
+			if audience, ok := value.(string); ok {
+				audiences = append(audiences, audience)
+			}
+		}
+	}
+	found := false
+	for _, audience := range audiences {
+		found = found || audience == provider.config.ClientID
+	}
+	if !found || (len(audiences) > 1 && claims["azp"] != provider.config.ClientID) {
+		return Identity{}, errors.New("Invalid SSO login!")
+	}
+
+	if claimString(claims, "nonce") == "" || subtle.ConstantTimeCompare([]byte(claimString(claims, "nonce")), []byte(nonce)) != 1 {
+		return Identity{}, errors.New("Invalid SSO login!")
+	}
+
+	identity := Identity{
+		Subject: claimString(claims, "sub"),
+		Email:   claimString(claims, provider.config.EmailClaim),
+	}
+	if identity.Subject == "" {
+		return Identity{}, errors.New("Invalid SSO login!")
+	}
+
+	// Only an email the provider says it verified is trusted, some providers send the flag as a string
+	switch verified := claims["email_verified"].(type) {
+	case bool:
+		identity.EmailVerified = verified
+	case string:
+		identity.EmailVerified = verified == "true"
+	}
+	if provider.config.RegNumberClaim != "" {
+		identity.RegNumber = claimString(claims, provider.config.RegNumberClaim)
+	}
+
+	return identity, nil
+}
+
+func (provider *Provider) keyfunc(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+
+	provider.mutex.Lock()
+	defer provider.mutex.Unlock()
+
+	key, ok := provider.keys[kid]
+	// An unknown key usually means the provider rotated its keys, they are fetched again at most once a minute
+	if !ok && time.Since(provider.keysFetchedAt) > time.Minute {
+		err := provider.fetchKeys()
+		if err != nil {
+			return nil, err
+		}
+		key, ok = provider.keys[kid]
+	}
+	if !ok {
+		return nil, errors.New("Unknown SSO signing key")
+	}
+
+	return key, nil
+}
+
+// discover reads the provider's endpoints from its discovery document, the issuer in it has to match the configured one.
+func (provider *Provider) discover() (*metadata, error) {
+	if !provider.config.Enabled() {
+		return nil, errors.New("SSO not configured!")
+	}
+
+	provider.mutex.Lock()
+	defer provider.mutex.Unlock()
+
+	if provider.metadata != nil {
+		return provider.metadata, nil
+	}
+
+	var discovered metadata
+	err := provider.getJSON(provider.config.Issuer+"/.well-known/openid-configuration", &discovered)
+	if err != nil {
+		return nil, err
+	}
+
+	if strings.TrimSuffix(discovered.Issuer, "/") != provider.config.Issuer || discovered.AuthorizationEndpoint == "" || discovered.TokenEndpoint == "" || discovered.JWKSURI == "" {
+		log.Println("Invalid SSO discovery document of " + provider.config.Issuer)
+		return nil, errors.New("SSO provider unreachable!")
+	}
+
+	provider.metadata = &discovered
+	return provider.metadata, nil
+}
+
+// fetchKeys replaces the cached signing keys, the caller holds the mutex.
+func (provider *Provider) fetchKeys() error {
+	if provider.metadata == nil {
+		return errors.New("SSO provider unreachable!")
+	}
+
+	provider.keysFetchedAt = time.Now()
+
+	var jwks jsonWebKeySet
+	err := provider.getJSON(provider.metadata.JWKSURI, &jwks)
+	if err != nil {
+		return err
+	}
+
+	keys := map[string]interface{}{}
+	for _, jwk := range jwks.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+
+		key, err := jwk.publicKey()
+		if err != nil {
+			log.Println(err.Error())
+			continue
+		}
+		keys[jwk.Kid] = key
+	}
+
+	provider.keys = keys
+	return nil
+}
+
+func (provider *Provider) getJSON(url string, value interface{}) error {
+	res, err := provider.client.Get(url)
+	if err != nil {
+		log.Println(err.Error())
+		return errors.New("SSO provider unreachable!")
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		log.Println(fmt.Sprintf("SSO request to %s failed with %d", url, res.StatusCode))
+		return errors.New("SSO provider unreachable!")
+	}
+
+	err = json.NewDecoder(res.Body).Decode(value)
+	if err != nil {
+		log.Println(err.Error())
+		return errors.New("SSO provider unreachable!")
+	}
+
+	return nil
+}
+
+// claimString reads a claim that may be a string or a number, register numbers are often numeric.
+func claimString(claims jwt.MapClaims, name string) string {
+	switch value := claims[name].(type) {
+	case string:
+		return strings.TrimSpace(value)
+	case json.Number:
+		return value.String()
+	}
+
+	return ""
+}
+
+func randomString() (string, error) {
+	buf := make([]byte, 32)
+	_, err := rand.Read(buf)
+	if err != nil {
+		log.Println(err.Error())
+		return "", err
+	}
+
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
@@ -0,0 +1,106 @@
+package oidc_test
+
+import (
+	"elect/oidc"
+	"elect/oidc/oidctest"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+)
+
+func login(t *testing.T, server *oidctest.Provider) (oidc.Identity, error) {
+	provider := oidc.New(oidc.Config{
+		Issuer:      server.URL,
+		ClientID:    server.ClientID,
+		RedirectURL: "https://elect.test/sso/callback",
+		Scopes:      []string{"openid", "email"},
+		EmailClaim:  "email",
+	})
+
+	loginURL, session, err := provider.BeginLogin()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	state, code := server.Authorize(loginURL)
+	return provider.FinishLogin(session, state, code)
+}
+
+func TestFinishLogin(t *testing.T) {
+	server := oidctest.NewProvider("elect")
+	defer server.Close()
+
+	identity, err := login(t, server)
+	if err != nil {
+		t.Fatalf("login failed: %v", err)
+	}
+	if identity.Subject != server.Subject || identity.Email != server.Email || !identity.EmailVerified {
+		t.Errorf("identity is %+v, want the subject and verified email of the provider", identity)
+	}
+}
+
+func TestFinishLoginRejectsBadTokens(t *testing.T) {
+	cases := map[string]func(claims jwt.MapClaims){
+		"bad nonce":    func(claims jwt.MapClaims) { claims["nonce"] = "another-login" },
+		"no nonce":     func(claims jwt.MapClaims) { delete(claims, "nonce") },
+		"bad audience": func(claims jwt.MapClaims) { claims["aud"] = "another-client" },
+		"bad issuer":   func(claims jwt.MapClaims) { claims["iss"] = "https://attacker.example" },
+		"expired":      func(claims jwt.MapClaims) { claims["exp"] = time.Now().Add(-time.Minute).Unix() },
+		"no subject":   func(claims jwt.MapClaims) { delete(claims, "sub") },
+	}
+
+	for name, change := range cases {
+		t.Run(name, func(t *testing.T) {
+			server := oidctest.NewProvider("elect")
+			defer server.Close()
+			server.Claims = change
+
+			_, err := login(t, server)
+			if err == nil || err.Error() != "Invalid SSO login!" {
+				t.Errorf("login returned %v, want Invalid SSO login!", err)
+			}
+		})
+	}
+}
+
+func TestFinishLoginRejectsBadState(t *testing.T) {
+	server := oidctest.NewProvider("elect")
+	defer server.Close()
+
+	provider := oidc.New(oidc.Config{Issuer: server.URL, ClientID: server.ClientID, EmailClaim: "email"})
+	loginURL, session, err := provider.BeginLogin()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, code := server.Authorize(loginURL)
+	_, err = provider.FinishLogin(session, "another-state", code)
+	if err == nil || err.Error() != "Invalid SSO state!" {
+		t.Errorf("login returned %v, want Invalid SSO state!", err)
+	}
+}
+
+func TestEmailVerified(t *testing.T) {
+	cases := map[string]func(claims jwt.MapClaims){
+		"false":   func(claims jwt.MapClaims) { claims["email_verified"] = false },
+		"missing": func(claims jwt.MapClaims) { delete(claims, "email_verified") },
+		"string":  func(claims jwt.MapClaims) { claims["email_verified"] = "false" },
+	}
+
+	for name, change := range cases {
+		t.Run(name, func(t *testing.T) {
+			server := oidctest.NewProvider("elect")
+			defer server.Close()
+			server.Claims = change
+
+			identity, err := login(t, server)
+			if err != nil {
+				t.Fatalf("login failed: %v", err)
+			}
+			if identity.EmailVerified {
+				t.Error("email counts as verified")
+			}
+		})
+	}
+}
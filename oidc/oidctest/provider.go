@@ -0,0 +1,159 @@
+// Package oidctest provides an OpenID Connect provider for testing single sign-on without a real identity provider.
+package oidctest
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+)
+
+// Provider serves discovery, JWKS and token endpoints and signs ID tokens with an ES256 key. The ID token is issued
+// for ClientID with the claims Subject, Email and email_verified, Claims can change them before it is signed.
+type Provider struct {
+	*httptest.Server
+	ClientID string
+	Subject  string
+	Email    string
+	Claims   func(claims jwt.MapClaims)
+
+	key   *ecdsa.PrivateKey
+	mutex sync.Mutex
+	codes map[string]authorization
+}
+
+type authorization struct {
+	nonce     string
+	challenge string
+}
+
+// NewProvider starts a provider, the caller closes it.
+func NewProvider(clientID string) *Provider {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		panic(err)
+	}
+
+	provider := &Provider{
+		ClientID: clientID,
+		Subject:  "subject",
+		Email:    "student@elect.test",
+		key:      key,
+		codes:    map[string]authorization{},
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", provider.discovery)
+	mux.HandleFunc("/jwks", provider.jwks)
+	mux.HandleFunc("/token", provider.token)
+	provider.Server = httptest.NewServer(mux)
+
+	return provider
+}
+
+// Authorize plays the user logging in at the provider, it returns the state and code the provider redirects back with.
+func (provider *Provider) Authorize(loginURL string) (string, string) {
+	parsed, err := url.Parse(loginURL)
+	if err != nil {
+		panic(err)
+	}
+	query := parsed.Query()
+
+	code := randomString()
+	provider.mutex.Lock()
+	provider.codes[code] = authorization{nonce: query.Get("nonce"), challenge: query.Get("code_challenge")}
+	provider.mutex.Unlock()
+
+	return query.Get("state"), code
+}
+
+func (provider *Provider) discovery(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]string{
+		"issuer":                 provider.URL,
+		"authorization_endpoint": provider.URL + "/authorize",
+		"token_endpoint":         provider.URL + "/token",
+		"jwks_uri":               provider.URL + "/jwks",
+	})
+}
+
+func (provider *Provider) jwks(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"keys": []map[string]string{{
+			"kty": "EC",
+			"kid": "test",
+			"use": "sig",
+			"crv": "P-256",
+			"x":   base64.RawURLEncoding.EncodeToString(pad(provider.key.X.Bytes())),
+			"y":   base64.RawURLEncoding.EncodeToString(pad(provider.key.Y.Bytes())),
+		}},
+	})
+}
+
+// token swaps a code for an ID token once, the code verifier has to match the challenge of the login.
+func (provider *Provider) token(w http.ResponseWriter, r *http.Request) {
+	r.ParseForm()
+
+	provider.mutex.Lock()
+	login, ok := provider.codes[r.PostForm.Get("code")]
+	delete(provider.codes, r.PostForm.Get("code"))
+	provider.mutex.Unlock()
+
+	verifier := sha256.Sum256([]byte(r.PostForm.Get("code_verifier")))
+	clientID, _, _ := r.BasicAuth()
+	if !ok || login.challenge != base64.RawURLEncoding.EncodeToString(verifier[:]) || clientID != provider.ClientID {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant"})
+		return
+	}
+
+	claims := jwt.MapClaims{
+		"iss":            provider.URL,
+		"aud":            provider.ClientID,
+		"sub":            provider.Subject,
+		"email":          provider.Email,
+		"email_verified": true,
+		"nonce":          login.nonce,
+		"iat":            time.Now().Unix(),
+		"exp":            time.Now().Add(5 * time.Minute).Unix(),
+	}
+	if provider.Claims != nil {
+		provider.Claims(claims)
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodES256, claims)
+	token.Header["kid"] = "test"
+	idToken, err := token.SignedString(provider.key)
+	if err != nil {
+		panic(err)
+	}
+
+	writeJSON(w, http.StatusOK, map[string]string{"id_token": idToken, "token_type": "Bearer"})
+}
+
+func writeJSON(w http.ResponseWriter, status int, value interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(value)
+}
+
+// pad left pads a P-256 coordinate to 32 bytes.
+func pad(coordinate []byte) []byte {
+	return append(make([]byte, 32-len(coordinate)), coordinate...)
+}
+
+func randomString() string {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		panic(err)
+	}
+
+	return base64.RawURLEncoding.EncodeToString(buf)
+}
@@ -0,0 +1,5 @@
+package otpmethods
+
+var Email int = 0
+var Authenticator int = 1
+var RecoveryCode int = 2
@@ -0,0 +1,235 @@
+package services
+
+import (
+	"crypto/rand"
+	"elect/database"
+	"elect/dto"
+	"elect/mappers"
+	"elect/models"
+	"elect/roles"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/casbin/casbin/v2/util"
+	uuid "github.com/satori/go.uuid"
+)
+
+// APIKeyPrefix starts every API key, so a Bearer token can be told apart from an access token without parsing it.
+const APIKeyPrefix = "elect_"
+
+// APIKeyService lets admins create keys for scripts, each limited to the paths and methods of its scopes.
+type APIKeyService interface {
+	CreateAPIKey(userId string, createAPIKeyDTO dto.CreateAPIKeyDTO) (dto.NewAPIKeyDTO, error)
+	GetAPIKeys(userId string) ([]dto.APIKeyDTO, error)
+	RevokeAPIKey(userId string, apiKeyId string) error
+	Authenticate(key string, path string, method string) (string, int, error)
+}
+
+type apiKeyService struct {
+	database database.Database
+}
+
+func NewAPIKeyService(database database.Database) APIKeyService {
+	return &apiKeyService{
+		database: database,
+	}
+}
+
+var apiKeyMethods = []string{"GET", "POST", "PUT", "DELETE", "*"}
+
+// apiKeyDeniedPaths manage credentials, sessions, keys and policies. A key that could reach them could mint more keys
+// or take over an account, so no scope may cover them and no key is let through to them.
+var apiKeyDeniedPaths = []string{
+	"/api/otp/*",
+	"/api/passkeys",
+	"/api/passkey/*",
+	"/api/sessions",
+	"/api/session/*",
+	"/api/apikeys",
+	"/api/apikey/*",
+	"/api/policies",
+	"/api/policies/*",
+	"/api/policy/*",
+	"/api/registeredstudent/*/sessions",
+	"/api/registeredstudent/*/recovery-codes",
+}
+
+// CreateAPIKey returns the new key, only its hash is stored so it can't be shown again.
+func (service *apiKeyService) CreateAPIKey(userId string, createAPIKeyDTO dto.CreateAPIKeyDTO) (dto.NewAPIKeyDTO, error) {
+	name := strings.TrimSpace(createAPIKeyDTO.Name)
+	if name == "" || len(name) > 64 {
+		return dto.NewAPIKeyDTO{}, errors.New("Invalid name!")
+	}
+
+	if len(createAPIKeyDTO.Scopes) == 0 {
+		return dto.NewAPIKeyDTO{}, errors.New("Invalid scope!")
+	}
+
+	scopes := []dto.APIKeyScopeDTO{}
+	for _, scope := range createAPIKeyDTO.Scopes {
+		scope.Path = strings.TrimSpace(scope.Path)
+		scope.Method = strings.ToUpper(strings.TrimSpace(scope.Method))
+
+		if !strings.HasPrefix(scope.Path, "/api/") || !isAPIKeyMethod(scope.Method) || coversDeniedPath(scope.Path) {
+			return dto.NewAPIKeyDTO{}, errors.New("Invalid scope!")
+		}
+
+		scopes = append(scopes, scope)
+	}
+
+	expiresIn := apiKeyMaxExpiry()
+	if createAPIKeyDTO.ExpiresIn != "" {
+		duration, err := time.ParseDuration(createAPIKeyDTO.ExpiresIn)
+		if err != nil || duration <= 0 || duration > apiKeyMaxExpiry() {
+			return dto.NewAPIKeyDTO{}, errors.New("Invalid expiry!")
+		}
+		expiresIn = duration
+	}
+
+	encodedScopes, err := json.Marshal(scopes)
+	if err != nil {
+		log.Println(err.Error())
+		return dto.NewAPIKeyDTO{}, err
+	}
+
+	buf := make([]byte, 32)
+	_, err = rand.Read(buf)
+	if err != nil {
+		log.Println(err.Error())
+		return dto.NewAPIKeyDTO{}, err
+	}
+	key := APIKeyPrefix + base64.RawURLEncoding.EncodeToString(buf)
+
+	now := time.Now().UTC()
+	apiKey := models.APIKey{
+		APIKeyID:  uuid.NewV4(),
+		UserID:    uuid.FromStringOrNil(userId),
+		Name:      name,
+		Prefix:    key[:len(APIKeyPrefix)+6],
+		Scopes:    string(encodedScopes),
+		ExpiresAt: now.Add(expiresIn),
+		CreatedAt: now,
+	}
+
+	err = service.database.CreateAPIKey(apiKey, key)
+	if err != nil {
+		return dto.NewAPIKeyDTO{}, err
+	}
+
+	apiKeyDTO, err := mappers.ToAPIKeyDTO(apiKey)
+	if err != nil {
+		return dto.NewAPIKeyDTO{}, err
+	}
+
+	return dto.NewAPIKeyDTO{
+		APIKeyDTO: apiKeyDTO,
+		Key:       key,
+	}, nil
+}
+
+func (service *apiKeyService) GetAPIKeys(userId string) ([]dto.APIKeyDTO, error) {
+	apiKeys, err := service.database.GetAPIKeys(userId)
+	if err != nil {
+		return nil, err
+	}
+
+	apiKeyDTOs := []dto.APIKeyDTO{}
+	for _, apiKey := range apiKeys {
+		apiKeyDTO, err := mappers.ToAPIKeyDTO(apiKey)
+		if err != nil {
+			return nil, err
+		}
+		apiKeyDTOs = append(apiKeyDTOs, apiKeyDTO)
+	}
+
+	return apiKeyDTOs, nil
+}
+
+func (service *apiKeyService) RevokeAPIKey(userId string, apiKeyId string) error {
+	if _, err := uuid.FromString(apiKeyId); err != nil {
+		return errors.New("Invalid API Key!")
+	}
+
+	return service.database.RevokeAPIKey(userId, apiKeyId)
+}
+
+// Authenticate returns the owner and role of a key when one of its scopes covers the request. The key acts with the
+// owner's current role, so it stops working when they are no longer an admin.
+func (service *apiKeyService) Authenticate(key string, path string, method string) (string, int, error) {
+	if isDeniedPath(path) {
+		return "", 0, errors.New("Out of scope!")
+	}
+
+	apiKey, err := service.database.UseAPIKey(key)
+	if err != nil {
+		return "", 0, err
+	}
+
+	user, err := service.database.GetUser(apiKey.UserID.String())
+	if err != nil || (user.Role != roles.Admin && user.Role != roles.SuperAdmin) {
+		log.Println("API Key of a user that is no admin: " + apiKey.APIKeyID.String())
+		return "", 0, errors.New("Invalid API Key!")
+	}
+
+	var scopes []dto.APIKeyScopeDTO
+	err = json.Unmarshal([]byte(apiKey.Scopes), &scopes)
+	if err != nil {
+		log.Println(err.Error())
+		return "", 0, err
+	}
+
+	for _, scope := range scopes {
+		if util.KeyMatch(path, scope.Path) && util.KeyMatch(method, scope.Method) {
+			return user.UserID.String(), user.Role, nil
+		}
+	}
+
+	return "", 0, errors.New("Out of scope!")
+}
+
+func isAPIKeyMethod(method string) bool {
+	for _, apiKeyMethod := range apiKeyMethods {
+		if method == apiKeyMethod {
+			return true
+		}
+	}
+
+	return false
+}
+
+// coversDeniedPath tells if a scope reaches a denied path, either through a wildcard like /api/* or by naming one.
+func coversDeniedPath(scopePath string) bool {
+	for _, deniedPath := range apiKeyDeniedPaths {
+		if util.KeyMatch(deniedPath, scopePath) || util.KeyMatch2(scopePath, deniedPath) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func isDeniedPath(path string) bool {
+	for _, deniedPath := range apiKeyDeniedPaths {
+		if util.KeyMatch2(path, deniedPath) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// apiKeyMaxExpiry is how long a key can stay valid and how long it does when no expiry is given, set by
+// API_KEY_MAX_EXPIRY(e.g, 2160h).
+func apiKeyMaxExpiry() time.Duration {
+	expiry, err := time.ParseDuration(os.Getenv("API_KEY_MAX_EXPIRY"))
+	if err != nil || expiry <= 0 {
+		return 90 * 24 * time.Hour
+	}
+
+	return expiry
+}
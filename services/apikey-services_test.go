@@ -0,0 +1,46 @@
+package services
+
+import (
+	"elect/dto"
+	"testing"
+)
+
+func TestAPIKeyScopesCannotReachManagementPaths(t *testing.T) {
+	service := NewAPIKeyService(nil)
+
+	denied := []string{
+		"/api/*",
+		"/api/apikeys",
+		"/api/apikey/*",
+		"/api/api*",
+		"/api/otp/method",
+		"/api/passkey/register/begin",
+		"/api/policies",
+		"/api/policy/*",
+		"/api/registeredstudent/*",
+		"/api/registeredstudent/5b3f/recovery-codes",
+	}
+	for _, path := range denied {
+		_, err := service.CreateAPIKey("", dto.CreateAPIKeyDTO{
+			Name:   "sync",
+			Scopes: []dto.APIKeyScopeDTO{{Path: path, Method: "*"}},
+		})
+		if err == nil || err.Error() != "Invalid scope!" {
+			t.Errorf("scope %s: got %v, want Invalid scope!", path, err)
+		}
+	}
+
+	for _, path := range []string{"/api/apikeys", "/api/policy/1", "/api/registeredstudent/5b3f/sessions"} {
+		if _, _, err := service.Authenticate(APIKeyPrefix+"key", path, "POST"); err == nil || err.Error() != "Out of scope!" {
+			t.Errorf("request to %s: got %v, want Out of scope!", path, err)
+		}
+	}
+}
+
+func TestAPIKeyScopesAllowOtherPaths(t *testing.T) {
+	for _, path := range []string{"/api/registerstudents", "/api/participants/*", "/api/registeredstudent/5b3f", "/api/election/*"} {
+		if coversDeniedPath(path) {
+			t.Errorf("scope %s is rejected", path)
+		}
+	}
+}
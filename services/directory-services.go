@@ -0,0 +1,146 @@
+package services
+
+import (
+	"elect/database"
+	"elect/directory"
+	"elect/dto"
+	"elect/mappers"
+	"elect/roles"
+	"errors"
+	"fmt"
+	"log"
+	"net/mail"
+	"os"
+	"time"
+)
+
+// DirectoryService checks the passwords of students synced from the institution's LDAP directory, and syncs them in
+// place of the Excel upload.
+type DirectoryService interface {
+	Authenticate(email string, password string) error
+	SyncStudents(registeredBy string) (dto.DirectorySyncDTO, error)
+	StartSync()
+}
+
+type directoryService struct {
+	database  database.Database
+	directory directory.Client
+	syncing   chan struct{}
+}
+
+func NewDirectoryService(database database.Database, config directory.Config) DirectoryService {
+	return &directoryService{
+		database:  database,
+		directory: directory.New(config),
+		syncing:   make(chan struct{}, 1),
+	}
+}
+
+func (service *directoryService) Authenticate(email string, password string) error {
+	_, err := service.directory.Authenticate(email, password)
+	return err
+}
+
+// SyncStudents creates or updates a student for every entry under the base DN, new ones are registered by the admin.
+// Entries that don't fit a student, or clash with another user, are skipped and logged.
+func (service *directoryService) SyncStudents(registeredBy string) (dto.DirectorySyncDTO, error) {
+	select {
+	case service.syncing <- struct{}{}:
+		defer func() { <-service.syncing }()
+	default:
+		return dto.DirectorySyncDTO{}, errors.New("Directory sync already running!")
+	}
+
+	entries, err := service.directory.Entries()
+	if err != nil {
+		return dto.DirectorySyncDTO{}, err
+	}
+
+	result := dto.DirectorySyncDTO{}
+	for _, entry := range entries {
+		err = validateDirectoryEntry(entry)
+		if err != nil {
+			log.Println("Skipped directory entry " + entry.DN + ": " + err.Error())
+			result.Skipped++
+			continue
+		}
+
+		created, updated, err := service.database.SyncDirectoryStudent(mappers.ToUserFromDirectoryEntry(entry, registeredBy))
+		if err != nil {
+			log.Println("Skipped directory entry " + entry.DN + ": " + err.Error())
+			result.Skipped++
+			continue
+		}
+
+		switch {
+		case created:
+			result.Created++
+		case updated:
+			result.Updated++
+		default:
+			result.Unchanged++
+		}
+	}
+
+	log.Println(fmt.Sprintf("Directory sync: %d created, %d updated, %d unchanged, %d skipped", result.Created, result.Updated, result.Unchanged, result.Skipped))
+
+	return result, nil
+}
+
+// StartSync syncs the students in the background every LDAP_SYNC_INTERVAL(e.g, 24h), registering new ones to the
+// admin with the email LDAP_SYNC_ADMIN. It does nothing unless both are set.
+func (service *directoryService) StartSync() {
+	interval := directorySyncInterval()
+	adminEmail := os.Getenv("LDAP_SYNC_ADMIN")
+	if !service.directory.Config().Enabled() || interval == 0 || adminEmail == "" {
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			admin, err := service.database.GetUserByEmail(adminEmail)
+			if err == nil && admin.Role != roles.Admin {
+				err = errors.New("LDAP_SYNC_ADMIN is not an admin!")
+			}
+			if err == nil {
+				_, err = service.SyncStudents(admin.UserID.String())
+			}
+			if err != nil {
+				log.Println("Directory sync failed: " + err.Error())
+			}
+
+			<-ticker.C
+		}
+	}()
+}
+
+// validateDirectoryEntry holds entries to the limits of the users table, which the Excel upload relies on the
+// database for.
+func validateDirectoryEntry(entry directory.Entry) error {
+	if entry.RegNumber == "" || len(entry.RegNumber) > 12 {
+		return errors.New("Invalid register number!")
+	}
+	if entry.FirstName == "" || len(entry.FirstName) > 64 || len(entry.LastName) > 64 {
+		return errors.New("Invalid name!")
+	}
+
+	address, err := mail.ParseAddress(entry.Email)
+	if err != nil || address.Address != entry.Email || len(entry.Email) > 384 {
+		return errors.New("Invalid email!")
+	}
+
+	return nil
+}
+
+// directorySyncInterval is how often the students are synced in the background, set by LDAP_SYNC_INTERVAL, 0 is never.
+func directorySyncInterval() time.Duration {
+	interval, err := time.ParseDuration(os.Getenv("LDAP_SYNC_INTERVAL"))
+	if err != nil || interval < 0 {
+		return 0
+	}
+
+	return interval
+}
@@ -0,0 +1,135 @@
+package services
+
+import (
+	"elect/database/databasetest"
+	"elect/directory"
+	"elect/directory/directorytest"
+	"elect/dto"
+	"elect/roles"
+	"strings"
+	"testing"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+)
+
+// blockingDirectory holds Entries until release is closed, so a sync can be caught while it runs.
+type blockingDirectory struct {
+	directory.Client
+	started chan struct{}
+	release chan struct{}
+}
+
+func (directory *blockingDirectory) Entries() ([]directory.Entry, error) {
+	select {
+	case directory.started <- struct{}{}:
+	default:
+	}
+	<-directory.release
+	return nil, nil
+}
+
+func TestDirectorySyncRunsOnce(t *testing.T) {
+	client := &blockingDirectory{started: make(chan struct{}, 1), release: make(chan struct{})}
+	service := &directoryService{directory: client, syncing: make(chan struct{}, 1)}
+
+	done := make(chan error)
+	go func() {
+		_, err := service.SyncStudents("admin")
+		done <- err
+	}()
+	<-client.started
+
+	_, err := service.SyncStudents("admin")
+	if err == nil || err.Error() != "Directory sync already running!" {
+		t.Errorf("second sync returned %v, want Directory sync already running!", err)
+	}
+
+	close(client.release)
+	if err := <-done; err != nil {
+		t.Fatalf("first sync failed: %v", err)
+	}
+
+	_, err = service.SyncStudents("admin")
+	if err != nil {
+		t.Errorf("sync after the first one finished returned %v", err)
+	}
+}
+
+func TestDirectorySync(t *testing.T) {
+	db, conn := databasetest.New(t)
+
+	server := directorytest.NewServer()
+	defer server.Close()
+
+	id := uuid.NewV4().String()
+	admin := databasetest.CreateUser(t, conn, roles.Admin)
+
+	baseDN := "ou=students-" + id + ",dc=elect,dc=test"
+	entry := func(uid string, regNumber string, firstName string, email string) directorytest.Entry {
+		return directorytest.Entry{
+			DN: "uid=" + uid + "," + baseDN,
+			Attributes: map[string][]string{
+				"objectClass":    {"person"},
+				"employeeNumber": {regNumber},
+				"givenName":      {firstName},
+				"sn":             {"Student"},
+				"mail":           {email},
+			},
+		}
+	}
+	regNumber := strings.ToUpper(id[24:])
+	studentEmail := "student-" + id + "@elect.test"
+	server.Add(entry("student", regNumber, "Alice", studentEmail))
+	server.Add(entry("clash", strings.ToUpper(id[:6])+"CLASH", "Mallory", admin.Email))
+	server.Add(entry("invalid", "", "Nobody", "invalid-"+id+"@elect.test"))
+
+	service := NewDirectoryService(db, directory.Config{
+		URL:            server.URL,
+		BindDN:         server.BindDN,
+		BindPassword:   server.BindPassword,
+		BaseDN:         baseDN,
+		Filter:         "(objectClass=person)",
+		RegNumberAttr:  "employeeNumber",
+		FirstNameAttr:  "givenName",
+		LastNameAttr:   "sn",
+		EmailAttr:      "mail",
+		RequestTimeout: 5 * time.Second,
+	})
+
+	sync := func(want dto.DirectorySyncDTO) {
+		t.Helper()
+		result, err := service.SyncStudents(admin.UserID.String())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if result != want {
+			t.Errorf("sync returned %+v, want %+v", result, want)
+		}
+	}
+
+	sync(dto.DirectorySyncDTO{Created: 1, Skipped: 2})
+
+	student, err := db.GetUserByEmail(studentEmail)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if student.Role != roles.Student || !student.Verified || student.RegisteredBy != admin.UserID.String() || student.FirstName != "Alice" {
+		t.Errorf("created student is %+v", student)
+	}
+
+	sync(dto.DirectorySyncDTO{Unchanged: 1, Skipped: 2})
+
+	server.Add(entry("student", regNumber, "Alicia", studentEmail))
+	sync(dto.DirectorySyncDTO{Updated: 1, Skipped: 2})
+
+	student, err = db.GetUserByEmail(studentEmail)
+	if err != nil || student.FirstName != "Alicia" {
+		t.Errorf("updated student is %+v, %v", student, err)
+	}
+
+	unchanged, err := db.GetUserByEmail(admin.Email)
+	if err != nil || unchanged.Role != roles.Admin || unchanged.FirstName != "Test" || unchanged.DirectoryDN != "" {
+		t.Errorf("admin clashing with a directory entry is %+v, %v", unchanged, err)
+	}
+}
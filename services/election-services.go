@@ -5,8 +5,10 @@ import (
 	"elect/dto"
 	"elect/mappers"
 	"elect/models"
+	"elect/votingmethods"
 	"errors"
 	"log"
+	"strings"
 
 	uuid "github.com/satori/go.uuid"
 )
@@ -15,18 +17,23 @@ type ElectionService interface {
 	CreateElection(userId string, createElectionDTO dto.CreateElectionDTO) error
 	EditElection(userId string, editElectionDTO dto.EditElectionDTO) error
 	DeleteElection(userId string, electionId string) error
+	AddPosition(userId string, createPositionDTO dto.CreatePositionDTO) error
+	DeletePosition(userId string, positionId string) error
 	AddParticipants(userId string, electionId string, participants []dto.CreateParticipantDTO) (int, error)
 	GetElectionsForAdmins(userId string, paginatorParams dto.PaginatorParams) ([]dto.GeneralElectionDTO, error)
 	GetElectionsForStudents(userId string, paginatorParams dto.PaginatorParams) ([]dto.GeneralElectionDTO, error)
 	DeleteParticipant(userId string, electionId string, participantId string) error
 	EnrollCandidate(userId string, createCandidateDTO dto.CreateCandidateDTO) error
-	CheckCandidateEligibility(userId string, electionId string) error
+	CheckCandidateEligibility(userId string, electionId string, positionId string) error
 	ApproveCandidate(userId string, candidateId string) error
 	UnapproveCandidate(userId string, candidateId string) error
 	GetElectionForAdmins(userId string, electionId string) (dto.GeneralElectionDTO, error)
 	GetElectionForStudents(userId string, electionId string) (dto.GeneralElectionDTO, error)
-	CastVote(userId string, castVoteDTO dto.CastVoteDTO) error
+	CastVote(userId string, castVoteDTO dto.CastVoteDTO) (string, error)
+	GetElectionReceipts(electionId string) (dto.ElectionReceiptsDTO, error)
+	VerifyAuditChain(userId string, role int, electionId string) (dto.AuditChainDTO, error)
 	GetElectionResults(userId string, role int, electionId string) (dto.GeneralElectionResultsDTO, error)
+	RecountVotes(userId string, role int, electionId string) (dto.GeneralElectionResultsDTO, error)
 }
 
 type electionService struct {
@@ -49,8 +56,26 @@ func (service *electionService) CreateElection(userId string, createElectionDTO
 	if election.StartingAt.After(election.EndingAt) {
 		return errors.New("Starting At is after Ending At!")
 	}
+	if election.VotingMethod != votingmethods.Plurality && election.VotingMethod != votingmethods.InstantRunoff {
+		return errors.New("Invalid voting method!")
+	}
 
-	err := service.database.CreateElection(election)
+	var positions []models.Position
+	titles := make(map[string]bool)
+	for _, title := range createElectionDTO.Positions {
+		title = strings.TrimSpace(title)
+		if title == "" {
+			return errors.New("Invalid position title!")
+		}
+		if titles[strings.ToUpper(title)] {
+			return errors.New("Duplicate position: " + title + "!")
+		}
+		titles[strings.ToUpper(title)] = true
+
+		positions = append(positions, models.Position{Title: title})
+	}
+
+	err := service.database.CreateElection(election, positions)
 	if err != nil {
 		return err
 	}
@@ -75,6 +100,31 @@ func (service *electionService) DeleteElection(userId string, electionId string)
 	return service.database.DeleteElection(userId, electionId)
 }
 
+func (service *electionService) AddPosition(userId string, createPositionDTO dto.CreatePositionDTO) error {
+	position := mappers.ToPositionFromCreatePositionDTO(createPositionDTO)
+	position.Title = strings.TrimSpace(position.Title)
+	if position.Title == "" {
+		return errors.New("Invalid position title!")
+	}
+
+	positions, err := service.database.GetPositions(createPositionDTO.ElectionId)
+	if err != nil {
+		return err
+	}
+
+	for _, p := range positions {
+		if strings.EqualFold(p.Title, position.Title) {
+			return errors.New("Duplicate position: " + position.Title + "!")
+		}
+	}
+
+	return service.database.AddPosition(userId, position)
+}
+
+func (service *electionService) DeletePosition(userId string, positionId string) error {
+	return service.database.DeletePosition(userId, positionId)
+}
+
 func (service *electionService) AddParticipants(userId string, electionId string, participants []dto.CreateParticipantDTO) (int, error) {
 	count := 0
 	for index, participant := range participants {
@@ -147,8 +197,8 @@ func (service *electionService) EnrollCandidate(userId string, createCandidateDT
 	return service.database.EnrollCandidate(candidate)
 }
 
-func (service *electionService) CheckCandidateEligibility(userId string, electionId string) error {
-	return service.database.CheckCandidateEligibility(userId, electionId)
+func (service *electionService) CheckCandidateEligibility(userId string, electionId string, positionId string) error {
+	return service.database.CheckCandidateEligibility(userId, electionId, positionId)
 }
 
 func (service *electionService) ApproveCandidate(userId string, candidateId string) error {
@@ -165,6 +215,11 @@ func (service *electionService) GetElectionForAdmins(userId string, electionId s
 		return dto.GeneralElectionDTO{}, err
 	}
 
+	positionDTOs, err := service.getPositionDTOs(electionId)
+	if err != nil {
+		return dto.GeneralElectionDTO{}, err
+	}
+
 	var generalCandidateDTOs []dto.GeneralCandidateDTO
 	for _, candidate := range candidates {
 		user, err := service.database.GetUser(candidate.UserID.String())
@@ -175,7 +230,7 @@ func (service *electionService) GetElectionForAdmins(userId string, electionId s
 		generalCandidateDTOs = append(generalCandidateDTOs, mappers.ToGeneralCandidateDTOFromCandidate(candidate, user))
 	}
 
-	return mappers.ToGeneralElectionDTOForAdmins(election, generalParticipantDTOs, generalCandidateDTOs), nil
+	return mappers.ToGeneralElectionDTOForAdmins(election, positionDTOs, generalParticipantDTOs, generalCandidateDTOs), nil
 }
 
 func (service *electionService) GetElectionForStudents(userId string, electionId string) (dto.GeneralElectionDTO, error) {
@@ -184,6 +239,11 @@ func (service *electionService) GetElectionForStudents(userId string, electionId
 		return dto.GeneralElectionDTO{}, err
 	}
 
+	positionDTOs, err := service.getPositionDTOs(electionId)
+	if err != nil {
+		return dto.GeneralElectionDTO{}, err
+	}
+
 	var generalCandidateDTOs []dto.GeneralCandidateDTO
 	for _, candidate := range candidates {
 		user, err := service.database.GetUser(candidate.UserID.String())
@@ -203,11 +263,48 @@ func (service *electionService) GetElectionForStudents(userId string, electionId
 		generalCandidateDTO = mappers.ToGeneralCandidateDTOFromCandidateForStudents(candidate, user)
 	}
 
-	return mappers.ToGeneralElectionDTOForStudents(election, generalCandidateDTOs, generalCandidateDTO, voted, blacklisted), nil
+	return mappers.ToGeneralElectionDTOForStudents(election, positionDTOs, generalCandidateDTOs, generalCandidateDTO, voted, blacklisted), nil
+}
+
+func (service *electionService) CastVote(userId string, castVoteDTO dto.CastVoteDTO) (string, error) {
+	choices := castVoteDTO.Choices
+	if len(choices) == 0 {
+		choices = []dto.BallotChoiceDTO{{CandidateId: castVoteDTO.CandidateId, Preferences: castVoteDTO.Preferences}}
+	}
+
+	for _, choice := range choices {
+		if choice.CandidateId == "" && len(choice.Preferences) == 0 {
+			return "", errors.New("Invalid ballot!")
+		}
+		if choice.CandidateId != "" && len(choice.Preferences) > 0 && choice.Preferences[0] != choice.CandidateId {
+			return "", errors.New("Invalid ballot!")
+		}
+	}
+
+	return service.database.CastVote(userId, castVoteDTO.ElectionId, choices)
 }
 
-func (service *electionService) CastVote(userId string, castVoteDTO dto.CastVoteDTO) error {
-	return service.database.CastVote(userId, castVoteDTO.ElectionId, castVoteDTO.CandidateId)
+func (service *electionService) GetElectionReceipts(electionId string) (dto.ElectionReceiptsDTO, error) {
+	election, receipts, err := service.database.GetReceipts(electionId)
+	if err != nil {
+		return dto.ElectionReceiptsDTO{}, err
+	}
+
+	return mappers.ToElectionReceiptsDTO(election, receipts), nil
+}
+
+func (service *electionService) VerifyAuditChain(userId string, role int, electionId string) (dto.AuditChainDTO, error) {
+	events, brokenAt, err := service.database.VerifyAuditChain(userId, role, electionId)
+	if err != nil {
+		return dto.AuditChainDTO{}, err
+	}
+
+	auditEventDTOs := make([]dto.AuditEventDTO, 0, len(events))
+	for _, event := range events {
+		auditEventDTOs = append(auditEventDTOs, mappers.ToAuditEventDTO(event))
+	}
+
+	return mappers.ToAuditChainDTO(electionId, auditEventDTOs, brokenAt), nil
 }
 
 func (service *electionService) GetElectionResults(userId string, role int, electionId string) (dto.GeneralElectionResultsDTO, error) {
@@ -216,66 +313,195 @@ func (service *electionService) GetElectionResults(userId string, role int, elec
 		return dto.GeneralElectionResultsDTO{}, err
 	}
 
-	if !election.GenderSpecific {
-		var candidateResultsDTOs []dto.CandidateResultsDTO
-		for _, candidate := range candidates {
-			user, err := service.database.GetUser(candidate.UserID.String())
-			if err != nil {
-				return dto.GeneralElectionResultsDTO{}, err
-			}
-			candidateResultsDTOs = append(candidateResultsDTOs, mappers.ToCandidateResultsDTOFromCandidate(candidate, user.FirstName+" "+user.LastName))
-		}
+	candidateResultsDTOs, err := service.toCandidateResultsDTOs(candidates)
+	if err != nil {
+		return dto.GeneralElectionResultsDTO{}, err
+	}
+
+	mCandidateResultsDTOs, err := service.toCandidateResultsDTOs(mCandidates)
+	if err != nil {
+		return dto.GeneralElectionResultsDTO{}, err
+	}
+
+	fCandidateResultsDTOs, err := service.toCandidateResultsDTOs(fCandidates)
+	if err != nil {
+		return dto.GeneralElectionResultsDTO{}, err
+	}
+
+	oCandidateResultsDTOs, err := service.toCandidateResultsDTOs(oCandidates)
+	if err != nil {
+		return dto.GeneralElectionResultsDTO{}, err
+	}
 
-		totalParticipants, err := service.database.GetTotalElectionParticipants(electionId, userId)
+	positions, err := service.database.GetPositions(electionId)
+	if err != nil {
+		return dto.GeneralElectionResultsDTO{}, err
+	}
+
+	var ballots [][]dto.BallotChoiceDTO
+	if election.VotingMethod == votingmethods.InstantRunoff {
+		storedBallots, err := service.database.GetBallots(electionId)
 		if err != nil {
 			return dto.GeneralElectionResultsDTO{}, err
 		}
 
-		if role == 1 || role == 2 {
-			return mappers.ToGeneralElectionResultsDTOForAdmins(election, totalParticipants, candidateResultsDTOs, nil, nil, nil, total), nil
-		} else if role == 0 {
-			return mappers.ToGeneralElectionResultsDTOForStudents(election, totalParticipants, candidateResultsDTOs, nil, nil, nil, total), nil
-		}
-	} else {
-		var mCandidateResultsDTOs []dto.CandidateResultsDTO
-		for _, candidate := range mCandidates {
-			user, err := service.database.GetUser(candidate.UserID.String())
+		for _, storedBallot := range storedBallots {
+			ballotChoiceDTOs, err := mappers.ToBallotChoiceDTOsFromBallot(storedBallot)
 			if err != nil {
 				return dto.GeneralElectionResultsDTO{}, err
 			}
-			mCandidateResultsDTOs = append(mCandidateResultsDTOs, mappers.ToCandidateResultsDTOFromCandidate(candidate, user.FirstName+" "+user.LastName))
+			ballots = append(ballots, ballotChoiceDTOs)
 		}
+	}
 
-		var fCandidateResultsDTOs []dto.CandidateResultsDTO
-		for _, candidate := range fCandidates {
-			user, err := service.database.GetUser(candidate.UserID.String())
-			if err != nil {
-				return dto.GeneralElectionResultsDTO{}, err
+	var runoffResultsDTOs []dto.RunoffResultsDTO
+	if election.VotingMethod == votingmethods.InstantRunoff && len(positions) == 0 {
+		runoffResultsDTOs = getRunoffs(election, "", ballots, candidateResultsDTOs, mCandidateResultsDTOs, fCandidateResultsDTOs, oCandidateResultsDTOs)
+	}
+
+	var positionResultsDTOs []dto.PositionResultsDTO
+	if len(positions) > 0 {
+		total = 0
+		for _, position := range positions {
+			pCandidateResultsDTOs := filterByPosition(candidateResultsDTOs, position.PositionID.String())
+			pMCandidateResultsDTOs := filterByPosition(mCandidateResultsDTOs, position.PositionID.String())
+			pFCandidateResultsDTOs := filterByPosition(fCandidateResultsDTOs, position.PositionID.String())
+			pOCandidateResultsDTOs := filterByPosition(oCandidateResultsDTOs, position.PositionID.String())
+
+			pTotal := 0
+			for _, results := range [][]dto.CandidateResultsDTO{pCandidateResultsDTOs, pMCandidateResultsDTOs, pFCandidateResultsDTOs, pOCandidateResultsDTOs} {
+				for _, candidateResultsDTO := range results {
+					pTotal += candidateResultsDTO.Votes
+				}
 			}
-			fCandidateResultsDTOs = append(fCandidateResultsDTOs, mappers.ToCandidateResultsDTOFromCandidate(candidate, user.FirstName+" "+user.LastName))
-		}
 
-		var oCandidateResultsDTOs []dto.CandidateResultsDTO
-		for _, candidate := range oCandidates {
-			user, err := service.database.GetUser(candidate.UserID.String())
-			if err != nil {
-				return dto.GeneralElectionResultsDTO{}, err
+			// Every ballot holds one choice per position, so the busiest position gives the number of ballots
+			if pTotal > total {
+				total = pTotal
+			}
+
+			var winners []dto.CandidateResultsDTO
+			var pRunoffResultsDTOs []dto.RunoffResultsDTO
+			if election.VotingMethod == votingmethods.InstantRunoff {
+				pRunoffResultsDTOs = getRunoffs(election, position.PositionID.String(), ballots, pCandidateResultsDTOs, pMCandidateResultsDTOs, pFCandidateResultsDTOs, pOCandidateResultsDTOs)
+				for _, runoffResultsDTO := range pRunoffResultsDTOs {
+					winners = append(winners, runoffResultsDTO.Winners...)
+				}
+			} else if !election.GenderSpecific {
+				winners = getWinners(pCandidateResultsDTOs)
+			} else {
+				winners = append(winners, getWinners(pMCandidateResultsDTOs)...)
+				winners = append(winners, getWinners(pFCandidateResultsDTOs)...)
+				winners = append(winners, getWinners(pOCandidateResultsDTOs)...)
 			}
-			oCandidateResultsDTOs = append(oCandidateResultsDTOs, mappers.ToCandidateResultsDTOFromCandidate(candidate, user.FirstName+" "+user.LastName))
+
+			positionResultsDTOs = append(positionResultsDTOs, mappers.ToPositionResultsDTO(position, pCandidateResultsDTOs, pMCandidateResultsDTOs, pFCandidateResultsDTOs, pOCandidateResultsDTOs, winners, pRunoffResultsDTOs, pTotal))
 		}
+	}
 
-		totalParticipants, err := service.database.GetTotalElectionParticipants(electionId, userId)
+	totalParticipants, err := service.database.GetTotalElectionParticipants(electionId, userId)
+	if err != nil {
+		return dto.GeneralElectionResultsDTO{}, err
+	}
+
+	if role == 1 || role == 2 {
+		return mappers.ToGeneralElectionResultsDTOForAdmins(election, totalParticipants, candidateResultsDTOs, mCandidateResultsDTOs, fCandidateResultsDTOs, oCandidateResultsDTOs, positionResultsDTOs, runoffResultsDTOs, total), nil
+	} else if role == 0 {
+		return mappers.ToGeneralElectionResultsDTOForStudents(election, totalParticipants, candidateResultsDTOs, mCandidateResultsDTOs, fCandidateResultsDTOs, oCandidateResultsDTOs, positionResultsDTOs, runoffResultsDTOs, total), nil
+	}
+
+	log.Println("Invalid role!")
+	return dto.GeneralElectionResultsDTO{}, errors.New("Invalid role!")
+}
+
+func (service *electionService) RecountVotes(userId string, role int, electionId string) (dto.GeneralElectionResultsDTO, error) {
+	err := service.database.RecountVotes(userId, electionId)
+	if err != nil {
+		return dto.GeneralElectionResultsDTO{}, err
+	}
+
+	return service.GetElectionResults(userId, role, electionId)
+}
+
+// Private functions
+func (service *electionService) getPositionDTOs(electionId string) ([]dto.PositionDTO, error) {
+	positions, err := service.database.GetPositions(electionId)
+	if err != nil {
+		return nil, err
+	}
+
+	var positionDTOs []dto.PositionDTO
+	for _, position := range positions {
+		positionDTOs = append(positionDTOs, mappers.ToPositionDTOFromPosition(position))
+	}
+
+	return positionDTOs, nil
+}
+
+func (service *electionService) toCandidateResultsDTOs(candidates []models.Candidate) ([]dto.CandidateResultsDTO, error) {
+	var candidateResultsDTOs []dto.CandidateResultsDTO
+	for _, candidate := range candidates {
+		user, err := service.database.GetUser(candidate.UserID.String())
 		if err != nil {
-			return dto.GeneralElectionResultsDTO{}, err
+			return nil, err
 		}
+		candidateResultsDTOs = append(candidateResultsDTOs, mappers.ToCandidateResultsDTOFromCandidate(candidate, user.FirstName+" "+user.LastName))
+	}
+
+	return candidateResultsDTOs, nil
+}
 
-		if role == 1 || role == 2 {
-			return mappers.ToGeneralElectionResultsDTOForAdmins(election, totalParticipants, nil, mCandidateResultsDTOs, fCandidateResultsDTOs, oCandidateResultsDTOs, total), nil
-		} else if role == 0 {
-			return mappers.ToGeneralElectionResultsDTOForStudents(election, totalParticipants, nil, mCandidateResultsDTOs, fCandidateResultsDTOs, oCandidateResultsDTOs, total), nil
+func filterByPosition(candidateResultsDTOs []dto.CandidateResultsDTO, positionId string) []dto.CandidateResultsDTO {
+	var filtered []dto.CandidateResultsDTO
+	for _, candidateResultsDTO := range candidateResultsDTOs {
+		if candidateResultsDTO.PositionID == positionId {
+			filtered = append(filtered, candidateResultsDTO)
 		}
 	}
 
-	log.Println("Invalid role!")
-	return dto.GeneralElectionResultsDTO{}, errors.New("Invalid role!")
+	return filtered
+}
+
+// getRunoffs runs an instant-runoff tally of the position's preferences, once per gender for gender-specific elections
+func getRunoffs(election models.Election, positionId string, ballots [][]dto.BallotChoiceDTO, candidateResultsDTOs []dto.CandidateResultsDTO, mCandidateResultsDTOs []dto.CandidateResultsDTO, fCandidateResultsDTOs []dto.CandidateResultsDTO, oCandidateResultsDTOs []dto.CandidateResultsDTO) []dto.RunoffResultsDTO {
+	var preferences [][]string
+	for _, ballot := range ballots {
+		for _, choice := range ballot {
+			if choice.PositionId == positionId {
+				preferences = append(preferences, choice.Preferences)
+			}
+		}
+	}
+
+	groups := []string{"all"}
+	groupCandidates := [][]dto.CandidateResultsDTO{candidateResultsDTOs}
+	if election.GenderSpecific {
+		groups = []string{"male", "female", "others"}
+		groupCandidates = [][]dto.CandidateResultsDTO{mCandidateResultsDTOs, fCandidateResultsDTOs, oCandidateResultsDTOs}
+	}
+
+	var runoffResultsDTOs []dto.RunoffResultsDTO
+	for i, group := range groups {
+		if len(groupCandidates[i]) == 0 {
+			continue
+		}
+
+		rounds, winners := instantRunoff(groupCandidates[i], preferences)
+		runoffResultsDTOs = append(runoffResultsDTOs, mappers.ToRunoffResultsDTO(group, rounds, winners))
+	}
+
+	return runoffResultsDTOs
+}
+
+// getWinners returns every candidate tied for the most votes, the list is expected to be sorted by votes
+func getWinners(candidateResultsDTOs []dto.CandidateResultsDTO) []dto.CandidateResultsDTO {
+	var winners []dto.CandidateResultsDTO
+	for _, candidateResultsDTO := range candidateResultsDTOs {
+		if candidateResultsDTO.Votes == 0 || candidateResultsDTO.Votes < candidateResultsDTOs[0].Votes {
+			break
+		}
+		winners = append(winners, candidateResultsDTO)
+	}
+
+	return winners
 }
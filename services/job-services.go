@@ -0,0 +1,167 @@
+package services
+
+import (
+	"elect/database"
+	"elect/email"
+	"elect/jobs"
+	"elect/lifecycle"
+	"elect/mappers"
+	"elect/models"
+	"encoding/json"
+	"errors"
+	"log"
+	"strconv"
+	"time"
+)
+
+// JobService runs the persisted election jobs, notify pushes events to the connected websocket clients. Statuses are
+// changed through the lifecycle service, so there is a single scheduler moving elections between phases.
+type JobService interface {
+	StartScheduler()
+	RunDueJobs()
+}
+
+type jobService struct {
+	database  database.Database
+	lifecycle LifecycleService
+	mailer    email.Mailer
+	notify    func(message []byte)
+}
+
+func NewJobService(database database.Database, lifecycle LifecycleService, mailer email.Mailer, notify func(message []byte)) JobService {
+	return &jobService{
+		database:  database,
+		lifecycle: lifecycle,
+		mailer:    mailer,
+		notify:    notify,
+	}
+}
+
+// StartScheduler schedules jobs for elections that don't have them yet, then applies the due transitions and runs the
+// due jobs in the background.
+func (service *jobService) StartScheduler() {
+	err := service.database.ScheduleMissingJobs()
+	if err != nil {
+		log.Println("Failed to schedule election jobs: " + err.Error())
+	}
+
+	go func() {
+		ticker := time.NewTicker(15 * time.Second)
+		defer ticker.Stop()
+
+		for {
+			service.lifecycle.SyncElections()
+			service.RunDueJobs()
+			<-ticker.C
+		}
+	}()
+}
+
+func (service *jobService) RunDueJobs() {
+	dueJobs, err := service.database.ClaimDueJobs(10)
+	if err != nil {
+		return
+	}
+
+	for _, job := range dueJobs {
+		status, runAt, err := service.runJob(job)
+
+		attempts := job.Attempts
+		lastError := ""
+		if err != nil {
+			attempts++
+			lastError = err.Error()
+			log.Println(job.Kind + " " + job.ElectionID.String() + ": " + lastError)
+
+			status = jobs.Pending
+			runAt = time.Now().UTC().Add(time.Duration(attempts) * time.Minute)
+			if attempts >= jobs.MaxAttempts {
+				status = jobs.Failed
+			}
+		}
+
+		err = service.database.FinishJob(job.JobID.String(), status, attempts, runAt, lastError)
+		if err != nil {
+			log.Println(err.Error())
+		}
+	}
+}
+
+// runJob returns the status the job ends up in and when it should run next if it has to wait.
+func (service *jobService) runJob(job models.Job) (int, time.Time, error) {
+	election, err := service.database.GetElection(job.ElectionID.String())
+	if err != nil {
+		if err.Error() == "Invalid Election!" {
+			return jobs.Skipped, job.RunAt, nil
+		}
+		return jobs.Pending, job.RunAt, err
+	}
+
+	now := time.Now().UTC()
+
+	// The election may have been edited or extended since the job was scheduled
+	if due := jobs.RunAt(election, job.Kind); due.After(now) {
+		return jobs.Pending, due, nil
+	}
+
+	if election.Status == lifecycle.Draft && !now.Before(election.EndingAt.UTC()) {
+		return jobs.Skipped, job.RunAt, nil
+	}
+	if election.Status == lifecycle.Draft || election.Paused {
+		return jobs.Pending, now.Add(time.Minute), nil
+	}
+
+	err = service.lifecycle.SyncElection(job.ElectionID.String())
+	if err != nil {
+		return jobs.Pending, job.RunAt, err
+	}
+
+	switch job.Kind {
+	case jobs.ElectionLocked:
+		err = service.database.FreezeCandidateList(job.ElectionID.String())
+		if err != nil {
+			return jobs.Pending, job.RunAt, err
+		}
+	case jobs.ElectionStarted:
+		err = service.emailParticipants(election)
+		if err != nil {
+			return jobs.Pending, job.RunAt, err
+		}
+	}
+
+	election, err = service.database.GetElection(job.ElectionID.String())
+	if err != nil {
+		return jobs.Pending, job.RunAt, err
+	}
+
+	message, err := json.Marshal(mappers.ToElectionEventDTO(job.Kind, election))
+	if err == nil {
+		service.notify(message)
+	}
+
+	return jobs.Done, job.RunAt, nil
+}
+
+// emailParticipants queues one email per participant under a key of the election and the user, a retry only queues the ones that are missing.
+func (service *jobService) emailParticipants(election models.Election) error {
+	users, err := service.database.GetParticipantUsers(election.ElectionID.String())
+	if err != nil {
+		return err
+	}
+
+	failed := 0
+	for _, user := range users {
+		idempotencyKey := jobs.ElectionStarted + ":" + election.ElectionID.String() + ":" + user.UserID.String()
+		err = email.SendVotingOpenEmail(service.mailer, user.FirstName, user.Email, election.Title, idempotencyKey, "voting.html")
+		if err != nil {
+			log.Println(user.Email + ": " + err.Error())
+			failed++
+		}
+	}
+
+	if failed > 0 {
+		return errors.New(strconv.Itoa(failed) + " voting emails couldn't be queued!")
+	}
+
+	return nil
+}
@@ -3,6 +3,7 @@ package services
 import (
 	"elect/database"
 	"elect/dto"
+	"elect/jwtkeys"
 	"errors"
 	"fmt"
 	"os"
@@ -10,68 +11,73 @@ import (
 	"time"
 
 	"github.com/golang-jwt/jwt"
+	uuid "github.com/satori/go.uuid"
 )
 
 type JWTService interface {
-	GenerateToken(dto.AuthUserDTO, int) string
-	GenerateTokenForSuperAdmin(authUserDTO dto.AuthUserDTO, role int) string
-	GenerateRefreshToken(dto.AuthUserDTO) string
-	GenerateRefreshTokenForSuperAdmin(authUserDTO dto.AuthUserDTO) string
+	GenerateToken(authUserDTO dto.AuthUserDTO, role int, sessionId string) string
+	GenerateTokenForSuperAdmin(authUserDTO dto.AuthUserDTO, role int, sessionId string) string
+	GenerateRefreshToken(authUserDTO dto.AuthUserDTO, sessionId string) string
+	GenerateRefreshTokenForSuperAdmin(authUserDTO dto.AuthUserDTO, sessionId string) string
 	GenerateNewTokens(tokenString string) (string, string, error)
 	ValidateAccessToken(tokenString string) (*jwt.Token, error)
 	ValidateRefreshToken(tokenString string) (*jwt.Token, error)
 	GetUserIDAndRole(tokenString string) (string, int, error)
 	GetRole(tokenString string) (int, error)
 	GetEmail(tokenString string) (string, error)
+	GetSessionID(tokenString string) (string, error)
+	GetIdentity(accessTokenString string, refreshTokenString string) (string, int, string, error)
 	GenerateOTPToken(email string) string
 	ValidateOTPToken(tokenString string) (*jwt.Token, error)
 	GetEmailFromOTPToken(tokenString string) (string, error)
+	JWKS() jwtkeys.JSONWebKeySet
 }
 
 type jwtService struct {
 	issuer   string
 	database database.Database
+	keySet   *jwtkeys.KeySet
 }
 
-func NewJWTService(issuer string, database database.Database) JWTService {
+// NewJWTService signs access tokens with the key set, refresh and OTP tokens stay HMAC since only ELECT reads them.
+func NewJWTService(issuer string, database database.Database, keySet *jwtkeys.KeySet) JWTService {
 	return &jwtService{
 		issuer:   issuer,
 		database: database,
+		keySet:   keySet,
 	}
 }
 
-func (service *jwtService) GenerateToken(authUserDTO dto.AuthUserDTO, role int) string {
+func (service *jwtService) GenerateToken(authUserDTO dto.AuthUserDTO, role int, sessionId string) string {
 	claims := &jwt.MapClaims{
 		"userid": authUserDTO.UserID,
 		"email":  authUserDTO.Email,
 		"role":   role,
+		"sid":    sessionId,
 		"exp":    time.Now().Add(time.Minute * 1).Unix(),
 		"iss":    service.issuer,
 		"iat":    time.Now().Unix(),
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-
-	t, err := token.SignedString([]byte(os.Getenv("ACCESS_TOKEN_SECRET")))
+	t, err := service.keySet.Sign(claims)
 	if err != nil {
 		panic(err)
 	}
 	return t
 }
 
-func (service *jwtService) GenerateTokenForSuperAdmin(authUserDTO dto.AuthUserDTO, role int) string {
+func (service *jwtService) GenerateTokenForSuperAdmin(authUserDTO dto.AuthUserDTO, role int, sessionId string) string {
 	claims := &jwt.MapClaims{
 		"userid": authUserDTO.UserID,
 		"email":  authUserDTO.Email,
 		"role":   role,
+		"sid":    sessionId,
 		"exp":    time.Now().Add(time.Hour * 24).Unix(),
 		"iss":    service.issuer,
 		"iat":    time.Now().Unix(),
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-
-	t, err := token.SignedString([]byte(os.Getenv("ACCESS_TOKEN_SECRET")))
+	t, err := service.keySet.Sign(claims)
 	if err != nil {
 		panic(err)
 	}
@@ -88,6 +94,7 @@ func (service *jwtService) GenerateNewTokens(tokenString string) (string, string
 	if claims := token.Claims.(jwt.MapClaims); token.Valid {
 		//Sprintf to convert interface{} to string
 		email := fmt.Sprintf("%v", claims["email"])
+		sessionId := fmt.Sprintf("%v", claims["sid"])
 
 		authDTO, err := service.database.FindUserForAuth(email)
 		if err != nil {
@@ -99,16 +106,19 @@ func (service *jwtService) GenerateNewTokens(tokenString string) (string, string
 			return "", "", err
 		}
 
-		return service.GenerateToken(authDTO, role), service.GenerateRefreshToken(authDTO), nil
+		return service.GenerateToken(authDTO, role, sessionId), service.GenerateRefreshToken(authDTO, sessionId), nil
 	} else {
 		return "", "", errors.New("Failed to extract JWT claims.")
 	}
 }
 
-func (service *jwtService) GenerateRefreshToken(authUserDTO dto.AuthUserDTO) string {
+// GenerateRefreshToken gives every token its own jti, so two rotations within the same second never repeat a token.
+func (service *jwtService) GenerateRefreshToken(authUserDTO dto.AuthUserDTO, sessionId string) string {
 	claims := &jwt.MapClaims{
 		"userid": authUserDTO.UserID,
 		"email":  authUserDTO.Email,
+		"sid":    sessionId,
+		"jti":    uuid.NewV4().String(),
 		"exp":    time.Now().Add(time.Hour * 24 * 7).Unix(),
 		"iss":    service.issuer,
 		"iat":    time.Now().Unix(),
@@ -124,10 +134,12 @@ func (service *jwtService) GenerateRefreshToken(authUserDTO dto.AuthUserDTO) str
 	return t
 }
 
-func (service *jwtService) GenerateRefreshTokenForSuperAdmin(authUserDTO dto.AuthUserDTO) string {
+func (service *jwtService) GenerateRefreshTokenForSuperAdmin(authUserDTO dto.AuthUserDTO, sessionId string) string {
 	claims := &jwt.MapClaims{
 		"userid": authUserDTO.UserID,
 		"email":  authUserDTO.Email,
+		"sid":    sessionId,
+		"jti":    uuid.NewV4().String(),
 		"exp":    time.Now().Add(time.Hour * 24).Unix(),
 		"iss":    service.issuer,
 		"iat":    time.Now().Unix(),
@@ -144,13 +156,7 @@ func (service *jwtService) GenerateRefreshTokenForSuperAdmin(authUserDTO dto.Aut
 }
 
 func (service *jwtService) ValidateAccessToken(tokenString string) (*jwt.Token, error) {
-	return jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, errors.New("Unexpected signing method.")
-		}
-
-		return []byte(os.Getenv("ACCESS_TOKEN_SECRET")), nil
-	})
+	return jwt.Parse(tokenString, service.keySet.Keyfunc)
 }
 
 func (service *jwtService) ValidateRefreshToken(tokenString string) (*jwt.Token, error) {
@@ -238,6 +244,68 @@ func (service *jwtService) GetEmail(tokenString string) (string, error) {
 	return email, nil
 }
 
+// GetSessionID returns the session the token was issued for.
+func (service *jwtService) GetSessionID(tokenString string) (string, error) {
+	token, err := service.ValidateAccessToken(tokenString)
+
+	if err != nil && err.Error() != "Token is expired" {
+		return "", errors.New("Failed to extract JWT claims.")
+	}
+
+	claims := token.Claims.(jwt.MapClaims)
+
+	sessionId, ok := claims["sid"].(string)
+	if !ok || sessionId == "" {
+		return "", errors.New("Failed to extract JWT claims.")
+	}
+
+	return sessionId, nil
+}
+
+// GetIdentity returns the user ID, role and session of an access token. An expired one still names the caller as long
+// as the refresh token of the same session can renew it. Only the cookie flow has a refresh token, so an expired Bearer token is never an identity.
+func (service *jwtService) GetIdentity(accessTokenString string, refreshTokenString string) (string, int, string, error) {
+	token, err := service.ValidateAccessToken(accessTokenString)
+	if err != nil && err.Error() != "Token is expired" {
+		return "", -1, "", errors.New("Failed to extract JWT claims.")
+	}
+
+	userId, role, sessionId, err := identityClaims(token)
+	if err != nil || token.Valid {
+		return userId, role, sessionId, err
+	}
+
+	refreshToken, err := service.ValidateRefreshToken(refreshTokenString)
+	if err != nil || !refreshToken.Valid {
+		return "", -1, "", errors.New("Token is expired")
+	}
+
+	claims := refreshToken.Claims.(jwt.MapClaims)
+	if claims["userid"] != userId || claims["sid"] != sessionId {
+		return "", -1, "", errors.New("Failed to extract JWT claims.")
+	}
+
+	return userId, role, sessionId, nil
+}
+
+func identityClaims(token *jwt.Token) (string, int, string, error) {
+	claims := token.Claims.(jwt.MapClaims)
+
+	userId, ok := claims["userid"].(string)
+	if !ok || userId == "" {
+		return "", -1, "", errors.New("Failed to extract JWT claims.")
+	}
+
+	role, err := strconv.Atoi(fmt.Sprintf("%v", claims["role"]))
+	if err != nil {
+		return "", -1, "", err
+	}
+
+	sessionId, _ := claims["sid"].(string)
+
+	return userId, role, sessionId, nil
+}
+
 func (service *jwtService) GenerateOTPToken(email string) string {
 	claims := &jwt.MapClaims{
 		"email": email,
@@ -285,3 +353,8 @@ func (service *jwtService) GetEmailFromOTPToken(tokenString string) (string, err
 
 	return email, nil
 }
+
+// JWKS publishes the public keys access tokens can be verified with.
+func (service *jwtService) JWKS() jwtkeys.JSONWebKeySet {
+	return service.keySet.JWKS()
+}
@@ -0,0 +1,91 @@
+package services
+
+import (
+	"elect/database"
+	"elect/dto"
+	"elect/lifecycle"
+	"elect/mappers"
+	"errors"
+	"log"
+	"time"
+)
+
+// LifecycleService is the only place election statuses change, either from the job scheduler or from the admin controls.
+type LifecycleService interface {
+	SyncElections()
+	SyncElection(electionId string) error
+	OpenElection(userId string, electionId string) error
+	PauseElection(userId string, electionId string) error
+	ResumeElection(userId string, electionId string) error
+	ExtendElection(userId string, electionId string, extendElectionDTO dto.ExtendElectionDTO) error
+	PublishResults(userId string, electionId string) error
+	ArchiveElection(userId string, electionId string) error
+}
+
+type lifecycleService struct {
+	database database.Database
+}
+
+func NewLifecycleService(database database.Database) LifecycleService {
+	return &lifecycleService{
+		database: database,
+	}
+}
+
+// SyncElections applies the time based transitions that are due, the job scheduler calls it on every tick.
+func (service *lifecycleService) SyncElections() {
+	elections, err := service.database.GetElectionsToSync()
+	if err != nil {
+		return
+	}
+
+	now := time.Now()
+	for _, election := range elections {
+		status := lifecycle.Current(election, now)
+		if status == election.Status {
+			continue
+		}
+
+		err = service.database.SyncElectionStatus(election.ElectionID.String())
+		if err != nil {
+			log.Println(election.ElectionID.String() + ": " + err.Error())
+			continue
+		}
+
+		log.Println(election.Title + ": " + lifecycle.Name(election.Status) + " -> " + lifecycle.Name(status))
+	}
+}
+
+// SyncElection moves one election to the status its timings call for.
+func (service *lifecycleService) SyncElection(electionId string) error {
+	return service.database.SyncElectionStatus(electionId)
+}
+
+func (service *lifecycleService) OpenElection(userId string, electionId string) error {
+	return service.database.OpenElection(userId, electionId)
+}
+
+func (service *lifecycleService) PauseElection(userId string, electionId string) error {
+	return service.database.PauseElection(userId, electionId)
+}
+
+func (service *lifecycleService) ResumeElection(userId string, electionId string) error {
+	return service.database.ResumeElection(userId, electionId)
+}
+
+func (service *lifecycleService) ExtendElection(userId string, electionId string, extendElectionDTO dto.ExtendElectionDTO) error {
+	endingAt := mappers.ToEndingAtFromExtendElectionDTO(extendElectionDTO)
+	if endingAt.IsZero() {
+		return errors.New("Invalid ending time!")
+	}
+
+	return service.database.ExtendElection(userId, electionId, endingAt)
+}
+
+func (service *lifecycleService) PublishResults(userId string, electionId string) error {
+	return service.database.PublishResults(userId, electionId)
+}
+
+func (service *lifecycleService) ArchiveElection(userId string, electionId string) error {
+	return service.database.ArchiveElection(userId, electionId)
+}
@@ -0,0 +1,96 @@
+package services
+
+import (
+	"elect/database"
+	"elect/email"
+	"elect/lockout"
+	"errors"
+	"log"
+	"time"
+)
+
+// LockoutService slows down and then locks out repeated failed logins and OTPs, both per account and per IP address.
+type LockoutService interface {
+	Check(email string, ip string) error
+	RecordFailure(email string, ip string) error
+	RecordSuccess(email string) error
+	UnlockAccount(userId string, role int, studentUserId string) error
+}
+
+type lockoutService struct {
+	database database.Database
+	mailer   email.Mailer
+}
+
+func NewLockoutService(database database.Database, mailer email.Mailer) LockoutService {
+	return &lockoutService{
+		database: database,
+		mailer:   mailer,
+	}
+}
+
+// Check fails while the account is locked, or while the account or the IP address has to wait out its backoff.
+func (service *lockoutService) Check(userEmail string, ip string) error {
+	accountKey, ipKey := lockout.AccountKey(userEmail), lockout.IPKey(ip)
+
+	throttles, err := service.database.GetLoginThrottles([]string{accountKey, ipKey})
+	if err != nil {
+		return err
+	}
+
+	now := time.Now().UTC()
+	for _, throttle := range throttles {
+		if throttle.Key == accountKey && throttle.LockedUntil != nil && throttle.LockedUntil.After(now) {
+			return errors.New("Account locked!")
+		}
+	}
+
+	for _, throttle := range throttles {
+		if (throttle.LockedUntil != nil && throttle.LockedUntil.After(now)) || throttle.BlockedUntil.After(now) {
+			return errors.New("Too many attempts!")
+		}
+	}
+
+	return nil
+}
+
+// RecordFailure counts a failed login or OTP, the owner of the account is emailed when it gets locked.
+func (service *lockoutService) RecordFailure(userEmail string, ip string) error {
+	throttle, locked, err := service.database.RecordLoginFailure(lockout.AccountKey(userEmail), lockout.MaxFailures())
+	if err != nil {
+		return err
+	}
+
+	if locked {
+		log.Println("Account locked: " + throttle.Key)
+
+		// Failures are counted for unknown emails too, only real accounts get the email
+		user, err := service.database.GetUserByEmail(userEmail)
+		if err == nil {
+			err = email.SendAccountLockedEmail(service.mailer, user.FirstName, user.Email, throttle.LockedUntil.Format("02 Jan 2006 15:04 MST"), "locked.html")
+			if err != nil {
+				log.Println(err.Error())
+			}
+		}
+	}
+
+	throttle, locked, err = service.database.RecordLoginFailure(lockout.IPKey(ip), lockout.IPMaxFailures())
+	if err != nil {
+		return err
+	}
+
+	if locked {
+		log.Println("IP address locked: " + throttle.Key)
+	}
+
+	return nil
+}
+
+// RecordSuccess clears the failures of the account, the IP address keeps its count so it can't be reset with one known login.
+func (service *lockoutService) RecordSuccess(userEmail string) error {
+	return service.database.ClearLoginFailures(lockout.AccountKey(userEmail))
+}
+
+func (service *lockoutService) UnlockAccount(userId string, role int, studentUserId string) error {
+	return service.database.UnlockAccount(userId, role, studentUserId)
+}
@@ -0,0 +1,414 @@
+package services
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"elect/database"
+	"elect/dto"
+	"elect/email"
+	"elect/otpmethods"
+	"elect/roles"
+	"encoding/base32"
+	"encoding/base64"
+	"errors"
+	"image/png"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/pquerna/otp"
+	"github.com/pquerna/otp/totp"
+)
+
+// OTPService sends and verifies the second factor of the login, either a code emailed to the user or one from their authenticator app.
+type OTPService interface {
+	SendLoginOTP(email string) (int, error)
+	VerifyLoginOTP(email string, code string, method *int) error
+	EnrollAuthenticator(userId string) (dto.TOTPEnrollmentDTO, error)
+	ConfirmAuthenticator(userId string, code string) error
+	SetOTPMethod(userId string, method int) error
+	GenerateRecoveryCodes(userId string) ([]string, error)
+	RegenerateStudentRecoveryCodes(userId string, role int, studentUserId string) ([]string, error)
+}
+
+type otpService struct {
+	database database.Database
+	mailer   email.Mailer
+	cipher   cipher.AEAD
+}
+
+// NewOTPService fails without an encryption key, the OTP secrets would otherwise be sealed with a key anyone can derive.
+func NewOTPService(database database.Database, mailer email.Mailer, encryptionKey string) (OTPService, error) {
+	gcm, err := otpCipher(encryptionKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return &otpService{
+		database: database,
+		mailer:   mailer,
+		cipher:   gcm,
+	}, nil
+}
+
+// OTPEncryptionKeyFromEnv reads TOTP_ENCRYPTION_KEY, or OTP_SECRET when it isn't set, so deployments that only had
+// OTP_SECRET keep reading the secrets they stored.
+func OTPEncryptionKeyFromEnv() string {
+	if key := os.Getenv("TOTP_ENCRYPTION_KEY"); key != "" {
+		return key
+	}
+
+	return os.Getenv("OTP_SECRET")
+}
+
+// Email codes stay valid as long as the otp cookie, authenticator apps use the standard 30 seconds. An email code is
+// only accepted in its own step, with the longer period a skew would keep it valid for up to 12 minutes.
+var emailOTPOpts = totp.ValidateOpts{Period: 240, Skew: 0, Digits: otp.DigitsSix, Algorithm: otp.AlgorithmSHA1}
+var authenticatorOTPOpts = totp.ValidateOpts{Period: 30, Skew: 1, Digits: otp.DigitsSix, Algorithm: otp.AlgorithmSHA1}
+
+// SendLoginOTP emails a code when the user logs in with email OTPs and returns the method the user has to answer with.
+func (service *otpService) SendLoginOTP(userEmail string) (int, error) {
+	user, err := service.database.GetUserByEmail(userEmail)
+	if err != nil {
+		return 0, err
+	}
+
+	if user.OTPMethod == otpmethods.Authenticator {
+		return otpmethods.Authenticator, nil
+	}
+
+	secret, err := service.emailSecret(user.UserID.String(), user.OTPSecret)
+	if err != nil {
+		return 0, err
+	}
+
+	code, err := totp.GenerateCodeCustom(secret, time.Now().UTC(), emailOTPOpts)
+	if err != nil {
+		log.Println(err.Error())
+		return 0, err
+	}
+
+	err = email.SendOTPEmail(service.mailer, user.Email, code, "otptemplate.html")
+	if err != nil {
+		return 0, err
+	}
+
+	return otpmethods.Email, nil
+}
+
+// VerifyLoginOTP checks the code against the user's configured method, or a recovery code. A method given by the client
+// can only pick between the two, it can't fall back to a method the user didn't set up.
+func (service *otpService) VerifyLoginOTP(userEmail string, code string, method *int) error {
+	user, err := service.database.GetUserByEmail(userEmail)
+	if err != nil {
+		return err
+	}
+
+	otpMethod := user.OTPMethod
+	if method != nil {
+		if *method != user.OTPMethod && *method != otpmethods.RecoveryCode {
+			log.Println("Invalid OTP Method!")
+			return errors.New("Invalid OTP Method!")
+		}
+		otpMethod = *method
+	} else if isRecoveryCode(code) {
+		otpMethod = otpmethods.RecoveryCode
+	}
+
+	if otpMethod == otpmethods.RecoveryCode {
+		return service.database.UseRecoveryCode(user.UserID.String(), normalizeRecoveryCode(code))
+	}
+
+	var encrypted string
+	var opts totp.ValidateOpts
+	switch otpMethod {
+	case otpmethods.Email:
+		encrypted, opts = user.OTPSecret, emailOTPOpts
+	case otpmethods.Authenticator:
+		encrypted, opts = user.TOTPSecret, authenticatorOTPOpts
+	default:
+		return errors.New("Invalid OTP Method!")
+	}
+
+	if encrypted == "" {
+		log.Println("Invalid OTP!")
+		return errors.New("Invalid OTP!")
+	}
+
+	secret, err := service.decryptSecret(encrypted)
+	if err != nil {
+		return err
+	}
+
+	step, valid := otpStep(code, secret, time.Now().UTC(), opts)
+	if !valid {
+		return errors.New("Invalid OTP!")
+	}
+
+	return service.database.UseOTPStep(user.UserID.String(), otpMethod, step)
+}
+
+// EnrollAuthenticator generates a new authenticator secret, it only replaces the active one once a code from it is confirmed.
+func (service *otpService) EnrollAuthenticator(userId string) (dto.TOTPEnrollmentDTO, error) {
+	user, err := service.database.GetUser(userId)
+	if err != nil {
+		return dto.TOTPEnrollmentDTO{}, err
+	}
+
+	key, err := totp.Generate(totp.GenerateOpts{
+		Issuer:      "ELECT",
+		AccountName: user.Email,
+	})
+	if err != nil {
+		log.Println(err.Error())
+		return dto.TOTPEnrollmentDTO{}, err
+	}
+
+	encrypted, err := service.encryptSecret(key.Secret())
+	if err != nil {
+		return dto.TOTPEnrollmentDTO{}, err
+	}
+
+	img, err := key.Image(200, 200)
+	if err != nil {
+		log.Println(err.Error())
+		return dto.TOTPEnrollmentDTO{}, err
+	}
+
+	var qrCode bytes.Buffer
+	err = png.Encode(&qrCode, img)
+	if err != nil {
+		log.Println(err.Error())
+		return dto.TOTPEnrollmentDTO{}, err
+	}
+
+	err = service.database.StorePendingTOTPSecret(userId, encrypted)
+	if err != nil {
+		return dto.TOTPEnrollmentDTO{}, err
+	}
+
+	return dto.TOTPEnrollmentDTO{
+		Secret:     key.Secret(),
+		OTPAuthURL: key.URL(),
+		QRCode:     base64.StdEncoding.EncodeToString(qrCode.Bytes()),
+	}, nil
+}
+
+func (service *otpService) ConfirmAuthenticator(userId string, code string) error {
+	user, err := service.database.GetUser(userId)
+	if err != nil {
+		return err
+	}
+
+	if user.PendingTOTPSecret == "" {
+		log.Println("No pending authenticator enrollment!")
+		return errors.New("No pending authenticator enrollment!")
+	}
+
+	secret, err := service.decryptSecret(user.PendingTOTPSecret)
+	if err != nil {
+		return err
+	}
+
+	valid, err := totp.ValidateCustom(code, secret, time.Now().UTC(), authenticatorOTPOpts)
+	if err != nil || !valid {
+		return errors.New("Invalid OTP!")
+	}
+
+	return service.database.ConfirmTOTPSecret(userId, user.PendingTOTPSecret)
+}
+
+func (service *otpService) SetOTPMethod(userId string, method int) error {
+	if method != otpmethods.Email && method != otpmethods.Authenticator {
+		return errors.New("Invalid OTP Method!")
+	}
+
+	return service.database.SetOTPMethod(userId, method)
+}
+
+// GenerateRecoveryCodes replaces the user's recovery codes, they are only shown this once.
+func (service *otpService) GenerateRecoveryCodes(userId string) ([]string, error) {
+	codes, err := newRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+
+	err = service.database.ReplaceRecoveryCodes(userId, userId, normalizeRecoveryCodes(codes))
+	if err != nil {
+		return nil, err
+	}
+
+	return codes, nil
+}
+
+// RegenerateStudentRecoveryCodes lets an admin hand new recovery codes to a student they registered, after checking the
+// registration number on the student's ID card matches.
+// RegenerateStudentRecoveryCodes trusts the admin to have made sure who is asking, ELECT can't check that for them. The
+// student is emailed, so codes regenerated without them knowing don't go unnoticed.
+func (service *otpService) RegenerateStudentRecoveryCodes(userId string, role int, studentUserId string) ([]string, error) {
+	student, err := service.database.GetUser(studentUserId)
+	if err != nil {
+		return nil, errors.New("Invalid Student!")
+	}
+
+	if student.Role != roles.Student || (role != roles.SuperAdmin && student.RegisteredBy != userId) {
+		log.Println("Invalid Student!")
+		return nil, errors.New("Invalid Student!")
+	}
+
+	codes, err := newRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+
+	err = service.database.ReplaceRecoveryCodes(userId, studentUserId, normalizeRecoveryCodes(codes))
+	if err != nil {
+		return nil, err
+	}
+
+	err = email.SendRecoveryCodesRegeneratedEmail(service.mailer, student.FirstName, student.Email, "recoverycodes.html")
+	if err != nil {
+		log.Println(err.Error())
+	}
+
+	return codes, nil
+}
+
+// emailSecret returns the decrypted email OTP secret, users without one get a random secret the first time they log in.
+func (service *otpService) emailSecret(userId string, encrypted string) (string, error) {
+	if encrypted == "" {
+		buf := make([]byte, 20)
+		_, err := rand.Read(buf)
+		if err != nil {
+			log.Println(err.Error())
+			return "", err
+		}
+
+		encrypted, err = service.encryptSecret(base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(buf))
+		if err != nil {
+			return "", err
+		}
+
+		encrypted, err = service.database.StoreOTPSecret(userId, encrypted)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	return service.decryptSecret(encrypted)
+}
+
+// otpStep returns the time step a valid code was generated for, within the skew of the options.
+func otpStep(code string, secret string, now time.Time, opts totp.ValidateOpts) (int64, bool) {
+	period := int64(opts.Period)
+	current := now.Unix() / period
+	for step := current - int64(opts.Skew); step <= current+int64(opts.Skew); step++ {
+		expected, err := totp.GenerateCodeCustom(secret, time.Unix(step*period, 0).UTC(), opts)
+		if err == nil && subtle.ConstantTimeCompare([]byte(expected), []byte(code)) == 1 {
+			return step, true
+		}
+	}
+
+	return 0, false
+}
+
+// Recovery codes look like "k7m2p-x9qtw", the alphabet leaves out characters that are easy to mix up.
+const recoveryCodeAlphabet = "23456789abcdefghjkmnpqrstuvwxyz"
+const recoveryCodeCount = 10
+
+func newRecoveryCodes() ([]string, error) {
+	codes := []string{}
+	for len(codes) < recoveryCodeCount {
+		code := []byte{}
+		for len(code) < 10 {
+			buf := make([]byte, 16)
+			_, err := rand.Read(buf)
+			if err != nil {
+				log.Println(err.Error())
+				return nil, err
+			}
+
+			// Bytes past the last full multiple of the alphabet are dropped so every character is equally likely
+			for _, b := range buf {
+				if int(b) < 256-256%len(recoveryCodeAlphabet) && len(code) < 10 {
+					code = append(code, recoveryCodeAlphabet[int(b)%len(recoveryCodeAlphabet)])
+				}
+			}
+		}
+		codes = append(codes, string(code[:5])+"-"+string(code[5:]))
+	}
+
+	return codes, nil
+}
+
+func normalizeRecoveryCode(code string) string {
+	return strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(code))
+}
+
+func normalizeRecoveryCodes(codes []string) []string {
+	normalized := []string{}
+	for _, code := range codes {
+		normalized = append(normalized, normalizeRecoveryCode(code))
+	}
+
+	return normalized
+}
+
+// isRecoveryCode tells a recovery code apart from a 6 digit OTP so the OTP field accepts both.
+func isRecoveryCode(code string) bool {
+	return len(normalizeRecoveryCode(code)) == 10
+}
+
+// otpEncryptionKeyMinLength keeps the key from being guessed, it is hashed to the AES key so anything shorter is weaker.
+const otpEncryptionKeyMinLength = 32
+
+// The OTP secrets are stored with AES-GCM, the key is derived from the encryption key.
+func otpCipher(encryptionKey string) (cipher.AEAD, error) {
+	if len(encryptionKey) < otpEncryptionKeyMinLength {
+		return nil, errors.New("TOTP_ENCRYPTION_KEY has to be at least 32 characters!")
+	}
+	key := sha256.Sum256([]byte(encryptionKey))
+
+	block, err := aes.NewCipher(key[:])
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
+
+func (service *otpService) encryptSecret(secret string) (string, error) {
+	gcm := service.cipher
+
+	nonce := make([]byte, gcm.NonceSize())
+	_, err := rand.Read(nonce)
+	if err != nil {
+		log.Println(err.Error())
+		return "", err
+	}
+
+	return base64.StdEncoding.EncodeToString(gcm.Seal(nonce, nonce, []byte(secret), nil)), nil
+}
+
+func (service *otpService) decryptSecret(encrypted string) (string, error) {
+	gcm := service.cipher
+
+	data, err := base64.StdEncoding.DecodeString(encrypted)
+	if err != nil || len(data) < gcm.NonceSize() {
+		log.Println("Invalid OTP secret!")
+		return "", errors.New("Invalid OTP secret!")
+	}
+
+	secret, err := gcm.Open(nil, data[:gcm.NonceSize()], data[gcm.NonceSize():], nil)
+	if err != nil {
+		log.Println(err.Error())
+		return "", errors.New("Invalid OTP secret!")
+	}
+
+	return string(secret), nil
+}
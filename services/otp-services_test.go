@@ -0,0 +1,108 @@
+package services
+
+import (
+	"elect/database"
+	"elect/models"
+	"elect/otpmethods"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/pquerna/otp/totp"
+	uuid "github.com/satori/go.uuid"
+)
+
+// otpDatabase keeps one user and the last OTP steps they used in memory.
+type otpDatabase struct {
+	database.Database
+	user models.User
+}
+
+func (db *otpDatabase) GetUserByEmail(email string) (models.User, error) {
+	if email != db.user.Email {
+		return models.User{}, errors.New("Invalid user!")
+	}
+	return db.user, nil
+}
+
+func (db *otpDatabase) UseOTPStep(userId string, method int, step int64) error {
+	last := &db.user.LastEmailOTPStep
+	if method == otpmethods.Authenticator {
+		last = &db.user.LastTOTPStep
+	}
+	if step <= *last {
+		return errors.New("Invalid OTP!")
+	}
+	*last = step
+	return nil
+}
+
+func TestOTPServiceNeedsEncryptionKey(t *testing.T) {
+	for _, key := range []string{"", "too-short"} {
+		if _, err := NewOTPService(nil, nil, key); err == nil {
+			t.Errorf("key %q: OTP service started", key)
+		}
+	}
+
+	service, err := NewOTPService(nil, nil, "0123456789abcdef0123456789abcdef")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	encrypted, err := service.(*otpService).encryptSecret("JBSWY3DPEHPK3PXP")
+	if err != nil {
+		t.Fatal(err)
+	}
+	secret, err := service.(*otpService).decryptSecret(encrypted)
+	if err != nil || secret != "JBSWY3DPEHPK3PXP" {
+		t.Fatalf("got %q, %v", secret, err)
+	}
+}
+
+func TestVerifyLoginOTPRejectsReplay(t *testing.T) {
+	db := &otpDatabase{user: models.User{UserID: uuid.NewV4(), Email: "student@elect.test", OTPMethod: otpmethods.Authenticator}}
+	service, err := NewOTPService(db, nil, "0123456789abcdef0123456789abcdef")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	secret := "JBSWY3DPEHPK3PXP"
+	db.user.TOTPSecret, err = service.(*otpService).encryptSecret(secret)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now().UTC()
+	code, err := totp.GenerateCodeCustom(secret, now, authenticatorOTPOpts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := service.VerifyLoginOTP(db.user.Email, code, nil); err != nil {
+		t.Fatalf("first use: %v", err)
+	}
+	if err := service.VerifyLoginOTP(db.user.Email, code, nil); err == nil {
+		t.Fatal("code was accepted twice")
+	}
+
+	// The previous step is still within the skew but older than the one just used
+	previous, err := totp.GenerateCodeCustom(secret, now.Add(-30*time.Second), authenticatorOTPOpts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := service.VerifyLoginOTP(db.user.Email, previous, nil); err == nil {
+		t.Fatal("code of an earlier step was accepted")
+	}
+}
+
+func TestEmailOTPHasNoSkew(t *testing.T) {
+	secret := "JBSWY3DPEHPK3PXP"
+	now := time.Now().UTC()
+	code, err := totp.GenerateCodeCustom(secret, now.Add(-240*time.Second), emailOTPOpts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, valid := otpStep(code, secret, now, emailOTPOpts); valid {
+		t.Fatal("email code of the previous period was accepted")
+	}
+}
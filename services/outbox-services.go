@@ -0,0 +1,136 @@
+package services
+
+import (
+	"elect/database"
+	"elect/dto"
+	"elect/email"
+	"elect/jobs"
+	"elect/mappers"
+	"log"
+	"time"
+)
+
+// OutboxService is the Mailer the other services use, emails are stored first and sent by the worker with the transport.
+type OutboxService interface {
+	email.Mailer
+	StartWorker()
+	SendQueuedEmails()
+	GetFailedEmails(userId string, role int) ([]dto.OutboxEmailDTO, error)
+	ResendEmails(userId string, role int, emailOutboxId string) (int, error)
+}
+
+type outboxService struct {
+	database  database.Database
+	transport email.Mailer
+	wake      chan struct{}
+}
+
+func NewOutboxService(database database.Database, transport email.Mailer) OutboxService {
+	return &outboxService{
+		database:  database,
+		transport: transport,
+		wake:      make(chan struct{}, 1),
+	}
+}
+
+func (service *outboxService) Send(message email.Message) error {
+	err := service.database.QueueEmail(message)
+	if err != nil {
+		return err
+	}
+
+	select {
+	case service.wake <- struct{}{}:
+
+	default:
+	}
+
+	return nil
+}
+
+// StartWorker sends the queued emails in the background, right away when they are queued by this instance.
+func (service *outboxService) StartWorker() {
+	go func() {
+		ticker := time.NewTicker(5 * time.Second)
+		defer ticker.Stop()
+
+		for {
+			service.SendQueuedEmails()
+
+			select {
+			case <-ticker.C:
+			case <-service.wake:
+			}
+		}
+	}()
+}
+
+func (service *outboxService) SendQueuedEmails() {
+	for {
+		emails, err := service.database.ClaimDueEmails(20)
+		if err != nil || len(emails) == 0 {
+			return
+		}
+
+		for _, e := range emails {
+			now := time.Now().UTC()
+
+			if e.ExpiresAt != nil && now.After(*e.ExpiresAt) {
+				err = service.database.FinishEmail(e.EmailOutboxID.String(), jobs.Skipped, e.Attempts, e.NextAttemptAt, "Expired!")
+				if err != nil {
+					return
+				}
+				continue
+			}
+
+			status, attempts, nextAttemptAt, lastError := jobs.Done, e.Attempts+1, e.NextAttemptAt, ""
+			err = service.transport.Send(email.Message{
+				To:      e.Recipient,
+				Subject: e.Subject,
+				Body:    e.Body,
+			})
+			if err != nil {
+				log.Println(e.Recipient + ": " + err.Error())
+
+				status, nextAttemptAt, lastError = jobs.Pending, now.Add(email.Backoff(attempts)), err.Error()
+				if attempts >= email.MaxAttempts() {
+					status = jobs.Failed
+				}
+			}
+
+			err = service.database.FinishEmail(e.EmailOutboxID.String(), status, attempts, nextAttemptAt, lastError)
+			if err != nil {
+				return
+			}
+		}
+	}
+}
+
+func (service *outboxService) GetFailedEmails(userId string, role int) ([]dto.OutboxEmailDTO, error) {
+	emails, err := service.database.GetFailedEmails(userId, role)
+	if err != nil {
+		return nil, err
+	}
+
+	outboxEmailDTOs := []dto.OutboxEmailDTO{}
+	for _, e := range emails {
+		outboxEmailDTOs = append(outboxEmailDTOs, mappers.ToOutboxEmailDTO(e))
+	}
+
+	return outboxEmailDTOs, nil
+}
+
+func (service *outboxService) ResendEmails(userId string, role int, emailOutboxId string) (int, error) {
+	count, err := service.database.ResendEmails(userId, role, emailOutboxId)
+	if err != nil {
+		return 0, err
+	}
+
+	select {
+	case service.wake <- struct{}{}:
+
+	default:
+	}
+
+	return count, nil
+}
@@ -0,0 +1,346 @@
+package services
+
+import (
+	"elect/database"
+	"elect/dto"
+	"elect/mappers"
+	"elect/models"
+	"elect/roles"
+	"errors"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/casbin/casbin/v2"
+	"github.com/casbin/casbin/v2/model"
+	"github.com/casbin/casbin/v2/persist"
+)
+
+// PolicyService keeps the authorization policy in the database, so super admins can change who may do what without a
+// redeploy. Every change is tried on a copy of the policy in the transaction saving it, and refused if super admins would
+// lose access to it.
+type PolicyService interface {
+	Enforcer() *casbin.SyncedEnforcer
+	GetPolicies() ([]dto.PolicyDTO, error)
+	AddPolicy(userId string, policyDTO dto.PolicyDTO) (dto.PolicyDTO, error)
+	EditPolicy(userId string, policyDTO dto.PolicyDTO) (dto.PolicyDTO, error)
+	DeletePolicy(userId string, policyId uint) error
+	Reload() error
+	Check(policyCheckDTO dto.PolicyCheckDTO) (dto.PolicyCheckResultDTO, error)
+	StartWatcher()
+}
+
+type policyService struct {
+	database  database.Database
+	modelPath string
+	enforcer  *casbin.SyncedEnforcer
+}
+
+// NewPolicyService adds the rules of the seed file(policy.csv) not added before and loads the enforcer from the table.
+func NewPolicyService(database database.Database, modelPath string, seedPath string) (PolicyService, error) {
+	seed, err := casbin.NewEnforcer(modelPath, seedPath)
+	if err != nil {
+		return nil, err
+	}
+
+	rules := []models.CasbinRule{}
+	for _, policy := range seed.GetPolicy() {
+		rules = append(rules, toCasbinRule(policy))
+	}
+
+	err = database.SeedPolicyRules(rules)
+	if err != nil {
+		return nil, err
+	}
+
+	enforcer, err := casbin.NewSyncedEnforcer(modelPath, &policyAdapter{rules: database.GetPolicyRules})
+	if err != nil {
+		return nil, err
+	}
+
+	return &policyService{
+		database:  database,
+		modelPath: modelPath,
+		enforcer:  enforcer,
+	}, nil
+}
+
+func (service *policyService) Enforcer() *casbin.SyncedEnforcer {
+	return service.enforcer
+}
+
+func (service *policyService) GetPolicies() ([]dto.PolicyDTO, error) {
+	rules, err := service.database.GetPolicyRules()
+	if err != nil {
+		return nil, err
+	}
+
+	policies := []dto.PolicyDTO{}
+	for _, rule := range rules {
+		policies = append(policies, mappers.ToPolicyDTO(rule))
+	}
+
+	return policies, nil
+}
+
+func (service *policyService) AddPolicy(userId string, policyDTO dto.PolicyDTO) (dto.PolicyDTO, error) {
+	policyDTO.ID = 0
+	rule, err := validatePolicy(policyDTO)
+	if err != nil {
+		return dto.PolicyDTO{}, err
+	}
+
+	rule, err = service.database.AddPolicyRule(userId, rule, service.tryPolicy(func(rules []models.CasbinRule) []models.CasbinRule {
+		return append(rules, rule)
+	}))
+	if err != nil {
+		return dto.PolicyDTO{}, err
+	}
+
+	// The change is saved either way, a failed reload is retried by the watcher
+	service.Reload()
+
+	return mappers.ToPolicyDTO(rule), nil
+}
+
+func (service *policyService) EditPolicy(userId string, policyDTO dto.PolicyDTO) (dto.PolicyDTO, error) {
+	rule, err := validatePolicy(policyDTO)
+	if err != nil {
+		return dto.PolicyDTO{}, err
+	}
+
+	rule, err = service.database.EditPolicyRule(userId, rule, service.tryPolicy(func(rules []models.CasbinRule) []models.CasbinRule {
+		for index := range rules {
+			if rules[index].ID == rule.ID {
+				rules[index] = rule
+			}
+		}
+		return rules
+	}))
+	if err != nil {
+		return dto.PolicyDTO{}, err
+	}
+
+	// The change is saved either way, a failed reload is retried by the watcher
+	service.Reload()
+
+	return mappers.ToPolicyDTO(rule), nil
+}
+
+func (service *policyService) DeletePolicy(userId string, policyId uint) error {
+	err := service.database.DeletePolicyRule(userId, policyId, service.tryPolicy(func(rules []models.CasbinRule) []models.CasbinRule {
+		kept := []models.CasbinRule{}
+		for _, rule := range rules {
+			if rule.ID != policyId {
+				kept = append(kept, rule)
+			}
+		}
+		return kept
+	}))
+	if err != nil {
+		return err
+	}
+
+	// The change is saved either way, a failed reload is retried by the watcher
+	service.Reload()
+
+	return nil
+}
+
+// Reload replaces the policy this instance enforces with the one in the database, a failed reload keeps the old one.
+func (service *policyService) Reload() error {
+	err := service.enforcer.LoadPolicy()
+	if err != nil {
+		log.Println("Failed to reload policies: " + err.Error())
+		return errors.New("Failed to reload policies!")
+	}
+
+	return nil
+}
+
+// Check answers whether the role may make the request under the policy being enforced, and which rule decided it.
+func (service *policyService) Check(policyCheckDTO dto.PolicyCheckDTO) (dto.PolicyCheckResultDTO, error) {
+	role := strings.TrimSpace(policyCheckDTO.Role)
+	if !validRole(role) || role == "*" {
+		return dto.PolicyCheckResultDTO{}, errors.New("Invalid role!")
+	}
+
+	allowed, explain, err := service.enforcer.EnforceEx(role, strings.TrimSpace(policyCheckDTO.Path), strings.ToUpper(strings.TrimSpace(policyCheckDTO.Method)))
+	if err != nil {
+		log.Println(err.Error())
+		return dto.PolicyCheckResultDTO{}, errors.New("Invalid Request!")
+	}
+
+	result := dto.PolicyCheckResultDTO{Allowed: allowed}
+	if len(explain) > 0 {
+		matched := mappers.ToPolicyDTO(toCasbinRule(explain))
+		result.Matched = &matched
+	}
+
+	return result, nil
+}
+
+// StartWatcher reloads the policy every POLICY_RELOAD_INTERVAL(default 1m), so every instance picks up the changes
+// made through another one.
+func (service *policyService) StartWatcher() {
+	go func() {
+		ticker := time.NewTicker(policyReloadInterval())
+		defer ticker.Stop()
+
+		for {
+			<-ticker.C
+			service.Reload()
+		}
+	}()
+}
+
+// superAdminRequests are the requests super admins have to keep being allowed, or nobody could fix the policy again.
+var superAdminRequests = [][]interface{}{
+	{strconv.Itoa(roles.SuperAdmin), "/api/policies", "GET"},
+	{strconv.Itoa(roles.SuperAdmin), "/api/policies", "POST"},
+	{strconv.Itoa(roles.SuperAdmin), "/api/policy/0", "PUT"},
+	{strconv.Itoa(roles.SuperAdmin), "/api/policy/0", "DELETE"},
+}
+
+// tryPolicy returns the check the database runs on the rules before saving a change. It applies the change to them in a
+// throwaway enforcer and checks super admins keep access.
+func (service *policyService) tryPolicy(change func(rules []models.CasbinRule) []models.CasbinRule) func(rules []models.CasbinRule) error {
+	return func(rules []models.CasbinRule) error {
+		rules = change(rules)
+
+		enforcer, err := casbin.NewEnforcer(service.modelPath, &policyAdapter{rules: func() ([]models.CasbinRule, error) {
+			return rules, nil
+		}})
+		if err != nil {
+			log.Println(err.Error())
+			return errors.New("Invalid Policy!")
+		}
+
+		results, err := enforcer.BatchEnforce(superAdminRequests)
+		if err != nil {
+			log.Println(err.Error())
+			return errors.New("Invalid Policy!")
+		}
+		for _, allowed := range results {
+			if !allowed {
+				return errors.New("Super admins would lose access to policies!")
+			}
+		}
+
+		return nil
+	}
+}
+
+var policyEffects = []string{"allow", "deny"}
+
+// validatePolicy keeps rules to what policy.csv holds, a role number or *, a path starting with /, a method and an effect.
+func validatePolicy(policyDTO dto.PolicyDTO) (models.CasbinRule, error) {
+	policyDTO.Role = strings.TrimSpace(policyDTO.Role)
+	policyDTO.Path = strings.TrimSpace(policyDTO.Path)
+	policyDTO.Method = strings.ToUpper(strings.TrimSpace(policyDTO.Method))
+	policyDTO.Effect = strings.ToLower(strings.TrimSpace(policyDTO.Effect))
+
+	if !validRole(policyDTO.Role) {
+		return models.CasbinRule{}, errors.New("Invalid role!")
+	}
+
+	// Rules are loaded as CSV lines, a comma or quote would split or garble them
+	if (policyDTO.Path != "*" && !strings.HasPrefix(policyDTO.Path, "/")) || len(policyDTO.Path) > 255 || strings.ContainsAny(policyDTO.Path, ",\" \t\n") {
+		return models.CasbinRule{}, errors.New("Invalid path!")
+	}
+
+	valid := false
+	for _, method := range apiKeyMethods {
+		valid = valid || policyDTO.Method == method
+	}
+	if !valid {
+		return models.CasbinRule{}, errors.New("Invalid method!")
+	}
+
+	valid = false
+	for _, effect := range policyEffects {
+		valid = valid || policyDTO.Effect == effect
+	}
+	if !valid {
+		return models.CasbinRule{}, errors.New("Invalid effect!")
+	}
+
+	return mappers.ToCasbinRuleFromPolicyDTO(policyDTO), nil
+}
+
+// validRole accepts * or one of the roles in package roles.
+func validRole(role string) bool {
+	if role == "*" {
+		return true
+	}
+
+	value, err := strconv.Atoi(role)
+	return err == nil && value >= roles.Authenticated && value <= roles.SuperAdmin
+}
+
+// toCasbinRule turns a policy line without its ptype(role, path, method, effect) into a rule.
+func toCasbinRule(policy []string) models.CasbinRule {
+	values := make([]string, 6)
+	copy(values, policy)
+
+	return models.CasbinRule{
+		PType: "p",
+		V0:    values[0],
+		V1:    values[1],
+		V2:    values[2],
+		V3:    values[3],
+		V4:    values[4],
+		V5:    values[5],
+	}
+}
+
+// policyAdapter loads rules into casbin. Rules are changed through the policy service rather than casbin's own
+// methods, so only loading is implemented.
+type policyAdapter struct {
+	rules func() ([]models.CasbinRule, error)
+}
+
+func (adapter *policyAdapter) LoadPolicy(model model.Model) error {
+	rules, err := adapter.rules()
+	if err != nil {
+		return err
+	}
+
+	for _, rule := range rules {
+		values := []string{rule.PType, rule.V0, rule.V1, rule.V2, rule.V3, rule.V4, rule.V5}
+		for len(values) > 0 && values[len(values)-1] == "" {
+			values = values[:len(values)-1]
+		}
+		persist.LoadPolicyLine(strings.Join(values, ", "), model)
+	}
+
+	return nil
+}
+
+func (adapter *policyAdapter) SavePolicy(model model.Model) error {
+	return errors.New("not implemented")
+}
+
+func (adapter *policyAdapter) AddPolicy(sec string, ptype string, rule []string) error {
+	return errors.New("not implemented")
+}
+
+func (adapter *policyAdapter) RemovePolicy(sec string, ptype string, rule []string) error {
+	return errors.New("not implemented")
+}
+
+func (adapter *policyAdapter) RemoveFilteredPolicy(sec string, ptype string, fieldIndex int, fieldValues ...string) error {
+	return errors.New("not implemented")
+}
+
+// policyReloadInterval is how often the policy is reloaded from the database, set by POLICY_RELOAD_INTERVAL(e.g, 30s).
+func policyReloadInterval() time.Duration {
+	interval, err := time.ParseDuration(os.Getenv("POLICY_RELOAD_INTERVAL"))
+	if err != nil || interval <= 0 {
+		return time.Minute
+	}
+
+	return interval
+}
@@ -0,0 +1,212 @@
+package services
+
+import (
+	"elect/database"
+	"elect/dto"
+	"elect/email"
+	"elect/jobs"
+	"elect/lifecycle"
+	"elect/mappers"
+	"elect/models"
+	"errors"
+	"log"
+	"os"
+	"strconv"
+	"time"
+)
+
+// ReminderService emails the participants who haven't voted, at most reminderRate emails a minute.
+type ReminderService interface {
+	StartWorker()
+	SendDueReminders()
+	ScheduleReminder(userId string, electionId string, scheduleReminderDTO dto.ScheduleReminderDTO) error
+	GetReminders(userId string, electionId string) ([]dto.ReminderDTO, error)
+}
+
+type reminderService struct {
+	database database.Database
+	mailer   email.Mailer
+	limiter  *time.Ticker
+}
+
+func NewReminderService(database database.Database, mailer email.Mailer) ReminderService {
+	return &reminderService{
+		database: database,
+		mailer:   mailer,
+		limiter:  time.NewTicker(time.Minute / time.Duration(reminderRate())),
+	}
+}
+
+// reminderRate is how many reminder emails go out per minute, set by REMINDER_RATE.
+func reminderRate() int {
+	rate, err := strconv.Atoi(os.Getenv("REMINDER_RATE"))
+	if err != nil || rate <= 0 {
+		return 60
+	}
+
+	return rate
+}
+
+// reminderInterval is the least time between two reminders of an election, set by REMINDER_INTERVAL(e.g, 1h).
+func reminderInterval() time.Duration {
+	interval, err := time.ParseDuration(os.Getenv("REMINDER_INTERVAL"))
+	if err != nil || interval < 0 {
+		return time.Hour
+	}
+
+	return interval
+}
+
+// StartWorker sends the due reminders in the background.
+func (service *reminderService) StartWorker() {
+	go func() {
+		ticker := time.NewTicker(15 * time.Second)
+		defer ticker.Stop()
+
+		for {
+			service.SendDueReminders()
+			<-ticker.C
+		}
+	}()
+}
+
+func (service *reminderService) SendDueReminders() {
+	reminders, err := service.database.ClaimDueReminders(1)
+	if err != nil {
+		return
+	}
+
+	for _, reminder := range reminders {
+		status, sendAt, err := service.sendReminder(reminder)
+		if err != nil {
+			log.Println("reminder " + reminder.ReminderID.String() + ": " + err.Error())
+			status = jobs.Pending
+			sendAt = time.Now().UTC().Add(time.Minute)
+		}
+
+		err = service.database.FinishReminder(reminder.ReminderID.String(), status, sendAt)
+		if err != nil {
+			log.Println(err.Error())
+		}
+	}
+}
+
+// sendReminder sends one batch of the reminder, a batch stays well within the lease so that no one is emailed twice.
+func (service *reminderService) sendReminder(reminder models.Reminder) (int, time.Time, error) {
+	election, err := service.database.GetElection(reminder.ElectionID.String())
+	if err != nil {
+		if err.Error() == "Invalid Election!" {
+			return jobs.Skipped, reminder.SendAt, nil
+		}
+		return jobs.Pending, reminder.SendAt, err
+	}
+
+	now := time.Now().UTC()
+	status := lifecycle.Current(election, now)
+	if lifecycle.HasEnded(status) {
+		return jobs.Skipped, reminder.SendAt, service.skipPendingDeliveries(reminder, "Election has ended!")
+	}
+	if status != lifecycle.Voting {
+		return jobs.Pending, now.Add(time.Minute), nil
+	}
+
+	err = service.database.QueueReminderDeliveries(reminder.ReminderID.String(), reminder.ElectionID.String())
+	if err != nil {
+		return jobs.Pending, reminder.SendAt, err
+	}
+
+	recipients, err := service.database.GetPendingReminderRecipients(reminder.ReminderID.String())
+	if err != nil {
+		return jobs.Pending, reminder.SendAt, err
+	}
+
+	batch := reminderRate() * 5
+	for i, recipient := range recipients {
+		if i == batch {
+			return jobs.Pending, time.Now().UTC(), nil
+		}
+
+		if recipient.Voted {
+			err = service.database.FinishReminderDelivery(recipient.ReminderDeliveryID, jobs.Skipped, "")
+			if err != nil {
+				return jobs.Pending, reminder.SendAt, err
+			}
+			continue
+		}
+
+		<-service.limiter.C
+
+		status, lastError := jobs.Done, ""
+		err = email.SendReminderEmail(service.mailer, recipient.FirstName, recipient.Email, election.Title, election.EndingAt.String(), "reminder.html")
+		if err != nil {
+			log.Println(recipient.Email + ": " + err.Error())
+			status, lastError = jobs.Failed, err.Error()
+		}
+
+		err = service.database.FinishReminderDelivery(recipient.ReminderDeliveryID, status, lastError)
+		if err != nil {
+			return jobs.Pending, reminder.SendAt, err
+		}
+	}
+
+	return jobs.Done, reminder.SendAt, nil
+}
+
+func (service *reminderService) skipPendingDeliveries(reminder models.Reminder, reason string) error {
+	recipients, err := service.database.GetPendingReminderRecipients(reminder.ReminderID.String())
+	if err != nil {
+		return err
+	}
+
+	for _, recipient := range recipients {
+		err = service.database.FinishReminderDelivery(recipient.ReminderDeliveryID, jobs.Skipped, reason)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ScheduleReminder sends the reminder at SendAt, BeforeEnd(e.g, 2h) before the election ends, or right away.
+func (service *reminderService) ScheduleReminder(userId string, electionId string, scheduleReminderDTO dto.ScheduleReminderDTO) error {
+	sendAt := time.Now().UTC()
+
+	if scheduleReminderDTO.SendAt != "" {
+		sendAt = mappers.ToSendAtFromScheduleReminderDTO(scheduleReminderDTO)
+		if sendAt.IsZero() {
+			return errors.New("Invalid sending time!")
+		}
+	} else if scheduleReminderDTO.BeforeEnd != "" {
+		beforeEnd, err := time.ParseDuration(scheduleReminderDTO.BeforeEnd)
+		if err != nil || beforeEnd <= 0 {
+			return errors.New("Invalid sending time!")
+		}
+
+		election, err := service.database.GetElection(electionId)
+		if err != nil {
+			return err
+		}
+		sendAt = election.EndingAt.UTC().Add(-beforeEnd)
+	}
+
+	if sendAt.Before(time.Now().UTC()) {
+		sendAt = time.Now().UTC()
+	}
+
+	return service.database.ScheduleReminder(userId, electionId, sendAt, reminderInterval())
+}
+
+func (service *reminderService) GetReminders(userId string, electionId string) ([]dto.ReminderDTO, error) {
+	reminders, deliveries, err := service.database.GetReminders(userId, electionId)
+	if err != nil {
+		return nil, err
+	}
+
+	reminderDTOs := []dto.ReminderDTO{}
+	for _, reminder := range reminders {
+		reminderDTOs = append(reminderDTOs, mappers.ToReminderDTO(reminder, deliveries))
+	}
+
+	return reminderDTOs, nil
+}
@@ -0,0 +1,156 @@
+package services
+
+import (
+	"elect/database"
+	"elect/dto"
+	"elect/mappers"
+	"elect/models"
+	"elect/roles"
+	"errors"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+	uuid "github.com/satori/go.uuid"
+)
+
+// SessionService keeps track of the devices a user is logged in on, each with its own refresh token.
+type SessionService interface {
+	CreateSession(sessionId string, userId string, refreshToken string, ipAddress string, userAgent string) error
+	RotateSession(sessionId string, refreshToken string, newRefreshToken string, ipAddress string) error
+	GetSessions(userId string, currentSessionId string) ([]dto.SessionDTO, error)
+	CheckSession(sessionId string) error
+	RevokeSession(userId string, sessionId string) error
+	RevokeOtherSessions(userId string, sessionId string) error
+	RevokeUserSessions(userId string, role int, targetUserId string) error
+}
+
+type sessionService struct {
+	database database.Database
+}
+
+func NewSessionService(database database.Database) SessionService {
+	return &sessionService{
+		database: database,
+	}
+}
+
+func (service *sessionService) CreateSession(sessionId string, userId string, refreshToken string, ipAddress string, userAgent string) error {
+	if len(userAgent) > 512 {
+		userAgent = userAgent[:512]
+	}
+
+	return service.database.CreateSession(models.Session{
+		SessionID: uuid.FromStringOrNil(sessionId),
+		UserID:    uuid.FromStringOrNil(userId),
+		Device:    deviceName(userAgent),
+		IPAddress: ipAddress,
+		UserAgent: userAgent,
+		ExpiresAt: refreshTokenExpiry(refreshToken),
+	}, refreshToken)
+}
+
+func (service *sessionService) RotateSession(sessionId string, refreshToken string, newRefreshToken string, ipAddress string) error {
+	if _, err := uuid.FromString(sessionId); err != nil {
+		return errors.New("Session revoked!")
+	}
+
+	return service.database.RotateSession(sessionId, refreshToken, newRefreshToken, ipAddress, refreshTokenExpiry(newRefreshToken))
+}
+
+func (service *sessionService) CheckSession(sessionId string) error {
+	if _, err := uuid.FromString(sessionId); err != nil {
+		return errors.New("Session revoked!")
+	}
+
+	return service.database.CheckSession(sessionId)
+}
+
+func (service *sessionService) GetSessions(userId string, currentSessionId string) ([]dto.SessionDTO, error) {
+	sessions, err := service.database.GetSessions(userId)
+	if err != nil {
+		return nil, err
+	}
+
+	sessionDTOs := []dto.SessionDTO{}
+	for _, session := range sessions {
+		sessionDTOs = append(sessionDTOs, mappers.ToSessionDTO(session, session.SessionID.String() == currentSessionId))
+	}
+
+	return sessionDTOs, nil
+}
+
+func (service *sessionService) RevokeSession(userId string, sessionId string) error {
+	if _, err := uuid.FromString(sessionId); err != nil {
+		return errors.New("Invalid Session!")
+	}
+
+	return service.database.RevokeSession(userId, sessionId)
+}
+
+// RevokeOtherSessions logs the user out everywhere except the session they are using.
+func (service *sessionService) RevokeOtherSessions(userId string, sessionId string) error {
+	return service.database.RevokeSessions(userId, userId, sessionId)
+}
+
+// RevokeUserSessions logs a user out everywhere, admins can only do this to the students they registered.
+func (service *sessionService) RevokeUserSessions(userId string, role int, targetUserId string) error {
+	user, err := service.database.GetUser(targetUserId)
+	if err != nil {
+		return errors.New("Invalid Student!")
+	}
+
+	if role != roles.SuperAdmin && (user.Role != roles.Student || user.RegisteredBy != userId) {
+		log.Println("Invalid Student!")
+		return errors.New("Invalid Student!")
+	}
+
+	return service.database.RevokeSessions(userId, targetUserId, "")
+}
+
+// refreshTokenExpiry reads the expiry of a refresh token this service just issued or already checked, so the claims
+// aren't verified again.
+func refreshTokenExpiry(refreshToken string) time.Time {
+	token, _ := jwt.Parse(refreshToken, nil)
+	if token != nil {
+		if claims, ok := token.Claims.(jwt.MapClaims); ok {
+			if exp, ok := claims["exp"].(float64); ok {
+				return time.Unix(int64(exp), 0).UTC()
+			}
+		}
+	}
+
+	return time.Now().UTC().Add(time.Hour * 24 * 7)
+}
+
+// deviceName gives the sessions list a readable name like "Chrome on Android" instead of the raw user agent.
+func deviceName(userAgent string) string {
+	browser := ""
+	for _, candidate := range [][2]string{{"Edg/", "Edge"}, {"OPR/", "Opera"}, {"Firefox/", "Firefox"}, {"Chrome/", "Chrome"}, {"Safari/", "Safari"}} {
+		if strings.Contains(userAgent, candidate[0]) {
+			browser = candidate[1]
+			break
+		}
+	}
+
+	// Phones and tablets come first, their user agents mention the desktop systems too
+	system := ""
+	for _, candidate := range [][2]string{{"Android", "Android"}, {"iPhone", "iPhone"}, {"iPad", "iPad"}, {"Windows", "Windows"}, {"CrOS", "ChromeOS"}, {"Mac OS X", "macOS"}, {"Linux", "Linux"}} {
+		if strings.Contains(userAgent, candidate[0]) {
+			system = candidate[1]
+			break
+		}
+	}
+
+	switch {
+	case browser != "" && system != "":
+		return browser + " on " + system
+	case browser != "":
+		return browser
+	case system != "":
+		return system
+	}
+
+	return "Unknown device"
+}
@@ -0,0 +1,75 @@
+package services
+
+import (
+	"elect/database"
+	"elect/oidc"
+	"elect/roles"
+	"errors"
+	"log"
+)
+
+// SSOService logs users in with their account at the institution's OpenID Connect provider.
+type SSOService interface {
+	BeginLogin() (string, oidc.SessionData, error)
+	FinishLogin(session oidc.SessionData, state string, code string) (string, error)
+}
+
+type ssoService struct {
+	database database.Database
+	provider *oidc.Provider
+}
+
+func NewSSOService(database database.Database, config oidc.Config) SSOService {
+	return &ssoService{
+		database: database,
+		provider: oidc.New(config),
+	}
+}
+
+func (service *ssoService) BeginLogin() (string, oidc.SessionData, error) {
+	return service.provider.BeginLogin()
+}
+
+// FinishLogin returns the email of the user the provider's account belongs to. The first login links the account to
+// the student with the same register number, or else the same verified email, nobody is registered through single
+// sign-on. Admins and super admins are never linked by a match, only an account already linked to them logs them in.
+func (service *ssoService) FinishLogin(session oidc.SessionData, state string, code string) (string, error) {
+	identity, err := service.provider.FinishLogin(session, state, code)
+	if err != nil {
+		return "", err
+	}
+
+	issuer := service.provider.Config().Issuer
+
+	user, err := service.database.GetOIDCIdentityUser(issuer, identity.Subject)
+	if err == nil {
+		return user.Email, nil
+	}
+	if err.Error() != "Invalid user!" {
+		return "", err
+	}
+
+	err = errors.New("Invalid user!")
+	if identity.RegNumber != "" {
+		user, err = service.database.GetUserByRegNumber(identity.RegNumber)
+	}
+	if err != nil && identity.Email != "" && identity.EmailVerified {
+		user, err = service.database.GetUserByEmail(identity.Email)
+	}
+	if err != nil {
+		log.Println("No user for SSO subject " + identity.Subject + " of " + issuer)
+		return "", errors.New("No account for this SSO login!")
+	}
+
+	if user.Role != roles.Student {
+		log.Println("SSO subject " + identity.Subject + " of " + issuer + " matches an admin, it has to be linked first")
+		return "", errors.New("No account for this SSO login!")
+	}
+
+	err = service.database.LinkOIDCIdentity(user.UserID.String(), issuer, identity.Subject)
+	if err != nil {
+		return "", err
+	}
+
+	return user.Email, nil
+}
@@ -0,0 +1,109 @@
+package services
+
+import (
+	"elect/database"
+	"elect/models"
+	"elect/oidc"
+	"elect/oidc/oidctest"
+	"elect/roles"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/golang-jwt/jwt"
+	uuid "github.com/satori/go.uuid"
+)
+
+// ssoDatabase keeps the users and the provider accounts linked to them in memory.
+type ssoDatabase struct {
+	database.Database
+	users []models.User
+	links map[string]uuid.UUID
+}
+
+func (db *ssoDatabase) find(match func(user models.User) bool) (models.User, error) {
+	for _, user := range db.users {
+		if match(user) {
+			return user, nil
+		}
+	}
+	return models.User{}, errors.New("Invalid user!")
+}
+
+func (db *ssoDatabase) GetUserByEmail(email string) (models.User, error) {
+	return db.find(func(user models.User) bool { return strings.EqualFold(user.Email, email) })
+}
+
+func (db *ssoDatabase) GetUserByRegNumber(regNumber string) (models.User, error) {
+	return db.find(func(user models.User) bool { return strings.EqualFold(user.RegNumber, regNumber) })
+}
+
+func (db *ssoDatabase) GetOIDCIdentityUser(issuer string, subject string) (models.User, error) {
+	userId, ok := db.links[issuer+" "+subject]
+	if !ok {
+		return models.User{}, errors.New("Invalid user!")
+	}
+	return db.find(func(user models.User) bool { return user.UserID == userId })
+}
+
+func (db *ssoDatabase) LinkOIDCIdentity(userId string, issuer string, subject string) error {
+	db.links[issuer+" "+subject] = uuid.FromStringOrNil(userId)
+	return nil
+}
+
+func TestSSOLinksOnlyStudents(t *testing.T) {
+	server := oidctest.NewProvider("elect")
+	defer server.Close()
+
+	cases := []struct {
+		name     string
+		role     int
+		verified bool
+		linked   bool
+		wantErr  bool
+	}{
+		{name: "student with a verified email", role: roles.Student, verified: true},
+		{name: "student with an unverified email", role: roles.Student, wantErr: true},
+		{name: "admin with a verified email", role: roles.Admin, verified: true, wantErr: true},
+		{name: "super admin with a verified email", role: roles.SuperAdmin, verified: true, wantErr: true},
+		{name: "linked admin", role: roles.Admin, linked: true},
+		{name: "linked super admin", role: roles.SuperAdmin, linked: true},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			user := models.User{UserID: uuid.NewV4(), Email: server.Email, Role: c.role}
+			db := &ssoDatabase{users: []models.User{user}, links: map[string]uuid.UUID{}}
+			if c.linked {
+				db.links[server.URL+" "+server.Subject] = user.UserID
+			}
+
+			verified := c.verified
+			server.Claims = func(claims jwt.MapClaims) { claims["email_verified"] = verified }
+
+			service := NewSSOService(db, oidc.Config{Issuer: server.URL, ClientID: server.ClientID, EmailClaim: "email"})
+			loginURL, session, err := service.BeginLogin()
+			if err != nil {
+				t.Fatal(err)
+			}
+			state, code := server.Authorize(loginURL)
+
+			email, err := service.FinishLogin(session, state, code)
+			if c.wantErr {
+				if err == nil {
+					t.Errorf("logged in as %s", email)
+				}
+				if _, ok := db.links[server.URL+" "+server.Subject]; ok {
+					t.Error("provider account was linked")
+				}
+				return
+			}
+			if err != nil || email != user.Email {
+				t.Fatalf("login returned %q, %v, want %q", email, err, user.Email)
+			}
+			if db.links[server.URL+" "+server.Subject] != user.UserID {
+				t.Error("provider account is not linked")
+			}
+		})
+	}
+}
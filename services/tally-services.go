@@ -0,0 +1,135 @@
+package services
+
+import (
+	"elect/dto"
+	"sort"
+)
+
+// instantRunoff tallies ranked ballots in elimination rounds until a candidate holds a majority of the continuing ballots.
+// Preferences for candidates outside of the given list are skipped, which lets gender-specific groups be tallied separately.
+func instantRunoff(candidateResultsDTOs []dto.CandidateResultsDTO, ballots [][]string) ([]dto.RunoffRoundDTO, []dto.CandidateResultsDTO) {
+	continuing := make(map[string]bool)
+	for _, candidateResultsDTO := range candidateResultsDTOs {
+		continuing[candidateResultsDTO.CandidateID] = true
+	}
+
+	top := func(preferences []string) string {
+		for _, candidateId := range preferences {
+			if continuing[candidateId] {
+				return candidateId
+			}
+		}
+
+		return ""
+	}
+
+	var rounds []dto.RunoffRoundDTO
+	var winnerIds []string
+	for len(continuing) > 0 {
+		round := dto.RunoffRoundDTO{Round: len(rounds) + 1}
+
+		tallies := make(map[string]int)
+		for _, preferences := range ballots {
+			if candidateId := top(preferences); candidateId != "" {
+				tallies[candidateId]++
+			} else {
+				round.Exhausted++
+			}
+		}
+
+		for _, candidateResultsDTO := range candidateResultsDTOs {
+			if continuing[candidateResultsDTO.CandidateID] {
+				round.Tallies = append(round.Tallies, dto.RunoffTallyDTO{
+					CandidateID: candidateResultsDTO.CandidateID,
+					Name:        candidateResultsDTO.Name,
+					Votes:       tallies[candidateResultsDTO.CandidateID],
+				})
+			}
+		}
+		sort.SliceStable(round.Tallies, func(i, j int) bool {
+			return round.Tallies[i].Votes > round.Tallies[j].Votes
+		})
+
+		active := len(ballots) - round.Exhausted
+		if active == 0 {
+			rounds = append(rounds, round)
+			break
+		}
+
+		leader := round.Tallies[0]
+		if leader.Votes*2 > active || len(round.Tallies) == 1 {
+			winnerIds = []string{leader.CandidateID}
+			rounds = append(rounds, round)
+			break
+		}
+
+		lowest := round.Tallies[len(round.Tallies)-1].Votes
+		var eliminated []string
+		for _, tally := range round.Tallies {
+			if tally.Votes == lowest {
+				eliminated = append(eliminated, tally.CandidateID)
+			}
+		}
+
+		// Everyone left is tied, so nobody can be eliminated
+		if len(eliminated) == len(round.Tallies) {
+			winnerIds = eliminated
+			rounds = append(rounds, round)
+			break
+		}
+
+		var from []string
+		for _, preferences := range ballots {
+			from = append(from, top(preferences))
+		}
+
+		for _, candidateId := range eliminated {
+			delete(continuing, candidateId)
+		}
+		round.Eliminated = eliminated
+
+		transfers := make(map[string]map[string]int)
+		for i, preferences := range ballots {
+			if from[i] == "" || continuing[from[i]] {
+				continue
+			}
+			if transfers[from[i]] == nil {
+				transfers[from[i]] = make(map[string]int)
+			}
+			transfers[from[i]][top(preferences)]++
+		}
+
+		for _, fromId := range eliminated {
+			for _, candidateResultsDTO := range candidateResultsDTOs {
+				if votes := transfers[fromId][candidateResultsDTO.CandidateID]; votes > 0 {
+					round.Transfers = append(round.Transfers, dto.RunoffTransferDTO{
+						From:  fromId,
+						To:    candidateResultsDTO.CandidateID,
+						Votes: votes,
+					})
+				}
+			}
+
+			// Ballots with no continuing preference left are exhausted
+			if votes := transfers[fromId][""]; votes > 0 {
+				round.Transfers = append(round.Transfers, dto.RunoffTransferDTO{
+					From:  fromId,
+					Votes: votes,
+				})
+			}
+		}
+
+		rounds = append(rounds, round)
+	}
+
+	var winners []dto.CandidateResultsDTO
+	for _, winnerId := range winnerIds {
+		for _, candidateResultsDTO := range candidateResultsDTOs {
+			if candidateResultsDTO.CandidateID == winnerId {
+				winners = append(winners, candidateResultsDTO)
+			}
+		}
+	}
+
+	return rounds, winners
+}
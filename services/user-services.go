@@ -1,16 +1,14 @@
 package services
 
 import (
-	"crypto/sha256"
 	"elect/database"
 	"elect/dto"
 	"elect/email"
+	"elect/lockout"
 	"elect/mappers"
 	"elect/models"
-	"encoding/hex"
-	"errors"
-	"math/rand"
-	"strconv"
+	"log"
+	"time"
 )
 
 type UserService interface {
@@ -22,9 +20,8 @@ type UserService interface {
 	RegisterStudent(registerStudentDTO dto.RegisterStudentDTO) error
 	RegisteredStudents(userId string, paginatorParams dto.PaginatorParams) ([]dto.GeneralStudentDTO, error)
 	DeleteRegisteredStudent(userId string, studentUserId string) error
-	SetActiveRefreshToken(token string, email string) error
-	CheckIfActiveRefreshToken(token string, email string) error
-	ClearActiveRefreshToken(email string) error
+	ResendVerification(userId string, studentUserId string) error
+	ResendVerificationByEmail(resendVerificationDTO dto.ResendVerificationDTO, ip string)
 	ChangePassword(userId string, changePasswordDTO dto.ChangePasswordDTO) error
 	CheckVerifyTokenValidity(token string) error
 	CheckResetTokenValidity(token string) error
@@ -34,11 +31,13 @@ type UserService interface {
 
 type userService struct {
 	database database.Database
+	mailer   email.Mailer
 }
 
-func NewUserService(database database.Database) UserService {
+func NewUserService(database database.Database, mailer email.Mailer) UserService {
 	return &userService{
 		database: database,
+		mailer:   mailer,
 	}
 }
 
@@ -67,50 +66,15 @@ func (service *userService) TokenValidity(token string) error {
 	return service.database.TokenValidity(token)
 }
 
-func (service *userService) SetActiveRefreshToken(token string, email string) error {
-	err := service.database.StoreActiveRefreshToken(token, email)
-	if err != nil {
-		return err
-	}
-
-	return nil
-}
-
-func (service *userService) CheckIfActiveRefreshToken(token string, email string) error {
-	activeToken, err := service.database.GetActiveRefreshToken(email)
-	if err != nil {
-		return err
-	}
-
-	if activeToken != token {
-		return errors.New("Logged in other device!")
-	}
-
-	return nil
-}
-
-func (service *userService) ClearActiveRefreshToken(email string) error {
-	err := service.database.ClearActiveRefreshToken(email)
-	if err != nil {
-		return err
-	}
-
-	return nil
-}
-
 func (service *userService) RegisterStudent(registerStudentDTO dto.RegisterStudentDTO) error {
 	user := mappers.ToUserFromRegisterStudentDTO(registerStudentDTO)
 
-	t := sha256.Sum256([]byte(strconv.Itoa(rand.Int()) + user.Email + strconv.Itoa(rand.Int())))
-	token := t[:]
-	user.VerifyToken = hex.EncodeToString(token)
-
-	err := service.database.RegisterStudent(user)
+	token, err := service.database.RegisterStudent(user)
 	if err != nil {
 		return err
 	}
 
-	err = email.SendVerificationEmail(user.FirstName, user.Email, user.VerifyToken, "template.html")
+	err = email.SendVerificationEmail(service.mailer, user.FirstName, user.Email, token, "template.html")
 	if err != nil {
 		return err
 	}
@@ -145,6 +109,58 @@ func (service *userService) DeleteRegisteredStudent(userId string, studentUserId
 	return service.database.DeleteRegisteredStudent(userId, studentUserId)
 }
 
+func (service *userService) ResendVerification(userId string, studentUserId string) error {
+	user, token, err := service.database.ResendVerification(userId, studentUserId)
+	if err != nil {
+		return err
+	}
+
+	return email.SendVerificationEmail(service.mailer, user.FirstName, user.Email, token, "template.html")
+}
+
+// Verification emails an email address or an IP address can ask for within the lockout window.
+const resendMaxRequests = 3
+const resendIPMaxRequests = 20
+
+// ResendVerificationByEmail tells nothing back, whether the email belongs to an unverified account can't be found out
+// from the answer. Requests are counted per email and IP address before the user is looked up.
+func (service *userService) ResendVerificationByEmail(resendVerificationDTO dto.ResendVerificationDTO, ip string) {
+	emailKey, ipKey := lockout.ResendKey(resendVerificationDTO.Email), lockout.ResendIPKey(ip)
+
+	throttles, err := service.database.GetLoginThrottles([]string{emailKey, ipKey})
+	if err != nil {
+		return
+	}
+
+	now := time.Now().UTC()
+	for _, throttle := range throttles {
+		if throttle.LockedUntil != nil && throttle.LockedUntil.After(now) {
+			log.Println("Too many verification emails: " + throttle.Key)
+			return
+		}
+	}
+
+	_, _, err = service.database.RecordLoginFailure(emailKey, resendMaxRequests)
+	if err != nil {
+		return
+	}
+	_, _, err = service.database.RecordLoginFailure(ipKey, resendIPMaxRequests)
+	if err != nil {
+		return
+	}
+
+	user, token, err := service.database.ResendVerificationByEmail(resendVerificationDTO.Email)
+	if err != nil {
+		log.Println(err.Error())
+		return
+	}
+
+	err = email.SendVerificationEmail(service.mailer, user.FirstName, user.Email, token, "template.html")
+	if err != nil {
+		log.Println(err.Error())
+	}
+}
+
 func (service *userService) ChangePassword(userId string, changePasswordDTO dto.ChangePasswordDTO) error {
 	return service.database.ChangePassword(userId, changePasswordDTO)
 }
@@ -163,7 +179,7 @@ func (service *userService) GenerateResetToken(createResetTokenDTO dto.CreateRes
 		return err
 	}
 
-	err = email.SendResetPasswordEmail(name, createResetTokenDTO.Email, token, "reset.html")
+	err = email.SendResetPasswordEmail(service.mailer, name, createResetTokenDTO.Email, token, "reset.html")
 	if err != nil {
 		return err
 	}
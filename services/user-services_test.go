@@ -0,0 +1,71 @@
+package services
+
+import (
+	"elect/database"
+	"elect/dto"
+	"elect/email"
+	"elect/models"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+// resendDatabase counts verification requests like login_throttles does and knows one unverified student.
+type resendDatabase struct {
+	database.Database
+	throttles map[string]models.LoginThrottle
+	lookups   int
+}
+
+func (db *resendDatabase) GetLoginThrottles(keys []string) ([]models.LoginThrottle, error) {
+	throttles := []models.LoginThrottle{}
+	for _, key := range keys {
+		if throttle, ok := db.throttles[key]; ok {
+			throttles = append(throttles, throttle)
+		}
+	}
+	return throttles, nil
+}
+
+func (db *resendDatabase) RecordLoginFailure(key string, maxFailures int) (models.LoginThrottle, bool, error) {
+	throttle := db.throttles[key]
+	throttle.Key = key
+	throttle.Failures++
+	if throttle.Failures >= maxFailures {
+		lockedUntil := time.Now().UTC().Add(time.Hour)
+		throttle.LockedUntil = &lockedUntil
+	}
+	db.throttles[key] = throttle
+	return throttle, throttle.LockedUntil != nil, nil
+}
+
+func (db *resendDatabase) ResendVerificationByEmail(userEmail string) (models.User, string, error) {
+	db.lookups++
+	if userEmail != "student@elect.test" {
+		return models.User{}, "", errors.New("Invalid email!")
+	}
+	return models.User{FirstName: "Student", Email: userEmail}, "token", nil
+}
+
+func TestResendVerificationByEmailIsRateLimited(t *testing.T) {
+	db := &resendDatabase{throttles: map[string]models.LoginThrottle{}}
+	service := NewUserService(db, email.NewMemoryMailer())
+
+	for i := 0; i < 5; i++ {
+		service.ResendVerificationByEmail(dto.ResendVerificationDTO{Email: "student@elect.test"}, "10.0.0.1")
+	}
+	if db.lookups != resendMaxRequests {
+		t.Fatalf("looked up the email %d times, want %d", db.lookups, resendMaxRequests)
+	}
+
+	// Unknown emails count the same, so they also run into the limit of the IP address
+	for i := 0; i < resendIPMaxRequests; i++ {
+		service.ResendVerificationByEmail(dto.ResendVerificationDTO{Email: fmt.Sprintf("nobody%d@elect.test", i)}, "10.0.0.2")
+	}
+	lookups := db.lookups
+	service.ResendVerificationByEmail(dto.ResendVerificationDTO{Email: "other@elect.test"}, "10.0.0.2")
+	if db.lookups != lookups {
+		t.Fatal("email was looked up after the IP address hit its limit")
+	}
+}
@@ -0,0 +1,267 @@
+package services
+
+import (
+	"bytes"
+	"elect/database"
+	"elect/dto"
+	"elect/mappers"
+	"elect/models"
+	"elect/webauthn"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"strings"
+	"time"
+
+	uuid "github.com/satori/go.uuid"
+)
+
+// WebAuthnService registers passkeys and logs users in with them. The challenge of every ceremony is stored under the
+// returned ID and can be answered once.
+type WebAuthnService interface {
+	BeginRegistration(userId string) (*webauthn.CredentialCreation, string, error)
+	FinishRegistration(userId string, challengeId string, name string, body io.Reader) error
+	BeginLogin(email string) (*webauthn.CredentialAssertion, string, error)
+	FinishLogin(challengeId string, body io.Reader) (string, error)
+	GetCredentials(userId string) ([]dto.PasskeyDTO, error)
+	DeleteCredential(userId string, credentialId string) error
+}
+
+type webAuthnService struct {
+	database database.Database
+	webAuthn *webauthn.WebAuthn
+}
+
+func NewWebAuthnService(database database.Database, config webauthn.Config) WebAuthnService {
+	return &webAuthnService{
+		database: database,
+		webAuthn: webauthn.New(config),
+	}
+}
+
+// Challenges expire with the timeout the browser is given.
+var passkeyChallengeExpiry = 5 * time.Minute
+
+func (service *webAuthnService) BeginRegistration(userId string) (*webauthn.CredentialCreation, string, error) {
+	user, err := service.database.GetUser(userId)
+	if err != nil {
+		return nil, "", errors.New("Invalid user!")
+	}
+
+	credentials, err := service.getCredentials(userId)
+	if err != nil {
+		return nil, "", err
+	}
+
+	// A passkey that is already registered can't be added twice
+	credentialIds := [][]byte{}
+	for _, credential := range credentials {
+		credentialIds = append(credentialIds, credential.ID)
+	}
+
+	creation, session, err := service.webAuthn.BeginRegistration(user.UserID.Bytes(), user.Email, user.FirstName+" "+user.LastName, credentialIds)
+	if err != nil {
+		return nil, "", err
+	}
+
+	challengeId, err := service.saveChallenge(user.UserID, session)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return creation, challengeId, nil
+}
+
+func (service *webAuthnService) FinishRegistration(userId string, challengeId string, name string, body io.Reader) error {
+	user, err := service.database.GetUser(userId)
+	if err != nil {
+		return errors.New("Invalid user!")
+	}
+
+	session, err := service.useChallenge(challengeId)
+	if err != nil {
+		return err
+	}
+
+	if !bytes.Equal(session.UserID, user.UserID.Bytes()) {
+		return errors.New("Invalid Passkey!")
+	}
+
+	credential, err := service.webAuthn.FinishRegistration(session, body)
+	if err != nil {
+		return err
+	}
+
+	name = strings.TrimSpace(name)
+	if name == "" {
+		name = "Passkey"
+	}
+	if len(name) > 64 {
+		name = name[:64]
+	}
+
+	return service.database.AddWebAuthnCredential(models.WebAuthnCredential{
+		UserID:          user.UserID,
+		Name:            name,
+		CredentialID:    base64.RawURLEncoding.EncodeToString(credential.ID),
+		PublicKey:       credential.PublicKey,
+		AttestationType: credential.AttestationType,
+		AAGUID:          credential.AAGUID,
+		SignCount:       int64(credential.SignCount),
+	})
+}
+
+func (service *webAuthnService) BeginLogin(email string) (*webauthn.CredentialAssertion, string, error) {
+	user, err := service.database.GetUserByEmail(email)
+	if err != nil {
+		return nil, "", err
+	}
+
+	credentials, err := service.getCredentials(user.UserID.String())
+	if err != nil {
+		return nil, "", err
+	}
+
+	if len(credentials) == 0 {
+		return nil, "", errors.New("No passkeys registered!")
+	}
+
+	credentialIds := [][]byte{}
+	for _, credential := range credentials {
+		credentialIds = append(credentialIds, credential.ID)
+	}
+
+	assertion, session, err := service.webAuthn.BeginLogin(user.UserID.Bytes(), credentialIds)
+	if err != nil {
+		return nil, "", err
+	}
+
+	challengeId, err := service.saveChallenge(user.UserID, session)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return assertion, challengeId, nil
+}
+
+// FinishLogin checks the assertion against the challenge and returns the email of the user it belongs to. The challenge
+// is used up first, so a replayed assertion fails even on authenticators without a signature counter.
+func (service *webAuthnService) FinishLogin(challengeId string, body io.Reader) (string, error) {
+	session, err := service.useChallenge(challengeId)
+	if err != nil {
+		return "", err
+	}
+
+	userId, err := uuid.FromBytes(session.UserID)
+	if err != nil {
+		return "", errors.New("Invalid Passkey!")
+	}
+
+	user, err := service.database.GetUser(userId.String())
+	if err != nil {
+		return "", errors.New("Invalid Passkey!")
+	}
+
+	credentials, err := service.getCredentials(userId.String())
+	if err != nil {
+		return "", err
+	}
+
+	credential, err := service.webAuthn.FinishLogin(session, credentials, body)
+	if err != nil {
+		return "", err
+	}
+
+	err = service.database.UseWebAuthnCredential(base64.RawURLEncoding.EncodeToString(credential.ID), int64(credential.SignCount))
+	if err != nil {
+		return "", err
+	}
+
+	return user.Email, nil
+}
+
+func (service *webAuthnService) GetCredentials(userId string) ([]dto.PasskeyDTO, error) {
+	credentials, err := service.database.GetWebAuthnCredentials(userId)
+	if err != nil {
+		return nil, err
+	}
+
+	passkeyDTOs := []dto.PasskeyDTO{}
+	for _, credential := range credentials {
+		passkeyDTOs = append(passkeyDTOs, mappers.ToPasskeyDTO(credential))
+	}
+
+	return passkeyDTOs, nil
+}
+
+func (service *webAuthnService) DeleteCredential(userId string, credentialId string) error {
+	return service.database.DeleteWebAuthnCredential(userId, credentialId)
+}
+
+func (service *webAuthnService) getCredentials(userId string) ([]webauthn.Credential, error) {
+	dbCredentials, err := service.database.GetWebAuthnCredentials(userId)
+	if err != nil {
+		return nil, err
+	}
+
+	credentials := []webauthn.Credential{}
+	for _, credential := range dbCredentials {
+		id, err := base64.RawURLEncoding.DecodeString(credential.CredentialID)
+		if err != nil {
+			continue
+		}
+
+		credentials = append(credentials, webauthn.Credential{
+			ID:              id,
+			PublicKey:       credential.PublicKey,
+			AttestationType: credential.AttestationType,
+			AAGUID:          credential.AAGUID,
+			SignCount:       uint32(credential.SignCount),
+		})
+	}
+
+	return credentials, nil
+}
+
+func (service *webAuthnService) saveChallenge(userId uuid.UUID, session webauthn.SessionData) (string, error) {
+	encoded, err := json.Marshal(session)
+	if err != nil {
+		log.Println(err.Error())
+		return "", err
+	}
+
+	challengeId := uuid.NewV4()
+	err = service.database.CreateWebAuthnChallenge(models.WebAuthnChallenge{
+		ChallengeID: challengeId,
+		UserID:      userId,
+		Session:     string(encoded),
+		ExpiresAt:   time.Now().UTC().Add(passkeyChallengeExpiry),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return challengeId.String(), nil
+}
+
+func (service *webAuthnService) useChallenge(challengeId string) (webauthn.SessionData, error) {
+	if _, err := uuid.FromString(challengeId); err != nil {
+		return webauthn.SessionData{}, errors.New("Passkey session expired!")
+	}
+
+	challenge, err := service.database.UseWebAuthnChallenge(challengeId)
+	if err != nil {
+		return webauthn.SessionData{}, err
+	}
+
+	var session webauthn.SessionData
+	err = json.Unmarshal([]byte(challenge.Session), &session)
+	if err != nil {
+		log.Println(err.Error())
+		return webauthn.SessionData{}, errors.New("Passkey session expired!")
+	}
+
+	return session, nil
+}
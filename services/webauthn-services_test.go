@@ -0,0 +1,119 @@
+package services
+
+import (
+	"bytes"
+	"elect/database"
+	"elect/models"
+	"elect/webauthn"
+	"elect/webauthn/webauthntest"
+	"errors"
+	"testing"
+
+	uuid "github.com/satori/go.uuid"
+)
+
+// passkeyDatabase keeps one user's passkeys and the pending challenges in memory.
+type passkeyDatabase struct {
+	database.Database
+	user        models.User
+	credentials []models.WebAuthnCredential
+	challenges  map[string]models.WebAuthnChallenge
+}
+
+func (db *passkeyDatabase) GetUser(userId string) (models.User, error) {
+	if userId != db.user.UserID.String() {
+		return models.User{}, errors.New("Invalid user!")
+	}
+	return db.user, nil
+}
+
+func (db *passkeyDatabase) GetUserByEmail(email string) (models.User, error) {
+	if email != db.user.Email {
+		return models.User{}, errors.New("Invalid user!")
+	}
+	return db.user, nil
+}
+
+func (db *passkeyDatabase) GetWebAuthnCredentials(userId string) ([]models.WebAuthnCredential, error) {
+	return db.credentials, nil
+}
+
+func (db *passkeyDatabase) AddWebAuthnCredential(credential models.WebAuthnCredential) error {
+	db.credentials = append(db.credentials, credential)
+	return nil
+}
+
+func (db *passkeyDatabase) UseWebAuthnCredential(credentialId string, signCount int64) error {
+	for i, credential := range db.credentials {
+		if credential.CredentialID == credentialId && (credential.SignCount < signCount || (credential.SignCount == 0 && signCount == 0)) {
+			db.credentials[i].SignCount = signCount
+			return nil
+		}
+	}
+	return errors.New("Invalid Passkey!")
+}
+
+func (db *passkeyDatabase) CreateWebAuthnChallenge(challenge models.WebAuthnChallenge) error {
+	db.challenges[challenge.ChallengeID.String()] = challenge
+	return nil
+}
+
+func (db *passkeyDatabase) UseWebAuthnChallenge(challengeId string) (models.WebAuthnChallenge, error) {
+	challenge, ok := db.challenges[challengeId]
+	if !ok {
+		return models.WebAuthnChallenge{}, errors.New("Passkey session expired!")
+	}
+	delete(db.challenges, challengeId)
+	return challenge, nil
+}
+
+// An authenticator without a signature counter reports 0 every time, only the used up challenge stops a replay.
+func TestPasskeyLoginReplayWithoutCounter(t *testing.T) {
+	db := &passkeyDatabase{
+		user:       models.User{UserID: uuid.NewV4(), FirstName: "Test", LastName: "User", Email: "user@elect.test"},
+		challenges: map[string]models.WebAuthnChallenge{},
+	}
+	service := NewWebAuthnService(db, webauthn.Config{RPID: "elect.test", RPName: "ELECT", Origin: "https://elect.test"})
+
+	authenticator := webauthntest.NewEdDSA()
+	authenticator.NoCounter = true
+
+	creation, challengeId, err := service.BeginRegistration(db.user.UserID.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = service.FinishRegistration(db.user.UserID.String(), challengeId, "Laptop", bytes.NewReader(authenticator.Create(creation)))
+	if err != nil {
+		t.Fatalf("registration failed: %v", err)
+	}
+
+	err = service.FinishRegistration(db.user.UserID.String(), challengeId, "Laptop", bytes.NewReader(authenticator.Create(creation)))
+	if err == nil || err.Error() != "Passkey session expired!" {
+		t.Errorf("reused registration challenge returned %v, want Passkey session expired!", err)
+	}
+
+	assertion, challengeId, err := service.BeginLogin(db.user.Email)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body := authenticator.Get(assertion, db.user.UserID.Bytes())
+
+	email, err := service.FinishLogin(challengeId, bytes.NewReader(body))
+	if err != nil || email != db.user.Email {
+		t.Fatalf("login returned %q, %v, want %q", email, err, db.user.Email)
+	}
+
+	_, err = service.FinishLogin(challengeId, bytes.NewReader(body))
+	if err == nil || err.Error() != "Passkey session expired!" {
+		t.Errorf("replayed assertion returned %v, want Passkey session expired!", err)
+	}
+
+	_, challengeId, err = service.BeginLogin(db.user.Email)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = service.FinishLogin(challengeId, bytes.NewReader(body))
+	if err == nil {
+		t.Error("replayed assertion was accepted for a new challenge")
+	}
+}
@@ -0,0 +1,4 @@
+package votingmethods
+
+var Plurality int = 0
+var InstantRunoff int = 1
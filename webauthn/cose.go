@@ -0,0 +1,118 @@
+package webauthn
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"errors"
+	"math/big"
+
+	"github.com/fxamacker/cbor/v2"
+)
+
+// COSE algorithms and key types the relying party accepts.
+const (
+	algES256 int64 = -7
+	algEdDSA int64 = -8
+	algRS256 int64 = -257
+
+	ktyOKP int64 = 1
+	ktyEC2 int64 = 2
+	ktyRSA int64 = 3
+
+	crvP256    int64 = 1
+	crvEd25519 int64 = 6
+)
+
+type coseKey struct {
+	Kty int64 `cbor:"1,keyasint"`
+	Alg int64 `cbor:"3,keyasint"`
+}
+
+type coseEC2Key struct {
+	Crv int64  `cbor:"-1,keyasint"`
+	X   []byte `cbor:"-2,keyasint"`
+	Y   []byte `cbor:"-3,keyasint"`
+}
+
+type coseOKPKey struct {
+	Crv int64  `cbor:"-1,keyasint"`
+	X   []byte `cbor:"-2,keyasint"`
+}
+
+type coseRSAKey struct {
+	N []byte `cbor:"-1,keyasint"`
+	E []byte `cbor:"-2,keyasint"`
+}
+
+type publicKey struct {
+	alg int64
+	key crypto.PublicKey
+}
+
+func parsePublicKey(data []byte) (publicKey, error) {
+	var key coseKey
+	err := cbor.Unmarshal(data, &key)
+	if err != nil {
+		return publicKey{}, errors.New("Invalid COSE key")
+	}
+
+	switch {
+	case key.Kty == ktyEC2 && key.Alg == algES256:
+		var ec2 coseEC2Key
+		err = cbor.Unmarshal(data, &ec2)
+		if err != nil || ec2.Crv != crvP256 {
+			return publicKey{}, errors.New("Invalid EC2 key")
+		}
+
+		x, y := new(big.Int).SetBytes(ec2.X), new(big.Int).SetBytes(ec2.Y)
+		if !elliptic.P256().IsOnCurve(x, y) {
+			return publicKey{}, errors.New("EC2 key not on curve")
+		}
+
+		return publicKey{alg: key.Alg, key: &ecdsa.PublicKey{Curve: elliptic.P256(), X: x, Y: y}}, nil
+
+	case key.Kty == ktyOKP && key.Alg == algEdDSA:
+		var okp coseOKPKey
+		err = cbor.Unmarshal(data, &okp)
+		if err != nil || okp.Crv != crvEd25519 || len(okp.X) != ed25519.PublicKeySize {
+			return publicKey{}, errors.New("Invalid OKP key")
+		}
+
+		return publicKey{alg: key.Alg, key: ed25519.PublicKey(okp.X)}, nil
+
+	case key.Kty == ktyRSA && key.Alg == algRS256:
+		var rsaKey coseRSAKey
+		err = cbor.Unmarshal(data, &rsaKey)
+		if err != nil || len(rsaKey.N) < 256 || len(rsaKey.E) == 0 || len(rsaKey.E) > 4 {
+			return publicKey{}, errors.New("Invalid RSA key")
+		}
+
+		e := 0
+		for _, b := range rsaKey.E {
+			e = e<<8 | int(b)
+		}
+
+		return publicKey{alg: key.Alg, key: &rsa.PublicKey{N: new(big.Int).SetBytes(rsaKey.N), E: e}}, nil
+	}
+
+	return publicKey{}, errors.New("Unsupported COSE algorithm")
+}
+
+func (key publicKey) verify(data []byte, signature []byte) bool {
+	switch k := key.key.(type) {
+	case *ecdsa.PublicKey:
+		digest := sha256.Sum256(data)
+		return ecdsa.VerifyASN1(k, digest[:], signature)
+	case ed25519.PublicKey:
+		return ed25519.Verify(k, data, signature)
+	case *rsa.PublicKey:
+		digest := sha256.Sum256(data)
+		return rsa.VerifyPKCS1v15(k, crypto.SHA256, digest[:], signature) == nil
+	}
+
+	return false
+}
@@ -0,0 +1,443 @@
+// Package webauthn is the relying party side of passkeys. It only asks for "none" attestation and accepts ES256, EdDSA and RS256 keys,
+// which keeps it small enough to check by hand instead of pulling in a full WebAuthn library with attestation formats and metadata this
+// app doesn't use. Every check of the ceremonies(origin, RP ID hash, flags, counter, key, challenge) has a test that makes it fail.
+package webauthn
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/fxamacker/cbor/v2"
+)
+
+// Config is the relying party, passkeys are bound to RPID(the domain) and only accepted from Origin.
+type Config struct {
+	RPID   string
+	RPName string
+	Origin string
+}
+
+// ConfigFromEnv reads WEBAUTHN_RP_ID and WEBAUTHN_RP_ORIGIN, the origin defaults to https on the RP ID.
+func ConfigFromEnv() Config {
+	config := Config{
+		RPID:   os.Getenv("WEBAUTHN_RP_ID"),
+		RPName: "ELECT",
+		Origin: os.Getenv("WEBAUTHN_RP_ORIGIN"),
+	}
+	if config.RPID == "" {
+		config.RPID = "e1ect.herokuapp.com"
+	}
+	if config.Origin == "" {
+		config.Origin = "https://" + config.RPID
+	}
+
+	return config
+}
+
+const timeout = 300000
+
+const createCeremony = "webauthn.create"
+const getCeremony = "webauthn.get"
+
+const (
+	flagUserPresent  byte = 0x01
+	flagUserVerified byte = 0x04
+	flagAttestedData byte = 0x40
+)
+
+// URLEncodedBase64 is how binary values travel in the JSON of the browser API, base64url without padding.
+type URLEncodedBase64 []byte
+
+func (data URLEncodedBase64) MarshalJSON() ([]byte, error) {
+	return json.Marshal(base64.RawURLEncoding.EncodeToString(data))
+}
+
+func (data *URLEncodedBase64) UnmarshalJSON(encoded []byte) error {
+	var value string
+	err := json.Unmarshal(encoded, &value)
+	if err != nil {
+		return err
+	}
+
+	decoded, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(value, "="))
+	if err != nil {
+		return err
+	}
+
+	*data = decoded
+	return nil
+}
+
+// Credential is what is stored for a passkey, PublicKey is the COSE key from the authenticator.
+type Credential struct {
+	ID              []byte
+	PublicKey       []byte
+	AttestationType string
+	AAGUID          []byte
+	SignCount       uint32
+}
+
+// SessionData is kept by the server between the options and the answer of the authenticator.
+type SessionData struct {
+	Ceremony             string
+	Challenge            string
+	UserID               []byte
+	AllowedCredentialIDs [][]byte
+}
+
+type CredentialDescriptor struct {
+	Type string           `json:"type"`
+	ID   URLEncodedBase64 `json:"id"`
+}
+
+type RelyingPartyEntity struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+type UserEntity struct {
+	ID          URLEncodedBase64 `json:"id"`
+	Name        string           `json:"name"`
+	DisplayName string           `json:"displayName"`
+}
+
+type CredentialParameter struct {
+	Type string `json:"type"`
+	Alg  int64  `json:"alg"`
+}
+
+type AuthenticatorSelection struct {
+	ResidentKey      string `json:"residentKey"`
+	UserVerification string `json:"userVerification"`
+}
+
+// CredentialCreation is passed to navigator.credentials.create().
+type CredentialCreation struct {
+	PublicKey struct {
+		Challenge              URLEncodedBase64       `json:"challenge"`
+		RP                     RelyingPartyEntity     `json:"rp"`
+		User                   UserEntity             `json:"user"`
+		PubKeyCredParams       []CredentialParameter  `json:"pubKeyCredParams"`
+		Timeout                int                    `json:"timeout"`
+		ExcludeCredentials     []CredentialDescriptor `json:"excludeCredentials"`
+		AuthenticatorSelection AuthenticatorSelection `json:"authenticatorSelection"`
+		Attestation            string                 `json:"attestation"`
+	} `json:"publicKey"`
+}
+
+// CredentialAssertion is passed to navigator.credentials.get().
+type CredentialAssertion struct {
+	PublicKey struct {
+		Challenge        URLEncodedBase64       `json:"challenge"`
+		Timeout          int                    `json:"timeout"`
+		RPID             string                 `json:"rpId"`
+		AllowCredentials []CredentialDescriptor `json:"allowCredentials"`
+		UserVerification string                 `json:"userVerification"`
+	} `json:"publicKey"`
+}
+
+type registrationResponse struct {
+	RawID    URLEncodedBase64 `json:"rawId"`
+	Type     string           `json:"type"`
+	Response struct {
+		ClientDataJSON    URLEncodedBase64 `json:"clientDataJSON"`
+		AttestationObject URLEncodedBase64 `json:"attestationObject"`
+	} `json:"response"`
+}
+
+type assertionResponse struct {
+	RawID    URLEncodedBase64 `json:"rawId"`
+	Type     string           `json:"type"`
+	Response struct {
+		ClientDataJSON    URLEncodedBase64 `json:"clientDataJSON"`
+		AuthenticatorData URLEncodedBase64 `json:"authenticatorData"`
+		Signature         URLEncodedBase64 `json:"signature"`
+		UserHandle        URLEncodedBase64 `json:"userHandle"`
+	} `json:"response"`
+}
+
+type clientData struct {
+	Type      string `json:"type"`
+	Challenge string `json:"challenge"`
+	Origin    string `json:"origin"`
+}
+
+type attestationObject struct {
+	Fmt      string          `cbor:"fmt"`
+	AttStmt  cbor.RawMessage `cbor:"attStmt"`
+	AuthData []byte          `cbor:"authData"`
+}
+
+type authenticatorData struct {
+	RPIDHash     []byte
+	Flags        byte
+	SignCount    uint32
+	AAGUID       []byte
+	CredentialID []byte
+	PublicKey    []byte
+}
+
+// WebAuthn runs the registration and login ceremonies, only the "none" attestation is asked for so any authenticator works.
+type WebAuthn struct {
+	config Config
+}
+
+func New(config Config) *WebAuthn {
+	return &WebAuthn{
+		config: config,
+	}
+}
+
+func (webAuthn *WebAuthn) BeginRegistration(userId []byte, name string, displayName string, excludeCredentialIds [][]byte) (*CredentialCreation, SessionData, error) {
+	challenge, err := newChallenge()
+	if err != nil {
+		return nil, SessionData{}, err
+	}
+
+	creation := &CredentialCreation{}
+	creation.PublicKey.Challenge = challenge
+	creation.PublicKey.RP = RelyingPartyEntity{ID: webAuthn.config.RPID, Name: webAuthn.config.RPName}
+	creation.PublicKey.User = UserEntity{ID: userId, Name: name, DisplayName: displayName}
+	creation.PublicKey.PubKeyCredParams = []CredentialParameter{
+		{Type: "public-key", Alg: algES256},
+		{Type: "public-key", Alg: algEdDSA},
+		{Type: "public-key", Alg: algRS256},
+	}
+	creation.PublicKey.Timeout = timeout
+	creation.PublicKey.ExcludeCredentials = descriptors(excludeCredentialIds)
+	creation.PublicKey.AuthenticatorSelection = AuthenticatorSelection{ResidentKey: "preferred", UserVerification: "required"}
+	creation.PublicKey.Attestation = "none"
+
+	return creation, SessionData{
+		Ceremony:  createCeremony,
+		Challenge: base64.RawURLEncoding.EncodeToString(challenge),
+		UserID:    userId,
+	}, nil
+}
+
+// FinishRegistration checks the answer of navigator.credentials.create() and returns the new credential.
+func (webAuthn *WebAuthn) FinishRegistration(session SessionData, body io.Reader) (Credential, error) {
+	var response registrationResponse
+	err := json.NewDecoder(body).Decode(&response)
+	if err != nil || response.Type != "public-key" {
+		return Credential{}, invalid("Invalid registration response")
+	}
+
+	err = webAuthn.verifyClientData(session, createCeremony, response.Response.ClientDataJSON)
+	if err != nil {
+		return Credential{}, err
+	}
+
+	var attestation attestationObject
+	err = cbor.Unmarshal(response.Response.AttestationObject, &attestation)
+	if err != nil {
+		return Credential{}, invalid("Invalid attestation object: " + err.Error())
+	}
+
+	authData, err := webAuthn.verifyAuthenticatorData(attestation.AuthData)
+	if err != nil {
+		return Credential{}, err
+	}
+
+	if authData.Flags&flagAttestedData == 0 || !bytes.Equal(authData.CredentialID, response.RawID) {
+		return Credential{}, invalid("Missing attested credential")
+	}
+
+	_, err = parsePublicKey(authData.PublicKey)
+	if err != nil {
+		return Credential{}, invalid(err.Error())
+	}
+
+	return Credential{
+		ID:              authData.CredentialID,
+		PublicKey:       authData.PublicKey,
+		AttestationType: attestation.Fmt,
+		AAGUID:          authData.AAGUID,
+		SignCount:       authData.SignCount,
+	}, nil
+}
+
+func (webAuthn *WebAuthn) BeginLogin(userId []byte, credentialIds [][]byte) (*CredentialAssertion, SessionData, error) {
+	challenge, err := newChallenge()
+	if err != nil {
+		return nil, SessionData{}, err
+	}
+
+	assertion := &CredentialAssertion{}
+	assertion.PublicKey.Challenge = challenge
+	assertion.PublicKey.Timeout = timeout
+	assertion.PublicKey.RPID = webAuthn.config.RPID
+	assertion.PublicKey.AllowCredentials = descriptors(credentialIds)
+	assertion.PublicKey.UserVerification = "required"
+
+	return assertion, SessionData{
+		Ceremony:             getCeremony,
+		Challenge:            base64.RawURLEncoding.EncodeToString(challenge),
+		UserID:               userId,
+		AllowedCredentialIDs: credentialIds,
+	}, nil
+}
+
+// FinishLogin checks the answer of navigator.credentials.get() against the user's credentials and returns the one used with its new
+// signature counter. A counter that didn't go up means the passkey may have been cloned.
+func (webAuthn *WebAuthn) FinishLogin(session SessionData, credentials []Credential, body io.Reader) (Credential, error) {
+	var response assertionResponse
+	err := json.NewDecoder(body).Decode(&response)
+	if err != nil || response.Type != "public-key" {
+		return Credential{}, invalid("Invalid assertion response")
+	}
+
+	allowed := false
+	for _, id := range session.AllowedCredentialIDs {
+		if bytes.Equal(id, response.RawID) {
+			allowed = true
+		}
+	}
+
+	var credential Credential
+	for _, c := range credentials {
+		if allowed && bytes.Equal(c.ID, response.RawID) {
+			credential = c
+		}
+	}
+	if credential.ID == nil {
+		return Credential{}, invalid("Unknown credential")
+	}
+
+	if len(response.Response.UserHandle) > 0 && !bytes.Equal(response.Response.UserHandle, session.UserID) {
+		return Credential{}, invalid("User handle mismatch")
+	}
+
+	err = webAuthn.verifyClientData(session, getCeremony, response.Response.ClientDataJSON)
+	if err != nil {
+		return Credential{}, err
+	}
+
+	authData, err := webAuthn.verifyAuthenticatorData(response.Response.AuthenticatorData)
+	if err != nil {
+		return Credential{}, err
+	}
+
+	publicKey, err := parsePublicKey(credential.PublicKey)
+	if err != nil {
+		return Credential{}, invalid(err.Error())
+	}
+
+	clientDataHash := sha256.Sum256(response.Response.ClientDataJSON)
+	signed := append(append([]byte{}, response.Response.AuthenticatorData...), clientDataHash[:]...)
+	if !publicKey.verify(signed, response.Response.Signature) {
+		return Credential{}, invalid("Invalid signature")
+	}
+
+	if (authData.SignCount != 0 || credential.SignCount != 0) && authData.SignCount <= credential.SignCount {
+		return Credential{}, invalid("Signature counter didn't increase, the passkey may be cloned")
+	}
+	credential.SignCount = authData.SignCount
+
+	return credential, nil
+}
+
+func (webAuthn *WebAuthn) verifyClientData(session SessionData, ceremony string, clientDataJSON []byte) error {
+	var data clientData
+	err := json.Unmarshal(clientDataJSON, &data)
+	if err != nil {
+		return invalid("Invalid client data")
+	}
+
+	if session.Ceremony != ceremony || data.Type != ceremony {
+		return invalid("Wrong ceremony " + data.Type)
+	}
+
+	if subtle.ConstantTimeCompare([]byte(strings.TrimRight(data.Challenge, "=")), []byte(session.Challenge)) != 1 {
+		return invalid("Challenge mismatch")
+	}
+
+	if data.Origin != webAuthn.config.Origin {
+		return invalid("Origin mismatch " + data.Origin)
+	}
+
+	return nil
+}
+
+// verifyAuthenticatorData parses the authenticator data and checks it is for this relying party with the user present and verified.
+func (webAuthn *WebAuthn) verifyAuthenticatorData(data []byte) (authenticatorData, error) {
+	if len(data) < 37 {
+		return authenticatorData{}, invalid("Authenticator data too short")
+	}
+
+	authData := authenticatorData{
+		RPIDHash:  data[:32],
+		Flags:     data[32],
+		SignCount: binary.BigEndian.Uint32(data[33:37]),
+	}
+
+	rpIdHash := sha256.Sum256([]byte(webAuthn.config.RPID))
+	if !bytes.Equal(authData.RPIDHash, rpIdHash[:]) {
+		return authenticatorData{}, invalid("RP ID mismatch")
+	}
+
+	if authData.Flags&flagUserPresent == 0 || authData.Flags&flagUserVerified == 0 {
+		return authenticatorData{}, invalid("User not verified")
+	}
+
+	if authData.Flags&flagAttestedData != 0 {
+		rest := data[37:]
+		if len(rest) < 18 {
+			return authenticatorData{}, invalid("Attested credential data too short")
+		}
+
+		authData.AAGUID = rest[:16]
+		idLength := int(binary.BigEndian.Uint16(rest[16:18]))
+		if len(rest) < 18+idLength {
+			return authenticatorData{}, invalid("Credential ID too short")
+		}
+		authData.CredentialID = rest[18 : 18+idLength]
+
+		// The public key is followed by the extensions, the decoder tells where it ends
+		var publicKey cbor.RawMessage
+		decoder := cbor.NewDecoder(bytes.NewReader(rest[18+idLength:]))
+		err := decoder.Decode(&publicKey)
+		if err != nil {
+			return authenticatorData{}, invalid("Invalid credential public key")
+		}
+		authData.PublicKey = rest[18+idLength : 18+idLength+decoder.NumBytesRead()]
+	}
+
+	return authData, nil
+}
+
+func newChallenge() ([]byte, error) {
+	challenge := make([]byte, 32)
+	_, err := rand.Read(challenge)
+	if err != nil {
+		log.Println(err.Error())
+		return nil, err
+	}
+
+	return challenge, nil
+}
+
+func descriptors(credentialIds [][]byte) []CredentialDescriptor {
+	credentialDescriptors := []CredentialDescriptor{}
+	for _, id := range credentialIds {
+		credentialDescriptors = append(credentialDescriptors, CredentialDescriptor{Type: "public-key", ID: id})
+	}
+
+	return credentialDescriptors
+}
+
+// invalid logs why a passkey was rejected and returns the error the user sees.
+func invalid(reason string) error {
+	log.Println("webauthn: " + reason)
+	return errors.New("Invalid Passkey!")
+}
@@ -0,0 +1,232 @@
+package webauthn_test
+
+import (
+	"bytes"
+	"elect/webauthn"
+	"elect/webauthn/webauthntest"
+	"testing"
+
+	"github.com/fxamacker/cbor/v2"
+)
+
+var config = webauthn.Config{RPID: "elect.test", RPName: "ELECT", Origin: "https://elect.test"}
+
+var userId = []byte("0123456789abcdef")
+
+var authenticators = map[string]func() *webauthntest.Authenticator{
+	"ES256": webauthntest.NewES256,
+	"EdDSA": webauthntest.NewEdDSA,
+}
+
+func register(t *testing.T, webAuthn *webauthn.WebAuthn, authenticator *webauthntest.Authenticator) (webauthn.Credential, error) {
+	creation, session, err := webAuthn.BeginRegistration(userId, "user@elect.test", "Test User", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return webAuthn.FinishRegistration(session, bytes.NewReader(authenticator.Create(creation)))
+}
+
+func login(t *testing.T, webAuthn *webauthn.WebAuthn, authenticator *webauthntest.Authenticator, credential webauthn.Credential) ([]byte, webauthn.SessionData) {
+	assertion, session, err := webAuthn.BeginLogin(userId, [][]byte{credential.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return authenticator.Get(assertion, userId), session
+}
+
+func TestRegisterAndLogin(t *testing.T) {
+	for name, newAuthenticator := range authenticators {
+		t.Run(name, func(t *testing.T) {
+			webAuthn := webauthn.New(config)
+			authenticator := newAuthenticator()
+
+			credential, err := register(t, webAuthn, authenticator)
+			if err != nil {
+				t.Fatalf("registration failed: %v", err)
+			}
+			if !bytes.Equal(credential.ID, authenticator.ID) || credential.AttestationType != "none" {
+				t.Fatalf("registered %x(%s), want %x(none)", credential.ID, credential.AttestationType, authenticator.ID)
+			}
+
+			body, session := login(t, webAuthn, authenticator, credential)
+			used, err := webAuthn.FinishLogin(session, []webauthn.Credential{credential}, bytes.NewReader(body))
+			if err != nil {
+				t.Fatalf("login failed: %v", err)
+			}
+			if used.SignCount != 1 {
+				t.Errorf("sign count is %d, want 1", used.SignCount)
+			}
+		})
+	}
+}
+
+func TestWrongOrigin(t *testing.T) {
+	webAuthn := webauthn.New(config)
+	authenticator := webauthntest.NewES256()
+
+	credential, err := register(t, webAuthn, authenticator)
+	if err != nil {
+		t.Fatalf("registration failed: %v", err)
+	}
+
+	authenticator.Origin = "https://elect.test.attacker.example"
+
+	_, err = register(t, webAuthn, authenticator)
+	if err == nil {
+		t.Error("registration from another origin was accepted")
+	}
+
+	body, session := login(t, webAuthn, authenticator, credential)
+	_, err = webAuthn.FinishLogin(session, []webauthn.Credential{credential}, bytes.NewReader(body))
+	if err == nil {
+		t.Error("login from another origin was accepted")
+	}
+}
+
+func TestWrongRPIDHash(t *testing.T) {
+	webAuthn := webauthn.New(config)
+	authenticator := webauthntest.NewES256()
+
+	credential, err := register(t, webAuthn, authenticator)
+	if err != nil {
+		t.Fatalf("registration failed: %v", err)
+	}
+
+	authenticator.RPID = "attacker.example"
+
+	_, err = register(t, webAuthn, authenticator)
+	if err == nil {
+		t.Error("registration for another relying party was accepted")
+	}
+
+	body, session := login(t, webAuthn, authenticator, credential)
+	_, err = webAuthn.FinishLogin(session, []webauthn.Credential{credential}, bytes.NewReader(body))
+	if err == nil {
+		t.Error("login for another relying party was accepted")
+	}
+}
+
+func TestUserPresenceAndVerification(t *testing.T) {
+	flags := map[string]byte{
+		"user not present":  webauthntest.FlagUserVerified,
+		"user not verified": webauthntest.FlagUserPresent,
+	}
+
+	for name, flag := range flags {
+		t.Run(name, func(t *testing.T) {
+			webAuthn := webauthn.New(config)
+			authenticator := webauthntest.NewEdDSA()
+
+			credential, err := register(t, webAuthn, authenticator)
+			if err != nil {
+				t.Fatalf("registration failed: %v", err)
+			}
+
+			authenticator.Flags = flag
+
+			_, err = register(t, webAuthn, authenticator)
+			if err == nil {
+				t.Error("registration was accepted")
+			}
+
+			body, session := login(t, webAuthn, authenticator, credential)
+			_, err = webAuthn.FinishLogin(session, []webauthn.Credential{credential}, bytes.NewReader(body))
+			if err == nil {
+				t.Error("login was accepted")
+			}
+		})
+	}
+}
+
+func TestReplayedAssertion(t *testing.T) {
+	webAuthn := webauthn.New(config)
+	authenticator := webauthntest.NewES256()
+
+	credential, err := register(t, webAuthn, authenticator)
+	if err != nil {
+		t.Fatalf("registration failed: %v", err)
+	}
+
+	body, session := login(t, webAuthn, authenticator, credential)
+	credential, err = webAuthn.FinishLogin(session, []webauthn.Credential{credential}, bytes.NewReader(body))
+	if err != nil {
+		t.Fatalf("login failed: %v", err)
+	}
+
+	// Against the same challenge the counter gives the replay away
+	_, err = webAuthn.FinishLogin(session, []webauthn.Credential{credential}, bytes.NewReader(body))
+	if err == nil {
+		t.Error("replayed assertion was accepted for the same challenge")
+	}
+
+	// Against a new challenge the signed client data no longer matches
+	_, session = login(t, webAuthn, authenticator, credential)
+	_, err = webAuthn.FinishLogin(session, []webauthn.Credential{credential}, bytes.NewReader(body))
+	if err == nil {
+		t.Error("replayed assertion was accepted for a new challenge")
+	}
+}
+
+func TestCounterGoingBackwards(t *testing.T) {
+	webAuthn := webauthn.New(config)
+	authenticator := webauthntest.NewES256()
+
+	credential, err := register(t, webAuthn, authenticator)
+	if err != nil {
+		t.Fatalf("registration failed: %v", err)
+	}
+
+	for i := 0; i < 2; i++ {
+		body, session := login(t, webAuthn, authenticator, credential)
+		credential, err = webAuthn.FinishLogin(session, []webauthn.Credential{credential}, bytes.NewReader(body))
+		if err != nil {
+			t.Fatalf("login failed: %v", err)
+		}
+	}
+
+	// A clone that was copied before the last login still reports the older counter
+	authenticator.Counter = 0
+
+	body, session := login(t, webAuthn, authenticator, credential)
+	_, err = webAuthn.FinishLogin(session, []webauthn.Credential{credential}, bytes.NewReader(body))
+	if err == nil {
+		t.Error("login with a lower signature counter was accepted")
+	}
+}
+
+func TestES256KeyNotOnCurve(t *testing.T) {
+	webAuthn := webauthn.New(config)
+	authenticator := webauthntest.NewES256()
+
+	credential, err := register(t, webAuthn, authenticator)
+	if err != nil {
+		t.Fatalf("registration failed: %v", err)
+	}
+
+	var key map[int]interface{}
+	err = cbor.Unmarshal(authenticator.PublicKey, &key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	y := append([]byte{}, key[-3].([]byte)...)
+	y[len(y)-1] ^= 1
+	key[-3] = y
+	authenticator.PublicKey, err = cbor.Marshal(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = register(t, webAuthn, authenticator)
+	if err == nil {
+		t.Error("registration with a key off the curve was accepted")
+	}
+
+	credential.PublicKey = authenticator.PublicKey
+	body, session := login(t, webAuthn, authenticator, credential)
+	_, err = webAuthn.FinishLogin(session, []webauthn.Credential{credential}, bytes.NewReader(body))
+	if err == nil {
+		t.Error("login with a key off the curve was accepted")
+	}
+}
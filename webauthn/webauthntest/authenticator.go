@@ -0,0 +1,205 @@
+// Package webauthntest provides a software authenticator for testing the passkey ceremonies without a browser.
+package webauthntest
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"elect/webauthn"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+
+	"github.com/fxamacker/cbor/v2"
+)
+
+const (
+	FlagUserPresent  byte = 0x01
+	FlagUserVerified byte = 0x04
+	flagAttestedData byte = 0x40
+)
+
+// Authenticator answers navigator.credentials.create() and get() like a platform authenticator with "none" attestation.
+// Flags, RPID and Origin can be changed between ceremonies to produce answers a relying party has to refuse.
+type Authenticator struct {
+	ID []byte
+	// Flags are the flags of the authenticator data, user present and verified by default.
+	Flags byte
+	// RPID overrides the relying party ID hashed into the authenticator data when set.
+	RPID string
+	// Origin is the origin the client data claims, it defaults to https on the relying party ID.
+	Origin string
+	// Counter is the signature counter, it goes up with every assertion unless NoCounter is set.
+	Counter   uint32
+	NoCounter bool
+	// PublicKey is the COSE key Create registers.
+	PublicKey []byte
+
+	sign func(data []byte) []byte
+}
+
+// NewES256 returns an authenticator with a P-256 key.
+func NewES256() *Authenticator {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		panic(err)
+	}
+
+	publicKey, err := cbor.Marshal(map[int]interface{}{
+		1:  2,
+		3:  -7,
+		-1: 1,
+		-2: pad(key.PublicKey.X.Bytes()),
+		-3: pad(key.PublicKey.Y.Bytes()),
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	return newAuthenticator(publicKey, func(data []byte) []byte {
+		digest := sha256.Sum256(data)
+		signature, err := ecdsa.SignASN1(rand.Reader, key, digest[:])
+		if err != nil {
+			panic(err)
+		}
+		return signature
+	})
+}
+
+// NewEdDSA returns an authenticator with an Ed25519 key.
+func NewEdDSA() *Authenticator {
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		panic(err)
+	}
+
+	publicKey, err := cbor.Marshal(map[int]interface{}{
+		1:  1,
+		3:  -8,
+		-1: 6,
+		-2: []byte(public),
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	return newAuthenticator(publicKey, func(data []byte) []byte {
+		return ed25519.Sign(private, data)
+	})
+}
+
+func newAuthenticator(publicKey []byte, sign func(data []byte) []byte) *Authenticator {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		panic(err)
+	}
+
+	return &Authenticator{
+		ID:        id,
+		Flags:     FlagUserPresent | FlagUserVerified,
+		PublicKey: publicKey,
+		sign:      sign,
+	}
+}
+
+// Create answers the creation options with a new credential, the result is the JSON body the browser would post.
+func (authenticator *Authenticator) Create(creation *webauthn.CredentialCreation) []byte {
+	rpId := creation.PublicKey.RP.ID
+	clientDataJSON := authenticator.clientData("webauthn.create", creation.PublicKey.Challenge, rpId)
+
+	authData := authenticator.authData(rpId, authenticator.Flags|flagAttestedData)
+	authData = append(authData, make([]byte, 16)...)
+	authData = append(authData, byte(len(authenticator.ID)>>8), byte(len(authenticator.ID)))
+	authData = append(authData, authenticator.ID...)
+	authData = append(authData, authenticator.PublicKey...)
+
+	attestationObject, err := cbor.Marshal(map[string]interface{}{
+		"fmt":      "none",
+		"attStmt":  map[string]interface{}{},
+		"authData": authData,
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	return marshal(map[string]interface{}{
+		"id":    encode(authenticator.ID),
+		"rawId": encode(authenticator.ID),
+		"type":  "public-key",
+		"response": map[string]string{
+			"clientDataJSON":    encode(clientDataJSON),
+			"attestationObject": encode(attestationObject),
+		},
+	})
+}
+
+// Get answers the assertion options by signing the challenge, the result is the JSON body the browser would post.
+func (authenticator *Authenticator) Get(assertion *webauthn.CredentialAssertion, userHandle []byte) []byte {
+	if !authenticator.NoCounter {
+		authenticator.Counter++
+	}
+
+	rpId := assertion.PublicKey.RPID
+	clientDataJSON := authenticator.clientData("webauthn.get", assertion.PublicKey.Challenge, rpId)
+	authData := authenticator.authData(rpId, authenticator.Flags)
+
+	clientDataHash := sha256.Sum256(clientDataJSON)
+	signature := authenticator.sign(append(append([]byte{}, authData...), clientDataHash[:]...))
+
+	return marshal(map[string]interface{}{
+		"id":    encode(authenticator.ID),
+		"rawId": encode(authenticator.ID),
+		"type":  "public-key",
+		"response": map[string]string{
+			"clientDataJSON":    encode(clientDataJSON),
+			"authenticatorData": encode(authData),
+			"signature":         encode(signature),
+			"userHandle":        encode(userHandle),
+		},
+	})
+}
+
+func (authenticator *Authenticator) clientData(ceremony string, challenge []byte, rpId string) []byte {
+	origin := authenticator.Origin
+	if origin == "" {
+		origin = "https://" + rpId
+	}
+
+	return marshal(map[string]string{
+		"type":      ceremony,
+		"challenge": encode(challenge),
+		"origin":    origin,
+	})
+}
+
+func (authenticator *Authenticator) authData(rpId string, flags byte) []byte {
+	if authenticator.RPID != "" {
+		rpId = authenticator.RPID
+	}
+
+	rpIdHash := sha256.Sum256([]byte(rpId))
+	counter := make([]byte, 4)
+	binary.BigEndian.PutUint32(counter, authenticator.Counter)
+
+	return append(append(append([]byte{}, rpIdHash[:]...), flags), counter...)
+}
+
+// pad left pads a P-256 coordinate to 32 bytes.
+func pad(coordinate []byte) []byte {
+	return append(make([]byte, 32-len(coordinate)), coordinate...)
+}
+
+func encode(data []byte) string {
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func marshal(value interface{}) []byte {
+	data, err := json.Marshal(value)
+	if err != nil {
+		panic(err)
+	}
+
+	return data
+}